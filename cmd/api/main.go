@@ -29,6 +29,21 @@ func main() {
     var logsSvc services.LogsService
     var usersSvc services.UsersService
     var authSvc services.AuthService
+    var coverageSvc services.CoverageService
+    var cutPlanningSvc services.CutPlanningService
+    var rollsSvc services.RollsService
+    var bundlesSvc services.BundlesService
+    var labelsSvc services.LabelsService
+    var tablesSvc services.TablesService
+    var scheduleSvc services.ScheduleService
+    var assignmentsSvc services.TaskAssignmentsService
+    var shiftsSvc services.ShiftsService
+    var reportsSvc services.ReportsService
+    var payrollSvc services.PayrollService
+    var forecastSvc services.ForecastService
+    var amendmentsSvc services.AmendmentsService
+    var customersSvc services.CustomersService
+    var stylesSvc services.StylesService
 
     if cfg.DatabaseURL != "" {
         conn, err := db.Open(cfg.DatabaseURL)
@@ -36,10 +51,10 @@ func main() {
             logger.L.Warn("db_connect_failed", "error", err)
         } else {
             defer conn.Close()
-            if err := db.RunMigrations(conn, "migrations/000001_initial_schema.up.sql"); err != nil {
+            if err := db.RunAllMigrations(conn, "migrations"); err != nil {
                 logger.L.Warn("migrations_failed", "error", err)
             } else {
-                logger.L.Info("migrations_applied", "script", "000001_initial_schema.up.sql")
+                logger.L.Info("migrations_applied", "dir", "migrations")
             }
 
             // Wire repositories
@@ -49,14 +64,40 @@ func main() {
             tasksRepo := repositories.NewSqlTasksRepository(conn)
             logsRepo := repositories.NewSqlLogsRepository(conn)
             usersRepo := repositories.NewSqlUsersRepository(conn)
+            rollsRepo := repositories.NewSqlRollsRepository(conn)
+            bundlesRepo := repositories.NewSqlBundlesRepository(conn)
+            tablesRepo := repositories.NewSqlTablesRepository(conn)
+            shiftsRepo := repositories.NewSqlShiftsRepository(conn)
+            stylesRepo := repositories.NewSqlStylesRepository(conn)
+            customersRepo := repositories.NewSqlCustomersRepository(conn)
 
             // Wire services
-            ordersSvc = services.NewOrdersService(ordersRepo)
+            ordersSvc = services.NewOrdersService(ordersRepo, stylesRepo, customersRepo)
             plansSvc = services.NewPlansService(plansRepo)
             layoutsSvc = services.NewLayoutsService(layoutsRepo)
             tasksSvc = services.NewTasksService(tasksRepo)
             logsSvc = services.NewLogsService(logsRepo)
             usersSvc = services.NewUsersService(usersRepo)
+            coverageSvc = services.NewCoverageService(plansRepo, ordersRepo, layoutsRepo, tasksRepo, stylesRepo)
+            cutPlanningSvc = services.NewCutPlanningService(ordersRepo, plansRepo)
+            rollsSvc = services.NewRollsService(rollsRepo)
+            bundlesSvc = services.NewBundlesService(bundlesRepo)
+            labelsSvc = services.NewLabelsService(bundlesRepo, tasksRepo, layoutsRepo, plansRepo, ordersRepo)
+            tablesSvc = services.NewTablesService(tablesRepo)
+            shifts, err := services.ParseShiftWindows(cfg.ScheduleShifts)
+            if err != nil {
+                logger.L.Warn("schedule_shifts_invalid", "error", err, "default", services.DefaultScheduleShifts)
+                shifts, _ = services.ParseShiftWindows("")
+            }
+            scheduleSvc = services.NewScheduleService(repositories.NewSqlScheduleRepository(conn), tablesRepo, shiftsRepo, shifts)
+            assignmentsSvc = services.NewTaskAssignmentsService(repositories.NewSqlTaskAssignmentsRepository(conn))
+            shiftsSvc = services.NewShiftsService(shiftsRepo)
+            reportsSvc = services.NewReportsService(repositories.NewSqlReportsRepository(conn), shiftsRepo)
+            payrollSvc = services.NewPayrollService(repositories.NewSqlPayrollRepository(conn))
+            forecastSvc = services.NewForecastService(repositories.NewSqlForecastRepository(conn))
+            amendmentsSvc = services.NewAmendmentsService(repositories.NewSqlAmendmentsRepository(conn))
+            customersSvc = services.NewCustomersService(customersRepo)
+            stylesSvc = services.NewStylesService(stylesRepo, ordersRepo)
 
             // Auth service with env-secret and default TTLs
             secret := os.Getenv("AUTH_SECRET")
@@ -90,7 +131,24 @@ func main() {
         handlers.NewPlansHandler(plansSvc).RegisterProtected(protected)
         handlers.NewLayoutsHandler(layoutsSvc).RegisterProtected(protected)
         handlers.NewTasksHandler(tasksSvc).RegisterProtected(protected)
-        handlers.NewLogsHandler(logsSvc).RegisterProtected(protected)
+        logsHandler := handlers.NewLogsHandler(logsSvc)
+        if cfg.RequireTaskAssignment { logsHandler.RequireAssignment(assignmentsSvc) }
+        logsHandler.RegisterProtected(protected)
+        handlers.NewCoverageHandler(coverageSvc).RegisterProtected(protected)
+        handlers.NewCutPlanningHandler(cutPlanningSvc).RegisterProtected(protected)
+        handlers.NewRollsHandler(rollsSvc).RegisterProtected(protected)
+        handlers.NewBundlesHandler(bundlesSvc).RegisterProtected(protected)
+        handlers.NewLabelsHandler(labelsSvc).RegisterProtected(protected)
+        handlers.NewTablesHandler(tablesSvc).RegisterProtected(protected)
+        handlers.NewScheduleHandler(scheduleSvc).RegisterProtected(protected)
+        handlers.NewTaskAssignmentsHandler(assignmentsSvc).RegisterProtected(protected)
+        handlers.NewShiftsHandler(shiftsSvc).RegisterProtected(protected)
+        handlers.NewReportsHandler(reportsSvc).RegisterProtected(protected)
+        handlers.NewPayrollHandler(payrollSvc).RegisterProtected(protected)
+        handlers.NewForecastHandler(forecastSvc).RegisterProtected(protected)
+        handlers.NewAmendmentsHandler(amendmentsSvc).RegisterProtected(protected)
+        handlers.NewCustomersHandler(customersSvc).RegisterProtected(protected)
+        handlers.NewStylesHandler(stylesSvc).RegisterProtected(protected)
     } else {
         // Fallback for environments without auth (e.g., local dev without DB)
         handlers.NewOrdersHandler(ordersSvc).Register(api)
@@ -98,6 +156,21 @@ func main() {
         handlers.NewLayoutsHandler(layoutsSvc).Register(api)
         handlers.NewTasksHandler(tasksSvc).Register(api)
         handlers.NewLogsHandler(logsSvc).Register(api)
+        handlers.NewCoverageHandler(coverageSvc).Register(api)
+        handlers.NewCutPlanningHandler(cutPlanningSvc).Register(api)
+        handlers.NewRollsHandler(rollsSvc).Register(api)
+        handlers.NewBundlesHandler(bundlesSvc).Register(api)
+        handlers.NewLabelsHandler(labelsSvc).Register(api)
+        handlers.NewTablesHandler(tablesSvc).Register(api)
+        handlers.NewScheduleHandler(scheduleSvc).Register(api)
+        handlers.NewTaskAssignmentsHandler(assignmentsSvc).Register(api)
+        handlers.NewShiftsHandler(shiftsSvc).Register(api)
+        handlers.NewReportsHandler(reportsSvc).Register(api)
+        handlers.NewPayrollHandler(payrollSvc).Register(api)
+        handlers.NewForecastHandler(forecastSvc).Register(api)
+        handlers.NewAmendmentsHandler(amendmentsSvc).Register(api)
+        handlers.NewCustomersHandler(customersSvc).Register(api)
+        handlers.NewStylesHandler(stylesSvc).Register(api)
     }
 
     r.NoRoute(func(c *gin.Context) { c.JSON(404, gin.H{"error": "route_not_found"}) })
@@ -1,10 +1,16 @@
 package config
 
-import "os"
+import (
+    "os"
+    "strconv"
+)
 
 type Config struct {
-    Port        string
-    DatabaseURL string
+    Port           string
+    DatabaseURL    string
+    ScheduleShifts string // e.g. "day=08:00-16:00,night=16:00-24:00"; empty uses the scheduler default
+    // RequireTaskAssignment makes POST /logs refuse worker logs on tasks not assigned to them (or their group).
+    RequireTaskAssignment bool
 }
 
 func Load() Config {
@@ -13,5 +19,11 @@ func Load() Config {
         port = ":8080"
     }
     dbURL := os.Getenv("DATABASE_URL")
-    return Config{Port: port, DatabaseURL: dbURL}
+    requireAssignment, _ := strconv.ParseBool(os.Getenv("REQUIRE_TASK_ASSIGNMENT"))
+    return Config{
+        Port:                  port,
+        DatabaseURL:           dbURL,
+        ScheduleShifts:        os.Getenv("SCHEDULE_SHIFTS"),
+        RequireTaskAssignment: requireAssignment,
+    }
 }
\ No newline at end of file
@@ -6,6 +6,7 @@ import (
     "fmt"
     "os"
     "path/filepath"
+    "sort"
     "strings"
     "log/slog"
 
@@ -62,4 +63,23 @@ func RunMigrations(db *sql.DB, scriptRelPath string) error {
     // Structured log: migrations applied successfully
     logger.L.Info("migrations_applied", slog.String("script", scriptRelPath))
     return nil
+}
+
+// RunAllMigrations executes every *.up.sql script under dir in lexical (version) order.
+// Each script must be idempotent since all of them are re-applied on every startup.
+func RunAllMigrations(db *sql.DB, dir string) error {
+    scripts, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+    if err != nil {
+        return err
+    }
+    if len(scripts) == 0 {
+        return fmt.Errorf("no migration scripts found in %s", dir)
+    }
+    sort.Strings(scripts)
+    for _, p := range scripts {
+        if err := RunMigrations(db, p); err != nil {
+            return fmt.Errorf("%s: %w", filepath.Base(p), err)
+        }
+    }
+    return nil
 }
\ No newline at end of file
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/services"
+    "cutrix-backend/internal/middleware"
+)
+
+// AmendmentsHandler exposes the change-request workflow for published plans.
+type AmendmentsHandler struct{ svc services.AmendmentsService }
+
+func NewAmendmentsHandler(svc services.AmendmentsService) *AmendmentsHandler { return &AmendmentsHandler{svc: svc} }
+
+func (h *AmendmentsHandler) Register(r *gin.RouterGroup) {
+    r.POST("/plans/:id/amendments", h.propose)
+    r.GET("/plans/:id/amendments", h.listByPlan)
+    r.GET("/amendments/:id", h.get)
+    r.POST("/amendments/:id/approve", h.approve)
+    r.POST("/amendments/:id/reject", h.reject)
+}
+
+// RegisterProtected registers routes with permissions applied. Use on authenticated groups.
+// Approve/reject require amendment:approve, which only the implicit admin/manager roles hold.
+func (h *AmendmentsHandler) RegisterProtected(r *gin.RouterGroup) {
+    r.POST("/plans/:id/amendments", middleware.RequirePermissions("amendment:create"), h.propose)
+    r.GET("/plans/:id/amendments", middleware.RequirePermissions("amendment:read"), h.listByPlan)
+    r.GET("/amendments/:id", middleware.RequirePermissions("amendment:read"), h.get)
+    r.POST("/amendments/:id/approve", middleware.RequirePermissions("amendment:approve"), h.approve)
+    r.POST("/amendments/:id/reject", middleware.RequirePermissions("amendment:approve"), h.reject)
+}
+
+// amendmentDecision is the optional body of approve/reject.
+type amendmentDecision struct {
+    Note *string `json:"note"`
+}
+
+func (h *AmendmentsHandler) propose(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    planID, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var in models.PlanAmendment
+    if err := c.ShouldBindJSON(&in); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    out, err := h.svc.Propose(planID, &in, currentUserID(c))
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusCreated, out)
+}
+
+// listByPlan lists a plan's amendments, newest first; ?status= filters by pending/approved/rejected.
+func (h *AmendmentsHandler) listByPlan(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    planID, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.ListByPlan(planID, c.Query("status"))
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *AmendmentsHandler) get(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.Get(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// approve applies a pending amendment to its plan and returns it with before/after values.
+func (h *AmendmentsHandler) approve(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var in amendmentDecision
+    if c.Request.ContentLength > 0 {
+        if err := c.ShouldBindJSON(&in); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    }
+    out, err := h.svc.Approve(id, currentUserID(c), in.Note)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *AmendmentsHandler) reject(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var in amendmentDecision
+    if c.Request.ContentLength > 0 {
+        if err := c.ShouldBindJSON(&in); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    }
+    out, err := h.svc.Reject(id, currentUserID(c), in.Note)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+    "encoding/csv"
+    "fmt"
+    "html/template"
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/services"
+    "cutrix-backend/internal/middleware"
+)
+
+// BundlesHandler exposes bundle ticket endpoints: list (JSON/CSV), printable tickets and regeneration.
+type BundlesHandler struct{ svc services.BundlesService }
+
+func NewBundlesHandler(svc services.BundlesService) *BundlesHandler { return &BundlesHandler{svc: svc} }
+
+func (h *BundlesHandler) Register(r *gin.RouterGroup) {
+    r.GET("/bundles/:id", h.get)
+    r.GET("/tasks/:id/bundles", h.listByTask)
+    r.GET("/tasks/:id/bundles/print", h.printByTask)
+    r.POST("/tasks/:id/bundles/regenerate", h.regenerate)
+    r.GET("/plans/:id/bundles", h.listByPlan)
+}
+
+// RegisterProtected registers routes with permissions applied. Use on authenticated groups.
+func (h *BundlesHandler) RegisterProtected(r *gin.RouterGroup) {
+    r.GET("/bundles/:id", middleware.RequirePermissions("bundle:read"), h.get)
+    r.GET("/tasks/:id/bundles", middleware.RequirePermissions("bundle:read"), h.listByTask)
+    r.GET("/tasks/:id/bundles/print", middleware.RequirePermissions("bundle:read"), h.printByTask)
+    r.POST("/tasks/:id/bundles/regenerate", middleware.RequirePermissions("bundle:create"), h.regenerate)
+    r.GET("/plans/:id/bundles", middleware.RequirePermissions("bundle:read"), h.listByPlan)
+}
+
+func (h *BundlesHandler) get(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.GetByID(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// listByTask returns the task's bundles; ?format=csv downloads them as CSV.
+func (h *BundlesHandler) listByTask(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.ListByTask(id)
+    if err != nil { writeSvcError(c, err); return }
+    if c.Query("format") == "csv" { writeBundlesCSV(c, fmt.Sprintf("task-%d-bundles.csv", id), out); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// listByPlan returns bundles of every task under the plan; ?format=csv downloads them as CSV.
+func (h *BundlesHandler) listByPlan(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.ListByPlan(id)
+    if err != nil { writeSvcError(c, err); return }
+    if c.Query("format") == "csv" { writeBundlesCSV(c, fmt.Sprintf("plan-%d-bundles.csv", id), out); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// printByTask renders one ticket per bundle as a printable HTML page.
+func (h *BundlesHandler) printByTask(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.ListByTask(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.Header("Content-Type", "text/html; charset=utf-8")
+    c.Status(http.StatusOK)
+    if err := bundleTicketsTmpl.Execute(c.Writer, gin.H{"TaskID": id, "Bundles": out}); err != nil {
+        _ = c.Error(err)
+    }
+}
+
+func (h *BundlesHandler) regenerate(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body struct{ BundleSize *int `json:"bundle_size"` }
+    // Body is optional: empty body keeps the layout bundle size
+    if c.Request.ContentLength > 0 {
+        if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    }
+    out, err := h.svc.Regenerate(id, body.BundleSize)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// writeBundlesCSV streams bundles as a CSV attachment (UTF-8 with BOM so spreadsheet tools keep Chinese text).
+func writeBundlesCSV(c *gin.Context, filename string, bundles []models.Bundle) {
+    c.Header("Content-Type", "text/csv; charset=utf-8")
+    c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+    c.Status(http.StatusOK)
+    _, _ = c.Writer.Write([]byte("\xEF\xBB\xBF"))
+    w := csv.NewWriter(c.Writer)
+    _ = w.Write([]string{"bundle_id", "bundle_no", "order_number", "style_number", "plan_id", "layout_name", "task_id",
+        "color", "size", "copy_no", "dye_lot", "ply_from", "ply_to", "pieces"})
+    for _, b := range bundles {
+        lot := ""
+        if b.DyeLot != nil { lot = *b.DyeLot }
+        _ = w.Write([]string{
+            strconv.Itoa(b.BundleID), strconv.Itoa(b.BundleNo), b.OrderNumber, b.StyleNumber, strconv.Itoa(b.PlanID),
+            b.LayoutName, strconv.Itoa(b.TaskID), b.Color, b.Size, strconv.Itoa(b.CopyNo), lot,
+            strconv.Itoa(b.PlyFrom), strconv.Itoa(b.PlyTo), strconv.Itoa(b.Pieces),
+        })
+    }
+    w.Flush()
+}
+
+var bundleTicketsTmpl = template.Must(template.New("bundles").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>扎票 - 任务 {{.TaskID}}</title>
+<style>
+body { font-family: sans-serif; margin: 0; }
+.ticket { display: inline-block; width: 9cm; margin: 0.2cm; padding: 0.3cm; border: 1px dashed #333; page-break-inside: avoid; }
+.ticket h2 { margin: 0 0 0.2cm; font-size: 16pt; }
+.ticket td { padding: 1px 6px 1px 0; font-size: 11pt; }
+@media print { .ticket { border-style: solid; } }
+</style></head><body>
+{{range .Bundles}}<div class="ticket">
+<h2>#{{.BundleNo}} · {{.Size}} · {{.Pieces}} 件</h2>
+<table>
+<tr><td>订单</td><td>{{.OrderNumber}}</td><td>款号</td><td>{{.StyleNumber}}</td></tr>
+<tr><td>颜色</td><td>{{.Color}}</td><td>缸号</td><td>{{if .DyeLot}}{{.DyeLot}}{{else}}-{{end}}</td></tr>
+<tr><td>层号</td><td>{{.PlyFrom}}-{{.PlyTo}}</td><td>份次</td><td>{{.CopyNo}}</td></tr>
+<tr><td>布局</td><td>{{.LayoutName}}</td><td>扎包ID</td><td>{{.BundleID}}</td></tr>
+</table></div>
+{{else}}<p>暂无扎包（任务完成后自动生成）</p>{{end}}
+</body></html>`))
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+
+    "cutrix-backend/internal/services"
+    "cutrix-backend/internal/middleware"
+)
+
+// CoverageHandler exposes plan coverage reports (planned/cut pieces vs. order quantities).
+type CoverageHandler struct{ svc services.CoverageService }
+
+func NewCoverageHandler(svc services.CoverageService) *CoverageHandler { return &CoverageHandler{svc: svc} }
+
+func (h *CoverageHandler) Register(r *gin.RouterGroup) {
+    r.GET("/plans/:id/coverage", h.planCoverage)
+}
+
+// RegisterProtected registers routes with permissions applied. Use on authenticated groups.
+func (h *CoverageHandler) RegisterProtected(r *gin.RouterGroup) {
+    r.GET("/plans/:id/coverage", middleware.RequirePermissions("plan:read"), h.planCoverage)
+}
+
+func (h *CoverageHandler) planCoverage(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.PlanCoverage(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/services"
+    "cutrix-backend/internal/middleware"
+)
+
+// CustomersHandler exposes customer master data endpoints: create, list, get, update and delete.
+type CustomersHandler struct{ svc services.CustomersService }
+
+func NewCustomersHandler(svc services.CustomersService) *CustomersHandler { return &CustomersHandler{svc: svc} }
+
+func (h *CustomersHandler) Register(r *gin.RouterGroup) {
+    r.POST("/customers", h.create)
+    r.GET("/customers", h.list)
+    r.GET("/customers/:id", h.get)
+    r.PATCH("/customers/:id", h.update)
+    r.DELETE("/customers/:id", h.delete)
+}
+
+// RegisterProtected registers routes with permissions applied. Use on authenticated groups.
+func (h *CustomersHandler) RegisterProtected(r *gin.RouterGroup) {
+    r.POST("/customers", middleware.RequirePermissions("customer:create"), h.create)
+    r.GET("/customers", middleware.RequirePermissions("customer:read"), h.list)
+    r.GET("/customers/:id", middleware.RequirePermissions("customer:read"), h.get)
+    r.PATCH("/customers/:id", middleware.RequirePermissions("customer:update"), h.update)
+    r.DELETE("/customers/:id", middleware.RequirePermissions("customer:delete"), h.delete)
+}
+
+func (h *CustomersHandler) create(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    var body models.Customer
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    if err := h.svc.Create(&body); err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusCreated, body)
+}
+
+// list supports an optional ?q= filter on code or name.
+func (h *CustomersHandler) list(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    out, err := h.svc.List(c.Query("q"))
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *CustomersHandler) get(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.GetByID(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// update replaces the customer's fields; returns the refreshed customer.
+func (h *CustomersHandler) update(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body models.Customer
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    body.CustomerID = id
+    if err := h.svc.Update(&body); err != nil { writeSvcError(c, err); return }
+    out, err := h.svc.GetByID(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *CustomersHandler) delete(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    if err := h.svc.Delete(id); err != nil { writeSvcError(c, err); return }
+    c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/services"
+    "cutrix-backend/internal/middleware"
+)
+
+// CutPlanningHandler exposes the cut-order-planning optimizer that drafts layouts, ratios and layers.
+type CutPlanningHandler struct{ svc services.CutPlanningService }
+
+func NewCutPlanningHandler(svc services.CutPlanningService) *CutPlanningHandler { return &CutPlanningHandler{svc: svc} }
+
+func (h *CutPlanningHandler) Register(r *gin.RouterGroup) {
+    r.POST("/plans/draft", h.generateDraft)
+}
+
+// RegisterProtected registers routes with permissions applied. Use on authenticated groups.
+func (h *CutPlanningHandler) RegisterProtected(r *gin.RouterGroup) {
+    r.POST("/plans/draft", middleware.RequirePermissions("plan:create"), h.generateDraft)
+}
+
+// generateDraft proposes a cut plan for an order; unless dry_run is set, it is written as a pending plan.
+func (h *CutPlanningHandler) generateDraft(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    var body struct {
+        OrderID  int    `json:"order_id"`
+        PlanName string `json:"plan_name"`
+        DryRun   bool   `json:"dry_run"`
+        models.CutPlanOptions
+    }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    if body.DryRun {
+        proposal, err := h.svc.Propose(body.OrderID, body.CutPlanOptions)
+        if err != nil { writeSvcError(c, err); return }
+        c.JSON(http.StatusOK, gin.H{"proposal": proposal})
+        return
+    }
+    plan, proposal, err := h.svc.GenerateDraft(body.OrderID, body.PlanName, body.CutPlanOptions)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusCreated, gin.H{"plan": plan, "proposal": proposal})
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+
+    "cutrix-backend/internal/services"
+    "cutrix-backend/internal/middleware"
+)
+
+// ForecastHandler exposes order due-date forecasts.
+type ForecastHandler struct{ svc services.ForecastService }
+
+func NewForecastHandler(svc services.ForecastService) *ForecastHandler { return &ForecastHandler{svc: svc} }
+
+func (h *ForecastHandler) Register(r *gin.RouterGroup) {
+    r.GET("/orders/at-risk", h.atRisk)
+}
+
+// RegisterProtected registers routes with permissions applied. Use on authenticated groups.
+func (h *ForecastHandler) RegisterProtected(r *gin.RouterGroup) {
+    r.GET("/orders/at-risk", middleware.RequirePermissions("report:read"), h.atRisk)
+}
+
+// atRisk returns at-risk and late orders; ?all=true also includes on-track orders, ?customer_id= narrows to one customer.
+func (h *ForecastHandler) atRisk(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    customerID, ok := queryCustomerID(c)
+    if !ok { return }
+    out, err := h.svc.AtRisk(c.Query("all") == "true", customerID)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
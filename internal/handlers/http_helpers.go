@@ -4,6 +4,7 @@ import (
     "database/sql"
     "errors"
     "net/http"
+    "strconv"
     "strings"
     "log/slog"
 
@@ -26,7 +27,19 @@ func bearerToken(c *gin.Context) string {
 // Logged fields: method, path, status_code, error, request_id, user_id.
 func writeSvcError(c *gin.Context, err error) {
     var status int
+    var tolErr *services.ToleranceViolationError
+    var lotErr *services.LotMixError
+    var cloneErr *services.PlanCloneMismatchError
     switch {
+    case errors.As(err, &tolErr):
+        status = http.StatusBadRequest
+        c.JSON(status, gin.H{"error":"tolerance_violation", "message": tolErr.Error(), "cells": tolErr.Violations})
+    case errors.As(err, &lotErr):
+        status = http.StatusConflict
+        c.JSON(status, gin.H{"error":"lot_mix", "message": lotErr.Error(), "existing_lots": lotErr.ExistingLots})
+    case errors.As(err, &cloneErr):
+        status = http.StatusBadRequest
+        c.JSON(status, gin.H{"error":"clone_mismatch", "message": cloneErr.Error(), "missing_colors": cloneErr.MissingColors, "missing_sizes": cloneErr.MissingSizes})
     case errors.Is(err, services.ErrUnauthorized):
         status = http.StatusUnauthorized
         c.JSON(status, gin.H{"error":"unauthorized"})
@@ -67,4 +80,28 @@ func writeSvcError(c *gin.Context, err error) {
     default:
         logger.L.Error("http_error", args...)
     }
-}
\ No newline at end of file
+}
+// currentRole returns the lower-cased role set by RequireAuth; empty on unauthenticated routes.
+func currentRole(c *gin.Context) string {
+    v, _ := c.Get("role")
+    role, _ := v.(string)
+    return strings.ToLower(strings.TrimSpace(role))
+}
+
+// currentUserID returns the user_id set by RequireAuth; nil on unauthenticated routes.
+func currentUserID(c *gin.Context) *int {
+    v, ok := c.Get("user_id")
+    if !ok { return nil }
+    id, ok := v.(int)
+    if !ok { return nil }
+    return &id
+}
+
+// queryCustomerID parses the optional ?customer_id= filter; on a malformed value it writes invalid_id and returns false.
+func queryCustomerID(c *gin.Context) (*int, bool) {
+    v := strings.TrimSpace(c.Query("customer_id"))
+    if v == "" { return nil, true }
+    id, err := strconv.Atoi(v)
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return nil, false }
+    return &id, true
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+    "fmt"
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/services"
+    "cutrix-backend/internal/middleware"
+)
+
+// LabelsHandler exposes printable bundle/task labels (ZPL or PDF) and the scan lookup endpoint.
+type LabelsHandler struct{ svc services.LabelsService }
+
+func NewLabelsHandler(svc services.LabelsService) *LabelsHandler { return &LabelsHandler{svc: svc} }
+
+func (h *LabelsHandler) Register(r *gin.RouterGroup) {
+    r.GET("/bundles/:id/label", h.bundleLabel)
+    r.GET("/tasks/:id/label", h.taskLabel)
+    r.GET("/tasks/:id/bundles/labels", h.taskBundleLabels)
+    r.GET("/labels/lookup", h.lookup)
+}
+
+// RegisterProtected registers routes with permissions applied. Use on authenticated groups.
+func (h *LabelsHandler) RegisterProtected(r *gin.RouterGroup) {
+    r.GET("/bundles/:id/label", middleware.RequirePermissions("label:read"), h.bundleLabel)
+    r.GET("/tasks/:id/label", middleware.RequirePermissions("label:read"), h.taskLabel)
+    r.GET("/tasks/:id/bundles/labels", middleware.RequirePermissions("label:read"), h.taskBundleLabels)
+    r.GET("/labels/lookup", middleware.RequirePermissions("label:read"), h.lookup)
+}
+
+// bundleLabel renders one bundle label; ?format=pdf|zpl&symbology=code128|qr.
+func (h *LabelsHandler) bundleLabel(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    doc, err := h.svc.BundleLabel(id, c.Query("format"), c.Query("symbology"))
+    if err != nil { writeSvcError(c, err); return }
+    writeLabelDocument(c, doc)
+}
+
+// taskLabel renders the task card label.
+func (h *LabelsHandler) taskLabel(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    doc, err := h.svc.TaskLabel(id, c.Query("format"), c.Query("symbology"))
+    if err != nil { writeSvcError(c, err); return }
+    writeLabelDocument(c, doc)
+}
+
+// taskBundleLabels renders labels for every bundle of the task in one document.
+func (h *LabelsHandler) taskBundleLabels(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    doc, err := h.svc.TaskBundleLabels(id, c.Query("format"), c.Query("symbology"))
+    if err != nil { writeSvcError(c, err); return }
+    writeLabelDocument(c, doc)
+}
+
+// lookup resolves a scanned payload (?code=...) to its bundle or task.
+func (h *LabelsHandler) lookup(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    out, err := h.svc.Lookup(c.Query("code"))
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// writeLabelDocument sends the rendered labels inline so browsers preview PDFs and print agents can stream ZPL.
+func writeLabelDocument(c *gin.Context, doc *models.LabelDocument) {
+    c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, doc.Filename))
+    c.Data(http.StatusOK, doc.ContentType, doc.Body)
+}
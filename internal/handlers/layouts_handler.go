@@ -22,7 +22,11 @@ func (h *LayoutsHandler) Register(r *gin.RouterGroup) {
     r.GET("/layouts/:id", h.get)
     r.GET("/plans/:id/layouts", h.listByPlan)
     r.PATCH("/layouts/:id/name", h.updateName)
+    r.PATCH("/layouts/:id/component", h.updateComponent)
     r.PATCH("/layouts/:id/note", h.updateNote)
+    r.PATCH("/layouts/:id/marker", h.updateMarker)
+    r.PATCH("/layouts/:id/bundle-size", h.updateBundleSize)
+    r.GET("/layouts/:id/fabric", h.fabric)
     r.POST("/layouts/:id/ratios", h.setRatios)
     r.GET("/layouts/:id/ratios", h.getRatios)
     r.POST("/layouts/ratios/batch", h.getRatiosBatch)
@@ -36,7 +40,11 @@ func (h *LayoutsHandler) RegisterProtected(r *gin.RouterGroup) {
     r.GET("/layouts/:id", middleware.RequirePermissions("layout:read"), h.get)
     r.GET("/plans/:id/layouts", middleware.RequirePermissions("layout:read"), h.listByPlan)
     r.PATCH("/layouts/:id/name", middleware.RequirePermissions("layout:update"), h.updateName)
+    r.PATCH("/layouts/:id/component", middleware.RequirePermissions("layout:update"), h.updateComponent)
     r.PATCH("/layouts/:id/note", middleware.RequirePermissions("layout:update"), h.updateNote)
+    r.PATCH("/layouts/:id/marker", middleware.RequirePermissions("layout:update"), h.updateMarker)
+    r.PATCH("/layouts/:id/bundle-size", middleware.RequirePermissions("layout:update"), h.updateBundleSize)
+    r.GET("/layouts/:id/fabric", middleware.RequirePermissions("layout:read"), h.fabric)
     r.POST("/layouts/:id/ratios", middleware.RequirePermissions("layout_ratios:create"), h.setRatios)
     r.GET("/layouts/:id/ratios", middleware.RequirePermissions("layout_ratios:read"), h.getRatios)
     r.POST("/layouts/ratios/batch", middleware.RequirePermissions("layout_ratios:read"), h.getRatiosBatch)
@@ -93,6 +101,16 @@ func (h *LayoutsHandler) updateName(c *gin.Context) {
     c.Status(http.StatusNoContent)
 }
 
+func (h *LayoutsHandler) updateComponent(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body struct{ Component string `json:"component"` }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    if err := h.svc.UpdateComponent(id, body.Component); err != nil { writeSvcError(c, err); return }
+    c.Status(http.StatusNoContent)
+}
+
 func (h *LayoutsHandler) updateNote(c *gin.Context) {
     if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
     id, err := strconv.Atoi(c.Param("id"))
@@ -129,4 +147,33 @@ func (h *LayoutsHandler) getRatiosBatch(c *gin.Context) {
     out, err := h.svc.GetRatiosBatch(body.LayoutIDs)
     if err != nil { writeSvcError(c, err); return }
     c.JSON(http.StatusOK, out)
+}
+
+func (h *LayoutsHandler) updateMarker(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body models.MarkerSpec
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    if err := h.svc.UpdateMarker(id, body); err != nil { writeSvcError(c, err); return }
+    c.Status(http.StatusNoContent)
+}
+
+func (h *LayoutsHandler) updateBundleSize(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body struct{ BundleSize *int `json:"bundle_size"` }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    if err := h.svc.UpdateBundleSize(id, body.BundleSize); err != nil { writeSvcError(c, err); return }
+    c.Status(http.StatusNoContent)
+}
+
+func (h *LayoutsHandler) fabric(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.Fabric(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
 }
\ No newline at end of file
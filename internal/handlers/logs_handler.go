@@ -13,10 +13,19 @@ import (
     "cutrix-backend/internal/middleware"
 )
 
-type LogsHandler struct{ svc services.LogsService }
+type LogsHandler struct{
+    svc         services.LogsService
+    assignments services.TaskAssignmentsService // non-nil: workers may only log against tasks assigned to them
+}
 
 func NewLogsHandler(svc services.LogsService) *LogsHandler { return &LogsHandler{svc: svc} }
 
+// RequireAssignment makes POST /logs refuse (403) worker logs on tasks not assigned to the worker or their group.
+func (h *LogsHandler) RequireAssignment(assignments services.TaskAssignmentsService) *LogsHandler {
+    h.assignments = assignments
+    return h
+}
+
 func (h *LogsHandler) Register(r *gin.RouterGroup) {
     r.POST("/logs", h.create)
     r.PATCH("/logs/:id", h.void)
@@ -55,6 +64,9 @@ func (h *LogsHandler) create(c *gin.Context) {
     if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
     var in models.ProductionLog
     if err := c.ShouldBindJSON(&in); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    if uid := currentUserID(c); h.assignments != nil && uid != nil && currentRole(c) == "worker" {
+        if err := h.assignments.EnsureAssignee(in.TaskID, *uid); err != nil { writeSvcError(c, err); return }
+    }
     if err := h.svc.Create(&in); err != nil { writeSvcError(c, err); return }
     c.JSON(http.StatusCreated, in)
 }
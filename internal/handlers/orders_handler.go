@@ -3,6 +3,7 @@ package handlers
 import (
     "net/http"
     "strconv"
+    "strings"
     "time"
 
     "github.com/gin-gonic/gin"
@@ -12,7 +13,7 @@ import (
     "cutrix-backend/internal/middleware"
 )
 
-// OrdersHandler exposes production order endpoints: create with items, query, update, delete.
+// OrdersHandler exposes production order endpoints: create with items, query, update, revise items, delete.
 type OrdersHandler struct{ svc services.OrdersService }
 
 func NewOrdersHandler(svc services.OrdersService) *OrdersHandler { return &OrdersHandler{svc: svc} }
@@ -25,9 +26,18 @@ func (h *OrdersHandler) Register(r *gin.RouterGroup) {
     r.GET("/orders/:id", h.get)
     r.GET("/orders/by-number/:number", h.getByNumber)
     r.GET("/orders/:id/full", h.getFull)
+    r.GET("/orders/:id/progress", h.progress)
+    r.GET("/orders/progress", h.progressBatch)
     // Updates
     r.PATCH("/orders/:id/note", h.updateNote)
     r.PATCH("/orders/:id/finish-date", h.updateFinishDate)
+    r.PATCH("/orders/:id/tolerance", h.updateTolerance)
+    r.PATCH("/orders/:id/status", h.updateStatus)
+    r.PATCH("/orders/:id/customer", h.updateCustomer)
+    // Revisions
+    r.POST("/orders/:id/revisions", h.revise)
+    r.GET("/orders/:id/revisions", h.listRevisions)
+    r.GET("/orders/:id/revisions/:revision", h.getRevision)
     // Delete
     r.DELETE("/orders/:id", h.delete)
 }
@@ -41,9 +51,18 @@ func (h *OrdersHandler) RegisterProtected(r *gin.RouterGroup) {
     r.GET("/orders/:id", h.get)
     r.GET("/orders/by-number/:number", h.getByNumber)
     r.GET("/orders/:id/full", h.getFull)
+    r.GET("/orders/:id/progress", h.progress)
+    r.GET("/orders/progress", h.progressBatch)
     // Updates restricted to admin/manager
     r.PATCH("/orders/:id/note", middleware.RequireRoles("admin", "manager"), h.updateNote)
     r.PATCH("/orders/:id/finish-date", middleware.RequireRoles("admin", "manager"), h.updateFinishDate)
+    r.PATCH("/orders/:id/tolerance", middleware.RequireRoles("admin", "manager"), h.updateTolerance)
+    r.PATCH("/orders/:id/status", middleware.RequireRoles("admin", "manager"), h.updateStatus)
+    r.PATCH("/orders/:id/customer", middleware.RequireRoles("admin", "manager"), h.updateCustomer)
+    // Revisions: creating one restricted to admin/manager; history readable by any authenticated role
+    r.POST("/orders/:id/revisions", middleware.RequireRoles("admin", "manager"), h.revise)
+    r.GET("/orders/:id/revisions", h.listRevisions)
+    r.GET("/orders/:id/revisions/:revision", h.getRevision)
     // Delete restricted to admin/manager
     r.DELETE("/orders/:id", middleware.RequireRoles("admin", "manager"), h.delete)
 }
@@ -61,10 +80,16 @@ func (h *OrdersHandler) create(c *gin.Context) {
     c.JSON(http.StatusCreated, out)
 }
 
-// list returns all orders ordered by created_at desc.
+// list returns orders ordered by created_at desc; ?status=, ?customer_id= and ?style_number= narrow the list.
 func (h *OrdersHandler) list(c *gin.Context) {
     if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
-    out, err := h.svc.GetAll(c.Request.Context())
+    var filter models.OrderFilter
+    if v, ok := c.GetQuery("status"); ok && v != "" { filter.Status = &v }
+    if v, ok := c.GetQuery("style_number"); ok && v != "" { filter.StyleNumber = &v }
+    customerID, ok := queryCustomerID(c)
+    if !ok { return }
+    filter.CustomerID = customerID
+    out, err := h.svc.GetAll(c.Request.Context(), filter)
     if err != nil { writeSvcError(c, err); return }
     c.JSON(http.StatusOK, out)
 }
@@ -98,6 +123,32 @@ func (h *OrdersHandler) getFull(c *gin.Context) {
     c.JSON(http.StatusOK, gin.H{"order": order, "items": items})
 }
 
+// progress returns the rollup of every plan, layout and task under the order.
+func (h *OrdersHandler) progress(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.Progress(c.Request.Context(), id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// progressBatch returns progress for ?ids=1,2,3 in one call (orders list page); unknown IDs are omitted.
+func (h *OrdersHandler) progressBatch(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    var ids []int
+    for _, part := range strings.Split(c.Query("ids"), ",") {
+        part = strings.TrimSpace(part)
+        if part == "" { continue }
+        id, err := strconv.Atoi(part)
+        if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+        ids = append(ids, id)
+    }
+    out, err := h.svc.ProgressBatch(c.Request.Context(), ids)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
 // updateNote updates the order note.
 func (h *OrdersHandler) updateNote(c *gin.Context) {
     if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
@@ -120,6 +171,81 @@ func (h *OrdersHandler) updateFinishDate(c *gin.Context) {
     c.Status(http.StatusNoContent)
 }
 
+// updateTolerance updates the order over/under-cut tolerance (percent, null disables that side).
+func (h *OrdersHandler) updateTolerance(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body struct {
+        Over  *float64 `json:"over_cut_tolerance"`
+        Under *float64 `json:"under_cut_tolerance"`
+    }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    if err := h.svc.UpdateTolerance(c.Request.Context(), id, body.Over, body.Under); err != nil { writeSvcError(c, err); return }
+    c.Status(http.StatusNoContent)
+}
+
+// updateStatus applies a manual status transition and returns the updated order.
+func (h *OrdersHandler) updateStatus(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body struct{ Status string `json:"status"` }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    out, err := h.svc.UpdateStatus(c.Request.Context(), id, body.Status)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// updateCustomer points the order at another customer record; null unlinks it.
+func (h *OrdersHandler) updateCustomer(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body struct{ CustomerID *int `json:"customer_id"` }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    out, err := h.svc.UpdateCustomer(c.Request.Context(), id, body.CustomerID)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// revise applies item changes as a new order revision.
+func (h *OrdersHandler) revise(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body struct {
+        Reason  *string                  `json:"reason"`
+        Changes []models.OrderItemChange `json:"changes"`
+    }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    out, err := h.svc.Revise(c.Request.Context(), id, body.Changes, body.Reason, currentUserID(c))
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusCreated, out)
+}
+
+// listRevisions returns the order's revisions, newest first.
+func (h *OrdersHandler) listRevisions(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.ListRevisions(c.Request.Context(), id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// getRevision returns one revision of the order with its item snapshot.
+func (h *OrdersHandler) getRevision(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    revision, err := strconv.Atoi(c.Param("revision"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.GetRevision(c.Request.Context(), id, revision)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
 // delete removes an order by ID.
 func (h *OrdersHandler) delete(c *gin.Context) {
     if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
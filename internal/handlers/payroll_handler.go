@@ -0,0 +1,192 @@
+package handlers
+
+import (
+    "encoding/csv"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/services"
+    "cutrix-backend/internal/middleware"
+)
+
+// PayrollHandler exposes piece rates, pay periods and per-worker payroll statements.
+type PayrollHandler struct{ svc services.PayrollService }
+
+func NewPayrollHandler(svc services.PayrollService) *PayrollHandler { return &PayrollHandler{svc: svc} }
+
+func (h *PayrollHandler) Register(r *gin.RouterGroup) {
+    r.GET("/payroll/rates", h.listRates)
+    r.POST("/payroll/rates", h.createRate)
+    r.PATCH("/payroll/rates/:id", h.updateRate)
+    r.DELETE("/payroll/rates/:id", h.deleteRate)
+    r.GET("/payroll/periods", h.listPeriods)
+    r.POST("/payroll/periods", h.createPeriod)
+    r.GET("/payroll/periods/:id", h.getPeriod)
+    r.DELETE("/payroll/periods/:id", h.deletePeriod)
+    r.POST("/payroll/periods/:id/lock", h.lockPeriod)
+    r.GET("/payroll/periods/:id/statement", h.statement)
+}
+
+// RegisterProtected registers routes with permissions applied. Use on authenticated groups.
+func (h *PayrollHandler) RegisterProtected(r *gin.RouterGroup) {
+    r.GET("/payroll/rates", middleware.RequirePermissions("payroll:read"), h.listRates)
+    r.POST("/payroll/rates", middleware.RequirePermissions("payroll:update"), h.createRate)
+    r.PATCH("/payroll/rates/:id", middleware.RequirePermissions("payroll:update"), h.updateRate)
+    r.DELETE("/payroll/rates/:id", middleware.RequirePermissions("payroll:update"), h.deleteRate)
+    r.GET("/payroll/periods", middleware.RequirePermissions("payroll:read"), h.listPeriods)
+    r.POST("/payroll/periods", middleware.RequirePermissions("payroll:update"), h.createPeriod)
+    r.GET("/payroll/periods/:id", middleware.RequirePermissions("payroll:read"), h.getPeriod)
+    r.DELETE("/payroll/periods/:id", middleware.RequirePermissions("payroll:update"), h.deletePeriod)
+    r.POST("/payroll/periods/:id/lock", middleware.RequirePermissions("payroll:lock"), h.lockPeriod)
+    r.GET("/payroll/periods/:id/statement", middleware.RequirePermissions("payroll:read"), h.statement)
+}
+
+func (h *PayrollHandler) listRates(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    out, err := h.svc.ListRates()
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *PayrollHandler) createRate(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    var rate models.PieceRate
+    if err := c.ShouldBindJSON(&rate); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    if err := h.svc.CreateRate(&rate); err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusCreated, rate)
+}
+
+// updateRate changes rate_per_layer and note; the scope (layout/style/default) is fixed at creation.
+func (h *PayrollHandler) updateRate(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body struct {
+        RatePerLayer float64 `json:"rate_per_layer"`
+        Note         *string `json:"note"`
+    }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    rate := models.PieceRate{RateID: id, RatePerLayer: body.RatePerLayer, Note: body.Note}
+    if err := h.svc.UpdateRate(&rate); err != nil { writeSvcError(c, err); return }
+    out, err := h.svc.GetRate(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *PayrollHandler) deleteRate(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    if err := h.svc.DeleteRate(id); err != nil { writeSvcError(c, err); return }
+    c.Status(http.StatusNoContent)
+}
+
+func (h *PayrollHandler) listPeriods(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    out, err := h.svc.ListPeriods()
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *PayrollHandler) createPeriod(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    var body struct {
+        PeriodStart string `json:"period_start"`
+        PeriodEnd   string `json:"period_end"`
+    }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    period := models.PayPeriod{PeriodStart: body.PeriodStart, PeriodEnd: body.PeriodEnd}
+    if err := h.svc.CreatePeriod(&period); err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusCreated, period)
+}
+
+func (h *PayrollHandler) getPeriod(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.GetPeriod(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *PayrollHandler) deletePeriod(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    if err := h.svc.DeletePeriod(id); err != nil { writeSvcError(c, err); return }
+    c.Status(http.StatusNoContent)
+}
+
+// lockPeriod snapshots the statement and returns the locked period; the caller is recorded as locked_by.
+func (h *PayrollHandler) lockPeriod(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.LockPeriod(id, currentUserID(c))
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// statement returns the period's payroll; ?worker_id= narrows it to one worker, ?format=csv returns line items as CSV.
+func (h *PayrollHandler) statement(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var workerID *int
+    if v := c.Query("worker_id"); v != "" {
+        wid, err := strconv.Atoi(v)
+        if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+        workerID = &wid
+    }
+    format := strings.ToLower(c.DefaultQuery("format", "json"))
+    if format != "json" && format != "csv" { c.JSON(http.StatusBadRequest, gin.H{"error":"validation_error"}); return }
+    out, err := h.svc.Statement(id, workerID)
+    if err != nil { writeSvcError(c, err); return }
+    if format == "csv" {
+        writeStatementCSV(c, out)
+        return
+    }
+    c.JSON(http.StatusOK, out)
+}
+
+// writeStatementCSV sends one row per line item so the file can be totalled or pivoted in a spreadsheet.
+func writeStatementCSV(c *gin.Context, st *models.PayrollStatement) {
+    c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="payroll-%d-%s-%s.csv"`, st.Period.PeriodID, st.Period.PeriodStart, st.Period.PeriodEnd))
+    c.Header("Content-Type", "text/csv; charset=utf-8")
+    c.Status(http.StatusOK)
+    w := csv.NewWriter(c.Writer)
+    _ = w.Write([]string{"period_id", "status", "worker_id", "worker_name", "kind", "log_id", "log_time", "shift_date",
+        "task_id", "layout_id", "layout_name", "style_number", "color", "layers", "rate_per_layer", "rate_scope", "amount"})
+    for _, ws := range st.Workers {
+        for _, l := range ws.Lines {
+            _ = w.Write([]string{
+                strconv.Itoa(st.Period.PeriodID), st.Period.Status, csvInt(l.WorkerID), ws.WorkerName, l.Kind, csvInt(l.LogID),
+                l.LogTime.Format(time.RFC3339), csvString(l.ShiftDate), csvInt(l.TaskID), csvInt(l.LayoutID), csvString(l.LayoutName),
+                csvString(l.StyleNumber), csvString(l.Color), strconv.Itoa(l.Layers), csvFloat(l.RatePerLayer, 4), csvString(l.RateScope),
+                strconv.FormatFloat(l.Amount, 'f', 2, 64),
+            })
+        }
+    }
+    w.Flush()
+}
+
+func csvInt(v *int) string {
+    if v == nil { return "" }
+    return strconv.Itoa(*v)
+}
+
+func csvString(v *string) string {
+    if v == nil { return "" }
+    return *v
+}
+
+func csvFloat(v *float64, prec int) string {
+    if v == nil { return "" }
+    return strconv.FormatFloat(*v, 'f', prec, 64)
+}
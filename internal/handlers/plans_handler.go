@@ -18,24 +18,34 @@ func NewPlansHandler(svc services.PlansService) *PlansHandler { return &PlansHan
 func (h *PlansHandler) Register(r *gin.RouterGroup) {
     r.GET("/plans", h.list)
     r.POST("/plans", h.create)
+    r.POST("/plans/:id/clone", h.clone)
     r.DELETE("/plans/:id", h.delete)
     r.GET("/plans/:id", h.get)
     r.GET("/orders/:id/plans", h.listByOrder)
     r.PATCH("/plans/:id/note", h.updateNote)
     r.POST("/plans/:id/publish", h.publish)
     r.POST("/plans/:id/freeze", h.freeze)
+    r.POST("/plans/:id/reopen", h.reopen)
+    r.GET("/plans/:id/reopen-history", h.reopenHistory)
+    r.GET("/plans/:id/fabric", h.fabric)
+    r.GET("/orders/:id/fabric", h.fabricByOrder)
 }
 
 // RegisterProtected registers routes with permissions applied. Use on authenticated groups.
 func (h *PlansHandler) RegisterProtected(r *gin.RouterGroup) {
     r.GET("/plans", middleware.RequirePermissions("plan:read"), h.list)
     r.POST("/plans", middleware.RequirePermissions("plan:create"), h.create)
+    r.POST("/plans/:id/clone", middleware.RequirePermissions("plan:create"), h.clone)
     r.DELETE("/plans/:id", middleware.RequirePermissions("plan:delete"), h.delete)
     r.GET("/plans/:id", middleware.RequirePermissions("plan:read"), h.get)
     r.GET("/orders/:id/plans", middleware.RequirePermissions("plan:read"), h.listByOrder)
     r.PATCH("/plans/:id/note", middleware.RequirePermissions("plan:update"), h.updateNote)
     r.POST("/plans/:id/publish", middleware.RequirePermissions("plan:publish"), h.publish)
     r.POST("/plans/:id/freeze", middleware.RequirePermissions("plan:freeze"), h.freeze)
+    r.POST("/plans/:id/reopen", middleware.RequirePermissions("plan:reopen"), h.reopen)
+    r.GET("/plans/:id/reopen-history", middleware.RequirePermissions("plan:read"), h.reopenHistory)
+    r.GET("/plans/:id/fabric", middleware.RequirePermissions("plan:read"), h.fabric)
+    r.GET("/orders/:id/fabric", middleware.RequirePermissions("plan:read"), h.fabricByOrder)
 }
 
 func (h *PlansHandler) list(c *gin.Context) {
@@ -53,6 +63,21 @@ func (h *PlansHandler) create(c *gin.Context) {
     c.JSON(http.StatusCreated, in)
 }
 
+// clone copies a plan's layouts, ratios and tasks into a new pending plan (optionally on another order).
+func (h *PlansHandler) clone(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var in models.PlanCloneOptions
+    // Body is optional: empty body clones onto the source plan's order
+    if c.Request.ContentLength > 0 {
+        if err := c.ShouldBindJSON(&in); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    }
+    plan, layouts, err := h.svc.Clone(id, in)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusCreated, gin.H{"plan": plan, "layouts": layouts})
+}
+
 func (h *PlansHandler) delete(c *gin.Context) {
     if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
     id, err := strconv.Atoi(c.Param("id"))
@@ -133,4 +158,43 @@ func (h *PlansHandler) freeze(c *gin.Context) {
     if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
     if err := h.svc.Freeze(id); err != nil { writeSvcError(c, err); return }
     c.Status(http.StatusNoContent)
+}
+
+// reopen moves a completed or frozen plan back to in_progress for a recut; a reason is required.
+func (h *PlansHandler) reopen(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body struct{ Reason string `json:"reason"` }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    plan, reopening, err := h.svc.Reopen(id, body.Reason, currentUserID(c))
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, gin.H{"plan": plan, "reopening": reopening})
+}
+
+func (h *PlansHandler) reopenHistory(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.ReopenHistory(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *PlansHandler) fabric(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.Fabric(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *PlansHandler) fabricByOrder(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    orderID, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.FabricByOrder(orderID)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
 }
\ No newline at end of file
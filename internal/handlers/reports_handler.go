@@ -0,0 +1,51 @@
+package handlers
+
+import (
+    "net/http"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+
+    "cutrix-backend/internal/services"
+    "cutrix-backend/internal/middleware"
+)
+
+// ReportsHandler exposes read-only production reports.
+type ReportsHandler struct{ svc services.ReportsService }
+
+func NewReportsHandler(svc services.ReportsService) *ReportsHandler { return &ReportsHandler{svc: svc} }
+
+func (h *ReportsHandler) Register(r *gin.RouterGroup) {
+    r.GET("/reports/shifts", h.shifts)
+    r.GET("/reports/productivity", h.productivity)
+}
+
+// RegisterProtected registers routes with permissions applied. Use on authenticated groups.
+func (h *ReportsHandler) RegisterProtected(r *gin.RouterGroup) {
+    r.GET("/reports/shifts", middleware.RequirePermissions("report:read"), h.shifts)
+    r.GET("/reports/productivity", middleware.RequirePermissions("report:read"), h.productivity)
+}
+
+// shifts returns layers and pieces per shift for ?date=YYYY-MM-DD (shift date, factory timezone; default today);
+// ?customer_id= limits it to that customer's orders.
+func (h *ReportsHandler) shifts(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    customerID, ok := queryCustomerID(c)
+    if !ok { return }
+    out, err := h.svc.ShiftReport(c.Query("date"), customerID)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// productivity returns per-worker rates for ?from=&to= (shift dates) and ?group_by=worker,group,layout,color;
+// ?customer_id= limits it to that customer's orders.
+func (h *ReportsHandler) productivity(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    var groupBy []string
+    if v := c.Query("group_by"); v != "" { groupBy = strings.Split(v, ",") }
+    customerID, ok := queryCustomerID(c)
+    if !ok { return }
+    out, err := h.svc.Productivity(c.Query("from"), c.Query("to"), groupBy, customerID)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
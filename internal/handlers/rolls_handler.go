@@ -0,0 +1,112 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/services"
+    "cutrix-backend/internal/middleware"
+)
+
+// RollsHandler exposes fabric roll inventory endpoints: receive, query, note, close, delete and usage.
+type RollsHandler struct{ svc services.RollsService }
+
+func NewRollsHandler(svc services.RollsService) *RollsHandler { return &RollsHandler{svc: svc} }
+
+func (h *RollsHandler) Register(r *gin.RouterGroup) {
+    r.POST("/rolls", h.create)
+    r.GET("/rolls", h.list)
+    r.GET("/rolls/:id", h.get)
+    r.GET("/rolls/:id/usage", h.usage)
+    r.PATCH("/rolls/:id/note", h.updateNote)
+    r.POST("/rolls/:id/close", h.close)
+    r.DELETE("/rolls/:id", h.delete)
+}
+
+// RegisterProtected registers routes with permissions applied. Use on authenticated groups.
+func (h *RollsHandler) RegisterProtected(r *gin.RouterGroup) {
+    r.POST("/rolls", middleware.RequirePermissions("roll:create"), h.create)
+    r.GET("/rolls", middleware.RequirePermissions("roll:read"), h.list)
+    r.GET("/rolls/:id", middleware.RequirePermissions("roll:read"), h.get)
+    r.GET("/rolls/:id/usage", middleware.RequirePermissions("roll:read"), h.usage)
+    r.PATCH("/rolls/:id/note", middleware.RequirePermissions("roll:update"), h.updateNote)
+    r.POST("/rolls/:id/close", middleware.RequirePermissions("roll:update"), h.close)
+    r.DELETE("/rolls/:id", middleware.RequirePermissions("roll:delete"), h.delete)
+}
+
+func (h *RollsHandler) create(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    var body models.FabricRoll
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    if err := h.svc.Create(&body); err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusCreated, body)
+}
+
+// list supports optional filters: ?color=&dye_lot=&status=
+func (h *RollsHandler) list(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    var filter models.RollFilter
+    if v := strings.TrimSpace(c.Query("color")); v != "" { filter.Color = &v }
+    if v := strings.TrimSpace(c.Query("dye_lot")); v != "" { filter.DyeLot = &v }
+    if v := strings.TrimSpace(c.Query("status")); v != "" { filter.Status = &v }
+    out, err := h.svc.List(filter)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *RollsHandler) get(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.GetByID(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *RollsHandler) usage(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.ListUsage(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *RollsHandler) updateNote(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body struct{ Note *string `json:"note"` }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    if err := h.svc.UpdateNote(id, body.Note); err != nil { writeSvcError(c, err); return }
+    c.Status(http.StatusNoContent)
+}
+
+// close records the measured remnant length and closes the roll.
+func (h *RollsHandler) close(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body struct {
+        RemnantLength *float64 `json:"remnant_length"`
+        Note          *string  `json:"note"`
+    }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    if body.RemnantLength == nil { c.JSON(http.StatusBadRequest, gin.H{"error":"validation_error"}); return }
+    if err := h.svc.Close(id, *body.RemnantLength, body.Note); err != nil { writeSvcError(c, err); return }
+    out, err := h.svc.GetByID(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *RollsHandler) delete(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    if err := h.svc.Delete(id); err != nil { writeSvcError(c, err); return }
+    c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+
+    "cutrix-backend/internal/services"
+    "cutrix-backend/internal/middleware"
+)
+
+// ScheduleHandler exposes the table/shift timeline computed by the scheduler.
+type ScheduleHandler struct{ svc services.ScheduleService }
+
+func NewScheduleHandler(svc services.ScheduleService) *ScheduleHandler { return &ScheduleHandler{svc: svc} }
+
+func (h *ScheduleHandler) Register(r *gin.RouterGroup) {
+    r.GET("/schedule", h.get)
+}
+
+// RegisterProtected registers routes with permissions applied. Use on authenticated groups.
+func (h *ScheduleHandler) RegisterProtected(r *gin.RouterGroup) {
+    r.GET("/schedule", middleware.RequirePermissions("schedule:read"), h.get)
+}
+
+// get returns the Gantt timeline; ?from=&to= accept RFC3339 or YYYY-MM-DD (server local time).
+func (h *ScheduleHandler) get(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    from, ok := parseTimeQuery(c, "from")
+    if !ok { return }
+    to, ok := parseTimeQuery(c, "to")
+    if !ok { return }
+    out, err := h.svc.Build(from, to)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// parseTimeQuery reads an optional time query parameter; on a bad value it writes 400 and returns ok=false.
+func parseTimeQuery(c *gin.Context, name string) (*time.Time, bool) {
+    v := c.Query(name)
+    if v == "" { return nil, true }
+    if t, err := time.Parse(time.RFC3339, v); err == nil { return &t, true }
+    if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil { return &t, true }
+    c.JSON(http.StatusBadRequest, gin.H{"error":"validation_error"})
+    return nil, false
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/services"
+    "cutrix-backend/internal/middleware"
+)
+
+// ShiftsHandler exposes shift definitions and the factory timezone used to attribute logs to shifts.
+type ShiftsHandler struct{ svc services.ShiftsService }
+
+func NewShiftsHandler(svc services.ShiftsService) *ShiftsHandler { return &ShiftsHandler{svc: svc} }
+
+// shiftBody is the create/update payload; is_active defaults to true on create and is kept on update when omitted.
+type shiftBody struct {
+    ShiftName string  `json:"shift_name"`
+    StartTime string  `json:"start_time"`
+    EndTime   string  `json:"end_time"`
+    IsActive  *bool   `json:"is_active"`
+    Note      *string `json:"note"`
+}
+
+func (h *ShiftsHandler) Register(r *gin.RouterGroup) {
+    r.POST("/shifts", h.create)
+    r.GET("/shifts", h.list)
+    r.GET("/shifts/:id", h.get)
+    r.PATCH("/shifts/:id", h.update)
+    r.DELETE("/shifts/:id", h.delete)
+    r.GET("/factory-settings", h.settings)
+    r.PATCH("/factory-settings", h.updateSettings)
+}
+
+// RegisterProtected registers routes with permissions applied. Use on authenticated groups.
+func (h *ShiftsHandler) RegisterProtected(r *gin.RouterGroup) {
+    r.POST("/shifts", middleware.RequirePermissions("shift:create"), h.create)
+    r.GET("/shifts", middleware.RequirePermissions("shift:read"), h.list)
+    r.GET("/shifts/:id", middleware.RequirePermissions("shift:read"), h.get)
+    r.PATCH("/shifts/:id", middleware.RequirePermissions("shift:update"), h.update)
+    r.DELETE("/shifts/:id", middleware.RequirePermissions("shift:delete"), h.delete)
+    r.GET("/factory-settings", middleware.RequirePermissions("shift:read"), h.settings)
+    r.PATCH("/factory-settings", middleware.RequirePermissions("shift:update"), h.updateSettings)
+}
+
+func (h *ShiftsHandler) create(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    var body shiftBody
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    shift := models.Shift{ShiftName: body.ShiftName, StartTime: body.StartTime, EndTime: body.EndTime, IsActive: true, Note: body.Note}
+    if body.IsActive != nil { shift.IsActive = *body.IsActive }
+    if err := h.svc.Create(&shift); err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusCreated, shift)
+}
+
+// list supports ?active=true to return enabled shifts only.
+func (h *ShiftsHandler) list(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    activeOnly := false
+    if v := c.Query("active"); v != "" {
+        b, err := strconv.ParseBool(v)
+        if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"validation_error"}); return }
+        activeOnly = b
+    }
+    out, err := h.svc.List(activeOnly)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *ShiftsHandler) get(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.GetByID(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// update replaces name, times and note; returns the refreshed shift.
+func (h *ShiftsHandler) update(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body shiftBody
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    current, err := h.svc.GetByID(id)
+    if err != nil { writeSvcError(c, err); return }
+    shift := models.Shift{ShiftID: id, ShiftName: body.ShiftName, StartTime: body.StartTime, EndTime: body.EndTime, IsActive: current.IsActive, Note: body.Note}
+    if body.IsActive != nil { shift.IsActive = *body.IsActive }
+    if err := h.svc.Update(&shift); err != nil { writeSvcError(c, err); return }
+    out, err := h.svc.GetByID(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *ShiftsHandler) delete(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    if err := h.svc.Delete(id); err != nil { writeSvcError(c, err); return }
+    c.Status(http.StatusNoContent)
+}
+
+func (h *ShiftsHandler) settings(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    out, err := h.svc.GetSettings()
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *ShiftsHandler) updateSettings(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    var body struct{ Timezone string `json:"timezone"` }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    out, err := h.svc.SetTimezone(body.Timezone)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
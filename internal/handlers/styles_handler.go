@@ -0,0 +1,103 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/services"
+    "cutrix-backend/internal/middleware"
+)
+
+// StylesHandler exposes the style catalog: size runs, colors and components, plus the orders of each style.
+type StylesHandler struct{ svc services.StylesService }
+
+func NewStylesHandler(svc services.StylesService) *StylesHandler { return &StylesHandler{svc: svc} }
+
+func (h *StylesHandler) Register(r *gin.RouterGroup) {
+    r.POST("/styles", h.create)
+    r.GET("/styles", h.list)
+    r.GET("/styles/:id", h.get)
+    r.GET("/styles/by-number/:number", h.getByNumber)
+    r.GET("/styles/:id/orders", h.orders)
+    r.PATCH("/styles/:id", h.update)
+    r.DELETE("/styles/:id", h.delete)
+}
+
+// RegisterProtected registers routes with permissions applied. Use on authenticated groups.
+func (h *StylesHandler) RegisterProtected(r *gin.RouterGroup) {
+    r.POST("/styles", middleware.RequirePermissions("style:create"), h.create)
+    r.GET("/styles", middleware.RequirePermissions("style:read"), h.list)
+    r.GET("/styles/:id", middleware.RequirePermissions("style:read"), h.get)
+    r.GET("/styles/by-number/:number", middleware.RequirePermissions("style:read"), h.getByNumber)
+    r.GET("/styles/:id/orders", middleware.RequirePermissions("style:read"), h.orders)
+    r.PATCH("/styles/:id", middleware.RequirePermissions("style:update"), h.update)
+    r.DELETE("/styles/:id", middleware.RequirePermissions("style:delete"), h.delete)
+}
+
+func (h *StylesHandler) create(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    var body models.Style
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    if err := h.svc.Create(&body); err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusCreated, body)
+}
+
+// list supports an optional ?q= filter on style number or name.
+func (h *StylesHandler) list(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    out, err := h.svc.List(c.Query("q"))
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *StylesHandler) get(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.GetByID(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// getByNumber resolves a style from the style_number an order carries.
+func (h *StylesHandler) getByNumber(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    out, err := h.svc.GetByNumber(c.Param("number"))
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// orders lists every order of the style, newest first.
+func (h *StylesHandler) orders(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.ListOrders(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// update replaces the style's name, note, size run, colors and components; returns the refreshed style.
+func (h *StylesHandler) update(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body models.Style
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    body.StyleID = id
+    if err := h.svc.Update(&body); err != nil { writeSvcError(c, err); return }
+    out, err := h.svc.GetByID(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *StylesHandler) delete(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    if err := h.svc.Delete(id); err != nil { writeSvcError(c, err); return }
+    c.Status(http.StatusNoContent)
+}
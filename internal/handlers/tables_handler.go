@@ -0,0 +1,104 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/services"
+    "cutrix-backend/internal/middleware"
+)
+
+// TablesHandler exposes cutting table endpoints: register, update, maintenance status, delete and assigned tasks.
+type TablesHandler struct{ svc services.TablesService }
+
+func NewTablesHandler(svc services.TablesService) *TablesHandler { return &TablesHandler{svc: svc} }
+
+func (h *TablesHandler) Register(r *gin.RouterGroup) {
+    r.POST("/tables", h.create)
+    r.GET("/tables", h.list)
+    r.GET("/tables/:id", h.get)
+    r.GET("/tables/:id/tasks", h.tasks)
+    r.PATCH("/tables/:id", h.update)
+    r.PATCH("/tables/:id/status", h.updateStatus)
+    r.DELETE("/tables/:id", h.delete)
+}
+
+// RegisterProtected registers routes with permissions applied. Use on authenticated groups.
+func (h *TablesHandler) RegisterProtected(r *gin.RouterGroup) {
+    r.POST("/tables", middleware.RequirePermissions("table:create"), h.create)
+    r.GET("/tables", middleware.RequirePermissions("table:read"), h.list)
+    r.GET("/tables/:id", middleware.RequirePermissions("table:read"), h.get)
+    r.GET("/tables/:id/tasks", middleware.RequirePermissions("table:read", "task:read"), h.tasks)
+    r.PATCH("/tables/:id", middleware.RequirePermissions("table:update"), h.update)
+    r.PATCH("/tables/:id/status", middleware.RequirePermissions("table:update"), h.updateStatus)
+    r.DELETE("/tables/:id", middleware.RequirePermissions("table:delete"), h.delete)
+}
+
+func (h *TablesHandler) create(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    var body models.CuttingTable
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    if err := h.svc.Create(&body); err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusCreated, body)
+}
+
+// list supports an optional ?status=active|maintenance filter.
+func (h *TablesHandler) list(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    out, err := h.svc.List(c.Query("status"))
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *TablesHandler) get(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.GetByID(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *TablesHandler) tasks(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.ListTasks(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// update replaces name, usable length and note; returns the refreshed table.
+func (h *TablesHandler) update(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body models.CuttingTable
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    body.TableID = id
+    if err := h.svc.Update(&body); err != nil { writeSvcError(c, err); return }
+    out, err := h.svc.GetByID(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *TablesHandler) updateStatus(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body struct{ Status string `json:"status"` }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    if err := h.svc.UpdateStatus(id, body.Status); err != nil { writeSvcError(c, err); return }
+    c.Status(http.StatusNoContent)
+}
+
+func (h *TablesHandler) delete(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    if err := h.svc.Delete(id); err != nil { writeSvcError(c, err); return }
+    c.Status(http.StatusNoContent)
+}
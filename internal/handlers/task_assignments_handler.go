@@ -0,0 +1,70 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+
+    "cutrix-backend/internal/services"
+    "cutrix-backend/internal/middleware"
+)
+
+// TaskAssignmentsHandler exposes assignment of tasks to workers or user groups, with history.
+type TaskAssignmentsHandler struct{ svc services.TaskAssignmentsService }
+
+func NewTaskAssignmentsHandler(svc services.TaskAssignmentsService) *TaskAssignmentsHandler {
+    return &TaskAssignmentsHandler{svc: svc}
+}
+
+func (h *TaskAssignmentsHandler) Register(r *gin.RouterGroup) {
+    r.GET("/tasks/:id/assignments", h.list)
+    r.POST("/tasks/:id/assignments", h.assign)
+    r.DELETE("/tasks/:id/assignments/:assignment_id", h.unassign)
+}
+
+// RegisterProtected registers routes with permissions applied. Use on authenticated groups.
+func (h *TaskAssignmentsHandler) RegisterProtected(r *gin.RouterGroup) {
+    r.GET("/tasks/:id/assignments", middleware.RequirePermissions("task:read"), h.list)
+    r.POST("/tasks/:id/assignments", middleware.RequirePermissions("task:assign"), h.assign)
+    r.DELETE("/tasks/:id/assignments/:assignment_id", middleware.RequirePermissions("task:assign"), h.unassign)
+}
+
+// list returns active assignments; ?history=true includes revoked ones.
+func (h *TaskAssignmentsHandler) list(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    history := false
+    if v := c.Query("history"); v != "" {
+        history, err = strconv.ParseBool(v)
+        if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"validation_error"}); return }
+    }
+    out, err := h.svc.List(id, history)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+func (h *TaskAssignmentsHandler) assign(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body struct{
+        UserIDs   []int   `json:"user_ids"`
+        UserGroup *string `json:"user_group"`
+    }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    out, err := h.svc.Assign(id, body.UserIDs, body.UserGroup, currentUserID(c))
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusCreated, out)
+}
+
+func (h *TaskAssignmentsHandler) unassign(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    assignmentID, err := strconv.Atoi(c.Param("assignment_id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    if err := h.svc.Unassign(id, assignmentID, currentUserID(c)); err != nil { writeSvcError(c, err); return }
+    c.Status(http.StatusNoContent)
+}
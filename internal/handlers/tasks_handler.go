@@ -19,7 +19,11 @@ func (h *TasksHandler) Register(r *gin.RouterGroup) {
     r.GET("/tasks", h.list)
     r.POST("/tasks", h.create)
     r.GET("/tasks/:id", h.get)
+    r.GET("/tasks/:id/lots", h.lots)
     r.DELETE("/tasks/:id", h.delete)
+    r.PATCH("/tasks/:id/table", h.assignTable)
+    r.POST("/tasks/:id/split", h.split)
+    r.POST("/tasks/:id/merge", h.merge)
     r.GET("/layouts/:id/tasks", h.listByLayout)
 }
 
@@ -28,15 +32,26 @@ func (h *TasksHandler) RegisterProtected(r *gin.RouterGroup) {
     r.GET("/tasks", middleware.RequirePermissions("task:read"), h.list)
     r.POST("/tasks", middleware.RequirePermissions("task:create"), h.create)
     r.GET("/tasks/:id", middleware.RequirePermissions("task:read"), h.get)
+    r.GET("/tasks/:id/lots", middleware.RequirePermissions("task:read"), h.lots)
     r.DELETE("/tasks/:id", middleware.RequirePermissions("task:delete"), h.delete)
+    r.PATCH("/tasks/:id/table", middleware.RequirePermissions("table:assign"), h.assignTable)
+    r.POST("/tasks/:id/split", middleware.RequirePermissions("task:adjust"), h.split)
+    r.POST("/tasks/:id/merge", middleware.RequirePermissions("task:adjust"), h.merge)
     // listByLayout: 允许有 task:read 或 layout:read 权限的用户访问
     // 这样 pattern_maker 可以通过 layout:read 权限查看版型下的任务
     r.GET("/layouts/:id/tasks", middleware.RequirePermissions("task:read", "layout:read"), h.listByLayout)
 }
 
+// list returns all tasks; workers only see tasks assigned to them or to their user group.
 func (h *TasksHandler) list(c *gin.Context) {
     if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
-    out, err := h.svc.List()
+    var out []models.ProductionTask
+    var err error
+    if uid := currentUserID(c); uid != nil && currentRole(c) == "worker" {
+        out, err = h.svc.ListAssigned(*uid)
+    } else {
+        out, err = h.svc.List()
+    }
     if err != nil { writeSvcError(c, err); return }
     c.JSON(http.StatusOK, out)
 }
@@ -73,4 +88,55 @@ func (h *TasksHandler) listByLayout(c *gin.Context) {
     out, err := h.svc.ListByLayout(layoutID)
     if err != nil { writeSvcError(c, err); return }
     c.JSON(http.StatusOK, out)
-}
\ No newline at end of file
+}
+
+// lots returns completed layers per dye lot of the task.
+func (h *TasksHandler) lots(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    out, err := h.svc.Lots(id)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+// assignTable assigns the task to a cutting table; {"table_id": null} clears the assignment.
+func (h *TasksHandler) assignTable(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body struct{ TableID *int `json:"table_id"` }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    out, err := h.svc.AssignTable(id, body.TableID)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
+
+// split moves part of the task's remaining layers into a new task, e.g. to spread one color on two tables.
+func (h *TasksHandler) split(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body struct {
+        Layers  int  `json:"layers"`
+        TableID *int `json:"table_id"`
+    }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    task, created, err := h.svc.Split(id, body.Layers, body.TableID)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusCreated, gin.H{"task": task, "new_task": created})
+}
+
+// merge folds the given tasks (same layout and color) into this one.
+func (h *TasksHandler) merge(c *gin.Context) {
+    if h.svc == nil { c.JSON(http.StatusServiceUnavailable, gin.H{"error":"db_not_configured"}); return }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_id"}); return }
+    var body struct {
+        TaskIDs     []int `json:"task_ids"`
+        AllowLotMix bool  `json:"allow_lot_mix"`
+    }
+    if err := c.ShouldBindJSON(&body); err != nil { c.JSON(http.StatusBadRequest, gin.H{"error":"invalid_json"}); return }
+    out, err := h.svc.Merge(id, body.TaskIDs, body.AllowLotMix)
+    if err != nil { writeSvcError(c, err); return }
+    c.JSON(http.StatusOK, out)
+}
@@ -0,0 +1,32 @@
+package labels
+
+import (
+    "fmt"
+    "strings"
+)
+
+// Symbology selects the barcode type printed on a label.
+type Symbology string
+
+const (
+    Code128 Symbology = "code128"
+    QR      Symbology = "qr"
+)
+
+// ParseSymbology validates a symbology name; empty means Code128.
+func ParseSymbology(s string) (Symbology, error) {
+    switch Symbology(strings.ToLower(strings.TrimSpace(s))) {
+    case "", Code128:
+        return Code128, nil
+    case QR:
+        return QR, nil
+    }
+    return "", fmt.Errorf("unsupported symbology %q", s)
+}
+
+// Label is one printable label: a title, a few text lines and the barcode payload.
+type Label struct {
+    Title   string
+    Lines   []string
+    Payload Payload
+}
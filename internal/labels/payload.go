@@ -0,0 +1,73 @@
+// Package labels renders printable bundle/task labels (ZPL for thermal printers, PDF for office printers)
+// and encodes/decodes the scan payload carried by their barcodes.
+package labels
+
+import (
+    "errors"
+    "fmt"
+    "net/url"
+    "strconv"
+    "strings"
+)
+
+// PayloadVersion prefixes every payload so scanners can tell our labels from other barcodes.
+const PayloadVersion = "CTX1"
+
+// Payload kinds.
+const (
+    KindBundle = "B"
+    KindTask   = "T"
+)
+
+// ErrInvalidPayload is returned when a scanned code is not a label payload.
+var ErrInvalidPayload = errors.New("invalid label payload")
+
+// Payload is the data encoded in a label barcode:
+// CTX1;<kind>;<id>;<order_number>;<style_number>;<color>;<size>
+// Text fields are percent-encoded so the payload stays printable ASCII (Code128 set B) and ';'-safe.
+type Payload struct {
+    Kind        string `json:"kind"` // B | T
+    ID          int    `json:"id"`
+    OrderNumber string `json:"order_number"`
+    StyleNumber string `json:"style_number"`
+    Color       string `json:"color"`
+    Size        string `json:"size"` // bundle size; for task cards the layout sizes joined by "/"
+}
+
+// Encode returns the payload string.
+func (p Payload) Encode() string {
+    return strings.Join([]string{
+        PayloadVersion, p.Kind, strconv.Itoa(p.ID),
+        escapeField(p.OrderNumber), escapeField(p.StyleNumber), escapeField(p.Color), escapeField(p.Size),
+    }, ";")
+}
+
+// ParsePayload decodes a scanned payload.
+func ParsePayload(code string) (Payload, error) {
+    parts := strings.Split(strings.TrimSpace(code), ";")
+    if len(parts) != 7 || parts[0] != PayloadVersion {
+        return Payload{}, ErrInvalidPayload
+    }
+    if parts[1] != KindBundle && parts[1] != KindTask {
+        return Payload{}, ErrInvalidPayload
+    }
+    id, err := strconv.Atoi(parts[2])
+    if err != nil || id <= 0 {
+        return Payload{}, ErrInvalidPayload
+    }
+    p := Payload{Kind: parts[1], ID: id}
+    fields := []*string{&p.OrderNumber, &p.StyleNumber, &p.Color, &p.Size}
+    for i, f := range fields {
+        v, err := url.QueryUnescape(parts[3+i])
+        if err != nil {
+            return Payload{}, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+        }
+        *f = v
+    }
+    return p, nil
+}
+
+// escapeField percent-encodes a text field; '~' is escaped too because it is a ZPL control prefix.
+func escapeField(s string) string {
+    return strings.ReplaceAll(url.QueryEscape(s), "~", "%7E")
+}
@@ -0,0 +1,110 @@
+package labels
+
+import (
+    "bytes"
+    "fmt"
+    "unicode/utf16"
+)
+
+// PDF page layout: A4 portrait, one column of labels, sizes in points.
+const (
+    pdfPageWidth    = 595.0
+    pdfPageHeight   = 842.0
+    pdfMargin       = 20.0
+    pdfLabelHeight  = 160.0
+    pdfLabelsOnPage = 5
+    pdfMaxModule    = 1.5 // widest bar module (≈0.53 mm)
+)
+
+// RenderPDF renders labels as an A4 PDF, five labels per page with Code128 barcodes drawn as vector bars.
+// Text uses the standard STSong-Light CJK font (UniGB-UCS2-H), so Chinese colors/sizes print without embedding fonts.
+func RenderPDF(labels []Label) ([]byte, error) {
+    var pages [][]byte
+    for start := 0; start < len(labels); start += pdfLabelsOnPage {
+        end := start + pdfLabelsOnPage
+        if end > len(labels) { end = len(labels) }
+        content, err := pdfPageContent(labels[start:end])
+        if err != nil { return nil, err }
+        pages = append(pages, content)
+    }
+    if len(pages) == 0 { pages = append(pages, nil) }
+
+    // Objects: 1 catalog, 2 pages, 3 Type0 font, 4 CID font, then (page, content) pairs.
+    objs := make([]string, 0, 4+2*len(pages))
+    kids := make([]byte, 0, 8*len(pages))
+    for i := range pages {
+        kids = append(kids, fmt.Sprintf("%d 0 R ", 5+2*i)...)
+    }
+    objs = append(objs,
+        "<< /Type /Catalog /Pages 2 0 R >>",
+        fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", bytes.TrimSpace(kids), len(pages)),
+        "<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [4 0 R] >>",
+        "<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light /CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> /DW 1000 /W [1 95 500] >>",
+    )
+    for i, content := range pages {
+        objs = append(objs,
+            fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+                pdfPageWidth, pdfPageHeight, 6+2*i),
+            fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+        )
+    }
+
+    var b bytes.Buffer
+    b.WriteString("%PDF-1.4\n")
+    offsets := make([]int, len(objs))
+    for i, o := range objs {
+        offsets[i] = b.Len()
+        fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+    }
+    xref := b.Len()
+    fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+    for _, off := range offsets {
+        fmt.Fprintf(&b, "%010d 00000 n \n", off)
+    }
+    fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+    return b.Bytes(), nil
+}
+
+// pdfPageContent draws up to pdfLabelsOnPage labels top-down, each framed by a thin cut line.
+func pdfPageContent(labels []Label) ([]byte, error) {
+    var b bytes.Buffer
+    width := pdfPageWidth - 2*pdfMargin
+    for i, l := range labels {
+        top := pdfPageHeight - pdfMargin - float64(i)*pdfLabelHeight
+        bottom := top - pdfLabelHeight
+        fmt.Fprintf(&b, "0.5 w %.2f %.2f %.2f %.2f re S\n", pdfMargin, bottom, width, pdfLabelHeight)
+
+        pdfText(&b, pdfMargin+10, top-24, 16, l.Title)
+        for j, line := range l.Lines {
+            pdfText(&b, pdfMargin+10, top-44-float64(j)*14, 10, line)
+        }
+
+        payload := l.Payload.Encode()
+        widths, err := EncodeCode128(payload)
+        if err != nil { return nil, err }
+        modules := 0
+        for _, w := range widths { modules += w }
+        // keep a 10-module quiet zone on both sides inside the label frame
+        module := (width - 20) / float64(modules+20)
+        if module > pdfMaxModule { module = pdfMaxModule }
+        x := pdfMargin + 10 + 10*module
+        barBottom, barHeight := bottom+22, 40.0
+        for k, w := range widths {
+            if k%2 == 0 {
+                fmt.Fprintf(&b, "%.3f %.2f %.3f %.2f re f\n", x, barBottom, float64(w)*module, barHeight)
+            }
+            x += float64(w) * module
+        }
+        pdfText(&b, pdfMargin+10, bottom+8, 8, payload)
+    }
+    return b.Bytes(), nil
+}
+
+// pdfText writes s as UTF-16BE hex so the UCS-2 CMap can map CJK and ASCII alike.
+func pdfText(b *bytes.Buffer, x, y, size float64, s string) {
+    fmt.Fprintf(b, "BT /F1 %.0f Tf %.2f %.2f Td <", size, x, y)
+    for _, u := range utf16.Encode([]rune(s)) {
+        fmt.Fprintf(b, "%04X", u)
+    }
+    b.WriteString("> Tj ET\n")
+}
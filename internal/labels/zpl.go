@@ -0,0 +1,46 @@
+package labels
+
+import (
+    "bytes"
+    "fmt"
+    "strings"
+)
+
+// ZPL label geometry: 4" × 2" at 203 dpi.
+const (
+    zplWidth  = 812
+    zplHeight = 406
+)
+
+// RenderZPL renders labels as one ZPL document (one ^XA..^XZ block per label).
+// The barcode itself is drawn by the printer (^BC for Code128, ^BQ for QR).
+// Text uses ^CI28 (UTF-8); CJK text needs a printer with a CJK font loaded.
+func RenderZPL(labels []Label, sym Symbology) []byte {
+    var b bytes.Buffer
+    for _, l := range labels {
+        payload := l.Payload.Encode()
+        b.WriteString("^XA\n^CI28\n")
+        fmt.Fprintf(&b, "^PW%d\n^LL%d\n", zplWidth, zplHeight)
+        fmt.Fprintf(&b, "^FO30,20^A0N,40,40^FH\\^FD%s^FS\n", zplEscape(l.Title))
+        y := 72
+        for _, line := range l.Lines {
+            fmt.Fprintf(&b, "^FO30,%d^A0N,28,28^FH\\^FD%s^FS\n", y, zplEscape(line))
+            y += 34
+        }
+        switch sym {
+        case QR:
+            fmt.Fprintf(&b, "^FO560,40^BQN,2,5^FH\\^FDQA,%s^FS\n", zplEscape(payload))
+        default:
+            fmt.Fprintf(&b, "^FO30,%d^BY2^BCN,90,Y,N,N^FH\\^FD%s^FS\n", zplHeight-140, zplEscape(payload))
+        }
+        b.WriteString("^XZ\n")
+    }
+    return b.Bytes()
+}
+
+// zplReplacer hex-escapes characters that ZPL treats as commands inside ^FH\ field data.
+var zplReplacer = strings.NewReplacer(`\`, `\5C`, "^", `\5E`, "~", `\7E`)
+
+func zplEscape(s string) string {
+    return zplReplacer.Replace(s)
+}
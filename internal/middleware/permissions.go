@@ -56,6 +56,12 @@ var RolePermissionsMap = map[string][]string{
         "task:read",
         "plan:read", // Allow workers to view plans (needed for WorkerDashboard)
         "layout:read", // Allow workers to view layouts (needed to associate tasks with plans)
+        "roll:read", // Allow workers to look up rolls when submitting logs
+        "bundle:read", // Allow workers to view and print bundle tickets
+        "label:read", // Allow workers to print labels and resolve scanned codes
+        "table:read", // Allow workers to see which cutting table a task is routed to
+        "schedule:read", // Allow workers to see the upcoming table/shift timeline
+        "shift:read", // Allow workers to see shift definitions and the factory timezone
     },
     // pattern_maker (制版员): can create/read/update plans, but cannot publish or freeze
     // Can manage layouts and tasks, but cannot view task management page (no task:read)
@@ -73,6 +79,10 @@ var RolePermissionsMap = map[string][]string{
         "task:create",
         "task:delete",
         "order:read",
+        "customer:read", // Look up customers behind the orders being planned
+        "style:read", // Size runs, colors and components of the styles being planned
+        "amendment:create", // Propose changes to published plans; approval stays with managers
+        "amendment:read",
     },
     // Other roles can be added here as needed
 }
@@ -1,7 +1,10 @@
 // models layer: domain models for laying-up (拉布) process
 package models
 
-import "time"
+import (
+    "encoding/json"
+    "time"
+)
 
 type User struct {
     UserID       int     `json:"user_id" db:"user_id"`
@@ -18,13 +21,65 @@ type ProductionOrder struct {
     OrderNumber         string  `json:"order_number"`
     StyleNumber         string  `json:"style_number"`
     CustomerName        *string `json:"customer_name,omitempty"`
+    CustomerID          *int    `json:"customer_id,omitempty"` // 客户主数据；创建时未指定则按 customer_name（忽略大小写）匹配
     OrderStartDate      *time.Time `json:"order_start_date,omitempty"`
     OrderFinishDate     *time.Time `json:"order_finish_date,omitempty"`
     Note                *string `json:"note,omitempty"`
+    OverCutTolerance    *float64 `json:"over_cut_tolerance,omitempty"`  // 百分比；nil 表示不限制超裁
+    UnderCutTolerance   *float64 `json:"under_cut_tolerance,omitempty"` // 百分比；nil 表示不限制短缺
+    Status              string  `json:"status"` // draft|confirmed|in_production|cut_complete|closed|cancelled
+    Revision            int     `json:"revision"` // 当前订单项版本，见 OrderRevision
+    StatusChangedAt     *time.Time `json:"status_changed_at,omitempty"`
     CreatedAt           time.Time `json:"created_at"`
     UpdatedAt           time.Time `json:"updated_at"`
 }
 
+// OrderFilter 订单列表过滤条件；nil 表示不过滤。
+type OrderFilter struct {
+    Status      *string
+    CustomerID  *int
+    StyleNumber *string
+}
+
+// Style 款式主数据，以 StyleNumber 与订单关联（创建后不可修改）。
+// Sizes 为尺码段，顺序即标准尺码顺序（布局与报表按此排序）；Colors 为允许颜色，空表示不限制。
+type Style struct {
+    StyleID     int              `json:"style_id"`
+    StyleNumber string           `json:"style_number"`
+    StyleName   *string          `json:"style_name,omitempty"`
+    Note        *string          `json:"note,omitempty"`
+    Sizes       []string         `json:"sizes"`
+    Colors      []string         `json:"colors"`
+    Components  []StyleComponent `json:"components"`
+    Orders      int              `json:"orders"` // 只读：该款号的订单数
+    CreatedAt   time.Time        `json:"created_at"`
+    UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// StyleComponent 款式部件（如面布、里布）及每件成衣的默认裁片数。
+type StyleComponent struct {
+    ComponentName string `json:"component_name"`
+    Pieces        int    `json:"pieces"`
+}
+
+// Customer 客户主数据。CustomerCode 与 CustomerName（忽略大小写）均唯一；
+// 默认容差（百分比，可空）在新建订单未指定容差时带入订单。
+type Customer struct {
+    CustomerID        int       `json:"customer_id"`
+    CustomerCode      string    `json:"customer_code"`
+    CustomerName      string    `json:"customer_name"`
+    ContactName       *string   `json:"contact_name,omitempty"`
+    Phone             *string   `json:"phone,omitempty"`
+    Email             *string   `json:"email,omitempty"`
+    Address           *string   `json:"address,omitempty"`
+    OverCutTolerance  *float64  `json:"over_cut_tolerance,omitempty"`
+    UnderCutTolerance *float64  `json:"under_cut_tolerance,omitempty"`
+    Note              *string   `json:"note,omitempty"`
+    Orders            int       `json:"orders"` // 只读：引用该客户的订单数
+    CreatedAt         time.Time `json:"created_at"`
+    UpdatedAt         time.Time `json:"updated_at"`
+}
+
 type OrderItem struct {
     ItemID   int    `json:"item_id"`
     OrderID  int    `json:"order_id"`
@@ -44,10 +99,16 @@ type ProductionPlan struct {
 }
 
 type CuttingLayout struct {
-    LayoutID   int     `json:"layout_id"`
-    PlanID     int     `json:"plan_id"`
-    LayoutName string  `json:"layout_name"`
-    Note       *string `json:"note,omitempty"`
+    LayoutID         int      `json:"layout_id"`
+    PlanID           int      `json:"plan_id"`
+    LayoutName       string   `json:"layout_name"`
+    Component        string   `json:"component"`                    // 部件（shell、lining、interlining…）；空时取款式首个部件，款式未登记为 shell
+    Note             *string  `json:"note,omitempty"`
+    MarkerLength     *float64 `json:"marker_length,omitempty"`      // 米
+    FabricWidth      *float64 `json:"fabric_width,omitempty"`       // 厘米
+    MarkerEfficiency *float64 `json:"marker_efficiency,omitempty"`  // 百分比
+    EndLossAllowance *float64 `json:"end_loss_allowance,omitempty"` // 每层两端损耗，米
+    BundleSize       *int     `json:"bundle_size,omitempty"`        // 每扎层数；空表示默认 10
 }
 
 type LayoutSizeRatio struct {
@@ -64,19 +125,629 @@ type ProductionTask struct {
     PlannedLayers   int    `json:"planned_layers"`
     CompletedLayers int    `json:"completed_layers"`
     Status          string `json:"status"`
+    TableID         *int   `json:"table_id,omitempty"` // 当前分配的裁床
 }
 
 type ProductionLog struct {
-    LogID           int        `json:"log_id"`
-    TaskID          int        `json:"task_id"`
-    WorkerID        *int       `json:"worker_id,omitempty"`
-    WorkerName      *string    `json:"worker_name,omitempty"`
-    LayersCompleted int        `json:"layers_completed"`
-    LogTime         time.Time  `json:"log_time"`
+    LogID           int            `json:"log_id"`
+    TaskID          int            `json:"task_id"`
+    WorkerID        *int           `json:"worker_id,omitempty"`
+    WorkerName      *string        `json:"worker_name,omitempty"`
+    LayersCompleted int            `json:"layers_completed"`
+    LogTime         time.Time      `json:"log_time"`
+    Note            *string        `json:"note,omitempty"`
+    Voided          bool           `json:"voided"`
+    VoidReason      *string        `json:"void_reason,omitempty"`
+    VoidedAt        *time.Time     `json:"voided_at,omitempty"`
+    VoidedBy        *int           `json:"voided_by,omitempty"`
+    VoidedByName    *string        `json:"voided_by_name,omitempty"`
+    DyeLot          *string        `json:"dye_lot,omitempty"`       // 缸号；领用布卷时默认取布卷缸号
+    AllowLotMix     bool           `json:"allow_lot_mix,omitempty"` // 明确允许向任务混入新缸号（返回警告）
+    TableID         *int           `json:"table_id,omitempty"`      // 拉布所在裁床；缺省取任务分配的裁床
+    ShiftID         *int           `json:"shift_id,omitempty"`      // 只读：按工厂时区归属的班次（不在任何班次内为空）
+    ShiftDate       *string        `json:"shift_date,omitempty"`    // 只读：班次日期 YYYY-MM-DD（跨零点班次取开班日期）
+    RollIDs         []int          `json:"roll_ids,omitempty"`      // 请求：本次拉布所用布卷，按顺序领用
+    Rolls           []LogRollUsage `json:"rolls,omitempty"`         // 响应：各布卷分摊的层数与长度
+    Warnings        []string       `json:"warnings,omitempty"`      // 响应：混缸等警告
+}
+
+type TaskLotLayers struct {
+    TaskID          int    `json:"task_id"`
+    DyeLot          string `json:"dye_lot"`
+    CompletedLayers int    `json:"completed_layers"`
+}
+
+type CoverageCell struct {
+    Color         string         `json:"color"`
+    Size          string         `json:"size"`
+    OrderedQty    int            `json:"ordered_qty"`
+    PlannedPieces int            `json:"planned_pieces"`
+    CutPieces     int            `json:"cut_pieces"`
+    PlannedDelta  int            `json:"planned_delta"`        // planned_pieces - ordered_qty（正数为超裁，负数为欠裁）
+    CutDelta      int            `json:"cut_delta"`            // cut_pieces - ordered_qty
+    CutByLot      map[string]int `json:"cut_by_lot,omitempty"` // 已裁件数按缸号拆分（仅含登记缸号的日志）
+}
+
+// PlanCoverage 计划覆盖矩阵。Cells 为整件口径：计划/已裁件数取各部件中的最小值（缺一个部件即不成件）；
+// Components 为各部件单独的覆盖矩阵。
+type PlanCoverage struct {
+    PlanID             int                 `json:"plan_id"`
+    OrderID            int                 `json:"order_id"`
+    Colors             []string            `json:"colors"`
+    Sizes              []string            `json:"sizes"`
+    Cells              []CoverageCell      `json:"cells"`
+    TotalOrdered       int                 `json:"total_ordered"`
+    TotalPlannedPieces int                 `json:"total_planned_pieces"`
+    TotalCutPieces     int                 `json:"total_cut_pieces"`
+    Components         []ComponentCoverage `json:"components"`
+}
+
+type ComponentCoverage struct {
+    Component          string         `json:"component"`
+    Cells              []CoverageCell `json:"cells"`
+    TotalPlannedPieces int            `json:"total_planned_pieces"`
+    TotalCutPieces     int            `json:"total_cut_pieces"`
+}
+
+// OrderProgress 订单进度汇总（GET /orders/:id/progress、GET /orders/progress）：覆盖订单下全部计划、布局与任务。
+type OrderProgress struct {
+    OrderID            int                 `json:"order_id"`
+    OrderNumber        string              `json:"order_number"`
+    StyleNumber        string              `json:"style_number"`
+    TotalOrdered       int                 `json:"total_ordered"`
+    TotalPlannedPieces int                 `json:"total_planned_pieces"`
+    TotalCutPieces     int                 `json:"total_cut_pieces"`
+    PercentComplete    float64             `json:"percent_complete"` // Σ min(已裁, 下单) / Σ 下单 × 100，逐格封顶，超裁不抵欠裁
+    FirstPublishDate   *time.Time          `json:"first_publish_date,omitempty"`
+    LastFinishDate     *time.Time          `json:"last_finish_date,omitempty"`
+    PlanStatus         map[string]int      `json:"plan_status"` // 各状态的计划数
+    Plans              []OrderPlanProgress `json:"plans"`
+    Cells              []CoverageCell      `json:"cells"` // 整件口径：计划/已裁件数取订单各部件中的最小值
+}
+
+type OrderPlanProgress struct {
+    PlanID             int        `json:"plan_id"`
+    PlanName           string     `json:"plan_name"`
+    Status             string     `json:"status"`
+    PlannedPublishDate *time.Time `json:"planned_publish_date,omitempty"`
+    PlannedFinishDate  *time.Time `json:"planned_finish_date,omitempty"`
+    Layouts            int        `json:"layouts"`
+    Tasks              int        `json:"tasks"`
+    CompletedTasks     int        `json:"completed_tasks"`
+    PlannedLayers      int        `json:"planned_layers"`
+    CompletedLayers    int        `json:"completed_layers"`
+    PlannedPieces      int        `json:"planned_pieces"` // 各部件件数合计中的最小值（整件口径）
+    CutPieces          int        `json:"cut_pieces"`
+}
+
+// OrderBacklog 交期预测输入：订单及其未完成任务的剩余计划层数。
+type OrderBacklog struct {
+    OrderID         int
+    OrderNumber     string
+    StyleNumber     string
+    CustomerID      *int
+    OrderFinishDate *time.Time
+    RemainingLayers int
+}
+
+// OrderForecast 订单交期预测：按交期先后排队消耗近期产能推算完成时间。
+type OrderForecast struct {
+    OrderID            int        `json:"order_id"`
+    OrderNumber        string     `json:"order_number"`
+    StyleNumber        string     `json:"style_number"`
+    OrderFinishDate    time.Time  `json:"order_finish_date"`
+    RemainingLayers    int        `json:"remaining_layers"`
+    QueuedLayers       int        `json:"queued_layers"`                  // 排在本订单之前（含本订单）的剩余层数
+    ForecastFinishDate *time.Time `json:"forecast_finish_date,omitempty"` // 近期无产量时为空
+    SlackHours         *float64   `json:"slack_hours,omitempty"`          // 交期 - 预测完成，负数为预计延误
+    Status             string     `json:"status"`                         // on_track | at_risk | late
+}
+
+// OrderRiskReport 交期风险列表；CustomerID 为请求的客户过滤条件。
+type OrderRiskReport struct {
+    GeneratedAt  time.Time       `json:"generated_at"`
+    LookbackDays int             `json:"lookback_days"`
+    LayersPerDay float64         `json:"layers_per_day"`
+    CustomerID   *int            `json:"customer_id,omitempty"`
+    Orders       []OrderForecast `json:"orders"`
+}
+
+type DraftLayout struct {
+    Layout CuttingLayout    `json:"layout"`
+    Ratios map[string]int   `json:"ratios"`
+    Tasks  []ProductionTask `json:"tasks"`
+}
+
+// PlanCloneOptions 计划复制参数（POST /plans/:id/clone）。
+type PlanCloneOptions struct {
+    OrderID  int               `json:"order_id"`  // 目标订单；0 表示源计划所在订单
+    PlanName string            `json:"plan_name"` // 空取 "<源计划名> (copy)"
+    Note     *string           `json:"note"`      // 空取源计划备注
+    ColorMap map[string]string `json:"color_map"` // 源颜色 → 目标订单颜色
+    SizeMap  map[string]string `json:"size_map"`  // 源尺码 → 目标订单尺码；映射到同一尺码的比例相加
+}
+
+type CutPlanOptions struct {
+    MaxPlies             int     `json:"max_plies"`
+    MaxGarmentsPerMarker int     `json:"max_garments_per_marker"`
+    OverCutTolerance     float64 `json:"over_cut_tolerance"` // 百分比，例如 3 表示每个颜色/尺码最多超裁 3%
+}
+
+type CutPlanProposal struct {
+    OrderID     int            `json:"order_id"`
+    Options     CutPlanOptions `json:"options"`
+    Layouts     []DraftLayout  `json:"layouts"`
+    MarkerCount int            `json:"marker_count"`
+    Cells       []CoverageCell `json:"cells"`
+}
+
+type ToleranceViolation struct {
+    Component     string `json:"component"`
+    Color         string `json:"color"`
+    Size          string `json:"size"`
+    OrderedQty    int    `json:"ordered_qty"`
+    PlannedPieces int    `json:"planned_pieces"` // 该部件在订单所有未冻结计划中的合计
+    MinQty        *int   `json:"min_qty,omitempty"` // nil 表示不限制短缺
+    MaxQty        *int   `json:"max_qty,omitempty"` // nil 表示不限制超裁
+    Kind          string `json:"kind"` // short | over
+}
+
+type MarkerSpec struct {
+    MarkerLength     *float64 `json:"marker_length"`
+    FabricWidth      *float64 `json:"fabric_width"`
+    MarkerEfficiency *float64 `json:"marker_efficiency"`
+    EndLossAllowance *float64 `json:"end_loss_allowance"`
+}
+
+type FabricRequirementLine struct {
+    TaskID           int      `json:"task_id"`
+    LayoutID         int      `json:"layout_id"`
+    LayoutName       string   `json:"layout_name"`
+    Component        string   `json:"component"`
+    PlanID           int      `json:"plan_id"`
+    Color            string   `json:"color"`
+    PlannedLayers    int      `json:"planned_layers"`
+    CompletedLayers  int      `json:"completed_layers"`
+    MarkerLength     *float64 `json:"marker_length,omitempty"`
+    FabricWidth      *float64 `json:"fabric_width,omitempty"`
+    MarkerEfficiency *float64 `json:"marker_efficiency,omitempty"`
+    EndLossAllowance *float64 `json:"end_loss_allowance,omitempty"`
+    PlannedLength    *float64 `json:"planned_length,omitempty"`  // planned_layers × (marker_length + end_loss_allowance)；未设置唛架长度时为空
+    ConsumedLength   *float64 `json:"consumed_length,omitempty"` // completed_layers × (marker_length + end_loss_allowance)
+}
+
+type FabricColorTotal struct {
+    Component      string   `json:"component"`
+    Color          string   `json:"color"`
+    FabricWidth    *float64 `json:"fabric_width,omitempty"`
+    PlannedLength  float64  `json:"planned_length"`
+    ConsumedLength float64  `json:"consumed_length"`
+}
+
+type FabricRequirement struct {
+    Scope               string                  `json:"scope"` // layout | plan | order
+    ID                  int                     `json:"id"`
+    Lines               []FabricRequirementLine `json:"lines"`
+    ByColor             []FabricColorTotal      `json:"by_color"`
+    TotalPlannedLength  float64                 `json:"total_planned_length"`
+    TotalConsumedLength float64                 `json:"total_consumed_length"`
+    MissingMarkerTasks  []int                   `json:"missing_marker_tasks"` // 未设置唛架长度、未计入合计的任务
+}
+
+type FabricRoll struct {
+    RollID          int        `json:"roll_id"`
+    RollCode        string     `json:"roll_code"`
+    Color           string     `json:"color"`
+    DyeLot          *string    `json:"dye_lot,omitempty"`
+    FabricWidth     *float64   `json:"fabric_width,omitempty"`
+    ReceivedLength  float64    `json:"received_length"`
+    RemainingLength float64    `json:"remaining_length"`
+    RemnantLength   *float64   `json:"remnant_length,omitempty"` // 结卷时实测余料
+    Status          string     `json:"status"`                   // available | in_use | closed
     Note            *string    `json:"note,omitempty"`
-    Voided          bool       `json:"voided"`
-    VoidReason      *string    `json:"void_reason,omitempty"`
-    VoidedAt        *time.Time `json:"voided_at,omitempty"`
-    VoidedBy        *int       `json:"voided_by,omitempty"`
-    VoidedByName    *string    `json:"voided_by_name,omitempty"`
-}
\ No newline at end of file
+    ReceivedAt      time.Time  `json:"received_at"`
+    ClosedAt        *time.Time `json:"closed_at,omitempty"`
+}
+
+type LogRollUsage struct {
+    LogID      int     `json:"log_id"`
+    RollID     int     `json:"roll_id"`
+    RollCode   string  `json:"roll_code"`
+    TaskID     int     `json:"task_id"`
+    Layers     int     `json:"layers"`
+    LengthUsed float64 `json:"length_used"`
+    Voided     bool    `json:"voided"`
+}
+
+type RollFilter struct {
+    Color  *string
+    DyeLot *string
+    Status *string
+}
+
+type Bundle struct {
+    BundleID    int       `json:"bundle_id"`
+    TaskID      int       `json:"task_id"`
+    BundleNo    int       `json:"bundle_no"` // 任务内序号
+    Color       string    `json:"color"`
+    Size        string    `json:"size"`
+    CopyNo      int       `json:"copy_no"` // 该尺码在唛架比例中的第几份
+    DyeLot      *string   `json:"dye_lot,omitempty"`
+    PlyFrom     int       `json:"ply_from"`
+    PlyTo       int       `json:"ply_to"`
+    Pieces      int       `json:"pieces"`
+    CreatedAt   time.Time `json:"created_at"`
+    PlanID      int       `json:"plan_id"`      // 只读：来源计划
+    LayoutName  string    `json:"layout_name"`  // 只读：来源布局
+    OrderNumber string    `json:"order_number"` // 只读：订单号（打印扎票用）
+    StyleNumber string    `json:"style_number"` // 只读：款号
+}
+// LabelDocument 渲染后的标签文件（ZPL 热敏打印或 PDF 办公打印）。
+type LabelDocument struct {
+    Filename    string
+    ContentType string
+    Body        []byte
+}
+
+// LabelLookup 扫码解析结果：按标签载荷定位扎包或任务。
+type LabelLookup struct {
+    Kind        string          `json:"kind"` // bundle | task
+    Bundle      *Bundle         `json:"bundle,omitempty"`
+    Task        *ProductionTask `json:"task,omitempty"`
+    OrderNumber string          `json:"order_number"`
+    StyleNumber string          `json:"style_number"`
+    Stale       bool            `json:"stale"` // 标签所印订单/款号/颜色/尺码与当前记录不一致（如扎包已重建）
+}
+
+// CuttingTable 裁床：可用长度（米）须不小于所分配任务布局的唛架长度；维护中的裁床不可分配或拉布。
+type CuttingTable struct {
+    TableID       int       `json:"table_id"`
+    TableName     string    `json:"table_name"`
+    UsableLength  float64   `json:"usable_length"`
+    Status        string    `json:"status"` // active | maintenance
+    Note          *string   `json:"note,omitempty"`
+    CreatedAt     time.Time `json:"created_at"`
+    CurrentTaskID *int      `json:"current_task_id,omitempty"` // 只读：当前分配（进行中优先）的未完成任务
+    OpenTasks     int       `json:"open_tasks"`                // 只读：已分配的未完成任务数
+}
+
+// ScheduleTask 排程输入：已发布且未完成的任务及其布局/计划/订单信息。
+type ScheduleTask struct {
+    TaskID            int
+    PlanID            int
+    LayoutID          int
+    LayoutName        string
+    OrderNumber       string
+    StyleNumber       string
+    Color             string
+    PlannedLayers     int
+    CompletedLayers   int
+    TableID           *int
+    MarkerLength      *float64
+    PlannedFinishDate *time.Time
+    PublishedAt       *time.Time
+}
+
+// LayerRate 历史拉布效率样本：相邻日志间隔内完成的层数与小时数，TableID 为空表示未记录裁床的日志。
+type LayerRate struct {
+    TableID *int
+    Layers  int
+    Hours   float64
+}
+
+// Schedule 裁床排程时间轴（甘特图数据）。
+type Schedule struct {
+    From        time.Time         `json:"from"`
+    To          time.Time         `json:"to"`
+    GeneratedAt time.Time         `json:"generated_at"`
+    Tables      []TableTimeline   `json:"tables"`
+    Unscheduled []UnscheduledTask `json:"unscheduled"`
+}
+
+// TableTimeline 单个裁床的排程条目。
+type TableTimeline struct {
+    TableID       int            `json:"table_id"`
+    TableName     string         `json:"table_name"`
+    LayersPerHour float64        `json:"layers_per_hour"`
+    RateSource    string         `json:"rate_source"` // table | global | default
+    Items         []ScheduleItem `json:"items"`
+}
+
+// ScheduleItem 任务在某个班次内的一段排程；跨班次的任务拆为多段。
+type ScheduleItem struct {
+    TaskID          int       `json:"task_id"`
+    PlanID          int       `json:"plan_id"`
+    LayoutID        int       `json:"layout_id"`
+    LayoutName      string    `json:"layout_name"`
+    OrderNumber     string    `json:"order_number"`
+    StyleNumber     string    `json:"style_number"`
+    Color           string    `json:"color"`
+    Shift           string    `json:"shift"`
+    Start           time.Time `json:"start"`
+    End             time.Time `json:"end"`
+    Layers          int       `json:"layers"`           // 本段预计完成层数
+    RemainingLayers int       `json:"remaining_layers"` // 排程时任务剩余层数
+    Assigned        bool      `json:"assigned"`         // true：任务已分配到该裁床；false：排程建议
+}
+
+// UnscheduledTask 未能排入时间轴的任务及原因。
+type UnscheduledTask struct {
+    TaskID int    `json:"task_id"`
+    Reason string `json:"reason"` // table_in_maintenance | marker_length_missing | no_fitting_table | beyond_window
+}
+
+// TaskAssignment 任务分配记录：分配给单个用户（UserID）或用户组（UserGroup）之一。
+// 撤销分配不删除记录，而是写入 UnassignedAt，保留分配历史。
+type TaskAssignment struct {
+    AssignmentID int        `json:"assignment_id"`
+    TaskID       int        `json:"task_id"`
+    UserID       *int       `json:"user_id,omitempty"`
+    UserName     *string    `json:"user_name,omitempty"` // 只读：被分配用户姓名
+    UserGroup    *string    `json:"user_group,omitempty"`
+    AssignedBy   *int       `json:"assigned_by,omitempty"`
+    AssignedAt   time.Time  `json:"assigned_at"`
+    UnassignedBy *int       `json:"unassigned_by,omitempty"`
+    UnassignedAt *time.Time `json:"unassigned_at,omitempty"` // 非空表示已撤销
+}
+
+// Shift 班次：工厂时区下的每日时间窗，EndTime <= StartTime 表示跨零点（如夜班 20:00-04:00）。
+type Shift struct {
+    ShiftID         int       `json:"shift_id"`
+    ShiftName       string    `json:"shift_name"`
+    StartTime       string    `json:"start_time"` // HH:MM
+    EndTime         string    `json:"end_time"`   // HH:MM
+    IsActive        bool      `json:"is_active"`
+    Note            *string   `json:"note,omitempty"`
+    CreatedAt       time.Time `json:"created_at"`
+    CrossesMidnight bool      `json:"crosses_midnight"` // 只读
+}
+
+// FactorySettings 工厂级设置；Timezone 为 IANA 时区名，日志时间按此归属班次。
+type FactorySettings struct {
+    Timezone  string    `json:"timezone"`
+    UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ShiftReportRow 班次报表明细：按班次、工人、计划汇总的未作废日志层数与件数。
+type ShiftReportRow struct {
+    ShiftID     *int
+    ShiftName   *string
+    StartTime   *string
+    EndTime     *string
+    WorkerID    *int
+    WorkerName  *string
+    UserGroup   *string
+    PlanID      int
+    PlanName    string
+    OrderNumber string
+    Layers      int
+    Pieces      int
+}
+
+// ShiftReport 某班次日期的产量汇总（GET /reports/shifts）。跨零点班次按开班日期归属。
+type ShiftReport struct {
+    Date       string         `json:"date"`
+    Timezone   string         `json:"timezone"`
+    CustomerID *int           `json:"customer_id,omitempty"`
+    Layers     int            `json:"layers"`
+    Pieces     int            `json:"pieces"`
+    Shifts     []ShiftSummary `json:"shifts"`
+}
+
+// ShiftSummary 单个班次的产量及按工人、组、计划的拆分；ShiftID 为空表示不在任何班次内的日志。
+type ShiftSummary struct {
+    ShiftID   *int                 `json:"shift_id"`
+    ShiftName string               `json:"shift_name"`
+    StartTime string               `json:"start_time,omitempty"`
+    EndTime   string               `json:"end_time,omitempty"`
+    Layers    int                  `json:"layers"`
+    Pieces    int                  `json:"pieces"`
+    Workers   []ShiftWorkerSummary `json:"workers"`
+    Groups    []ShiftGroupSummary  `json:"groups"`
+    Plans     []ShiftPlanSummary   `json:"plans"`
+}
+
+type ShiftWorkerSummary struct {
+    WorkerID   *int    `json:"worker_id,omitempty"`
+    WorkerName string  `json:"worker_name"`
+    UserGroup  *string `json:"user_group,omitempty"` // 工人当前所在组
+    Layers     int     `json:"layers"`
+    Pieces     int     `json:"pieces"`
+}
+
+type ShiftGroupSummary struct {
+    UserGroup *string `json:"user_group"` // 空表示未分组
+    Layers    int     `json:"layers"`
+    Pieces    int     `json:"pieces"`
+}
+
+type ShiftPlanSummary struct {
+    PlanID      int    `json:"plan_id"`
+    PlanName    string `json:"plan_name"`
+    OrderNumber string `json:"order_number"`
+    Layers      int    `json:"layers"`
+    Pieces      int    `json:"pieces"`
+}
+
+// ProductivityReport 工人效率分析（GET /reports/productivity）：按班次日期区间、所选维度汇总。
+type ProductivityReport struct {
+    From       string            `json:"from"`
+    To         string            `json:"to"`
+    GroupBy    []string          `json:"group_by"`
+    CustomerID *int              `json:"customer_id,omitempty"`
+    Rows       []ProductivityRow `json:"rows"`
+}
+
+// ProductivityRow 单个维度组合的效率；未参与分组的维度字段为空。
+// 工时由同一工人相邻有效日志的间隔推算（超过上限的间隔视为休息不计），效率仅以有间隔的日志层数计算。
+type ProductivityRow struct {
+    WorkerID      *int     `json:"worker_id,omitempty"`
+    WorkerName    *string  `json:"worker_name,omitempty"`
+    UserGroup     *string  `json:"user_group,omitempty"` // 工人当前所在组
+    LayoutID      *int     `json:"layout_id,omitempty"`
+    LayoutName    *string  `json:"layout_name,omitempty"`
+    Color         *string  `json:"color,omitempty"`
+    Component     *string  `json:"component,omitempty"`
+    Logs          int      `json:"logs"`
+    VoidedLogs    int      `json:"voided_logs"`
+    VoidRate      float64  `json:"void_rate"`
+    Layers        int      `json:"layers"`
+    Pieces        int      `json:"pieces"`
+    ActiveHours   float64  `json:"active_hours"`
+    LayersPerHour *float64 `json:"layers_per_hour"` // 无有效工时为空
+    PiecesPerHour *float64 `json:"pieces_per_hour"`
+    RatedLayers   int      `json:"-"`
+    RatedPieces   int      `json:"-"`
+}
+
+// PieceRate 计件单价（每层）：LayoutID、StyleNumber 均为空表示默认单价；布局优先于款号，款号优先于默认。
+type PieceRate struct {
+    RateID       int       `json:"rate_id"`
+    LayoutID     *int      `json:"layout_id,omitempty"`
+    StyleNumber  *string   `json:"style_number,omitempty"`
+    RatePerLayer float64   `json:"rate_per_layer"`
+    Note         *string   `json:"note,omitempty"`
+    CreatedAt    time.Time `json:"created_at"`
+    UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// PayPeriod 工资周期：覆盖班次日期 [PeriodStart, PeriodEnd]（YYYY-MM-DD）；Status 为 open 或 locked。
+type PayPeriod struct {
+    PeriodID    int        `json:"period_id"`
+    PeriodStart string     `json:"period_start"`
+    PeriodEnd   string     `json:"period_end"`
+    Status      string     `json:"status"`
+    LockedAt    *time.Time `json:"locked_at,omitempty"`
+    LockedBy    *int       `json:"locked_by,omitempty"`
+    CreatedAt   time.Time  `json:"created_at"`
+}
+
+// PayrollLine 工资明细：Kind 为 log（日志计件）或 void_adjustment（已锁定周期内的日志被作废后的冲销，层数与金额为负）。
+// 未锁定周期的明细为即时计算，LineID 为空；RatePerLayer 为空表示没有适用的单价。
+type PayrollLine struct {
+    LineID       *int      `json:"line_id,omitempty"`
+    Kind         string    `json:"kind"`
+    LogID        *int      `json:"log_id"`
+    WorkerID     *int      `json:"worker_id,omitempty"`
+    WorkerName   *string   `json:"worker_name"`
+    TaskID       *int      `json:"task_id"`
+    LayoutID     *int      `json:"layout_id"`
+    LayoutName   *string   `json:"layout_name"`
+    StyleNumber  *string   `json:"style_number"`
+    Color        *string   `json:"color"`
+    ShiftDate    *string   `json:"shift_date"`
+    LogTime      time.Time `json:"log_time"`
+    Layers       int       `json:"layers"`
+    RateID       *int      `json:"rate_id,omitempty"`
+    RatePerLayer *float64  `json:"rate_per_layer"`
+    RateScope    *string   `json:"rate_scope"` // layout | style | default
+    Amount       float64   `json:"amount"`
+}
+
+// PayrollStatement 工资周期的计件工资单，按工人汇总并保留明细。
+type PayrollStatement struct {
+    Period       PayPeriod         `json:"period"`
+    Layers       int               `json:"layers"`
+    Amount       float64           `json:"amount"`
+    MissingRates int               `json:"missing_rates"` // 无适用单价的明细数；存在时不可锁定
+    Workers      []WorkerStatement `json:"workers"`
+}
+
+type WorkerStatement struct {
+    WorkerID    *int          `json:"worker_id,omitempty"`
+    WorkerName  string        `json:"worker_name"`
+    Layers      int           `json:"layers"`
+    Amount      float64       `json:"amount"`
+    Adjustments float64       `json:"adjustments"` // 作废冲销金额（负数），已含在 Amount 中
+    Lines       []PayrollLine `json:"lines"`
+}
+
+// PlanAmendment 已发布计划的变更申请：制版员提出，主管批准后在调整上下文中应用；Status 为 pending、approved 或 rejected。
+type PlanAmendment struct {
+    AmendmentID    int               `json:"amendment_id"`
+    PlanID         int               `json:"plan_id"`
+    Status         string            `json:"status"`
+    Reason         *string           `json:"reason,omitempty"`
+    ProposedBy     *int              `json:"proposed_by,omitempty"`
+    ProposedByName *string           `json:"proposed_by_name,omitempty"`
+    ProposedAt     time.Time         `json:"proposed_at"`
+    DecidedBy      *int              `json:"decided_by,omitempty"`
+    DecidedByName  *string           `json:"decided_by_name,omitempty"`
+    DecidedAt      *time.Time        `json:"decided_at,omitempty"`
+    DecisionNote   *string           `json:"decision_note,omitempty"`
+    Changes        []AmendmentChange `json:"changes"`
+}
+
+// AmendmentChange 变更项。Kind：
+// - add_layout：新增布局（Layout、Ratios，可附 Tasks）；批准后 LayoutID 为新布局。
+// - add_task：向计划内布局 LayoutID 新增任务（Color、PlannedLayers）；批准后 TaskID 为新任务。
+// - update_task：修改任务 TaskID 的计划层数（PlannedLayers）。
+// Before / After 在应用时写入，记录变更前后的值。
+type AmendmentChange struct {
+    ChangeID      int              `json:"change_id"`
+    Kind          string           `json:"kind"`
+    LayoutID      *int             `json:"layout_id,omitempty"`
+    TaskID        *int             `json:"task_id,omitempty"`
+    Layout        *CuttingLayout   `json:"layout,omitempty"`
+    Ratios        map[string]int   `json:"ratios,omitempty"`
+    Tasks         []ProductionTask `json:"tasks,omitempty"`
+    Color         *string          `json:"color,omitempty"`
+    PlannedLayers *int             `json:"planned_layers,omitempty"`
+    Before        json.RawMessage  `json:"before,omitempty"`
+    After         json.RawMessage  `json:"after,omitempty"`
+}
+
+// PlanReopening 计划重新打开记录：completed/frozen 计划回到 in_progress 时写入，只追加。
+// PreviousFinishDate 为重新打开时清空的计划完成时间。
+type PlanReopening struct {
+    ReopenID           int        `json:"reopen_id"`
+    PlanID             int        `json:"plan_id"`
+    FromStatus         string     `json:"from_status"`
+    Reason             string     `json:"reason"`
+    PreviousFinishDate *time.Time `json:"previous_finish_date,omitempty"`
+    ReopenedBy         *int       `json:"reopened_by,omitempty"`
+    ReopenedByName     *string    `json:"reopened_by_name,omitempty"`
+    ReopenedAt         time.Time  `json:"reopened_at"`
+}
+
+// OrderRevision 订单修订版本：订单项每次变更生成新版本，Revision 从 1 递增，只追加。
+// Items 为该版本订单项的完整快照；Changes / FlaggedPlans 相对上一版本（首版为空）。
+type OrderRevision struct {
+    RevisionID    int                `json:"revision_id"`
+    OrderID       int                `json:"order_id"`
+    Revision      int                `json:"revision"`
+    Reason        *string            `json:"reason,omitempty"`
+    Items         []OrderRevisionLine `json:"items"`
+    Changes       []OrderItemChange  `json:"changes"`
+    FlaggedPlans  []RevisionPlanFlag `json:"flagged_plans"`
+    CreatedBy     *int               `json:"created_by,omitempty"`
+    CreatedByName *string            `json:"created_by_name,omitempty"`
+    CreatedAt     time.Time          `json:"created_at"`
+}
+
+// OrderRevisionLine 修订快照中的一行订单项。
+type OrderRevisionLine struct {
+    Color    string `json:"color"`
+    Size     string `json:"size"`
+    Quantity int    `json:"quantity"`
+}
+
+// OrderItemChange 订单项变更。Kind：add（新增颜色/尺码行）、remove（删除行）、update（修改数量）。
+// Quantity 为新数量（remove 不填）；PreviousQuantity 在应用时写入（add 为空）。
+type OrderItemChange struct {
+    Kind             string `json:"kind"`
+    Color            string `json:"color"`
+    Size             string `json:"size"`
+    Quantity         *int   `json:"quantity,omitempty"`
+    PreviousQuantity *int   `json:"previous_quantity,omitempty"`
+}
+
+// RevisionPlanFlag 修订后覆盖不再匹配的已发布计划（in_progress / completed）。
+// Cells 为该计划有任务覆盖、且本次变更涉及的颜色/尺码中超出新数量容差窗口的格（按部件）；件数为同一部件在订单所有未冻结计划中的合计，
+// 未设置容差的一侧按 0% 计。
+type RevisionPlanFlag struct {
+    PlanID   int                  `json:"plan_id"`
+    PlanName string               `json:"plan_name"`
+    Status   string               `json:"status"`
+    Cells    []ToleranceViolation `json:"cells"`
+}
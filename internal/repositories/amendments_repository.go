@@ -0,0 +1,25 @@
+package repositories
+
+import (
+    "context"
+    "cutrix-backend/internal/models"
+)
+
+// AmendmentsRepository persists change requests against published plans and applies approved ones.
+// 设计约束：
+// - 仅进行中（in_progress）的计划可提出与批准变更；提出时校验目标任务/布局属于该计划。
+// - 批准在单事务中完成：锁定申请、订单与计划行，在 cutrix.plan_adjustment_flag / task_adjustment_flag 上下文中
+//   依次应用变更并写入每项的 before/after，随后校验订单裁剪容差，任一失败整体回滚、申请保持 pending。
+// - 已处理（approved/rejected）的申请由触发器禁止修改。
+type AmendmentsRepository interface {
+    // Create stores a pending amendment with its changes; ID, status and timestamps are written back into a.
+    Create(ctx context.Context, a *models.PlanAmendment) error
+    // GetByID returns the amendment with its changes; sql.ErrNoRows when missing.
+    GetByID(ctx context.Context, id int) (*models.PlanAmendment, error)
+    // ListByPlan returns the plan's amendments (optionally filtered by status) newest first; sql.ErrNoRows when the plan is missing.
+    ListByPlan(ctx context.Context, planID int, status string) ([]models.PlanAmendment, error)
+    // Apply applies a pending amendment and marks it approved; *ToleranceViolationError when the result breaks the order's cut tolerance.
+    Apply(ctx context.Context, id int, decidedBy *int, note *string) error
+    // Reject marks a pending amendment rejected without applying it.
+    Reject(ctx context.Context, id int, decidedBy *int, note *string) error
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+    "context"
+    "cutrix-backend/internal/models"
+)
+
+// BundlesRepository defines data access for bundle tickets.
+// 设计约束：
+// - 扎包由触发器在任务完成时自动生成（production.generate_task_bundles），任务因作废回退时自动清除。
+// - 重建为原地更新：尺码、份次与起始层号相同的扎包保留 bundle_id，已打印的扎票仍可扫码定位。
+// - 不提供逐条增删改接口；如需按其它扎包层数重排，使用 Regenerate 整体重建（仅 completed 任务）。
+// - 查询返回的订单号/款号/布局名为只读联查字段，便于打印扎票。
+type BundlesRepository interface {
+    // Regenerate rebuilds the task's bundles; size nil uses the layout bundle_size (default 10). Returns bundle count.
+    Regenerate(ctx context.Context, taskID int, size *int) (int, error)
+
+    // Queries
+    GetByID(ctx context.Context, id int) (*models.Bundle, error)
+    // ListByTask / ListByPlan return bundles ordered by task and bundle_no; sql.ErrNoRows when the parent does not exist.
+    ListByTask(ctx context.Context, taskID int) ([]models.Bundle, error)
+    ListByPlan(ctx context.Context, planID int) ([]models.Bundle, error)
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+    "context"
+    "cutrix-backend/internal/models"
+)
+
+// CustomersRepository defines data access for customer master data.
+// 设计约束：
+// - customer_code 唯一，customer_name 忽略大小写唯一（唯一索引 lower(customer_name)）。
+// - 订单通过 customer_id 引用客户；被订单引用的客户不可删除（外键 RESTRICT），仓储层预检并返回 ErrConflict。
+// - 迁移时按 customer_name（忽略大小写与首尾空白）为存量订单建立客户并回填 customer_id，仅执行一次。
+type CustomersRepository interface {
+    // Basic
+    Create(ctx context.Context, customer *models.Customer) (int, error)
+    Delete(ctx context.Context, id int) error
+
+    // Mutations
+    // Update replaces code, name, contact fields, default tolerance and note.
+    Update(ctx context.Context, customer *models.Customer) error
+
+    // Queries
+    GetByID(ctx context.Context, id int) (*models.Customer, error)
+    GetByCode(ctx context.Context, code string) (*models.Customer, error)
+    // GetByName matches the name case-insensitively.
+    GetByName(ctx context.Context, name string) (*models.Customer, error)
+    // List returns customers ordered by code; a non-empty query matches code or name (ILIKE).
+    List(ctx context.Context, query string) ([]models.Customer, error)
+}
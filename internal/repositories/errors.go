@@ -0,0 +1,59 @@
+package repositories
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+
+    "cutrix-backend/internal/models"
+)
+
+// ErrConflict and ErrValidation mark checks a repository repeats under a row lock, when the data changed after the
+// service validated it. services re-exports them, so handlers map them to 409 conflict / 400 validation_error.
+var (
+    ErrConflict   = errors.New("conflict")
+    ErrValidation = errors.New("validation error")
+)
+
+// ToleranceViolationError is returned by PlansRepository.Publish when the order's cut tolerance would be broken.
+// Violations lists every short/over component-color-size cell.
+type ToleranceViolationError struct {
+    Violations []models.ToleranceViolation
+}
+
+func (e *ToleranceViolationError) Error() string {
+    parts := make([]string, 0, len(e.Violations))
+    for _, v := range e.Violations {
+        kind := "超裁"
+        if v.Kind == "short" { kind = "短缺" }
+        parts = append(parts, fmt.Sprintf("%s %s/%s %s(下单 %d, 计划 %d)", v.Component, v.Color, v.Size, kind, v.OrderedQty, v.PlannedPieces))
+    }
+    return "发布失败：超出裁剪容差：" + strings.Join(parts, "; ")
+}
+
+// LotMixError is returned by LogsRepository when a log would spread a second dye lot into a task.
+// ExistingLots lists the lots the task already carries.
+type LotMixError struct {
+    TaskID       int
+    DyeLot       string
+    ExistingLots []string
+}
+
+func (e *LotMixError) Error() string {
+    return fmt.Sprintf("任务 %d 已使用缸号 %s，不可混入缸号 %s", e.TaskID, strings.Join(e.ExistingLots, ", "), e.DyeLot)
+}
+
+// PlanCloneMismatchError is returned by PlansRepository.Clone when, after remapping, the source plan still uses
+// colors or sizes that the target order does not have (the color/size triggers would reject them).
+type PlanCloneMismatchError struct {
+    OrderID       int
+    MissingColors []string
+    MissingSizes  []string
+}
+
+func (e *PlanCloneMismatchError) Error() string {
+    var parts []string
+    if len(e.MissingColors) > 0 { parts = append(parts, "颜色 "+strings.Join(e.MissingColors, ", ")) }
+    if len(e.MissingSizes) > 0 { parts = append(parts, "尺码 "+strings.Join(e.MissingSizes, ", ")) }
+    return fmt.Sprintf("复制失败：订单 %d 不含%s", e.OrderID, strings.Join(parts, "；"))
+}
\ No newline at end of file
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+    "context"
+    "time"
+    "cutrix-backend/internal/models"
+)
+
+// ForecastRepository provides read-only inputs for order due-date forecasting.
+// 设计约束：
+// - 产能口径：按 log_time 统计全厂未作废日志的层数，不区分裁床、班次与订单；统计窗口（14 天）由服务层决定。
+type ForecastRepository interface {
+    // ListOrderBacklog returns orders with remaining planned layers on unfinished tasks, skipping closed and
+    // cancelled orders, ordered by order_finish_date (NULLs last) then order_id.
+    ListOrderBacklog(ctx context.Context) ([]models.OrderBacklog, error)
+    // LayersSince sums layers of non-voided logs since the given time.
+    LayersSince(ctx context.Context, since time.Time) (int, error)
+}
@@ -8,7 +8,8 @@ import (
 // LayoutsRepository defines data access for cutting layouts.
 // 设计约束：
 // - 布局在所属计划发布后（status = in_progress/后续），INSERT/UPDATE/DELETE 将被触发器拒绝。
-// - 允许在发布前更新 layout_name 与 note；发布后请通过计划层接口控制。
+// - 允许在发布前更新 layout_name、component、note、唛架参数（长度/门幅/利用率/损耗）与扎包层数；发布后请通过计划层接口控制。
+// - component 为空时由触发器取款式首个部件（款式未登记或未列部件为 shell）；款式列出部件时必须属于其中，否则触发器报错。
 // - 删除受外键约束：会级联删除其任务与比例（若未发布）。
 type LayoutsRepository interface {
     // Basic
@@ -17,15 +18,24 @@ type LayoutsRepository interface {
 
     // Mutations (触发器在发布后拒绝)
     UpdateName(ctx context.Context, id int, name string) error
+    // UpdateComponent changes the garment component the layout cuts; empty restores the default (pending only).
+    UpdateComponent(ctx context.Context, id int, component string) error
     UpdateNote(ctx context.Context, id int, note *string) error
+    // UpdateMarker replaces marker length, fabric width, efficiency and end-loss allowance (pending only).
+    UpdateMarker(ctx context.Context, id int, spec models.MarkerSpec) error
+    // UpdateBundleSize sets plies per bundle ticket; nil restores the default (pending only).
+    UpdateBundleSize(ctx context.Context, id int, size *int) error
 
     // Queries
     GetByID(ctx context.Context, id int) (*models.CuttingLayout, error)
     List(ctx context.Context) ([]models.CuttingLayout, error)
     ListByPlan(ctx context.Context, planID int) ([]models.CuttingLayout, error)
+    // ListFabricByLayout returns per-task fabric lines of the layout; sql.ErrNoRows when the layout does not exist.
+    ListFabricByLayout(ctx context.Context, layoutID int) ([]models.FabricRequirementLine, error)
 
     // Size Ratios
     SetRatios(ctx context.Context, layoutID int, ratios map[string]int) error
+    // GetRatios / GetRatiosBatch return sizes in the style's size order; sizes the style does not list sort last by name.
     GetRatios(ctx context.Context, layoutID int) ([]models.LayoutSizeRatio, error)
     GetRatiosBatch(ctx context.Context, layoutIDs []int) (map[int][]models.LayoutSizeRatio, error)
 }
\ No newline at end of file
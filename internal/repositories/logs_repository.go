@@ -6,6 +6,13 @@ type LogsRepository interface {
     // Create 记录新的生产日志；仅允许向 in_progress 任务提交，DB 触发器强制校验。
     Create(log *models.ProductionLog) error
 
+    // CreateWithRolls 记录日志并按 RollIDs 顺序领用布卷（事务）；每层长度 = 唛架长度 + 两端损耗，
+    // 布卷剩余长度不足、颜色不符或已结卷时整体回滚。成功后填充 log.Rolls。
+    CreateWithRolls(log *models.ProductionLog) error
+
+    // ListRollsByLog 查看日志的布卷领用明细。
+    ListRollsByLog(logID int) ([]models.LogRollUsage, error)
+
     // GetByID 获取单个日志详情。
     GetByID(logID int) (*models.ProductionLog, error)
 
@@ -9,10 +9,15 @@ import (
 // OrdersRepository defines the order data access contract.
 // Notes:
 // - Creating an order requires at least one item; empty orders are not allowed.
-// - Orders allow updates to `note` and `order_finish_date` only.
+// - Orders allow updates to `note`, `order_finish_date`, cut tolerance, `status` and `customer_id` only.
+// - `customer_id` references production.customers. At creation it falls back to the customer whose name matches
+//   `customer_name` ignoring case, and the customer's name and default tolerances fill fields the order leaves empty.
+// - Status follows draft → confirmed → in_production → cut_complete → closed, plus cancelled; DB triggers
+//   move orders to in_production / cut_complete on plan publish and completion and reject other transitions.
 // - `order_start_date` is set at creation and remains immutable.
 // - `created_at` and `updated_at` are maintained by DB defaults/triggers.
-// - Order items are created at order creation and immutable afterward.
+// - Order items change only through Revise, which writes a new numbered revision with a full item snapshot;
+//   earlier revisions stay readable via ListRevisions / GetRevision. Revision 1 is written at creation.
 // - Deleting an order cascades to its items via foreign key.
 type OrdersRepository interface {
     // Basic operations
@@ -26,16 +31,37 @@ type OrdersRepository interface {
     UpdateNote(id int, note *string) error
     // UpdateFinishDate updates finish date (nullable); DB triggers update `updated_at`.
     UpdateFinishDate(id int, finishDate *time.Time) error
+    // UpdateTolerance updates over/under-cut tolerance percentages; nil disables that side.
+    UpdateTolerance(id int, over, under *float64) error
+    // UpdateStatus changes the order status and returns the updated order; sql.ErrNoRows when it does not exist.
+    UpdateStatus(ctx context.Context, id int, status string) (*models.ProductionOrder, error)
+    // UpdateCustomer sets the referenced customer (nil unlinks); sql.ErrNoRows when the order does not exist.
+    UpdateCustomer(ctx context.Context, id int, customerID *int) error
+
+    // Revisions
+    // Revise applies add / remove / update item changes as the next revision and flags published plans whose
+    // coverage no longer matches. Re-checked under the order lock: a closed or cancelled order returns ErrConflict;
+    // adding an existing item, changing a missing one or removing the last one returns ErrValidation.
+    Revise(ctx context.Context, orderID int, changes []models.OrderItemChange, reason *string, createdBy *int) (*models.OrderRevision, error)
+    // ListRevisions returns the order's revisions newest first; sql.ErrNoRows when the order does not exist.
+    ListRevisions(ctx context.Context, orderID int) ([]models.OrderRevision, error)
+    // GetRevision returns one revision of the order.
+    GetRevision(ctx context.Context, orderID, revision int) (*models.OrderRevision, error)
 
     // Queries
     // GetByID returns an order by ID.
     GetByID(id int) (*models.ProductionOrder, error)
-    // GetAll returns all orders ordered by created_at desc.
-    GetAll(ctx context.Context) ([]models.ProductionOrder, error)
+    // GetAll returns orders ordered by created_at desc, filtered by status, customer and style number when set.
+    GetAll(ctx context.Context, filter models.OrderFilter) ([]models.ProductionOrder, error)
     // GetByOrderNumber returns an order by unique order_number.
     GetByOrderNumber(ctx context.Context, number string) (*models.ProductionOrder, error)
-    // GetWithItems returns the order and its items.
+    // GetWithItems returns the order and the items of its current revision.
     GetWithItems(ctx context.Context, id int) (*models.ProductionOrder, []models.OrderItem, error)
+    // GetProgressBatch aggregates plans, layouts and tasks of several orders in a fixed number of queries.
+    // Cells (ordered / planned / cut pieces per color and size) list colors in item entry order and sizes in the
+    // style's size order (item entry order for sizes the style does not list); orders that
+    // do not exist are omitted. PercentComplete is left to the caller.
+    GetProgressBatch(ctx context.Context, ids []int) ([]models.OrderProgress, error)
 
     // Delete removes an order by ID (cascades to items).
     Delete(id int) error
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+    "context"
+    "cutrix-backend/internal/models"
+)
+
+// PayrollRepository defines data access for piece rates, pay periods and payroll statements.
+// 设计约束：
+// - 计件按未作废日志的层数 × 单价；单价取布局单价，其次款号单价，再次默认单价。
+// - 周期按日志的班次日期划分；未锁定周期的明细每次即时计算，锁定时写入 payroll_lines 作为快照，之后不随单价或日志变化。
+// - 已锁定周期中的日志被作废后，冲销明细（层数、金额取原明细的相反数）计入最早的未锁定周期。
+// - 锁定的周期与明细不可修改或删除（触发器拒绝）。
+type PayrollRepository interface {
+    // Rates
+    CreateRate(ctx context.Context, rate *models.PieceRate) (int, error)
+    // UpdateRate changes the rate and note; the scope is immutable.
+    UpdateRate(ctx context.Context, rate *models.PieceRate) error
+    DeleteRate(ctx context.Context, id int) error
+    GetRate(ctx context.Context, id int) (*models.PieceRate, error)
+    // FindRate returns the rate with exactly the given scope (both nil for the default).
+    FindRate(ctx context.Context, layoutID *int, styleNumber *string) (*models.PieceRate, error)
+    ListRates(ctx context.Context) ([]models.PieceRate, error)
+
+    // Periods
+    CreatePeriod(ctx context.Context, period *models.PayPeriod) (int, error)
+    // DeletePeriod removes an open period.
+    DeletePeriod(ctx context.Context, id int) error
+    GetPeriod(ctx context.Context, id int) (*models.PayPeriod, error)
+    // ListPeriods returns periods ordered by start date, latest first.
+    ListPeriods(ctx context.Context) ([]models.PayPeriod, error)
+    // LockPeriod snapshots the pending lines of an open period and marks it locked, in one transaction.
+    LockPeriod(ctx context.Context, id int, lockedBy *int) error
+
+    // Statements
+    // ListLines returns the stored lines of a locked period, or the pending lines of an open one.
+    ListLines(ctx context.Context, periodID int) ([]models.PayrollLine, error)
+}
@@ -7,25 +7,45 @@ import (
 
 // PlansRepository defines data access for production plans.
 // 设计约束：
-// - 发布/完成仅更新 status，余下自动行为由触发器处理（publish/finish 日期、数量校验等）。
+// - 发布/完成仅更新 status，余下自动行为由触发器处理（publish/finish 日期、数量校验等）；发布前另行预检订单裁剪容差。
 // - 发布后允许更新的字段仅 note；其它字段由触发器限制不可写。
+// - completed/frozen 为终态，仅可经 Reopen（计划调整上下文）回到 in_progress，并写入重新打开记录。
 // - 删除允许在任何状态执行，将级联删除其布局/任务/比例；子表的发布后约束由各自触发器控制。
 // - 跨表原子创建（计划+布局+任务）如需支持应在服务层组合或另行定义聚合方法。
 // 如需扩展查询（分页、筛选），建议统一由服务层定义 filter 结构体，仓储层使用参数化方法避免循环依赖。
 type PlansRepository interface {
     // Basic
     Create(ctx context.Context, plan *models.ProductionPlan) (int, error)
+    // CreateWithLayouts atomically creates a pending plan with its layouts, size ratios and tasks (transaction).
+    // Generated IDs are written back into plan and layouts.
+    CreateWithLayouts(ctx context.Context, plan *models.ProductionPlan, layouts []models.DraftLayout) (int, error)
+    // Clone copies sourceID's layouts, size ratios and tasks into a new pending plan (transaction), renaming colors and
+    // sizes via colorMap/sizeMap. Empty plan.OrderID / PlanName / Note default to the source's (name suffixed " (copy)").
+    // Returns *PlanCloneMismatchError when the target order lacks a color or size; sql.ErrNoRows when the plan or order is missing.
+    Clone(ctx context.Context, sourceID int, plan *models.ProductionPlan, colorMap, sizeMap map[string]string) ([]models.DraftLayout, error)
     Delete(ctx context.Context, id int) error
 
     // Mutations
     UpdateNote(ctx context.Context, id int, note *string) error
 
     // Business actions (rely on DB triggers for validation & auto dates)
-    Publish(ctx context.Context, id int) error   // status -> in_progress
+    Publish(ctx context.Context, id int) error   // status -> in_progress; *ToleranceViolationError when out of order tolerance
     Freeze(ctx context.Context, id int) error    // status -> frozen
+    // Reopen moves a completed/frozen plan back to in_progress inside the plan adjustment context, clears
+    // planned_finish_date and records the reopen in plan_reopen_history. Reopening a frozen plan re-adds its pieces to
+    // the order's totals: *ToleranceViolationError when that goes over the order's tolerance.
+    Reopen(ctx context.Context, id int, reason string, reopenedBy *int) (*models.PlanReopening, error)
 
     // Queries
     GetByID(ctx context.Context, id int) (*models.ProductionPlan, error)
     List(ctx context.Context) ([]models.ProductionPlan, error)
     ListByOrder(ctx context.Context, orderID int) ([]models.ProductionPlan, error)
+    // ToleranceViolations returns cells outside the order's cut tolerance if this plan were published
+    // (planned pieces summed with the order's other non-frozen plans). Empty when the order has no tolerance set.
+    ToleranceViolations(ctx context.Context, id int) ([]models.ToleranceViolation, error)
+    // ListReopenHistory returns the plan's reopen records, latest first; sql.ErrNoRows when the plan does not exist.
+    ListReopenHistory(ctx context.Context, planID int) ([]models.PlanReopening, error)
+    // ListFabricByPlan / ListFabricByOrder return per-task fabric lines; sql.ErrNoRows when the plan/order does not exist.
+    ListFabricByPlan(ctx context.Context, planID int) ([]models.FabricRequirementLine, error)
+    ListFabricByOrder(ctx context.Context, orderID int) ([]models.FabricRequirementLine, error)
 }
\ No newline at end of file
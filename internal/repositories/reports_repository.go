@@ -0,0 +1,21 @@
+package repositories
+
+import (
+    "context"
+    "time"
+    "cutrix-backend/internal/models"
+)
+
+// ReportsRepository provides read-only aggregates over production logs for reporting.
+// 设计约束：
+// - 只读；作废日志一律排除。件数 = 层数 × 布局尺码比例之和。
+// - customerID 非 nil 时仅统计该客户订单下的日志。
+type ReportsRepository interface {
+    // ListShiftRows aggregates non-voided logs of the shift date (YYYY-MM-DD) by shift, worker and plan.
+    ListShiftRows(ctx context.Context, shiftDate string, customerID *int) ([]models.ShiftReportRow, error)
+    // ListProductivity aggregates logs with a shift date in [from, to] (YYYY-MM-DD) by the given dimensions
+    // (worker, group, layout, color, component). Active hours are the gaps between consecutive non-voided logs of the same
+    // worker, ignoring gaps longer than maxGap; voided logs only count towards Logs/VoidedLogs. Gaps are measured over
+    // all of the worker's logs before the customer filter applies.
+    ListProductivity(ctx context.Context, from, to string, maxGap time.Duration, dims []string, customerID *int) ([]models.ProductivityRow, error)
+}
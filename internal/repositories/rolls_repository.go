@@ -0,0 +1,29 @@
+package repositories
+
+import (
+    "context"
+    "cutrix-backend/internal/models"
+)
+
+// RollsRepository defines data access for fabric roll inventory.
+// 设计约束：
+// - 剩余长度仅由领用（log_rolls 触发器扣减）与日志作废（触发器回补）变更，不提供直接修改接口。
+// - 结卷（closed）需登记实测余料长度；结卷后不可再领用，触发器拒绝除备注外的修改。
+// - 已有领用记录的布卷不可删除（外键 RESTRICT），仓储层预检并返回业务错误。
+type RollsRepository interface {
+    // Basic
+    Create(ctx context.Context, roll *models.FabricRoll) (int, error)
+    Delete(ctx context.Context, id int) error
+
+    // Mutations
+    UpdateNote(ctx context.Context, id int, note *string) error
+    // Close marks the roll closed and records the measured remnant length.
+    Close(ctx context.Context, id int, remnant float64, note *string) error
+
+    // Queries
+    GetByID(ctx context.Context, id int) (*models.FabricRoll, error)
+    GetByCode(ctx context.Context, code string) (*models.FabricRoll, error)
+    List(ctx context.Context, filter models.RollFilter) ([]models.FabricRoll, error)
+    // ListUsage returns all logs that spread from the roll (including voided ones).
+    ListUsage(ctx context.Context, rollID int) ([]models.LogRollUsage, error)
+}
\ No newline at end of file
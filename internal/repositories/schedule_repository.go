@@ -0,0 +1,18 @@
+package repositories
+
+import (
+    "context"
+    "time"
+    "cutrix-backend/internal/models"
+)
+
+// ScheduleRepository provides read-only inputs for the task scheduler.
+// 设计约束：
+// - 只读：排程结果不落库，每次请求按当前任务与日志重新计算，因此日志提交或作废后立即生效。
+type ScheduleRepository interface {
+    // ListOpenTasks returns in-progress tasks of published plans that still have layers to spread.
+    ListOpenTasks(ctx context.Context) ([]models.ScheduleTask, error)
+    // ListLayerRates aggregates non-voided logs since the given time by table: for each log, the layers and the
+    // hours since the previous log of the same task; gaps longer than maxGap (breaks, overnight) are ignored.
+    ListLayerRates(ctx context.Context, since time.Time, maxGap time.Duration) ([]models.LayerRate, error)
+}
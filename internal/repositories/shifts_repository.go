@@ -0,0 +1,30 @@
+package repositories
+
+import (
+    "context"
+    "cutrix-backend/internal/models"
+)
+
+// ShiftsRepository defines data access for shift definitions and the factory timezone.
+// 设计约束：
+// - 班次时间为工厂时区下的 HH:MM；end_time <= start_time 表示跨零点。
+// - 日志写入时由触发器按当时的有效班次归属 shift_id / shift_date，之后修改班次不影响历史日志。
+// - 被日志引用的班次不可删除，可停用（is_active=false）。
+type ShiftsRepository interface {
+    // Basic
+    Create(ctx context.Context, shift *models.Shift) (int, error)
+    Delete(ctx context.Context, id int) error
+
+    // Mutations
+    // Update changes name, times, active flag and note.
+    Update(ctx context.Context, shift *models.Shift) error
+    // SetTimezone stores the factory timezone (IANA name); the DB rejects unknown names.
+    SetTimezone(ctx context.Context, timezone string) (*models.FactorySettings, error)
+
+    // Queries
+    GetByID(ctx context.Context, id int) (*models.Shift, error)
+    GetByName(ctx context.Context, name string) (*models.Shift, error)
+    // List returns shifts ordered by start time; activeOnly filters out disabled ones.
+    List(ctx context.Context, activeOnly bool) ([]models.Shift, error)
+    GetSettings(ctx context.Context) (*models.FactorySettings, error)
+}
@@ -0,0 +1,347 @@
+package repositories
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "fmt"
+    "sort"
+
+    "cutrix-backend/internal/models"
+)
+
+type SqlAmendmentsRepository struct{ db *sql.DB }
+
+var _ AmendmentsRepository = (*SqlAmendmentsRepository)(nil)
+
+func NewSqlAmendmentsRepository(db *sql.DB) *SqlAmendmentsRepository { return &SqlAmendmentsRepository{db: db} }
+
+// amendmentPayload is the proposed part of a change, stored as JSONB.
+type amendmentPayload struct {
+    Layout        *models.CuttingLayout   `json:"layout,omitempty"`
+    Ratios        map[string]int          `json:"ratios,omitempty"`
+    Tasks         []models.ProductionTask `json:"tasks,omitempty"`
+    Color         *string                 `json:"color,omitempty"`
+    PlannedLayers *int                    `json:"planned_layers,omitempty"`
+}
+
+// amendmentColumns is the select list matching scanAmendment.
+const amendmentColumns = `amendment_id, plan_id, status, reason, proposed_by, proposed_by_name, proposed_at,
+    decided_by, decided_by_name, decided_at, decision_note`
+
+func scanAmendment(row scanner) (*models.PlanAmendment, error) {
+    a := models.PlanAmendment{Changes: []models.AmendmentChange{}}
+    if err := row.Scan(&a.AmendmentID, &a.PlanID, &a.Status, &a.Reason, &a.ProposedBy, &a.ProposedByName, &a.ProposedAt,
+        &a.DecidedBy, &a.DecidedByName, &a.DecidedAt, &a.DecisionNote); err != nil {
+        return nil, err
+    }
+    return &a, nil
+}
+
+func (r *SqlAmendmentsRepository) Create(ctx context.Context, a *models.PlanAmendment) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil { return err }
+    defer tx.Rollback()
+
+    var status string
+    if err := tx.QueryRowContext(ctx, `SELECT status FROM production.plans WHERE plan_id = $1 FOR UPDATE`, a.PlanID).Scan(&status); err != nil {
+        return err
+    }
+    if status != "in_progress" {
+        return fmt.Errorf("仅进行中的计划可提出变更 (plan_id=%d, status=%s)", a.PlanID, status)
+    }
+    for _, c := range a.Changes {
+        switch c.Kind {
+        case "add_task":
+            if err := checkLayoutInPlan(ctx, tx, *c.LayoutID, a.PlanID); err != nil { return err }
+        case "update_task":
+            var planID, completed int
+            err := tx.QueryRowContext(ctx, `
+                SELECT cl.plan_id, t.completed_layers FROM production.tasks t
+                JOIN production.cutting_layouts cl ON cl.layout_id = t.layout_id
+                WHERE t.task_id = $1`, *c.TaskID).Scan(&planID, &completed)
+            if err == sql.ErrNoRows || (err == nil && planID != a.PlanID) {
+                return fmt.Errorf("任务 %d 不属于计划 %d", *c.TaskID, a.PlanID)
+            }
+            if err != nil { return err }
+            if *c.PlannedLayers < completed {
+                return fmt.Errorf("任务 %d 的计划层数不能小于已完成层数 %d", *c.TaskID, completed)
+            }
+        }
+    }
+
+    if err := tx.QueryRowContext(ctx, `
+        INSERT INTO production.plan_amendments (plan_id, reason, proposed_by, proposed_by_name)
+        VALUES ($1, $2, $3, (SELECT name FROM public.users WHERE user_id = $3))
+        RETURNING amendment_id, status, proposed_by_name, proposed_at`,
+        a.PlanID, a.Reason, a.ProposedBy,
+    ).Scan(&a.AmendmentID, &a.Status, &a.ProposedByName, &a.ProposedAt); err != nil {
+        return err
+    }
+    for i := range a.Changes {
+        c := &a.Changes[i]
+        payload, err := json.Marshal(amendmentPayload{Layout: c.Layout, Ratios: c.Ratios, Tasks: c.Tasks, Color: c.Color, PlannedLayers: c.PlannedLayers})
+        if err != nil { return err }
+        if err := tx.QueryRowContext(ctx, `
+            INSERT INTO production.plan_amendment_changes (amendment_id, seq, kind, layout_id, task_id, payload)
+            VALUES ($1, $2, $3, $4, $5, $6) RETURNING change_id`,
+            a.AmendmentID, i+1, c.Kind, c.LayoutID, c.TaskID, payload,
+        ).Scan(&c.ChangeID); err != nil {
+            return err
+        }
+    }
+    return tx.Commit()
+}
+
+// checkLayoutInPlan returns an error unless the layout belongs to the plan.
+func checkLayoutInPlan(ctx context.Context, tx *sql.Tx, layoutID, planID int) error {
+    var got int
+    err := tx.QueryRowContext(ctx, `SELECT plan_id FROM production.cutting_layouts WHERE layout_id = $1`, layoutID).Scan(&got)
+    if err == sql.ErrNoRows || (err == nil && got != planID) {
+        return fmt.Errorf("布局 %d 不属于计划 %d", layoutID, planID)
+    }
+    return err
+}
+
+func (r *SqlAmendmentsRepository) GetByID(ctx context.Context, id int) (*models.PlanAmendment, error) {
+    a, err := scanAmendment(r.db.QueryRowContext(ctx, `SELECT `+amendmentColumns+` FROM production.plan_amendments WHERE amendment_id = $1`, id))
+    if err != nil { return nil, err }
+    list := []models.PlanAmendment{*a}
+    if err := r.loadChanges(ctx, list, `c.amendment_id = $1`, id); err != nil { return nil, err }
+    return &list[0], nil
+}
+
+func (r *SqlAmendmentsRepository) ListByPlan(ctx context.Context, planID int, status string) ([]models.PlanAmendment, error) {
+    if err := r.db.QueryRowContext(ctx, `SELECT plan_id FROM production.plans WHERE plan_id = $1`, planID).Scan(&planID); err != nil {
+        return nil, err
+    }
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT `+amendmentColumns+` FROM production.plan_amendments
+        WHERE plan_id = $1 AND ($2 = '' OR status = $2)
+        ORDER BY amendment_id DESC`, planID, status)
+    if err != nil { return nil, err }
+    res := []models.PlanAmendment{}
+    for rows.Next() {
+        a, err := scanAmendment(rows)
+        if err != nil { rows.Close(); return nil, err }
+        res = append(res, *a)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil { return nil, err }
+    if err := r.loadChanges(ctx, res, `a.plan_id = $1`, planID); err != nil { return nil, err }
+    return res, nil
+}
+
+// loadChanges fills the changes of the given amendments; where filters plan_amendment_changes c joined with plan_amendments a.
+func (r *SqlAmendmentsRepository) loadChanges(ctx context.Context, list []models.PlanAmendment, where string, arg int) error {
+    index := make(map[int]int, len(list))
+    for i, a := range list { index[a.AmendmentID] = i }
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT c.amendment_id, c.change_id, c.kind, c.layout_id, c.task_id, c.payload, c.before_values, c.after_values
+        FROM production.plan_amendment_changes c
+        JOIN production.plan_amendments a ON a.amendment_id = c.amendment_id
+        WHERE `+where+`
+        ORDER BY c.amendment_id, c.seq`, arg)
+    if err != nil { return err }
+    defer rows.Close()
+    for rows.Next() {
+        var amendmentID int
+        var c models.AmendmentChange
+        var payload, before, after []byte
+        if err := rows.Scan(&amendmentID, &c.ChangeID, &c.Kind, &c.LayoutID, &c.TaskID, &payload, &before, &after); err != nil { return err }
+        var p amendmentPayload
+        if err := json.Unmarshal(payload, &p); err != nil { return err }
+        c.Layout, c.Ratios, c.Tasks, c.Color, c.PlannedLayers = p.Layout, p.Ratios, p.Tasks, p.Color, p.PlannedLayers
+        if before != nil { c.Before = json.RawMessage(before) }
+        if after != nil { c.After = json.RawMessage(after) }
+        i, ok := index[amendmentID]
+        if !ok { continue }
+        list[i].Changes = append(list[i].Changes, c)
+    }
+    return rows.Err()
+}
+
+// Apply runs every change in proposal order inside the adjustment context and records before/after values.
+func (r *SqlAmendmentsRepository) Apply(ctx context.Context, id int, decidedBy *int, note *string) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil { return err }
+    defer tx.Rollback()
+
+    var planID int
+    var status string
+    if err := tx.QueryRowContext(ctx, `SELECT plan_id, status FROM production.plan_amendments WHERE amendment_id = $1 FOR UPDATE`, id).
+        Scan(&planID, &status); err != nil {
+        return err
+    }
+    if status != "pending" {
+        return fmt.Errorf("变更申请已处理 (amendment_id=%d, status=%s)", id, status)
+    }
+    // Lock the order like Publish so the tolerance check sees concurrent plan changes
+    if _, err := tx.ExecContext(ctx, `
+        SELECT o.order_id FROM production.orders o
+        JOIN production.plans p ON p.order_id = o.order_id
+        WHERE p.plan_id = $1
+        FOR UPDATE OF o`, planID); err != nil {
+        return err
+    }
+    if err := tx.QueryRowContext(ctx, `SELECT status FROM production.plans WHERE plan_id = $1 FOR UPDATE`, planID).Scan(&status); err != nil {
+        return err
+    }
+    if status != "in_progress" {
+        return fmt.Errorf("仅进行中的计划可应用变更 (plan_id=%d, status=%s)", planID, status)
+    }
+    if _, err := tx.ExecContext(ctx, `SET LOCAL cutrix.plan_adjustment_flag = true`); err != nil { return err }
+    if _, err := tx.ExecContext(ctx, `SET LOCAL cutrix.task_adjustment_flag = true`); err != nil { return err }
+
+    type pending struct {
+        changeID int
+        kind     string
+        layoutID *int
+        taskID   *int
+        payload  amendmentPayload
+    }
+    var changes []pending
+    rows, err := tx.QueryContext(ctx, `
+        SELECT change_id, kind, layout_id, task_id, payload FROM production.plan_amendment_changes
+        WHERE amendment_id = $1 ORDER BY seq`, id)
+    if err != nil { return err }
+    for rows.Next() {
+        var p pending
+        var raw []byte
+        if err := rows.Scan(&p.changeID, &p.kind, &p.layoutID, &p.taskID, &raw); err != nil { rows.Close(); return err }
+        if err := json.Unmarshal(raw, &p.payload); err != nil { rows.Close(); return err }
+        changes = append(changes, p)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil { return err }
+
+    for _, c := range changes {
+        var before, after any
+        switch c.kind {
+        case "add_layout":
+            l := models.DraftLayout{Layout: *c.payload.Layout, Ratios: c.payload.Ratios, Tasks: c.payload.Tasks}
+            if err := insertAmendmentLayout(ctx, tx, planID, &l); err != nil { return err }
+            c.layoutID = &l.Layout.LayoutID
+            after = l
+        case "add_task":
+            if c.layoutID == nil { return fmt.Errorf("变更 %d 的目标布局已不存在", c.changeID) }
+            if err := checkLayoutInPlan(ctx, tx, *c.layoutID, planID); err != nil { return err }
+            t := models.ProductionTask{LayoutID: *c.layoutID, Color: *c.payload.Color, PlannedLayers: *c.payload.PlannedLayers}
+            if err := insertAmendmentTask(ctx, tx, &t); err != nil { return err }
+            c.taskID = &t.TaskID
+            after = t
+        case "update_task":
+            if c.taskID == nil { return fmt.Errorf("变更 %d 的目标任务已不存在", c.changeID) }
+            var t models.ProductionTask
+            if err := tx.QueryRowContext(ctx, `
+                SELECT task_id, layout_id, color, planned_layers, completed_layers, status FROM production.tasks
+                WHERE task_id = $1 FOR UPDATE`, *c.taskID,
+            ).Scan(&t.TaskID, &t.LayoutID, &t.Color, &t.PlannedLayers, &t.CompletedLayers, &t.Status); err != nil {
+                return err
+            }
+            layers := *c.payload.PlannedLayers
+            if layers < t.CompletedLayers {
+                return fmt.Errorf("任务 %d 的计划层数不能小于已完成层数 %d", t.TaskID, t.CompletedLayers)
+            }
+            before = t
+            if err := tx.QueryRowContext(ctx, `
+                UPDATE production.tasks
+                SET planned_layers = $1,
+                    status = CASE WHEN completed_layers >= $1 THEN 'completed' ELSE 'in_progress' END
+                WHERE task_id = $2
+                RETURNING planned_layers, status`, layers, t.TaskID,
+            ).Scan(&t.PlannedLayers, &t.Status); err != nil {
+                return err
+            }
+            after = t
+        default:
+            return fmt.Errorf("未知的变更类型 %s", c.kind)
+        }
+        beforeJSON, err := marshalNullable(before)
+        if err != nil { return err }
+        afterJSON, err := marshalNullable(after)
+        if err != nil { return err }
+        if _, err := tx.ExecContext(ctx, `
+            UPDATE production.plan_amendment_changes
+            SET layout_id = $1, task_id = $2, before_values = $3, after_values = $4
+            WHERE change_id = $5`, c.layoutID, c.taskID, beforeJSON, afterJSON, c.changeID); err != nil {
+            return err
+        }
+    }
+
+    violations, err := queryToleranceViolations(ctx, tx, planID)
+    if err != nil { return err }
+    if len(violations) > 0 {
+        return &ToleranceViolationError{Violations: violations}
+    }
+    if _, err := tx.ExecContext(ctx, `
+        UPDATE production.plan_amendments
+        SET status = 'approved', decided_by = $1, decided_by_name = (SELECT name FROM public.users WHERE user_id = $1),
+            decided_at = CURRENT_TIMESTAMP, decision_note = $2
+        WHERE amendment_id = $3`, decidedBy, note, id); err != nil {
+        return err
+    }
+    return tx.Commit()
+}
+
+// insertAmendmentLayout adds a layout with its ratios and tasks to a published plan; tasks start in_progress so logs are accepted.
+func insertAmendmentLayout(ctx context.Context, tx *sql.Tx, planID int, l *models.DraftLayout) error {
+    l.Layout.PlanID = planID
+    if err := tx.QueryRowContext(ctx,
+        `INSERT INTO production.cutting_layouts (plan_id, layout_name, component, note,
+            marker_length, fabric_width, marker_efficiency, end_loss_allowance, bundle_size)
+         VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9) RETURNING layout_id, component`,
+        planID, l.Layout.LayoutName, l.Layout.Component, l.Layout.Note,
+        l.Layout.MarkerLength, l.Layout.FabricWidth, l.Layout.MarkerEfficiency, l.Layout.EndLossAllowance, l.Layout.BundleSize,
+    ).Scan(&l.Layout.LayoutID, &l.Layout.Component); err != nil {
+        return err
+    }
+    sizes := make([]string, 0, len(l.Ratios))
+    for size := range l.Ratios { sizes = append(sizes, size) }
+    sort.Strings(sizes)
+    for _, size := range sizes {
+        if l.Ratios[size] <= 0 { continue }
+        if _, err := tx.ExecContext(ctx,
+            `INSERT INTO production.layout_size_ratios (layout_id, size, ratio) VALUES ($1, $2, $3)`,
+            l.Layout.LayoutID, size, l.Ratios[size],
+        ); err != nil {
+            return err
+        }
+    }
+    for i := range l.Tasks {
+        l.Tasks[i].LayoutID = l.Layout.LayoutID
+        if err := insertAmendmentTask(ctx, tx, &l.Tasks[i]); err != nil { return err }
+    }
+    return nil
+}
+
+func insertAmendmentTask(ctx context.Context, tx *sql.Tx, t *models.ProductionTask) error {
+    return tx.QueryRowContext(ctx,
+        `INSERT INTO production.tasks (layout_id, color, planned_layers, status) VALUES ($1, $2, $3, 'in_progress')
+         RETURNING task_id, completed_layers, status`,
+        t.LayoutID, t.Color, t.PlannedLayers,
+    ).Scan(&t.TaskID, &t.CompletedLayers, &t.Status)
+}
+
+// marshalNullable encodes v as JSON, or SQL NULL when v is nil.
+func marshalNullable(v any) (any, error) {
+    if v == nil { return nil, nil }
+    return json.Marshal(v)
+}
+
+func (r *SqlAmendmentsRepository) Reject(ctx context.Context, id int, decidedBy *int, note *string) error {
+    res, err := r.db.ExecContext(ctx, `
+        UPDATE production.plan_amendments
+        SET status = 'rejected', decided_by = $1, decided_by_name = (SELECT name FROM public.users WHERE user_id = $1),
+            decided_at = CURRENT_TIMESTAMP, decision_note = $2
+        WHERE amendment_id = $3 AND status = 'pending'`, decidedBy, note, id)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 {
+        var status string
+        if err := r.db.QueryRowContext(ctx, `SELECT status FROM production.plan_amendments WHERE amendment_id = $1`, id).Scan(&status); err != nil {
+            return err
+        }
+        return fmt.Errorf("变更申请已处理 (amendment_id=%d, status=%s)", id, status)
+    }
+    return nil
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "cutrix-backend/internal/models"
+)
+
+type SqlBundlesRepository struct{ db *sql.DB }
+
+var _ BundlesRepository = (*SqlBundlesRepository)(nil)
+
+func NewSqlBundlesRepository(db *sql.DB) *SqlBundlesRepository { return &SqlBundlesRepository{db: db} }
+
+// bundleSelect joins the task's layout, plan and order for ticket fields; matches scanBundle.
+const bundleSelect = `
+    SELECT b.bundle_id, b.task_id, b.bundle_no, b.color, b.size, b.copy_no, b.dye_lot,
+           b.ply_from, b.ply_to, b.pieces, b.created_at,
+           l.plan_id, l.layout_name, o.order_number, o.style_number
+    FROM production.bundles b
+    JOIN production.tasks t ON t.task_id = b.task_id
+    JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+    JOIN production.plans p ON p.plan_id = l.plan_id
+    JOIN production.orders o ON o.order_id = p.order_id`
+
+func scanBundle(s scanner) (*models.Bundle, error) {
+    var b models.Bundle
+    if err := s.Scan(&b.BundleID, &b.TaskID, &b.BundleNo, &b.Color, &b.Size, &b.CopyNo, &b.DyeLot,
+        &b.PlyFrom, &b.PlyTo, &b.Pieces, &b.CreatedAt,
+        &b.PlanID, &b.LayoutName, &b.OrderNumber, &b.StyleNumber); err != nil {
+        return nil, err
+    }
+    return &b, nil
+}
+
+func (r *SqlBundlesRepository) Regenerate(ctx context.Context, taskID int, size *int) (int, error) {
+    // Pre-check: bundles only exist for completed tasks
+    var status string
+    if err := r.db.QueryRowContext(ctx, `SELECT status FROM production.tasks WHERE task_id = $1`, taskID).Scan(&status); err != nil {
+        return 0, err
+    }
+    if status != "completed" {
+        return 0, fmt.Errorf("任务未完成，不能生成扎包 (task_id=%d, status=%s)", taskID, status)
+    }
+    var n int
+    err := r.db.QueryRowContext(ctx, `SELECT production.generate_task_bundles($1, $2)`, taskID, size).Scan(&n)
+    return n, err
+}
+
+func (r *SqlBundlesRepository) GetByID(ctx context.Context, id int) (*models.Bundle, error) {
+    return scanBundle(r.db.QueryRowContext(ctx, bundleSelect+` WHERE b.bundle_id = $1`, id))
+}
+
+func (r *SqlBundlesRepository) ListByTask(ctx context.Context, taskID int) ([]models.Bundle, error) {
+    var exists bool
+    if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM production.tasks WHERE task_id = $1)`, taskID).Scan(&exists); err != nil {
+        return nil, err
+    }
+    if !exists { return nil, sql.ErrNoRows }
+    return r.queryBundles(ctx, bundleSelect+` WHERE b.task_id = $1 ORDER BY b.bundle_no ASC`, taskID)
+}
+
+func (r *SqlBundlesRepository) ListByPlan(ctx context.Context, planID int) ([]models.Bundle, error) {
+    var exists bool
+    if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM production.plans WHERE plan_id = $1)`, planID).Scan(&exists); err != nil {
+        return nil, err
+    }
+    if !exists { return nil, sql.ErrNoRows }
+    return r.queryBundles(ctx, bundleSelect+` WHERE l.plan_id = $1 ORDER BY b.task_id ASC, b.bundle_no ASC`, planID)
+}
+
+func (r *SqlBundlesRepository) queryBundles(ctx context.Context, q string, arg any) ([]models.Bundle, error) {
+    rows, err := r.db.QueryContext(ctx, q, arg)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    res := []models.Bundle{}
+    for rows.Next() {
+        b, err := scanBundle(rows)
+        if err != nil { return nil, err }
+        res = append(res, *b)
+    }
+    return res, rows.Err()
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "cutrix-backend/internal/models"
+)
+
+type SqlCustomersRepository struct{ db *sql.DB }
+
+var _ CustomersRepository = (*SqlCustomersRepository)(nil)
+
+func NewSqlCustomersRepository(db *sql.DB) *SqlCustomersRepository { return &SqlCustomersRepository{db: db} }
+
+// customerSelect includes the number of orders referencing the customer.
+const customerSelect = `
+    SELECT c.customer_id, c.customer_code, c.customer_name, c.contact_name, c.phone, c.email, c.address,
+        c.over_cut_tolerance::float8, c.under_cut_tolerance::float8, c.note, c.created_at, c.updated_at,
+        (SELECT COUNT(*) FROM production.orders o WHERE o.customer_id = c.customer_id)
+    FROM production.customers c`
+
+func scanCustomer(s scanner) (*models.Customer, error) {
+    var c models.Customer
+    if err := s.Scan(&c.CustomerID, &c.CustomerCode, &c.CustomerName, &c.ContactName, &c.Phone, &c.Email, &c.Address,
+        &c.OverCutTolerance, &c.UnderCutTolerance, &c.Note, &c.CreatedAt, &c.UpdatedAt, &c.Orders); err != nil {
+        return nil, err
+    }
+    return &c, nil
+}
+
+func (r *SqlCustomersRepository) Create(ctx context.Context, customer *models.Customer) (int, error) {
+    const q = `
+        INSERT INTO production.customers (customer_code, customer_name, contact_name, phone, email, address,
+                                          over_cut_tolerance, under_cut_tolerance, note)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING customer_id, created_at, updated_at`
+    err := r.db.QueryRowContext(ctx, q, customer.CustomerCode, customer.CustomerName, customer.ContactName, customer.Phone,
+        customer.Email, customer.Address, customer.OverCutTolerance, customer.UnderCutTolerance, customer.Note).
+        Scan(&customer.CustomerID, &customer.CreatedAt, &customer.UpdatedAt)
+    return customer.CustomerID, err
+}
+
+func (r *SqlCustomersRepository) Delete(ctx context.Context, id int) error {
+    // Pre-check: orders keep a reference to the customer
+    var used bool
+    if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM production.orders WHERE customer_id = $1)`, id).Scan(&used); err != nil {
+        return err
+    }
+    if used {
+        return fmt.Errorf("%w: 客户已被订单引用，不允许删除 (customer_id=%d)", ErrConflict, id)
+    }
+    res, err := r.db.ExecContext(ctx, `DELETE FROM production.customers WHERE customer_id = $1`, id)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
+func (r *SqlCustomersRepository) Update(ctx context.Context, customer *models.Customer) error {
+    const q = `
+        UPDATE production.customers
+        SET customer_code = $1, customer_name = $2, contact_name = $3, phone = $4, email = $5, address = $6,
+            over_cut_tolerance = $7, under_cut_tolerance = $8, note = $9
+        WHERE customer_id = $10`
+    res, err := r.db.ExecContext(ctx, q, customer.CustomerCode, customer.CustomerName, customer.ContactName, customer.Phone,
+        customer.Email, customer.Address, customer.OverCutTolerance, customer.UnderCutTolerance, customer.Note, customer.CustomerID)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
+func (r *SqlCustomersRepository) GetByID(ctx context.Context, id int) (*models.Customer, error) {
+    return scanCustomer(r.db.QueryRowContext(ctx, customerSelect+` WHERE c.customer_id = $1`, id))
+}
+
+func (r *SqlCustomersRepository) GetByCode(ctx context.Context, code string) (*models.Customer, error) {
+    return scanCustomer(r.db.QueryRowContext(ctx, customerSelect+` WHERE c.customer_code = $1`, code))
+}
+
+func (r *SqlCustomersRepository) GetByName(ctx context.Context, name string) (*models.Customer, error) {
+    return scanCustomer(r.db.QueryRowContext(ctx, customerSelect+` WHERE lower(c.customer_name) = lower($1)`, name))
+}
+
+func (r *SqlCustomersRepository) List(ctx context.Context, query string) ([]models.Customer, error) {
+    rows, err := r.db.QueryContext(ctx, customerSelect+`
+        WHERE ($1 = '' OR c.customer_code ILIKE '%' || $1 || '%' OR c.customer_name ILIKE '%' || $1 || '%')
+        ORDER BY c.customer_code ASC`, query)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    res := []models.Customer{}
+    for rows.Next() {
+        c, err := scanCustomer(rows)
+        if err != nil { return nil, err }
+        res = append(res, *c)
+    }
+    return res, rows.Err()
+}
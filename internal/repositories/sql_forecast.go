@@ -0,0 +1,46 @@
+package repositories
+
+import (
+    "context"
+    "database/sql"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+type SqlForecastRepository struct{ db *sql.DB }
+
+var _ ForecastRepository = (*SqlForecastRepository)(nil)
+
+func NewSqlForecastRepository(db *sql.DB) *SqlForecastRepository { return &SqlForecastRepository{db: db} }
+
+func (r *SqlForecastRepository) ListOrderBacklog(ctx context.Context) ([]models.OrderBacklog, error) {
+    const q = `
+        SELECT o.order_id, o.order_number, o.style_number, o.customer_id, o.order_finish_date,
+               SUM(GREATEST(t.planned_layers - t.completed_layers, 0))::int
+        FROM production.orders o
+        JOIN production.plans p ON p.order_id = o.order_id
+        JOIN production.cutting_layouts cl ON cl.plan_id = p.plan_id
+        JOIN production.tasks t ON t.layout_id = cl.layout_id
+        WHERE t.status <> 'completed' AND o.status NOT IN ('closed', 'cancelled')
+        GROUP BY o.order_id
+        HAVING SUM(GREATEST(t.planned_layers - t.completed_layers, 0)) > 0
+        ORDER BY o.order_finish_date NULLS LAST, o.order_id`
+    rows, err := r.db.QueryContext(ctx, q)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var res []models.OrderBacklog
+    for rows.Next() {
+        var b models.OrderBacklog
+        if err := rows.Scan(&b.OrderID, &b.OrderNumber, &b.StyleNumber, &b.CustomerID, &b.OrderFinishDate, &b.RemainingLayers); err != nil { return nil, err }
+        res = append(res, b)
+    }
+    return res, rows.Err()
+}
+
+func (r *SqlForecastRepository) LayersSince(ctx context.Context, since time.Time) (int, error) {
+    const q = `SELECT COALESCE(SUM(layers_completed), 0)::int FROM production.logs WHERE NOT voided AND log_time >= $1`
+    var n int
+    err := r.db.QueryRowContext(ctx, q, since).Scan(&n)
+    return n, err
+}
@@ -34,6 +34,21 @@ func (r *SqlLayoutsRepository) planStatusByPlan(ctx context.Context, planID int)
     return status, nil
 }
 
+// layoutColumns is the select list matching scanLayout.
+const layoutColumns = `layout_id, plan_id, layout_name, component, note,
+    marker_length::float8, fabric_width::float8, marker_efficiency::float8, end_loss_allowance::float8, bundle_size`
+
+func scanLayout(row scanner) (*models.CuttingLayout, error) {
+    var l models.CuttingLayout
+    var note sql.NullString
+    if err := row.Scan(&l.LayoutID, &l.PlanID, &l.LayoutName, &l.Component, &note,
+        &l.MarkerLength, &l.FabricWidth, &l.MarkerEfficiency, &l.EndLossAllowance, &l.BundleSize); err != nil {
+        return nil, err
+    }
+    if note.Valid { v := note.String; l.Note = &v }
+    return &l, nil
+}
+
 func (r *SqlLayoutsRepository) Create(ctx context.Context, layout *models.CuttingLayout) (int, error) {
     // Pre-check: only allow creating layouts when plan is pending
     status, err := r.planStatusByPlan(ctx, layout.PlanID)
@@ -43,13 +58,15 @@ func (r *SqlLayoutsRepository) Create(ctx context.Context, layout *models.Cuttin
     }
 
     const q = `
-        INSERT INTO production.cutting_layouts (plan_id, layout_name, note)
-        VALUES ($1, $2, $3)
-        RETURNING layout_id`
+        INSERT INTO production.cutting_layouts (plan_id, layout_name, component, note,
+            marker_length, fabric_width, marker_efficiency, end_loss_allowance, bundle_size)
+        VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9)
+        RETURNING layout_id, component`
     var id int
     var note any
     if layout.Note != nil { note = *layout.Note } else { note = nil }
-    err = r.db.QueryRowContext(ctx, q, layout.PlanID, layout.LayoutName, note).Scan(&id)
+    err = r.db.QueryRowContext(ctx, q, layout.PlanID, layout.LayoutName, layout.Component, note,
+        layout.MarkerLength, layout.FabricWidth, layout.MarkerEfficiency, layout.EndLossAllowance, layout.BundleSize).Scan(&id, &layout.Component)
     if err == nil { layout.LayoutID = id }
     return id, err
 }
@@ -79,6 +96,17 @@ func (r *SqlLayoutsRepository) UpdateName(ctx context.Context, id int, name stri
     return err
 }
 
+func (r *SqlLayoutsRepository) UpdateComponent(ctx context.Context, id int, component string) error {
+    // Pre-check: the component decides which rollup the layout counts towards, only editable while plan is pending
+    status, err := r.planStatusByLayout(ctx, id)
+    if err != nil { return err }
+    if status != "pending" {
+        return fmt.Errorf("计划发布后不允许更新布局部件 (layout_id=%d, status=%s)", id, status)
+    }
+    _, err = r.db.ExecContext(ctx, `UPDATE production.cutting_layouts SET component = NULLIF($1, '') WHERE layout_id = $2`, component, id)
+    return err
+}
+
 func (r *SqlLayoutsRepository) UpdateNote(ctx context.Context, id int, note *string) error {
     // Pre-check: only allow updating layout fields when plan is pending
     status, err := r.planStatusByLayout(ctx, id)
@@ -90,44 +118,63 @@ func (r *SqlLayoutsRepository) UpdateNote(ctx context.Context, id int, note *str
     return err
 }
 
+func (r *SqlLayoutsRepository) UpdateMarker(ctx context.Context, id int, spec models.MarkerSpec) error {
+    // Pre-check: marker spec is part of the layout structure, only editable while plan is pending
+    status, err := r.planStatusByLayout(ctx, id)
+    if err != nil { return err }
+    if status != "pending" {
+        return fmt.Errorf("计划发布后不允许更新唛架参数 (layout_id=%d, status=%s)", id, status)
+    }
+    const q = `
+        UPDATE production.cutting_layouts
+        SET marker_length = $1, fabric_width = $2, marker_efficiency = $3, end_loss_allowance = $4
+        WHERE layout_id = $5`
+    _, err = r.db.ExecContext(ctx, q, spec.MarkerLength, spec.FabricWidth, spec.MarkerEfficiency, spec.EndLossAllowance, id)
+    return err
+}
+
+func (r *SqlLayoutsRepository) UpdateBundleSize(ctx context.Context, id int, size *int) error {
+    // Pre-check: bundle size belongs to the layout structure, only editable while plan is pending
+    status, err := r.planStatusByLayout(ctx, id)
+    if err != nil { return err }
+    if status != "pending" {
+        return fmt.Errorf("计划发布后不允许更新扎包层数 (layout_id=%d, status=%s)", id, status)
+    }
+    _, err = r.db.ExecContext(ctx, `UPDATE production.cutting_layouts SET bundle_size = $1 WHERE layout_id = $2`, size, id)
+    return err
+}
+
 func (r *SqlLayoutsRepository) GetByID(ctx context.Context, id int) (*models.CuttingLayout, error) {
-    const q = `SELECT layout_id, plan_id, layout_name, note FROM production.cutting_layouts WHERE layout_id = $1`
-    row := r.db.QueryRowContext(ctx, q, id)
-    var l models.CuttingLayout
-    var note sql.NullString
-    if err := row.Scan(&l.LayoutID, &l.PlanID, &l.LayoutName, &note); err != nil { return nil, err }
-    if note.Valid { v := note.String; l.Note = &v }
-    return &l, nil
+    q := `SELECT ` + layoutColumns + ` FROM production.cutting_layouts WHERE layout_id = $1`
+    l, err := scanLayout(r.db.QueryRowContext(ctx, q, id))
+    if err != nil { return nil, err }
+    return l, nil
 }
 
 func (r *SqlLayoutsRepository) List(ctx context.Context) ([]models.CuttingLayout, error) {
-    const q = `SELECT layout_id, plan_id, layout_name, note FROM production.cutting_layouts ORDER BY layout_id ASC`
+    q := `SELECT ` + layoutColumns + ` FROM production.cutting_layouts ORDER BY layout_id ASC`
     rows, err := r.db.QueryContext(ctx, q)
     if err != nil { return nil, err }
     defer rows.Close()
     var res []models.CuttingLayout
     for rows.Next() {
-        var l models.CuttingLayout
-        var note sql.NullString
-        if err := rows.Scan(&l.LayoutID, &l.PlanID, &l.LayoutName, &note); err != nil { return nil, err }
-        if note.Valid { v := note.String; l.Note = &v }
-        res = append(res, l)
+        l, err := scanLayout(rows)
+        if err != nil { return nil, err }
+        res = append(res, *l)
     }
     return res, rows.Err()
 }
 
 func (r *SqlLayoutsRepository) ListByPlan(ctx context.Context, planID int) ([]models.CuttingLayout, error) {
-    const q = `SELECT layout_id, plan_id, layout_name, note FROM production.cutting_layouts WHERE plan_id = $1 ORDER BY layout_id ASC`
+    q := `SELECT ` + layoutColumns + ` FROM production.cutting_layouts WHERE plan_id = $1 ORDER BY layout_id ASC`
     rows, err := r.db.QueryContext(ctx, q, planID)
     if err != nil { return nil, err }
     defer rows.Close()
     var res []models.CuttingLayout
     for rows.Next() {
-        var l models.CuttingLayout
-        var note sql.NullString
-        if err := rows.Scan(&l.LayoutID, &l.PlanID, &l.LayoutName, &note); err != nil { return nil, err }
-        if note.Valid { v := note.String; l.Note = &v }
-        res = append(res, l)
+        l, err := scanLayout(rows)
+        if err != nil { return nil, err }
+        res = append(res, *l)
     }
     return res, rows.Err()
 }
@@ -168,9 +215,16 @@ func (r *SqlLayoutsRepository) SetRatios(ctx context.Context, layoutID int, rati
     return tx.Commit()
 }
 
-// GetRatios retrieves all size ratios for a layout.
+// ratioSizeOrder sorts ratios by the size order of the order's style, then by size for sizes the style does not list.
+const ratioSizeOrder = `production.style_size_rank((
+        SELECT o.style_number FROM production.cutting_layouts cl
+        JOIN production.plans p ON p.plan_id = cl.plan_id
+        JOIN production.orders o ON o.order_id = p.order_id
+        WHERE cl.layout_id = r.layout_id), r.size) NULLS LAST, r.size`
+
+// GetRatios retrieves all size ratios for a layout in the style's size order.
 func (r *SqlLayoutsRepository) GetRatios(ctx context.Context, layoutID int) ([]models.LayoutSizeRatio, error) {
-    const q = `SELECT ratio_id, layout_id, size, ratio FROM production.layout_size_ratios WHERE layout_id = $1 ORDER BY size`
+    const q = `SELECT r.ratio_id, r.layout_id, r.size, r.ratio FROM production.layout_size_ratios r WHERE r.layout_id = $1 ORDER BY ` + ratioSizeOrder
     rows, err := r.db.QueryContext(ctx, q, layoutID)
     if err != nil { return nil, err }
     defer rows.Close()
@@ -185,7 +239,7 @@ func (r *SqlLayoutsRepository) GetRatios(ctx context.Context, layoutID int) ([]m
     return res, rows.Err()
 }
 
-// GetRatiosBatch retrieves size ratios for multiple layouts in a single query.
+// GetRatiosBatch retrieves size ratios for multiple layouts in a single query, each in the style's size order.
 func (r *SqlLayoutsRepository) GetRatiosBatch(ctx context.Context, layoutIDs []int) (map[int][]models.LayoutSizeRatio, error) {
     if len(layoutIDs) == 0 {
         return make(map[int][]models.LayoutSizeRatio), nil
@@ -203,7 +257,7 @@ func (r *SqlLayoutsRepository) GetRatiosBatch(ctx context.Context, layoutIDs []i
     }
     
     q := fmt.Sprintf(
-        `SELECT ratio_id, layout_id, size, ratio FROM production.layout_size_ratios WHERE layout_id IN (%s) ORDER BY layout_id, size`,
+        `SELECT r.ratio_id, r.layout_id, r.size, r.ratio FROM production.layout_size_ratios r WHERE r.layout_id IN (%s) ORDER BY r.layout_id, `+ratioSizeOrder,
         placeholderStr,
     )
     
@@ -222,4 +276,40 @@ func (r *SqlLayoutsRepository) GetRatiosBatch(ctx context.Context, layoutIDs []i
         result[ratio.LayoutID] = append(result[ratio.LayoutID], ratio)
     }
     return result, rows.Err()
+}
+
+func (r *SqlLayoutsRepository) ListFabricByLayout(ctx context.Context, layoutID int) ([]models.FabricRequirementLine, error) {
+    if err := r.db.QueryRowContext(ctx, `SELECT layout_id FROM production.cutting_layouts WHERE layout_id = $1`, layoutID).Scan(&layoutID); err != nil {
+        return nil, err
+    }
+    return queryFabricLines(ctx, r.db, `l.layout_id = $1`, layoutID)
+}
+
+// queryFabricLines loads per-task fabric lines under the given filter on layouts (l), plans (p) or tasks (t).
+// Lengths use planned/completed layers × (marker_length + end_loss_allowance); NULL when the marker length is unset.
+func queryFabricLines(ctx context.Context, q queryer, where string, arg any) ([]models.FabricRequirementLine, error) {
+    sel := `
+        SELECT t.task_id, l.layout_id, l.layout_name, l.component, l.plan_id, t.color, t.planned_layers, t.completed_layers,
+               l.marker_length::float8, l.fabric_width::float8, l.marker_efficiency::float8, l.end_loss_allowance::float8,
+               (t.planned_layers * (l.marker_length + COALESCE(l.end_loss_allowance, 0)))::float8,
+               (t.completed_layers * (l.marker_length + COALESCE(l.end_loss_allowance, 0)))::float8
+        FROM production.tasks t
+        JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+        JOIN production.plans p ON p.plan_id = l.plan_id
+        WHERE ` + where + `
+        ORDER BY l.plan_id, l.layout_id, t.task_id`
+    rows, err := q.QueryContext(ctx, sel, arg)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var res []models.FabricRequirementLine
+    for rows.Next() {
+        var f models.FabricRequirementLine
+        if err := rows.Scan(&f.TaskID, &f.LayoutID, &f.LayoutName, &f.Component, &f.PlanID, &f.Color, &f.PlannedLayers, &f.CompletedLayers,
+            &f.MarkerLength, &f.FabricWidth, &f.MarkerEfficiency, &f.EndLossAllowance,
+            &f.PlannedLength, &f.ConsumedLength); err != nil {
+            return nil, err
+        }
+        res = append(res, f)
+    }
+    return res, rows.Err()
 }
\ No newline at end of file
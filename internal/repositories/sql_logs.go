@@ -4,6 +4,7 @@ import (
     "context"
     "database/sql"
     "fmt"
+    "math"
     "strings"
 
     "cutrix-backend/internal/models"
@@ -32,6 +33,11 @@ func scanLog(s scanner) (*models.ProductionLog, error) {
         &vAt,
         &vBy,
         &vByName,
+        &l.DyeLot,
+        &l.AllowLotMix,
+        &l.TableID,
+        &l.ShiftID,
+        &l.ShiftDate,
     ); err != nil { return nil, err }
     if wID.Valid { tmp := int(wID.Int64); l.WorkerID = &tmp }
     if wName.Valid { tmp := wName.String; l.WorkerName = &tmp }
@@ -46,26 +52,171 @@ func scanLog(s scanner) (*models.ProductionLog, error) {
 func NewSqlLogsRepository(db *sql.DB) *SqlLogsRepository { return &SqlLogsRepository{db: db} }
 
 func (r *SqlLogsRepository) Create(log *models.ProductionLog) error {
+    ctx := context.Background()
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil { return err }
+    defer tx.Rollback()
+    if err := checkLotMix(ctx, tx, log); err != nil { return err }
+    if err := insertLog(ctx, tx, log); err != nil { return err }
+    return tx.Commit()
+}
+
+func insertLog(ctx context.Context, tx *sql.Tx, log *models.ProductionLog) error {
     const q = `
-        INSERT INTO production.logs (task_id, worker_id, worker_name, layers_completed, note)
-        VALUES ($1, $2, $3, $4, $5)
-        RETURNING log_id, log_time
+        INSERT INTO production.logs (task_id, worker_id, worker_name, layers_completed, note, dye_lot, allow_lot_mix, table_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING log_id, log_time, table_id, shift_id, to_char(shift_date, 'YYYY-MM-DD')
     `
-    ctx := context.Background()
-    return r.db.QueryRowContext(ctx, q,
+    return tx.QueryRowContext(ctx, q,
         log.TaskID,
         log.WorkerID,
         log.WorkerName,
         log.LayersCompleted,
         log.Note,
-    ).Scan(&log.LogID, &log.LogTime)
+        log.DyeLot,
+        log.AllowLotMix,
+        log.TableID,
+    ).Scan(&log.LogID, &log.LogTime, &log.TableID, &log.ShiftID, &log.ShiftDate)
+}
+
+// checkLotMix pre-checks that the log does not bring a second dye lot into the task.
+// The task row is locked so concurrent submissions are serialized (the DB trigger is the backstop).
+// With AllowLotMix the log passes and a warning is appended instead of *LotMixError.
+func checkLotMix(ctx context.Context, tx *sql.Tx, log *models.ProductionLog) error {
+    if log.DyeLot == nil { return nil }
+    if _, err := tx.ExecContext(ctx, `SELECT 1 FROM production.tasks WHERE task_id = $1 FOR UPDATE`, log.TaskID); err != nil {
+        return err
+    }
+    rows, err := tx.QueryContext(ctx, `
+        SELECT dye_lot FROM production.task_lot_layers
+        WHERE task_id = $1 AND dye_lot <> $2 AND completed_layers > 0
+        ORDER BY dye_lot ASC`, log.TaskID, *log.DyeLot)
+    if err != nil { return err }
+    defer rows.Close()
+    var lots []string
+    for rows.Next() {
+        var lot string
+        if err := rows.Scan(&lot); err != nil { return err }
+        lots = append(lots, lot)
+    }
+    if err := rows.Err(); err != nil { return err }
+    if len(lots) == 0 { return nil }
+    mixErr := &LotMixError{TaskID: log.TaskID, DyeLot: *log.DyeLot, ExistingLots: lots}
+    if !log.AllowLotMix { return mixErr }
+    log.Warnings = append(log.Warnings, mixErr.Error())
+    return nil
+}
+
+// CreateWithRolls inserts the log and its roll usage in one transaction.
+// Rolls are consumed in the given order: each covers as many whole layers as its remaining length allows,
+// the per-layer length being the layout's marker_length + end_loss_allowance. Triggers decrement the rolls.
+// The log's dye lot defaults to the rolls' lot; rolls of different lots cannot share one log.
+// Errors: unknown roll wraps sql.ErrNoRows; mixed or mismatched dye lots wrap ErrValidation; a layout without
+// marker_length or rolls too short for the layers wrap ErrConflict.
+func (r *SqlLogsRepository) CreateWithRolls(log *models.ProductionLog) error {
+    ctx := context.Background()
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil { return err }
+    defer tx.Rollback()
+
+    var marker sql.NullFloat64
+    var allowance float64
+    const qLayer = `
+        SELECT l.marker_length::float8, COALESCE(l.end_loss_allowance, 0)::float8
+        FROM production.tasks t
+        JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+        WHERE t.task_id = $1`
+    if err := tx.QueryRowContext(ctx, qLayer, log.TaskID).Scan(&marker, &allowance); err != nil { return err }
+    if !marker.Valid {
+        return fmt.Errorf("%w: 布局未设置唛架长度，无法扣减布卷 (task_id=%d)", ErrConflict, log.TaskID)
+    }
+    perLayer := toMillimeters(marker.Float64 + allowance)
+
+    remaining := make([]int64, len(log.RollIDs))
+    codes := make([]string, len(log.RollIDs))
+    rollLot := "" // 首个带缸号布卷的缸号
+    for i, id := range log.RollIDs {
+        var length float64
+        var lot sql.NullString
+        if err := tx.QueryRowContext(ctx,
+            `SELECT roll_code, remaining_length::float8, dye_lot FROM production.fabric_rolls WHERE roll_id = $1 FOR UPDATE`, id,
+        ).Scan(&codes[i], &length, &lot); err != nil {
+            if err == sql.ErrNoRows { return fmt.Errorf("%w: 布卷不存在 (roll_id=%d)", sql.ErrNoRows, id) }
+            return err
+        }
+        remaining[i] = toMillimeters(length)
+        if !lot.Valid { continue }
+        // 同一次拉布不可混用缸号：所选布卷缸号须一致，且与日志填写的缸号一致
+        if rollLot != "" && lot.String != rollLot {
+            return fmt.Errorf("%w: 所选布卷缸号不一致（%s / %s），请按缸号分开提交日志", ErrValidation, rollLot, lot.String)
+        }
+        if log.DyeLot != nil && *log.DyeLot != lot.String {
+            return fmt.Errorf("%w: 日志缸号 %s 与布卷 %s 缸号 %s 不一致", ErrValidation, *log.DyeLot, codes[i], lot.String)
+        }
+        rollLot = lot.String
+    }
+    if log.DyeLot == nil && rollLot != "" { log.DyeLot = &rollLot }
+    layers, err := allocateRollLayers(log.LayersCompleted, perLayer, remaining)
+    if err != nil { return err }
+
+    if err := checkLotMix(ctx, tx, log); err != nil { return err }
+    if err := insertLog(ctx, tx, log); err != nil { return err }
+    log.Rolls = nil
+    for i, id := range log.RollIDs {
+        if layers[i] == 0 { continue }
+        used := float64(int64(layers[i])*perLayer) / 1000
+        if _, err := tx.ExecContext(ctx,
+            `INSERT INTO production.log_rolls (log_id, roll_id, layers, length_used) VALUES ($1, $2, $3, $4)`,
+            log.LogID, id, layers[i], used,
+        ); err != nil {
+            return err
+        }
+        log.Rolls = append(log.Rolls, models.LogRollUsage{
+            LogID: log.LogID, RollID: id, RollCode: codes[i], TaskID: log.TaskID, Layers: layers[i], LengthUsed: used,
+        })
+    }
+    return tx.Commit()
+}
+
+// allocateRollLayers splits layers over rolls in order; every roll takes as many whole layers as fit.
+// Lengths are in millimeters to avoid float rounding. Rolls left unused get 0 layers.
+func allocateRollLayers(layers int, perLayer int64, remaining []int64) ([]int, error) {
+    out := make([]int, len(remaining))
+    left := layers
+    for i, rem := range remaining {
+        if left == 0 { break }
+        fit := 0
+        if perLayer > 0 { fit = int(rem / perLayer) } else { fit = left }
+        if fit > left { fit = left }
+        out[i] = fit
+        left -= fit
+    }
+    if left > 0 {
+        return nil, fmt.Errorf("%w: 所选布卷剩余长度不足：还差 %d 层（每层 %.3f 米）", ErrConflict, left, float64(perLayer)/1000)
+    }
+    return out, nil
+}
+
+func toMillimeters(meters float64) int64 { return int64(math.Round(meters * 1000)) }
+
+// ListRollsByLog returns the roll usage recorded for a log.
+func (r *SqlLogsRepository) ListRollsByLog(logID int) ([]models.LogRollUsage, error) {
+    const q = `
+        SELECT lr.log_id, lr.roll_id, fr.roll_code, l.task_id, lr.layers, lr.length_used::float8, l.voided
+        FROM production.log_rolls lr
+        JOIN production.fabric_rolls fr ON fr.roll_id = lr.roll_id
+        JOIN production.logs l ON l.log_id = lr.log_id
+        WHERE lr.log_id = $1
+        ORDER BY lr.roll_id ASC`
+    return queryRollUsage(context.Background(), r.db, q, logID)
 }
 
 func (r *SqlLogsRepository) GetByID(logID int) (*models.ProductionLog, error) {
     const q = `
         SELECT 
             l.log_id, l.task_id, l.worker_id, l.worker_name, l.layers_completed, l.log_time, l.note,
-            l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name
+            l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name, l.dye_lot, l.allow_lot_mix, l.table_id,
+            l.shift_id, to_char(l.shift_date, 'YYYY-MM-DD')
         FROM production.logs l
         WHERE l.log_id = $1
     `
@@ -119,7 +270,8 @@ func (r *SqlLogsRepository) ListByTask(taskID int) ([]models.ProductionLog, erro
     const q = `
         SELECT 
             l.log_id, l.task_id, l.worker_id, l.worker_name, l.layers_completed, l.log_time, l.note,
-            l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name
+            l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name, l.dye_lot, l.allow_lot_mix, l.table_id,
+            l.shift_id, to_char(l.shift_date, 'YYYY-MM-DD')
         FROM production.logs l
         WHERE l.task_id = $1
         ORDER BY l.log_time ASC, l.log_id ASC
@@ -141,7 +293,8 @@ func (r *SqlLogsRepository) ListByLayout(layoutID int) ([]models.ProductionLog,
     const q = `
         SELECT 
             l.log_id, l.task_id, l.worker_id, l.worker_name, l.layers_completed, l.log_time, l.note,
-            l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name
+            l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name, l.dye_lot, l.allow_lot_mix, l.table_id,
+            l.shift_id, to_char(l.shift_date, 'YYYY-MM-DD')
         FROM production.logs l
         JOIN production.tasks t ON t.task_id = l.task_id
         WHERE t.layout_id = $1
@@ -164,7 +317,8 @@ func (r *SqlLogsRepository) ListByPlan(planID int) ([]models.ProductionLog, erro
     const q = `
         SELECT 
             l.log_id, l.task_id, l.worker_id, l.worker_name, l.layers_completed, l.log_time, l.note,
-            l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name
+            l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name, l.dye_lot, l.allow_lot_mix, l.table_id,
+            l.shift_id, to_char(l.shift_date, 'YYYY-MM-DD')
         FROM production.logs l
         JOIN production.tasks t ON t.task_id = l.task_id
         JOIN production.cutting_layouts lay ON lay.layout_id = t.layout_id
@@ -193,7 +347,8 @@ func (r *SqlLogsRepository) ListByWorker(workerID *int, workerName *string) ([]m
         q = `
             SELECT 
                 l.log_id, l.task_id, l.worker_id, l.worker_name, l.layers_completed, l.log_time, l.note,
-                l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name
+                l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name, l.dye_lot, l.allow_lot_mix, l.table_id,
+                l.shift_id, to_char(l.shift_date, 'YYYY-MM-DD')
             FROM production.logs l
             WHERE (l.worker_id = $1 OR l.worker_name = $2)
             ORDER BY l.log_time DESC, l.log_id DESC
@@ -204,7 +359,8 @@ func (r *SqlLogsRepository) ListByWorker(workerID *int, workerName *string) ([]m
         q = `
             SELECT 
                 l.log_id, l.task_id, l.worker_id, l.worker_name, l.layers_completed, l.log_time, l.note,
-                l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name
+                l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name, l.dye_lot, l.allow_lot_mix, l.table_id,
+                l.shift_id, to_char(l.shift_date, 'YYYY-MM-DD')
             FROM production.logs l
             WHERE l.worker_id = $1
             ORDER BY l.log_time DESC, l.log_id DESC
@@ -215,7 +371,8 @@ func (r *SqlLogsRepository) ListByWorker(workerID *int, workerName *string) ([]m
         q = `
             SELECT 
                 l.log_id, l.task_id, l.worker_id, l.worker_name, l.layers_completed, l.log_time, l.note,
-                l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name
+                l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name, l.dye_lot, l.allow_lot_mix, l.table_id,
+                l.shift_id, to_char(l.shift_date, 'YYYY-MM-DD')
             FROM production.logs l
             WHERE l.worker_name = $1
             ORDER BY l.log_time DESC, l.log_id DESC
@@ -260,7 +417,8 @@ func (r *SqlLogsRepository) ListRecentVoided(limit int) ([]models.ProductionLog,
     const q = `
         SELECT 
             l.log_id, l.task_id, l.worker_id, l.worker_name, l.layers_completed, l.log_time, l.note,
-            l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name
+            l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name, l.dye_lot, l.allow_lot_mix, l.table_id,
+            l.shift_id, to_char(l.shift_date, 'YYYY-MM-DD')
         FROM production.logs l
         WHERE l.voided = TRUE
         ORDER BY l.voided_at DESC
@@ -330,7 +488,8 @@ func (r *SqlLogsRepository) ListAll(taskID *int, workerID *int, voided *bool, li
     dataQuery := fmt.Sprintf(`
         SELECT 
             l.log_id, l.task_id, l.worker_id, l.worker_name, l.layers_completed, l.log_time, l.note,
-            l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name
+            l.voided, l.void_reason, l.voided_at, l.voided_by, l.voided_by_name, l.dye_lot, l.allow_lot_mix, l.table_id,
+            l.shift_id, to_char(l.shift_date, 'YYYY-MM-DD')
         FROM production.logs l
         %s
         ORDER BY l.log_time DESC, l.log_id DESC
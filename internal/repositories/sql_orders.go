@@ -3,7 +3,11 @@ package repositories
 import (
     "context"
     "database/sql"
+    "encoding/json"
     "errors"
+    "fmt"
+    "math"
+    "strings"
     "time"
 
     "cutrix-backend/internal/models"
@@ -32,10 +36,21 @@ func (r *SqlOrdersRepository) CreateWithItems(order *models.ProductionOrder, ite
     tx, err := r.db.BeginTx(ctx, nil)
     if err != nil { return err }
 
+    // The customer is the given customer_id, else the customer whose name matches customer_name ignoring case;
+    // its name and default tolerances fill whatever the order leaves empty.
     const insertOrder = `
-        INSERT INTO production.orders (order_number, style_number, customer_name, order_start_date, order_finish_date, note)
-        VALUES ($1, $2, $3, $4, $5, $6)
-        RETURNING order_id, created_at, updated_at
+        WITH c AS (
+            SELECT customer_id, customer_name, over_cut_tolerance, under_cut_tolerance
+            FROM production.customers
+            WHERE customer_id = $9::int
+               OR ($9::int IS NULL AND lower(customer_name) = lower(btrim($3::text)))
+        )
+        INSERT INTO production.orders (order_number, style_number, customer_name, customer_id, order_start_date, order_finish_date, note,
+                                       over_cut_tolerance, under_cut_tolerance)
+        SELECT $1, $2, COALESCE($3::text, (SELECT customer_name FROM c)), COALESCE($9::int, (SELECT customer_id FROM c)), $4, $5, $6,
+               COALESCE($7::numeric, (SELECT over_cut_tolerance FROM c)), COALESCE($8::numeric, (SELECT under_cut_tolerance FROM c))
+        RETURNING order_id, customer_name, customer_id, over_cut_tolerance::float8, under_cut_tolerance::float8,
+                  status, revision, created_at, updated_at
     `
     if err := tx.QueryRowContext(ctx, insertOrder,
         order.OrderNumber,
@@ -44,7 +59,13 @@ func (r *SqlOrdersRepository) CreateWithItems(order *models.ProductionOrder, ite
         order.OrderStartDate,
         order.OrderFinishDate,
         order.Note,
-    ).Scan(&order.OrderID, &order.CreatedAt, &order.UpdatedAt); err != nil {
+        order.OverCutTolerance,
+        order.UnderCutTolerance,
+        order.CustomerID,
+    ).Scan(
+        &order.OrderID, &order.CustomerName, &order.CustomerID, &order.OverCutTolerance, &order.UnderCutTolerance,
+        &order.Status, &order.Revision, &order.CreatedAt, &order.UpdatedAt,
+    ); err != nil {
         tx.Rollback()
         return err
     }
@@ -62,6 +83,10 @@ func (r *SqlOrdersRepository) CreateWithItems(order *models.ProductionOrder, ite
             return err
         }
     }
+    if err := insertOrderRevision(ctx, tx, order.OrderID, order.Revision, nil, nil, nil, nil); err != nil {
+        tx.Rollback()
+        return err
+    }
 
     return tx.Commit()
 }
@@ -82,32 +107,76 @@ func (r *SqlOrdersRepository) UpdateFinishDate(id int, finishDate *time.Time) er
     return err
 }
 
+// UpdateTolerance updates the over/under-cut tolerance percentages (nil disables that side).
+func (r *SqlOrdersRepository) UpdateTolerance(id int, over, under *float64) error {
+    const q = `UPDATE production.orders SET over_cut_tolerance = $1, under_cut_tolerance = $2 WHERE order_id = $3`
+    ctx := context.Background()
+    res, err := r.db.ExecContext(ctx, q, over, under, id)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
+// UpdateStatus moves an order to the given status under a row lock; DB triggers enforce the allowed transitions.
+func (r *SqlOrdersRepository) UpdateStatus(ctx context.Context, id int, status string) (*models.ProductionOrder, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil { return nil, err }
+    defer tx.Rollback()
+
+    var current string
+    if err := tx.QueryRowContext(ctx, `SELECT status FROM production.orders WHERE order_id = $1 FOR UPDATE`, id).Scan(&current); err != nil {
+        return nil, err
+    }
+    if current != status {
+        if _, err := tx.ExecContext(ctx, `UPDATE production.orders SET status = $1 WHERE order_id = $2`, status, id); err != nil {
+            return nil, err
+        }
+    }
+    if err := tx.Commit(); err != nil { return nil, err }
+    return r.GetByID(id)
+}
+
+// UpdateCustomer points the order at another customer record (nil unlinks it); customer_name keeps the entered text.
+func (r *SqlOrdersRepository) UpdateCustomer(ctx context.Context, id int, customerID *int) error {
+    res, err := r.db.ExecContext(ctx, `UPDATE production.orders SET customer_id = $1 WHERE order_id = $2`, customerID, id)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
 // GetByID loads an order by ID.
 func (r *SqlOrdersRepository) GetByID(id int) (*models.ProductionOrder, error) {
     const q = `
-        SELECT order_id, order_number, style_number, customer_name, order_start_date, order_finish_date, note, created_at, updated_at
+        SELECT order_id, order_number, style_number, customer_name, customer_id, order_start_date, order_finish_date, note,
+               over_cut_tolerance::float8, under_cut_tolerance::float8, status, status_changed_at, revision, created_at, updated_at
         FROM production.orders WHERE order_id = $1
     `
     ctx := context.Background()
     var o models.ProductionOrder
     err := r.db.QueryRowContext(ctx, q, id).
         Scan(
-            &o.OrderID, &o.OrderNumber, &o.StyleNumber, &o.CustomerName,
+            &o.OrderID, &o.OrderNumber, &o.StyleNumber, &o.CustomerName, &o.CustomerID,
             &o.OrderStartDate, &o.OrderFinishDate, &o.Note,
+            &o.OverCutTolerance, &o.UnderCutTolerance,
+            &o.Status, &o.StatusChangedAt, &o.Revision,
             &o.CreatedAt, &o.UpdatedAt,
         )
     if err != nil { return nil, err }
     return &o, nil
 }
 
-// GetAll returns all orders ordered by created_at desc.
-func (r *SqlOrdersRepository) GetAll(ctx context.Context) ([]models.ProductionOrder, error) {
+// GetAll returns orders ordered by created_at desc; nil filter fields match every order.
+func (r *SqlOrdersRepository) GetAll(ctx context.Context, filter models.OrderFilter) ([]models.ProductionOrder, error) {
     const q = `
-        SELECT order_id, order_number, style_number, customer_name, order_start_date, order_finish_date, note, created_at, updated_at
+        SELECT order_id, order_number, style_number, customer_name, customer_id, order_start_date, order_finish_date, note,
+               over_cut_tolerance::float8, under_cut_tolerance::float8, status, status_changed_at, revision, created_at, updated_at
         FROM production.orders
+        WHERE ($1::text IS NULL OR status = $1)
+          AND ($2::int IS NULL OR customer_id = $2)
+          AND ($3::text IS NULL OR style_number = $3)
         ORDER BY created_at DESC
     `
-    rows, err := r.db.QueryContext(ctx, q)
+    rows, err := r.db.QueryContext(ctx, q, filter.Status, filter.CustomerID, filter.StyleNumber)
     if err != nil { return nil, err }
     defer rows.Close()
 
@@ -115,8 +184,10 @@ func (r *SqlOrdersRepository) GetAll(ctx context.Context) ([]models.ProductionOr
     for rows.Next() {
         var o models.ProductionOrder
         if err := rows.Scan(
-            &o.OrderID, &o.OrderNumber, &o.StyleNumber, &o.CustomerName,
+            &o.OrderID, &o.OrderNumber, &o.StyleNumber, &o.CustomerName, &o.CustomerID,
             &o.OrderStartDate, &o.OrderFinishDate, &o.Note,
+            &o.OverCutTolerance, &o.UnderCutTolerance,
+            &o.Status, &o.StatusChangedAt, &o.Revision,
             &o.CreatedAt, &o.UpdatedAt,
         ); err != nil { return nil, err }
         list = append(list, o)
@@ -127,14 +198,17 @@ func (r *SqlOrdersRepository) GetAll(ctx context.Context) ([]models.ProductionOr
 // GetByOrderNumber returns an order by unique order_number.
 func (r *SqlOrdersRepository) GetByOrderNumber(ctx context.Context, number string) (*models.ProductionOrder, error) {
     const q = `
-        SELECT order_id, order_number, style_number, customer_name, order_start_date, order_finish_date, note, created_at, updated_at
+        SELECT order_id, order_number, style_number, customer_name, customer_id, order_start_date, order_finish_date, note,
+               over_cut_tolerance::float8, under_cut_tolerance::float8, status, status_changed_at, revision, created_at, updated_at
         FROM production.orders WHERE order_number = $1
     `
     var o models.ProductionOrder
     err := r.db.QueryRowContext(ctx, q, number).
         Scan(
-            &o.OrderID, &o.OrderNumber, &o.StyleNumber, &o.CustomerName,
+            &o.OrderID, &o.OrderNumber, &o.StyleNumber, &o.CustomerName, &o.CustomerID,
             &o.OrderStartDate, &o.OrderFinishDate, &o.Note,
+            &o.OverCutTolerance, &o.UnderCutTolerance,
+            &o.Status, &o.StatusChangedAt, &o.Revision,
             &o.CreatedAt, &o.UpdatedAt,
         )
     if err != nil { return nil, err }
@@ -163,6 +237,170 @@ func (r *SqlOrdersRepository) GetWithItems(ctx context.Context, id int) (*models
     return order, items, rows.Err()
 }
 
+// GetProgressBatch aggregates plans, layouts and tasks for several orders: one query each for headers, plans and cells.
+func (r *SqlOrdersRepository) GetProgressBatch(ctx context.Context, ids []int) ([]models.OrderProgress, error) {
+    if len(ids) == 0 {
+        return []models.OrderProgress{}, nil
+    }
+    args := make([]interface{}, len(ids))
+    placeholders := make([]string, len(ids))
+    for i, id := range ids {
+        args[i] = id
+        placeholders[i] = fmt.Sprintf("$%d", i+1)
+    }
+    in := strings.Join(placeholders, ", ")
+
+    byID := make(map[int]*models.OrderProgress, len(ids))
+    rows, err := r.db.QueryContext(ctx, `SELECT order_id, order_number, style_number FROM production.orders WHERE order_id IN (`+in+`)`, args...)
+    if err != nil { return nil, err }
+    for rows.Next() {
+        p := &models.OrderProgress{PlanStatus: map[string]int{}, Plans: []models.OrderPlanProgress{}, Cells: []models.CoverageCell{}}
+        if err := rows.Scan(&p.OrderID, &p.OrderNumber, &p.StyleNumber); err != nil { rows.Close(); return nil, err }
+        byID[p.OrderID] = p
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil { return nil, err }
+
+    // Plans: layout count, task counts, layers and pieces (layers × sum of layout ratios); pieces are summed per
+    // component and the smallest component total is reported, so shell and lining pieces are not added together.
+    // A component the order's style lists but the plan has no tasks for counts as 0.
+    qp := `
+        WITH rs AS (
+            SELECT layout_id, SUM(ratio) AS ratio_sum FROM production.layout_size_ratios GROUP BY layout_id
+        ), pieces AS (
+            SELECT plan_id, MIN(planned) AS planned, MIN(cut) AS cut
+            FROM (
+                SELECT cl.plan_id, cl.component,
+                       COALESCE(SUM(t.planned_layers * rs.ratio_sum), 0) AS planned, COALESCE(SUM(t.completed_layers * rs.ratio_sum), 0) AS cut
+                FROM production.cutting_layouts cl
+                JOIN production.plans p ON p.plan_id = cl.plan_id
+                JOIN production.tasks t ON t.layout_id = cl.layout_id
+                LEFT JOIN rs ON rs.layout_id = cl.layout_id
+                WHERE p.order_id IN (` + in + `)
+                GROUP BY cl.plan_id, cl.component
+                UNION ALL
+                SELECT p.plan_id, sc.component_name, 0, 0
+                FROM production.plans p
+                JOIN production.orders o ON o.order_id = p.order_id
+                JOIN production.styles s ON s.style_number = o.style_number
+                JOIN production.style_components sc ON sc.style_id = s.style_id
+                WHERE p.order_id IN (` + in + `)
+                  AND EXISTS (
+                      SELECT 1 FROM production.cutting_layouts cl JOIN production.tasks t ON t.layout_id = cl.layout_id
+                      WHERE cl.plan_id = p.plan_id
+                  )
+                  AND NOT EXISTS (
+                      SELECT 1 FROM production.cutting_layouts cl JOIN production.tasks t ON t.layout_id = cl.layout_id
+                      WHERE cl.plan_id = p.plan_id AND cl.component = sc.component_name
+                  )
+            ) c
+            GROUP BY plan_id
+        )
+        SELECT p.order_id, p.plan_id, p.plan_name, p.status, p.planned_publish_date, p.planned_finish_date,
+               COUNT(DISTINCT cl.layout_id)::int, COUNT(t.task_id)::int, (COUNT(t.task_id) FILTER (WHERE t.status = 'completed'))::int,
+               COALESCE(SUM(t.planned_layers), 0)::int, COALESCE(SUM(t.completed_layers), 0)::int,
+               COALESCE(MAX(pc.planned), 0)::int, COALESCE(MAX(pc.cut), 0)::int
+        FROM production.plans p
+        LEFT JOIN production.cutting_layouts cl ON cl.plan_id = p.plan_id
+        LEFT JOIN production.tasks t ON t.layout_id = cl.layout_id
+        LEFT JOIN pieces pc ON pc.plan_id = p.plan_id
+        WHERE p.order_id IN (` + in + `)
+        GROUP BY p.plan_id
+        ORDER BY p.order_id, p.plan_id`
+    rows, err = r.db.QueryContext(ctx, qp, args...)
+    if err != nil { return nil, err }
+    for rows.Next() {
+        var orderID int
+        var pp models.OrderPlanProgress
+        if err := rows.Scan(&orderID, &pp.PlanID, &pp.PlanName, &pp.Status, &pp.PlannedPublishDate, &pp.PlannedFinishDate,
+            &pp.Layouts, &pp.Tasks, &pp.CompletedTasks, &pp.PlannedLayers, &pp.CompletedLayers, &pp.PlannedPieces, &pp.CutPieces); err != nil {
+            rows.Close()
+            return nil, err
+        }
+        if p, ok := byID[orderID]; ok {
+            p.Plans = append(p.Plans, pp)
+            p.PlanStatus[pp.Status]++
+            // Only published plans count for the first publish date, and only completed/frozen plans for the last
+            // finish date (a plan reopened or un-completed by a voided log keeps its old finish date).
+            if pp.Status != "pending" && pp.PlannedPublishDate != nil && (p.FirstPublishDate == nil || pp.PlannedPublishDate.Before(*p.FirstPublishDate)) {
+                p.FirstPublishDate = pp.PlannedPublishDate
+            }
+            if (pp.Status == "completed" || pp.Status == "frozen") && pp.PlannedFinishDate != nil && (p.LastFinishDate == nil || pp.PlannedFinishDate.After(*p.LastFinishDate)) {
+                p.LastFinishDate = pp.PlannedFinishDate
+            }
+        }
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil { return nil, err }
+
+    // Cells: order items full-joined with task pieces per color/size; colors in item entry order, sizes in the
+    // style's size order, then item entry order for sizes the style does not list. Pieces are whole garments: per
+    // cell the smallest count across the order's components (a component with nothing on the cell counts as 0).
+    // The components are those with tasks plus those the order's style lists, so an unplanned lining keeps every
+    // cell at 0.
+    qc := `
+        WITH items AS (
+            SELECT order_id, color, size, SUM(quantity) AS qty, MIN(item_id) AS pos
+            FROM production.order_items WHERE order_id IN (` + in + `)
+            GROUP BY order_id, color, size
+        ), component_work AS (
+            SELECT p.order_id, cl.component, t.color, r.size, SUM(t.planned_layers * r.ratio) AS planned, SUM(t.completed_layers * r.ratio) AS cut
+            FROM production.plans p
+            JOIN production.cutting_layouts cl ON cl.plan_id = p.plan_id
+            JOIN production.tasks t ON t.layout_id = cl.layout_id
+            JOIN production.layout_size_ratios r ON r.layout_id = cl.layout_id
+            WHERE p.order_id IN (` + in + `)
+            GROUP BY p.order_id, cl.component, t.color, r.size
+        ), components AS (
+            SELECT DISTINCT order_id, component FROM component_work
+            UNION
+            SELECT o.order_id, sc.component_name
+            FROM production.orders o
+            JOIN production.styles s ON s.style_number = o.style_number
+            JOIN production.style_components sc ON sc.style_id = s.style_id
+            WHERE o.order_id IN (` + in + `)
+        ), work AS (
+            SELECT k.order_id, k.color, k.size, MIN(COALESCE(cw.planned, 0)) AS planned, MIN(COALESCE(cw.cut, 0)) AS cut
+            FROM (SELECT DISTINCT order_id, color, size FROM component_work) k
+            JOIN components c ON c.order_id = k.order_id
+            LEFT JOIN component_work cw ON cw.order_id = k.order_id AND cw.component = c.component AND cw.color = k.color AND cw.size = k.size
+            GROUP BY k.order_id, k.color, k.size
+        )
+        SELECT COALESCE(i.order_id, w.order_id), COALESCE(i.color, w.color), COALESCE(i.size, w.size),
+               COALESCE(i.qty, 0)::int, COALESCE(w.planned, 0)::int, COALESCE(w.cut, 0)::int
+        FROM items i
+        FULL JOIN work w ON w.order_id = i.order_id AND w.color = i.color AND w.size = i.size
+        JOIN production.orders o ON o.order_id = COALESCE(i.order_id, w.order_id)
+        ORDER BY 1, MIN(i.pos) OVER (PARTITION BY COALESCE(i.order_id, w.order_id), COALESCE(i.color, w.color)) NULLS LAST, 2,
+                 production.style_size_rank(o.style_number, COALESCE(i.size, w.size)) NULLS LAST, i.pos NULLS LAST, 3`
+    rows, err = r.db.QueryContext(ctx, qc, args...)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    for rows.Next() {
+        var orderID int
+        var c models.CoverageCell
+        if err := rows.Scan(&orderID, &c.Color, &c.Size, &c.OrderedQty, &c.PlannedPieces, &c.CutPieces); err != nil { return nil, err }
+        p, ok := byID[orderID]
+        if !ok { continue }
+        c.PlannedDelta = c.PlannedPieces - c.OrderedQty
+        c.CutDelta = c.CutPieces - c.OrderedQty
+        p.Cells = append(p.Cells, c)
+        p.TotalOrdered += c.OrderedQty
+        p.TotalPlannedPieces += c.PlannedPieces
+        p.TotalCutPieces += c.CutPieces
+    }
+    if err := rows.Err(); err != nil { return nil, err }
+
+    out := make([]models.OrderProgress, 0, len(byID))
+    for _, id := range ids {
+        if p, ok := byID[id]; ok {
+            out = append(out, *p)
+            delete(byID, id)
+        }
+    }
+    return out, nil
+}
+
 // Delete removes an order by ID (items are removed via cascade).
 func (r *SqlOrdersRepository) Delete(id int) error {
     const q = `DELETE FROM production.orders WHERE order_id = $1`
@@ -171,4 +409,204 @@ func (r *SqlOrdersRepository) Delete(id int) error {
     if err != nil { return err }
     if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
     return nil
-}
\ No newline at end of file
+}
+
+// orderRevisionColumns is the select list matching scanOrderRevision.
+const orderRevisionColumns = `revision_id, order_id, revision, reason, items, changes, flagged_plans, created_by, created_by_name, created_at`
+
+func scanOrderRevision(row scanner) (*models.OrderRevision, error) {
+    rev := models.OrderRevision{Items: []models.OrderRevisionLine{}, Changes: []models.OrderItemChange{}, FlaggedPlans: []models.RevisionPlanFlag{}}
+    var items, changes, flagged []byte
+    if err := row.Scan(&rev.RevisionID, &rev.OrderID, &rev.Revision, &rev.Reason, &items, &changes, &flagged,
+        &rev.CreatedBy, &rev.CreatedByName, &rev.CreatedAt); err != nil {
+        return nil, err
+    }
+    if err := json.Unmarshal(items, &rev.Items); err != nil { return nil, err }
+    if err := json.Unmarshal(changes, &rev.Changes); err != nil { return nil, err }
+    if err := json.Unmarshal(flagged, &rev.FlaggedPlans); err != nil { return nil, err }
+    return &rev, nil
+}
+
+// insertOrderRevision records the order's current items as the given revision, with the changes and flagged plans that led to it.
+func insertOrderRevision(ctx context.Context, tx *sql.Tx, orderID, revision int, reason *string, createdBy *int,
+    changes []models.OrderItemChange, flagged []models.RevisionPlanFlag) error {
+    if changes == nil { changes = []models.OrderItemChange{} }
+    if flagged == nil { flagged = []models.RevisionPlanFlag{} }
+    changesJSON, err := json.Marshal(changes)
+    if err != nil { return err }
+    flaggedJSON, err := json.Marshal(flagged)
+    if err != nil { return err }
+    _, err = tx.ExecContext(ctx, `
+        INSERT INTO production.order_revisions (order_id, revision, reason, items, changes, flagged_plans, created_by, created_by_name)
+        SELECT $1, $2, $3,
+               COALESCE((SELECT jsonb_agg(jsonb_build_object('color', color, 'size', size, 'quantity', quantity) ORDER BY item_id)
+                         FROM production.order_items WHERE order_id = $1), '[]'),
+               $4, $5, $6, (SELECT name FROM public.users WHERE user_id = $6)`,
+        orderID, revision, reason, changesJSON, flaggedJSON, createdBy)
+    return err
+}
+
+// Revise applies item changes as the next revision in one transaction: lock the order, edit items under
+// cutrix.order_revision_flag, bump orders.revision, flag published plans and snapshot the result. The service
+// checks are repeated under the lock, since a concurrent revision or status change may land in between.
+func (r *SqlOrdersRepository) Revise(ctx context.Context, orderID int, changes []models.OrderItemChange, reason *string, createdBy *int) (*models.OrderRevision, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil { return nil, err }
+    defer tx.Rollback()
+
+    var status string
+    var over, under *float64
+    if err := tx.QueryRowContext(ctx, `
+        SELECT status, over_cut_tolerance::float8, under_cut_tolerance::float8
+        FROM production.orders WHERE order_id = $1 FOR UPDATE`, orderID).Scan(&status, &over, &under); err != nil {
+        return nil, err
+    }
+    if status == "closed" || status == "cancelled" {
+        return nil, fmt.Errorf("%w: 订单已关闭或取消，不可修订订单项 (order_id=%d, status=%s)", ErrConflict, orderID, status)
+    }
+
+    current := map[string]int{}
+    rows, err := tx.QueryContext(ctx, `SELECT color, size, quantity FROM production.order_items WHERE order_id = $1`, orderID)
+    if err != nil { return nil, err }
+    for rows.Next() {
+        var color, size string
+        var qty int
+        if err := rows.Scan(&color, &size, &qty); err != nil { rows.Close(); return nil, err }
+        current[color+"\x00"+size] = qty
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil { return nil, err }
+
+    if _, err := tx.ExecContext(ctx, `SET LOCAL cutrix.order_revision_flag = true`); err != nil { return nil, err }
+    changed := make(map[string]bool, len(changes))
+    for i := range changes {
+        c := &changes[i]
+        key := c.Color + "\x00" + c.Size
+        prev, exists := current[key]
+        switch c.Kind {
+        case "add":
+            if exists { return nil, fmt.Errorf("%w: 订单项已存在 (color=%s, size=%s)", ErrValidation, c.Color, c.Size) }
+            if _, err := tx.ExecContext(ctx, `INSERT INTO production.order_items (order_id, color, size, quantity) VALUES ($1, $2, $3, $4)`,
+                orderID, c.Color, c.Size, *c.Quantity); err != nil {
+                return nil, err
+            }
+            current[key] = *c.Quantity
+        case "update", "remove":
+            if !exists { return nil, fmt.Errorf("%w: 订单项不存在 (color=%s, size=%s)", ErrValidation, c.Color, c.Size) }
+            c.PreviousQuantity = &prev
+            if c.Kind == "update" {
+                _, err = tx.ExecContext(ctx, `UPDATE production.order_items SET quantity = $1 WHERE order_id = $2 AND color = $3 AND size = $4`,
+                    *c.Quantity, orderID, c.Color, c.Size)
+                current[key] = *c.Quantity
+            } else {
+                _, err = tx.ExecContext(ctx, `DELETE FROM production.order_items WHERE order_id = $1 AND color = $2 AND size = $3`,
+                    orderID, c.Color, c.Size)
+                delete(current, key)
+            }
+            if err != nil { return nil, err }
+        default:
+            return nil, fmt.Errorf("未知的订单项变更类型: %s", c.Kind)
+        }
+        changed[key] = true
+    }
+    if len(current) == 0 {
+        return nil, fmt.Errorf("%w: 订单必须至少包含一个订单项", ErrValidation)
+    }
+
+    flagged, err := flagRevisedPlans(ctx, tx, orderID, changed, over, under)
+    if err != nil { return nil, err }
+    var revision int
+    if err := tx.QueryRowContext(ctx, `UPDATE production.orders SET revision = revision + 1 WHERE order_id = $1 RETURNING revision`, orderID).
+        Scan(&revision); err != nil {
+        return nil, err
+    }
+    if err := insertOrderRevision(ctx, tx, orderID, revision, reason, createdBy, changes, flagged); err != nil { return nil, err }
+    rev, err := scanOrderRevision(tx.QueryRowContext(ctx,
+        `SELECT `+orderRevisionColumns+` FROM production.order_revisions WHERE order_id = $1 AND revision = $2`, orderID, revision))
+    if err != nil { return nil, err }
+    if err := tx.Commit(); err != nil { return nil, err }
+    return rev, nil
+}
+
+// flagRevisedPlans lists in_progress / completed plans with tasks on a changed color/size whose order-wide planned
+// pieces of the same component (non-frozen plans, as at publish) now fall outside the revised quantity's tolerance
+// window; an unset side counts as 0%.
+func flagRevisedPlans(ctx context.Context, q queryer, orderID int, changed map[string]bool, over, under *float64) ([]models.RevisionPlanFlag, error) {
+    rows, err := q.QueryContext(ctx, `
+        WITH planned AS (
+            SELECT l.component, t.color, r.size, SUM(t.planned_layers * r.ratio)::int AS pieces
+            FROM production.plans p
+            JOIN production.cutting_layouts l ON l.plan_id = p.plan_id
+            JOIN production.tasks t ON t.layout_id = l.layout_id
+            JOIN production.layout_size_ratios r ON r.layout_id = l.layout_id
+            WHERE p.order_id = $1 AND p.status <> 'frozen'
+            GROUP BY l.component, t.color, r.size
+        )
+        SELECT DISTINCT p.plan_id, p.plan_name, p.status, l.component, t.color, r.size, COALESCE(oi.quantity, 0), COALESCE(pl.pieces, 0),
+               production.style_component_rank(o.style_number, l.component), production.style_size_rank(o.style_number, r.size)
+        FROM production.plans p
+        JOIN production.cutting_layouts l ON l.plan_id = p.plan_id
+        JOIN production.tasks t ON t.layout_id = l.layout_id
+        JOIN production.layout_size_ratios r ON r.layout_id = l.layout_id
+        LEFT JOIN planned pl ON pl.component = l.component AND pl.color = t.color AND pl.size = r.size
+        LEFT JOIN production.order_items oi ON oi.order_id = p.order_id AND oi.color = t.color AND oi.size = r.size
+        JOIN production.orders o ON o.order_id = p.order_id
+        WHERE p.order_id = $1 AND p.status IN ('in_progress', 'completed')
+        ORDER BY p.plan_id, 9 NULLS LAST, l.component, t.color, 10 NULLS LAST, r.size`, orderID)
+    if err != nil { return nil, err }
+    defer rows.Close()
+
+    pct := func(p *float64) float64 { if p == nil { return 0 }; return *p }
+    flagged := []models.RevisionPlanFlag{}
+    for rows.Next() {
+        var f models.RevisionPlanFlag
+        var v models.ToleranceViolation
+        var componentRank, sizeRank *int
+        if err := rows.Scan(&f.PlanID, &f.PlanName, &f.Status, &v.Component, &v.Color, &v.Size, &v.OrderedQty, &v.PlannedPieces,
+            &componentRank, &sizeRank); err != nil {
+            return nil, err
+        }
+        if !changed[v.Color+"\x00"+v.Size] { continue }
+        minQty := v.OrderedQty - int(math.Floor(float64(v.OrderedQty)*pct(under)/100))
+        maxQty := v.OrderedQty + int(math.Floor(float64(v.OrderedQty)*pct(over)/100))
+        switch {
+        case v.PlannedPieces < minQty:
+            v.Kind = "short"
+        case v.PlannedPieces > maxQty:
+            v.Kind = "over"
+        default:
+            continue
+        }
+        v.MinQty, v.MaxQty = &minQty, &maxQty
+        if n := len(flagged); n > 0 && flagged[n-1].PlanID == f.PlanID {
+            flagged[n-1].Cells = append(flagged[n-1].Cells, v)
+            continue
+        }
+        f.Cells = []models.ToleranceViolation{v}
+        flagged = append(flagged, f)
+    }
+    return flagged, rows.Err()
+}
+
+// ListRevisions returns every revision of the order, newest first; sql.ErrNoRows when the order does not exist.
+func (r *SqlOrdersRepository) ListRevisions(ctx context.Context, orderID int) ([]models.OrderRevision, error) {
+    if err := r.db.QueryRowContext(ctx, `SELECT order_id FROM production.orders WHERE order_id = $1`, orderID).Scan(&orderID); err != nil {
+        return nil, err
+    }
+    rows, err := r.db.QueryContext(ctx, `SELECT `+orderRevisionColumns+` FROM production.order_revisions WHERE order_id = $1 ORDER BY revision DESC`, orderID)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    res := []models.OrderRevision{}
+    for rows.Next() {
+        rev, err := scanOrderRevision(rows)
+        if err != nil { return nil, err }
+        res = append(res, *rev)
+    }
+    return res, rows.Err()
+}
+
+// GetRevision returns one revision of the order; sql.ErrNoRows when it does not exist.
+func (r *SqlOrdersRepository) GetRevision(ctx context.Context, orderID, revision int) (*models.OrderRevision, error) {
+    return scanOrderRevision(r.db.QueryRowContext(ctx,
+        `SELECT `+orderRevisionColumns+` FROM production.order_revisions WHERE order_id = $1 AND revision = $2`, orderID, revision))
+}
@@ -0,0 +1,241 @@
+package repositories
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "cutrix-backend/internal/models"
+)
+
+type SqlPayrollRepository struct{ db *sql.DB }
+
+var _ PayrollRepository = (*SqlPayrollRepository)(nil)
+
+func NewSqlPayrollRepository(db *sql.DB) *SqlPayrollRepository { return &SqlPayrollRepository{db: db} }
+
+const rateSelect = `
+    SELECT rate_id, layout_id, style_number, rate_per_layer::float8, note, created_at, updated_at
+    FROM production.piece_rates`
+
+func scanRate(s scanner) (*models.PieceRate, error) {
+    var r models.PieceRate
+    if err := s.Scan(&r.RateID, &r.LayoutID, &r.StyleNumber, &r.RatePerLayer, &r.Note, &r.CreatedAt, &r.UpdatedAt); err != nil {
+        return nil, err
+    }
+    return &r, nil
+}
+
+const periodSelect = `
+    SELECT period_id, to_char(period_start, 'YYYY-MM-DD'), to_char(period_end, 'YYYY-MM-DD'), status, locked_at, locked_by, created_at
+    FROM production.pay_periods`
+
+func scanPeriod(s scanner) (*models.PayPeriod, error) {
+    var p models.PayPeriod
+    if err := s.Scan(&p.PeriodID, &p.PeriodStart, &p.PeriodEnd, &p.Status, &p.LockedAt, &p.LockedBy, &p.CreatedAt); err != nil {
+        return nil, err
+    }
+    return &p, nil
+}
+
+// payrollPendingSelect lists the lines an open period ($1) would lock now:
+// unpaid non-voided logs of the period priced at the current rate, plus reversals of paid logs voided
+// since, which only the earliest open period carries.
+const payrollPendingSelect = `
+    WITH p AS (
+        SELECT pp.period_id, pp.period_start, pp.period_end,
+               NOT EXISTS (SELECT 1 FROM production.pay_periods o
+                           WHERE o.status = 'open' AND (o.period_start, o.period_id) < (pp.period_start, pp.period_id)) AS earliest
+        FROM production.pay_periods pp
+        WHERE pp.period_id = $1 AND pp.status = 'open'
+    )
+    SELECT 'log'::text AS kind, l.log_id, l.worker_id, COALESCE(u.name, l.worker_name)::text AS worker_name,
+           l.task_id, cl.layout_id, cl.layout_name::text, o.style_number::text, t.color::text, l.shift_date, l.log_time,
+           l.layers_completed AS layers, rt.rate_id, rt.rate_per_layer, rt.scope AS rate_scope,
+           ROUND(l.layers_completed * COALESCE(rt.rate_per_layer, 0), 2) AS amount
+    FROM p
+    JOIN production.logs l ON l.shift_date BETWEEN p.period_start AND p.period_end
+    JOIN production.tasks t ON t.task_id = l.task_id
+    JOIN production.cutting_layouts cl ON cl.layout_id = t.layout_id
+    JOIN production.plans pl ON pl.plan_id = cl.plan_id
+    JOIN production.orders o ON o.order_id = pl.order_id
+    LEFT JOIN public.users u ON u.user_id = l.worker_id
+    LEFT JOIN LATERAL (
+        SELECT r.rate_id, r.rate_per_layer,
+               (CASE WHEN r.layout_id IS NOT NULL THEN 'layout' WHEN r.style_number IS NOT NULL THEN 'style' ELSE 'default' END)::text AS scope
+        FROM production.piece_rates r
+        WHERE r.layout_id = cl.layout_id OR r.style_number = o.style_number OR (r.layout_id IS NULL AND r.style_number IS NULL)
+        ORDER BY (r.layout_id IS NOT NULL) DESC, (r.style_number IS NOT NULL) DESC
+        LIMIT 1
+    ) rt ON TRUE
+    WHERE NOT l.voided
+      AND (l.worker_id IS NOT NULL OR l.worker_name IS NOT NULL)
+      AND NOT EXISTS (SELECT 1 FROM production.payroll_lines x WHERE x.log_id = l.log_id AND x.kind = 'log')
+    UNION ALL
+    SELECT 'void_adjustment'::text, x.log_id, x.worker_id, x.worker_name::text,
+           x.task_id, x.layout_id, x.layout_name::text, x.style_number::text, x.color::text, x.shift_date, x.log_time,
+           -x.layers, x.rate_id, x.rate_per_layer, x.rate_scope::text, -x.amount
+    FROM p
+    JOIN production.payroll_lines x ON x.kind = 'log'
+    JOIN production.logs l ON l.log_id = x.log_id
+    WHERE p.earliest AND l.voided
+      AND NOT EXISTS (SELECT 1 FROM production.payroll_lines y WHERE y.log_id = x.log_id AND y.kind = 'void_adjustment')`
+
+const payrollLineColumns = `kind, log_id, worker_id, worker_name, task_id, layout_id, layout_name, style_number, color,
+    shift_date, log_time, layers, rate_id, rate_per_layer, rate_scope, amount`
+
+func scanPayrollLine(s scanner) (*models.PayrollLine, error) {
+    var l models.PayrollLine
+    if err := s.Scan(&l.LineID, &l.Kind, &l.LogID, &l.WorkerID, &l.WorkerName, &l.TaskID, &l.LayoutID, &l.LayoutName,
+        &l.StyleNumber, &l.Color, &l.ShiftDate, &l.LogTime, &l.Layers, &l.RateID, &l.RatePerLayer, &l.RateScope, &l.Amount); err != nil {
+        return nil, err
+    }
+    return &l, nil
+}
+
+func (r *SqlPayrollRepository) CreateRate(ctx context.Context, rate *models.PieceRate) (int, error) {
+    const q = `
+        INSERT INTO production.piece_rates (layout_id, style_number, rate_per_layer, note)
+        VALUES ($1, $2, $3, $4)
+        RETURNING rate_id, created_at, updated_at`
+    err := r.db.QueryRowContext(ctx, q, rate.LayoutID, rate.StyleNumber, rate.RatePerLayer, rate.Note).
+        Scan(&rate.RateID, &rate.CreatedAt, &rate.UpdatedAt)
+    return rate.RateID, err
+}
+
+func (r *SqlPayrollRepository) UpdateRate(ctx context.Context, rate *models.PieceRate) error {
+    const q = `
+        UPDATE production.piece_rates
+        SET rate_per_layer = $1, note = $2, updated_at = CURRENT_TIMESTAMP
+        WHERE rate_id = $3`
+    res, err := r.db.ExecContext(ctx, q, rate.RatePerLayer, rate.Note, rate.RateID)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
+func (r *SqlPayrollRepository) DeleteRate(ctx context.Context, id int) error {
+    res, err := r.db.ExecContext(ctx, `DELETE FROM production.piece_rates WHERE rate_id = $1`, id)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
+func (r *SqlPayrollRepository) GetRate(ctx context.Context, id int) (*models.PieceRate, error) {
+    return scanRate(r.db.QueryRowContext(ctx, rateSelect+` WHERE rate_id = $1`, id))
+}
+
+func (r *SqlPayrollRepository) FindRate(ctx context.Context, layoutID *int, styleNumber *string) (*models.PieceRate, error) {
+    q := rateSelect + ` WHERE layout_id IS NOT DISTINCT FROM $1 AND style_number IS NOT DISTINCT FROM $2`
+    return scanRate(r.db.QueryRowContext(ctx, q, layoutID, styleNumber))
+}
+
+func (r *SqlPayrollRepository) ListRates(ctx context.Context) ([]models.PieceRate, error) {
+    q := rateSelect + ` ORDER BY (layout_id IS NOT NULL) DESC, (style_number IS NOT NULL) DESC, layout_id, style_number`
+    rows, err := r.db.QueryContext(ctx, q)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var res []models.PieceRate
+    for rows.Next() {
+        rate, err := scanRate(rows)
+        if err != nil { return nil, err }
+        res = append(res, *rate)
+    }
+    return res, rows.Err()
+}
+
+func (r *SqlPayrollRepository) CreatePeriod(ctx context.Context, period *models.PayPeriod) (int, error) {
+    const q = `
+        INSERT INTO production.pay_periods (period_start, period_end)
+        VALUES ($1::date, $2::date)
+        RETURNING period_id, status, created_at`
+    err := r.db.QueryRowContext(ctx, q, period.PeriodStart, period.PeriodEnd).
+        Scan(&period.PeriodID, &period.Status, &period.CreatedAt)
+    return period.PeriodID, err
+}
+
+func (r *SqlPayrollRepository) DeletePeriod(ctx context.Context, id int) error {
+    // Pre-check: locked periods are final (the trigger rejects them as well)
+    p, err := r.GetPeriod(ctx, id)
+    if err != nil { return err }
+    if p.Status == "locked" {
+        return fmt.Errorf("工资周期已锁定，不允许删除 (period_id=%d)", id)
+    }
+    res, err := r.db.ExecContext(ctx, `DELETE FROM production.pay_periods WHERE period_id = $1`, id)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
+func (r *SqlPayrollRepository) GetPeriod(ctx context.Context, id int) (*models.PayPeriod, error) {
+    return scanPeriod(r.db.QueryRowContext(ctx, periodSelect+` WHERE period_id = $1`, id))
+}
+
+func (r *SqlPayrollRepository) ListPeriods(ctx context.Context) ([]models.PayPeriod, error) {
+    rows, err := r.db.QueryContext(ctx, periodSelect+` ORDER BY period_start DESC, period_id DESC`)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var res []models.PayPeriod
+    for rows.Next() {
+        p, err := scanPeriod(rows)
+        if err != nil { return nil, err }
+        res = append(res, *p)
+    }
+    return res, rows.Err()
+}
+
+func (r *SqlPayrollRepository) LockPeriod(ctx context.Context, id int, lockedBy *int) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil { return err }
+    defer tx.Rollback()
+
+    // Serialize locking across periods so reversals are posted exactly once
+    if _, err := tx.ExecContext(ctx, `LOCK TABLE production.pay_periods IN SHARE ROW EXCLUSIVE MODE`); err != nil { return err }
+    var status string
+    if err := tx.QueryRowContext(ctx, `SELECT status FROM production.pay_periods WHERE period_id = $1`, id).Scan(&status); err != nil {
+        return err
+    }
+    if status != "open" {
+        return fmt.Errorf("工资周期已锁定 (period_id=%d)", id)
+    }
+    // Pre-check: every line needs a rate
+    var missing int
+    if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM (`+payrollPendingSelect+`) x WHERE x.rate_per_layer IS NULL`, id).Scan(&missing); err != nil {
+        return err
+    }
+    if missing > 0 {
+        return fmt.Errorf("工资周期存在 %d 条无计件单价的日志，请先设置单价 (period_id=%d)", missing, id)
+    }
+    q := `INSERT INTO production.payroll_lines (period_id, ` + payrollLineColumns + `)
+        SELECT $1, ` + payrollLineColumns + ` FROM (` + payrollPendingSelect + `) x`
+    if _, err := tx.ExecContext(ctx, q, id); err != nil { return err }
+    const upd = `UPDATE production.pay_periods SET status = 'locked', locked_at = CURRENT_TIMESTAMP, locked_by = $2 WHERE period_id = $1`
+    if _, err := tx.ExecContext(ctx, upd, id, lockedBy); err != nil { return err }
+    return tx.Commit()
+}
+
+func (r *SqlPayrollRepository) ListLines(ctx context.Context, periodID int) ([]models.PayrollLine, error) {
+    p, err := r.GetPeriod(ctx, periodID)
+    if err != nil { return nil, err }
+    var q string
+    if p.Status == "locked" {
+        q = `SELECT line_id, ` + payrollLineColumns + `
+            FROM production.payroll_lines WHERE period_id = $1`
+    } else {
+        q = `SELECT NULL::int, ` + payrollLineColumns + ` FROM (` + payrollPendingSelect + `) x`
+    }
+    q = `SELECT line_id, kind, log_id, worker_id, worker_name, task_id, layout_id, layout_name, style_number, color,
+            to_char(shift_date, 'YYYY-MM-DD'), log_time, layers, rate_id, rate_per_layer::float8, rate_scope, amount::float8
+        FROM (` + q + `) s(line_id, ` + payrollLineColumns + `)
+        ORDER BY worker_name, worker_id, log_time, kind, log_id`
+    rows, err := r.db.QueryContext(ctx, q, periodID)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var res []models.PayrollLine
+    for rows.Next() {
+        l, err := scanPayrollLine(rows)
+        if err != nil { return nil, err }
+        res = append(res, *l)
+    }
+    return res, rows.Err()
+}
@@ -3,6 +3,8 @@ package repositories
 import (
     "context"
     "database/sql"
+    "fmt"
+    "sort"
 
     "cutrix-backend/internal/models"
 )
@@ -28,6 +30,194 @@ func (r *SqlPlansRepository) Create(ctx context.Context, plan *models.Production
     return id, nil
 }
 
+// CreateWithLayouts inserts the plan, its layouts, size ratios and tasks in one transaction.
+// Zero ratios are skipped (same as SetRatios); the plan is created as pending so child triggers accept the writes.
+func (r *SqlPlansRepository) CreateWithLayouts(ctx context.Context, plan *models.ProductionPlan, layouts []models.DraftLayout) (int, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil { return 0, err }
+    defer tx.Rollback()
+
+    planID, err := insertDraftPlan(ctx, tx, plan, layouts)
+    if err != nil { return 0, err }
+    if err := tx.Commit(); err != nil { return 0, err }
+    plan.PlanID = planID
+    plan.Status = "pending"
+    return planID, nil
+}
+
+// insertDraftPlan writes a pending plan with its layouts, ratios and tasks inside tx; generated IDs are written back into layouts.
+func insertDraftPlan(ctx context.Context, tx *sql.Tx, plan *models.ProductionPlan, layouts []models.DraftLayout) (int, error) {
+    var planID int
+    if err := tx.QueryRowContext(ctx,
+        `INSERT INTO production.plans (plan_name, order_id, note) VALUES ($1, $2, $3) RETURNING plan_id`,
+        plan.PlanName, plan.OrderID, plan.Note,
+    ).Scan(&planID); err != nil {
+        return 0, err
+    }
+
+    for i := range layouts {
+        l := &layouts[i]
+        l.Layout.PlanID = planID
+        if err := tx.QueryRowContext(ctx,
+            `INSERT INTO production.cutting_layouts (plan_id, layout_name, component, note,
+                marker_length, fabric_width, marker_efficiency, end_loss_allowance, bundle_size)
+             VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9) RETURNING layout_id, component`,
+            planID, l.Layout.LayoutName, l.Layout.Component, l.Layout.Note,
+            l.Layout.MarkerLength, l.Layout.FabricWidth, l.Layout.MarkerEfficiency, l.Layout.EndLossAllowance, l.Layout.BundleSize,
+        ).Scan(&l.Layout.LayoutID, &l.Layout.Component); err != nil {
+            return 0, err
+        }
+
+        sizes := make([]string, 0, len(l.Ratios))
+        for size := range l.Ratios { sizes = append(sizes, size) }
+        sort.Strings(sizes)
+        for _, size := range sizes {
+            if l.Ratios[size] <= 0 { continue }
+            if _, err := tx.ExecContext(ctx,
+                `INSERT INTO production.layout_size_ratios (layout_id, size, ratio) VALUES ($1, $2, $3)`,
+                l.Layout.LayoutID, size, l.Ratios[size],
+            ); err != nil {
+                return 0, err
+            }
+        }
+
+        for j := range l.Tasks {
+            t := &l.Tasks[j]
+            t.LayoutID = l.Layout.LayoutID
+            if err := tx.QueryRowContext(ctx,
+                `INSERT INTO production.tasks (layout_id, color, planned_layers) VALUES ($1, $2, $3)
+                 RETURNING task_id, completed_layers, status`,
+                t.LayoutID, t.Color, t.PlannedLayers,
+            ).Scan(&t.TaskID, &t.CompletedLayers, &t.Status); err != nil {
+                return 0, err
+            }
+        }
+    }
+    return planID, nil
+}
+
+// Clone copies the source plan's layouts, ratios and tasks into a new pending plan in one transaction.
+// Colors and sizes are remapped first; anything still absent from the target order's items yields *PlanCloneMismatchError.
+func (r *SqlPlansRepository) Clone(ctx context.Context, sourceID int, plan *models.ProductionPlan, colorMap, sizeMap map[string]string) ([]models.DraftLayout, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil { return nil, err }
+    defer tx.Rollback()
+
+    var srcName string
+    var srcOrderID int
+    var srcNote sql.NullString
+    if err := tx.QueryRowContext(ctx, `SELECT plan_name, order_id, note FROM production.plans WHERE plan_id = $1`, sourceID).
+        Scan(&srcName, &srcOrderID, &srcNote); err != nil {
+        return nil, err
+    }
+    if plan.OrderID == 0 { plan.OrderID = srcOrderID }
+    if plan.PlanName == "" { plan.PlanName = srcName + " (copy)" }
+    if plan.Note == nil && srcNote.Valid { v := srcNote.String; plan.Note = &v }
+
+    // Lock the target order so its items cannot change between the check and the inserts
+    if err := tx.QueryRowContext(ctx, `SELECT order_id FROM production.orders WHERE order_id = $1 FOR SHARE`, plan.OrderID).Scan(&plan.OrderID); err != nil {
+        return nil, err
+    }
+    colors, sizes := map[string]bool{}, map[string]bool{}
+    rows, err := tx.QueryContext(ctx, `SELECT color, size FROM production.order_items WHERE order_id = $1`, plan.OrderID)
+    if err != nil { return nil, err }
+    for rows.Next() {
+        var c, sz string
+        if err := rows.Scan(&c, &sz); err != nil { rows.Close(); return nil, err }
+        colors[c], sizes[sz] = true, true
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil { return nil, err }
+
+    layouts, err := loadDraftLayouts(ctx, tx, sourceID)
+    if err != nil { return nil, err }
+    remapDraftLayouts(layouts, colorMap, sizeMap)
+    mismatch := &PlanCloneMismatchError{OrderID: plan.OrderID}
+    seen := map[string]bool{}
+    for _, l := range layouts {
+        for size := range l.Ratios {
+            if !sizes[size] && !seen["s:"+size] { seen["s:"+size] = true; mismatch.MissingSizes = append(mismatch.MissingSizes, size) }
+        }
+        for _, t := range l.Tasks {
+            if !colors[t.Color] && !seen["c:"+t.Color] { seen["c:"+t.Color] = true; mismatch.MissingColors = append(mismatch.MissingColors, t.Color) }
+        }
+    }
+    if len(mismatch.MissingColors) > 0 || len(mismatch.MissingSizes) > 0 {
+        sort.Strings(mismatch.MissingColors)
+        sort.Strings(mismatch.MissingSizes)
+        return nil, mismatch
+    }
+
+    planID, err := insertDraftPlan(ctx, tx, plan, layouts)
+    if err != nil { return nil, err }
+    if err := tx.Commit(); err != nil { return nil, err }
+    plan.PlanID = planID
+    plan.Status = "pending"
+    return layouts, nil
+}
+
+// loadDraftLayouts reads a plan's layouts with their ratios and tasks (task progress and table assignment are not copied).
+func loadDraftLayouts(ctx context.Context, tx *sql.Tx, planID int) ([]models.DraftLayout, error) {
+    rows, err := tx.QueryContext(ctx, `SELECT `+layoutColumns+` FROM production.cutting_layouts WHERE plan_id = $1 ORDER BY layout_id`, planID)
+    if err != nil { return nil, err }
+    var layouts []models.DraftLayout
+    index := map[int]int{}
+    for rows.Next() {
+        l, err := scanLayout(rows)
+        if err != nil { rows.Close(); return nil, err }
+        index[l.LayoutID] = len(layouts)
+        layouts = append(layouts, models.DraftLayout{Layout: *l, Ratios: map[string]int{}, Tasks: []models.ProductionTask{}})
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil { return nil, err }
+
+    rows, err = tx.QueryContext(ctx, `
+        SELECT r.layout_id, r.size, r.ratio FROM production.layout_size_ratios r
+        JOIN production.cutting_layouts cl ON cl.layout_id = r.layout_id
+        WHERE cl.plan_id = $1`, planID)
+    if err != nil { return nil, err }
+    for rows.Next() {
+        var layoutID, ratio int
+        var size string
+        if err := rows.Scan(&layoutID, &size, &ratio); err != nil { rows.Close(); return nil, err }
+        layouts[index[layoutID]].Ratios[size] = ratio
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil { return nil, err }
+
+    rows, err = tx.QueryContext(ctx, `
+        SELECT t.layout_id, t.color, t.planned_layers FROM production.tasks t
+        JOIN production.cutting_layouts cl ON cl.layout_id = t.layout_id
+        WHERE cl.plan_id = $1 ORDER BY t.task_id`, planID)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    for rows.Next() {
+        var t models.ProductionTask
+        if err := rows.Scan(&t.LayoutID, &t.Color, &t.PlannedLayers); err != nil { return nil, err }
+        l := &layouts[index[t.LayoutID]]
+        l.Tasks = append(l.Tasks, t)
+    }
+    return layouts, rows.Err()
+}
+
+// remapDraftLayouts renames colors and sizes in place; sizes mapped onto the same target have their ratios summed.
+func remapDraftLayouts(layouts []models.DraftLayout, colorMap, sizeMap map[string]string) {
+    for i := range layouts {
+        l := &layouts[i]
+        if len(sizeMap) > 0 {
+            ratios := make(map[string]int, len(l.Ratios))
+            for size, ratio := range l.Ratios {
+                if to, ok := sizeMap[size]; ok { size = to }
+                ratios[size] += ratio
+            }
+            l.Ratios = ratios
+        }
+        for j := range l.Tasks {
+            if to, ok := colorMap[l.Tasks[j].Color]; ok { l.Tasks[j].Color = to }
+        }
+    }
+}
+
 func (r *SqlPlansRepository) Delete(ctx context.Context, id int) error {
     tx, err := r.db.BeginTx(ctx, nil)
     if err != nil {
@@ -55,9 +245,62 @@ func (r *SqlPlansRepository) UpdateNote(ctx context.Context, id int, note *strin
     return err
 }
 
+// Publish moves the plan to in_progress. The order row is locked so concurrent publishes of the same
+// order see each other's pieces; tolerance is pre-checked here for a structured error and enforced again by the trigger.
 func (r *SqlPlansRepository) Publish(ctx context.Context, id int) error {
-    _, err := r.db.ExecContext(ctx, `UPDATE production.plans SET status = 'in_progress' WHERE plan_id = $1`, id)
-    return err
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    const lock = `
+        SELECT o.order_id FROM production.orders o
+        JOIN production.plans p ON p.order_id = o.order_id
+        WHERE p.plan_id = $1
+        FOR UPDATE OF o`
+    var orderID int
+    if err := tx.QueryRowContext(ctx, lock, id).Scan(&orderID); err != nil && err != sql.ErrNoRows {
+        return err
+    }
+    violations, err := queryToleranceViolations(ctx, tx, id)
+    if err != nil {
+        return err
+    }
+    if len(violations) > 0 {
+        return &ToleranceViolationError{Violations: violations}
+    }
+    if _, err := tx.ExecContext(ctx, `UPDATE production.plans SET status = 'in_progress' WHERE plan_id = $1`, id); err != nil {
+        return err
+    }
+    return tx.Commit()
+}
+
+func (r *SqlPlansRepository) ToleranceViolations(ctx context.Context, id int) ([]models.ToleranceViolation, error) {
+    return queryToleranceViolations(ctx, r.db, id)
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+    QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func queryToleranceViolations(ctx context.Context, q queryer, planID int) ([]models.ToleranceViolation, error) {
+    const sel = `
+        SELECT component, color, size, ordered_qty, planned_pieces, min_qty, max_qty, kind
+        FROM production.component_tolerance_violations($1)`
+    rows, err := q.QueryContext(ctx, sel, planID)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var res []models.ToleranceViolation
+    for rows.Next() {
+        var v models.ToleranceViolation
+        if err := rows.Scan(&v.Component, &v.Color, &v.Size, &v.OrderedQty, &v.PlannedPieces, &v.MinQty, &v.MaxQty, &v.Kind); err != nil {
+            return nil, err
+        }
+        res = append(res, v)
+    }
+    return res, rows.Err()
 }
 
 func (r *SqlPlansRepository) Freeze(ctx context.Context, id int) error {
@@ -65,6 +308,77 @@ func (r *SqlPlansRepository) Freeze(ctx context.Context, id int) error {
     return err
 }
 
+func (r *SqlPlansRepository) Reopen(ctx context.Context, id int, reason string, reopenedBy *int) (*models.PlanReopening, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil { return nil, err }
+    defer tx.Rollback()
+
+    // Lock the order like Publish so the tolerance check sees concurrent plan changes
+    if _, err := tx.ExecContext(ctx, `
+        SELECT o.order_id FROM production.orders o
+        JOIN production.plans p ON p.order_id = o.order_id
+        WHERE p.plan_id = $1
+        FOR UPDATE OF o`, id); err != nil {
+        return nil, err
+    }
+    h := models.PlanReopening{PlanID: id, Reason: reason}
+    if err := tx.QueryRowContext(ctx, `SELECT status, planned_finish_date FROM production.plans WHERE plan_id = $1 FOR UPDATE`, id).
+        Scan(&h.FromStatus, &h.PreviousFinishDate); err != nil {
+        return nil, err
+    }
+    if h.FromStatus != "completed" && h.FromStatus != "frozen" {
+        return nil, fmt.Errorf("仅允许重新打开已完成或冻结的计划 (plan_id=%d, status=%s)", id, h.FromStatus)
+    }
+    // guard_plan_update only allows completed -> frozen after publish; the adjustment context bypasses it
+    if _, err := tx.ExecContext(ctx, `SET LOCAL cutrix.plan_adjustment_flag = true`); err != nil { return nil, err }
+    if _, err := tx.ExecContext(ctx, `
+        UPDATE production.plans SET status = 'in_progress', planned_finish_date = NULL WHERE plan_id = $1`, id); err != nil {
+        return nil, err
+    }
+    if h.FromStatus == "frozen" {
+        // Frozen plans are excluded from the order totals; only newly exceeded caps block the reopen
+        violations, err := queryToleranceViolations(ctx, tx, id)
+        if err != nil { return nil, err }
+        var over []models.ToleranceViolation
+        for _, v := range violations {
+            if v.Kind == "over" { over = append(over, v) }
+        }
+        if len(over) > 0 { return nil, &ToleranceViolationError{Violations: over} }
+    }
+    if err := tx.QueryRowContext(ctx, `
+        INSERT INTO production.plan_reopen_history (plan_id, from_status, reason, previous_finish_date, reopened_by, reopened_by_name)
+        VALUES ($1, $2, $3, $4, $5, (SELECT name FROM public.users WHERE user_id = $5))
+        RETURNING reopen_id, reopened_by, reopened_by_name, reopened_at`,
+        id, h.FromStatus, reason, h.PreviousFinishDate, reopenedBy,
+    ).Scan(&h.ReopenID, &h.ReopenedBy, &h.ReopenedByName, &h.ReopenedAt); err != nil {
+        return nil, err
+    }
+    if err := tx.Commit(); err != nil { return nil, err }
+    return &h, nil
+}
+
+func (r *SqlPlansRepository) ListReopenHistory(ctx context.Context, planID int) ([]models.PlanReopening, error) {
+    if err := r.db.QueryRowContext(ctx, `SELECT plan_id FROM production.plans WHERE plan_id = $1`, planID).Scan(&planID); err != nil {
+        return nil, err
+    }
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT reopen_id, plan_id, from_status, reason, previous_finish_date, reopened_by, reopened_by_name, reopened_at
+        FROM production.plan_reopen_history
+        WHERE plan_id = $1
+        ORDER BY reopen_id DESC`, planID)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    res := []models.PlanReopening{}
+    for rows.Next() {
+        var h models.PlanReopening
+        if err := rows.Scan(&h.ReopenID, &h.PlanID, &h.FromStatus, &h.Reason, &h.PreviousFinishDate, &h.ReopenedBy, &h.ReopenedByName, &h.ReopenedAt); err != nil {
+            return nil, err
+        }
+        res = append(res, h)
+    }
+    return res, rows.Err()
+}
+
 func (r *SqlPlansRepository) GetByID(ctx context.Context, id int) (*models.ProductionPlan, error) {
     const q = `
         SELECT plan_id, plan_name, order_id, note, planned_publish_date, planned_finish_date, status
@@ -129,4 +443,18 @@ func (r *SqlPlansRepository) ListByOrder(ctx context.Context, orderID int) ([]mo
         res = append(res, p)
     }
     return res, rows.Err()
+}
+
+func (r *SqlPlansRepository) ListFabricByPlan(ctx context.Context, planID int) ([]models.FabricRequirementLine, error) {
+    if err := r.db.QueryRowContext(ctx, `SELECT plan_id FROM production.plans WHERE plan_id = $1`, planID).Scan(&planID); err != nil {
+        return nil, err
+    }
+    return queryFabricLines(ctx, r.db, `p.plan_id = $1`, planID)
+}
+
+func (r *SqlPlansRepository) ListFabricByOrder(ctx context.Context, orderID int) ([]models.FabricRequirementLine, error) {
+    if err := r.db.QueryRowContext(ctx, `SELECT order_id FROM production.orders WHERE order_id = $1`, orderID).Scan(&orderID); err != nil {
+        return nil, err
+    }
+    return queryFabricLines(ctx, r.db, `p.order_id = $1`, orderID)
 }
\ No newline at end of file
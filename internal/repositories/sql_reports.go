@@ -0,0 +1,128 @@
+package repositories
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "strings"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+type SqlReportsRepository struct{ db *sql.DB }
+
+var _ ReportsRepository = (*SqlReportsRepository)(nil)
+
+func NewSqlReportsRepository(db *sql.DB) *SqlReportsRepository { return &SqlReportsRepository{db: db} }
+
+// logPiecesExpr is the number of pieces cut by a log: plies × sum of the layout's size ratios.
+const logPiecesExpr = `l.layers_completed * COALESCE((SELECT SUM(r.ratio) FROM production.layout_size_ratios r WHERE r.layout_id = t.layout_id), 0)`
+
+func (r *SqlReportsRepository) ListShiftRows(ctx context.Context, shiftDate string, customerID *int) ([]models.ShiftReportRow, error) {
+    q := `
+        SELECT l.shift_id, s.shift_name, to_char(s.start_time, 'HH24:MI'), to_char(s.end_time, 'HH24:MI'),
+               l.worker_id, COALESCE(u.name, l.worker_name), u.user_group,
+               p.plan_id, p.plan_name, o.order_number,
+               SUM(l.layers_completed)::int, SUM(` + logPiecesExpr + `)::int
+        FROM production.logs l
+        JOIN production.tasks t ON t.task_id = l.task_id
+        JOIN production.cutting_layouts cl ON cl.layout_id = t.layout_id
+        JOIN production.plans p ON p.plan_id = cl.plan_id
+        JOIN production.orders o ON o.order_id = p.order_id
+        LEFT JOIN production.shifts s ON s.shift_id = l.shift_id
+        LEFT JOIN public.users u ON u.user_id = l.worker_id
+        WHERE NOT l.voided AND l.shift_date = $1::date
+          AND ($2::int IS NULL OR o.customer_id = $2)
+        GROUP BY l.shift_id, s.shift_name, s.start_time, s.end_time, l.worker_id, COALESCE(u.name, l.worker_name), u.user_group,
+                 p.plan_id, p.plan_name, o.order_number
+        ORDER BY s.start_time ASC NULLS LAST, l.shift_id ASC, p.plan_id ASC`
+    rows, err := r.db.QueryContext(ctx, q, shiftDate, customerID)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var res []models.ShiftReportRow
+    for rows.Next() {
+        var row models.ShiftReportRow
+        if err := rows.Scan(&row.ShiftID, &row.ShiftName, &row.StartTime, &row.EndTime,
+            &row.WorkerID, &row.WorkerName, &row.UserGroup,
+            &row.PlanID, &row.PlanName, &row.OrderNumber, &row.Layers, &row.Pieces); err != nil {
+            return nil, err
+        }
+        res = append(res, row)
+    }
+    return res, rows.Err()
+}
+
+// productivityDims maps a breakdown dimension to its positions in the productivity select list.
+var productivityDims = map[string][]int{
+    "worker": {0, 1},
+    "group":  {2},
+    "layout":    {3, 4},
+    "color":     {5},
+    "component": {6},
+}
+
+func (r *SqlReportsRepository) ListProductivity(ctx context.Context, from, to string, maxGap time.Duration, dims []string, customerID *int) ([]models.ProductivityRow, error) {
+    cols := []string{"worker_id", "worker_name", "user_group", "layout_id", "layout_name", "color", "component"}
+    sel := []string{"NULL::int", "NULL::text", "NULL::text", "NULL::int", "NULL::text", "NULL::text", "NULL::text"}
+    var group []string
+    for _, d := range dims {
+        idx, ok := productivityDims[d]
+        if !ok { return nil, fmt.Errorf("unknown productivity dimension %q", d) }
+        for _, i := range idx {
+            sel[i] = cols[i]
+            group = append(group, cols[i])
+        }
+    }
+    groupBy, orderBy := "", "1"
+    if len(group) > 0 {
+        groupBy = "GROUP BY " + strings.Join(group, ", ")
+        orderBy = strings.Join(group, ", ")
+    }
+    // 间隔按工人与作废标记分区：有效日志之间的间隔不受作废日志打断
+    // 客户过滤在间隔计算之后进行，工人在其他客户订单上的日志仍参与间隔切分
+    q := `
+        WITH base AS (
+            SELECT l.worker_id, COALESCE(u.name, l.worker_name)::text AS worker_name, u.user_group::text AS user_group,
+                   cl.layout_id, cl.layout_name::text AS layout_name, t.color::text AS color, cl.component::text AS component,
+                   o.customer_id, l.voided, l.layers_completed, ` + logPiecesExpr + ` AS pieces,
+                   EXTRACT(EPOCH FROM l.log_time - LAG(l.log_time) OVER (
+                       PARTITION BY COALESCE(l.worker_id::text, 'name:' || l.worker_name), l.voided
+                       ORDER BY l.log_time, l.log_id)) / 3600.0 AS gap_hours
+            FROM production.logs l
+            JOIN production.tasks t ON t.task_id = l.task_id
+            JOIN production.cutting_layouts cl ON cl.layout_id = t.layout_id
+            JOIN production.plans p ON p.plan_id = cl.plan_id
+            JOIN production.orders o ON o.order_id = p.order_id
+            LEFT JOIN public.users u ON u.user_id = l.worker_id
+            WHERE l.shift_date BETWEEN $1::date AND $2::date
+              AND (l.worker_id IS NOT NULL OR l.worker_name IS NOT NULL)
+        ), rated AS (
+            SELECT *, (NOT voided AND gap_hours > 0 AND gap_hours <= $3) AS active
+            FROM base
+        )
+        SELECT ` + strings.Join(sel, ", ") + `,
+               COUNT(*)::int, (COUNT(*) FILTER (WHERE voided))::int,
+               COALESCE(SUM(layers_completed) FILTER (WHERE NOT voided), 0)::int,
+               COALESCE(SUM(pieces) FILTER (WHERE NOT voided), 0)::int,
+               COALESCE(SUM(layers_completed) FILTER (WHERE active), 0)::int,
+               COALESCE(SUM(pieces) FILTER (WHERE active), 0)::int,
+               COALESCE(SUM(gap_hours) FILTER (WHERE active), 0)::float8
+        FROM rated
+        WHERE ($4::int IS NULL OR customer_id = $4)
+        ` + groupBy + `
+        ORDER BY ` + orderBy
+    rows, err := r.db.QueryContext(ctx, q, from, to, maxGap.Hours(), customerID)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var res []models.ProductivityRow
+    for rows.Next() {
+        var row models.ProductivityRow
+        if err := rows.Scan(&row.WorkerID, &row.WorkerName, &row.UserGroup, &row.LayoutID, &row.LayoutName, &row.Color, &row.Component,
+            &row.Logs, &row.VoidedLogs, &row.Layers, &row.Pieces, &row.RatedLayers, &row.RatedPieces, &row.ActiveHours); err != nil {
+            return nil, err
+        }
+        res = append(res, row)
+    }
+    return res, rows.Err()
+}
@@ -0,0 +1,142 @@
+package repositories
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "strings"
+
+    "cutrix-backend/internal/models"
+)
+
+type SqlRollsRepository struct{ db *sql.DB }
+
+var _ RollsRepository = (*SqlRollsRepository)(nil)
+
+func NewSqlRollsRepository(db *sql.DB) *SqlRollsRepository { return &SqlRollsRepository{db: db} }
+
+const rollColumns = `roll_id, roll_code, color, dye_lot, fabric_width::float8, received_length::float8,
+    remaining_length::float8, remnant_length::float8, status, note, received_at, closed_at`
+
+func scanRoll(s scanner) (*models.FabricRoll, error) {
+    var r models.FabricRoll
+    if err := s.Scan(&r.RollID, &r.RollCode, &r.Color, &r.DyeLot, &r.FabricWidth, &r.ReceivedLength,
+        &r.RemainingLength, &r.RemnantLength, &r.Status, &r.Note, &r.ReceivedAt, &r.ClosedAt); err != nil {
+        return nil, err
+    }
+    return &r, nil
+}
+
+func (r *SqlRollsRepository) Create(ctx context.Context, roll *models.FabricRoll) (int, error) {
+    const q = `
+        INSERT INTO production.fabric_rolls (roll_code, color, dye_lot, fabric_width, received_length, remaining_length, note)
+        VALUES ($1, $2, $3, $4, $5, $5, $6)
+        RETURNING roll_id, remaining_length::float8, status, received_at`
+    err := r.db.QueryRowContext(ctx, q, roll.RollCode, roll.Color, roll.DyeLot, roll.FabricWidth, roll.ReceivedLength, roll.Note).
+        Scan(&roll.RollID, &roll.RemainingLength, &roll.Status, &roll.ReceivedAt)
+    return roll.RollID, err
+}
+
+func (r *SqlRollsRepository) Delete(ctx context.Context, id int) error {
+    // Pre-check: rolls with usage records keep the audit trail
+    var used bool
+    if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM production.log_rolls WHERE roll_id = $1)`, id).Scan(&used); err != nil {
+        return err
+    }
+    if used {
+        return fmt.Errorf("布卷已有领用记录，不允许删除 (roll_id=%d)", id)
+    }
+    res, err := r.db.ExecContext(ctx, `DELETE FROM production.fabric_rolls WHERE roll_id = $1`, id)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
+func (r *SqlRollsRepository) UpdateNote(ctx context.Context, id int, note *string) error {
+    res, err := r.db.ExecContext(ctx, `UPDATE production.fabric_rolls SET note = $1 WHERE roll_id = $2`, note, id)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
+func (r *SqlRollsRepository) Close(ctx context.Context, id int, remnant float64, note *string) error {
+    // Pre-check: only open rolls can be closed
+    roll, err := r.GetByID(ctx, id)
+    if err != nil { return err }
+    if roll.Status == "closed" {
+        return fmt.Errorf("布卷已结卷 (roll_id=%d)", id)
+    }
+    const q = `
+        UPDATE production.fabric_rolls
+        SET status = 'closed', remnant_length = $1, note = COALESCE($2, note)
+        WHERE roll_id = $3`
+    _, err = r.db.ExecContext(ctx, q, remnant, note, id)
+    return err
+}
+
+func (r *SqlRollsRepository) GetByID(ctx context.Context, id int) (*models.FabricRoll, error) {
+    return scanRoll(r.db.QueryRowContext(ctx, `SELECT `+rollColumns+` FROM production.fabric_rolls WHERE roll_id = $1`, id))
+}
+
+func (r *SqlRollsRepository) GetByCode(ctx context.Context, code string) (*models.FabricRoll, error) {
+    return scanRoll(r.db.QueryRowContext(ctx, `SELECT `+rollColumns+` FROM production.fabric_rolls WHERE roll_code = $1`, code))
+}
+
+func (r *SqlRollsRepository) List(ctx context.Context, filter models.RollFilter) ([]models.FabricRoll, error) {
+    var where []string
+    var args []any
+    if filter.Color != nil {
+        args = append(args, *filter.Color)
+        where = append(where, fmt.Sprintf("color = $%d", len(args)))
+    }
+    if filter.DyeLot != nil {
+        args = append(args, *filter.DyeLot)
+        where = append(where, fmt.Sprintf("dye_lot = $%d", len(args)))
+    }
+    if filter.Status != nil {
+        args = append(args, *filter.Status)
+        where = append(where, fmt.Sprintf("status = $%d", len(args)))
+    }
+    q := `SELECT ` + rollColumns + ` FROM production.fabric_rolls`
+    if len(where) > 0 {
+        q += ` WHERE ` + strings.Join(where, " AND ")
+    }
+    q += ` ORDER BY roll_id DESC`
+    rows, err := r.db.QueryContext(ctx, q, args...)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var res []models.FabricRoll
+    for rows.Next() {
+        roll, err := scanRoll(rows)
+        if err != nil { return nil, err }
+        res = append(res, *roll)
+    }
+    return res, rows.Err()
+}
+
+func (r *SqlRollsRepository) ListUsage(ctx context.Context, rollID int) ([]models.LogRollUsage, error) {
+    const q = `
+        SELECT lr.log_id, lr.roll_id, fr.roll_code, l.task_id, lr.layers, lr.length_used::float8, l.voided
+        FROM production.log_rolls lr
+        JOIN production.fabric_rolls fr ON fr.roll_id = lr.roll_id
+        JOIN production.logs l ON l.log_id = lr.log_id
+        WHERE lr.roll_id = $1
+        ORDER BY l.log_time ASC, lr.log_id ASC`
+    return queryRollUsage(ctx, r.db, q, rollID)
+}
+
+// queryRollUsage scans rows of (log_id, roll_id, roll_code, task_id, layers, length_used, voided).
+func queryRollUsage(ctx context.Context, q queryer, sel string, arg any) ([]models.LogRollUsage, error) {
+    rows, err := q.QueryContext(ctx, sel, arg)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var res []models.LogRollUsage
+    for rows.Next() {
+        var u models.LogRollUsage
+        if err := rows.Scan(&u.LogID, &u.RollID, &u.RollCode, &u.TaskID, &u.Layers, &u.LengthUsed, &u.Voided); err != nil {
+            return nil, err
+        }
+        res = append(res, u)
+    }
+    return res, rows.Err()
+}
\ No newline at end of file
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+    "context"
+    "database/sql"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+type SqlScheduleRepository struct{ db *sql.DB }
+
+var _ ScheduleRepository = (*SqlScheduleRepository)(nil)
+
+func NewSqlScheduleRepository(db *sql.DB) *SqlScheduleRepository { return &SqlScheduleRepository{db: db} }
+
+func (r *SqlScheduleRepository) ListOpenTasks(ctx context.Context) ([]models.ScheduleTask, error) {
+    const q = `
+        SELECT t.task_id, p.plan_id, l.layout_id, l.layout_name, o.order_number, o.style_number, t.color,
+               t.planned_layers, t.completed_layers, t.table_id, l.marker_length::float8,
+               p.planned_finish_date, p.planned_publish_date
+        FROM production.tasks t
+        JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+        JOIN production.plans p ON p.plan_id = l.plan_id
+        JOIN production.orders o ON o.order_id = p.order_id
+        WHERE p.status = 'in_progress' AND t.status = 'in_progress' AND t.completed_layers < t.planned_layers
+        ORDER BY t.task_id ASC`
+    rows, err := r.db.QueryContext(ctx, q)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var res []models.ScheduleTask
+    for rows.Next() {
+        var t models.ScheduleTask
+        if err := rows.Scan(&t.TaskID, &t.PlanID, &t.LayoutID, &t.LayoutName, &t.OrderNumber, &t.StyleNumber, &t.Color,
+            &t.PlannedLayers, &t.CompletedLayers, &t.TableID, &t.MarkerLength,
+            &t.PlannedFinishDate, &t.PublishedAt); err != nil {
+            return nil, err
+        }
+        res = append(res, t)
+    }
+    return res, rows.Err()
+}
+
+func (r *SqlScheduleRepository) ListLayerRates(ctx context.Context, since time.Time, maxGap time.Duration) ([]models.LayerRate, error) {
+    const q = `
+        WITH seq AS (
+            SELECT l.table_id, l.layers_completed,
+                   EXTRACT(EPOCH FROM l.log_time - LAG(l.log_time) OVER (PARTITION BY l.task_id ORDER BY l.log_time, l.log_id)) / 3600.0 AS gap_hours
+            FROM production.logs l
+            WHERE NOT l.voided AND l.log_time >= $1
+        )
+        SELECT table_id, SUM(layers_completed)::int, SUM(gap_hours)::float8
+        FROM seq
+        WHERE gap_hours > 0 AND gap_hours <= $2
+        GROUP BY table_id`
+    rows, err := r.db.QueryContext(ctx, q, since, maxGap.Hours())
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var res []models.LayerRate
+    for rows.Next() {
+        var lr models.LayerRate
+        if err := rows.Scan(&lr.TableID, &lr.Layers, &lr.Hours); err != nil { return nil, err }
+        res = append(res, lr)
+    }
+    return res, rows.Err()
+}
@@ -0,0 +1,103 @@
+package repositories
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "cutrix-backend/internal/models"
+)
+
+type SqlShiftsRepository struct{ db *sql.DB }
+
+var _ ShiftsRepository = (*SqlShiftsRepository)(nil)
+
+func NewSqlShiftsRepository(db *sql.DB) *SqlShiftsRepository { return &SqlShiftsRepository{db: db} }
+
+const shiftSelect = `
+    SELECT shift_id, shift_name, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI'),
+           is_active, note, created_at, end_time <= start_time
+    FROM production.shifts`
+
+func scanShift(s scanner) (*models.Shift, error) {
+    var sh models.Shift
+    if err := s.Scan(&sh.ShiftID, &sh.ShiftName, &sh.StartTime, &sh.EndTime,
+        &sh.IsActive, &sh.Note, &sh.CreatedAt, &sh.CrossesMidnight); err != nil {
+        return nil, err
+    }
+    return &sh, nil
+}
+
+func (r *SqlShiftsRepository) Create(ctx context.Context, shift *models.Shift) (int, error) {
+    const q = `
+        INSERT INTO production.shifts (shift_name, start_time, end_time, is_active, note)
+        VALUES ($1, $2::time, $3::time, $4, $5)
+        RETURNING shift_id, created_at, end_time <= start_time`
+    err := r.db.QueryRowContext(ctx, q, shift.ShiftName, shift.StartTime, shift.EndTime, shift.IsActive, shift.Note).
+        Scan(&shift.ShiftID, &shift.CreatedAt, &shift.CrossesMidnight)
+    return shift.ShiftID, err
+}
+
+func (r *SqlShiftsRepository) Delete(ctx context.Context, id int) error {
+    // Pre-check: attributed logs keep a reference to the shift
+    var used bool
+    if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM production.logs WHERE shift_id = $1)`, id).Scan(&used); err != nil {
+        return err
+    }
+    if used {
+        return fmt.Errorf("班次已有归属日志，不允许删除，请停用 (shift_id=%d)", id)
+    }
+    res, err := r.db.ExecContext(ctx, `DELETE FROM production.shifts WHERE shift_id = $1`, id)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
+func (r *SqlShiftsRepository) Update(ctx context.Context, shift *models.Shift) error {
+    const q = `
+        UPDATE production.shifts
+        SET shift_name = $1, start_time = $2::time, end_time = $3::time, is_active = $4, note = $5
+        WHERE shift_id = $6`
+    res, err := r.db.ExecContext(ctx, q, shift.ShiftName, shift.StartTime, shift.EndTime, shift.IsActive, shift.Note, shift.ShiftID)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
+func (r *SqlShiftsRepository) SetTimezone(ctx context.Context, timezone string) (*models.FactorySettings, error) {
+    const q = `
+        UPDATE production.factory_settings SET timezone = $1 WHERE singleton
+        RETURNING timezone, updated_at`
+    var s models.FactorySettings
+    if err := r.db.QueryRowContext(ctx, q, timezone).Scan(&s.Timezone, &s.UpdatedAt); err != nil { return nil, err }
+    return &s, nil
+}
+
+func (r *SqlShiftsRepository) GetByID(ctx context.Context, id int) (*models.Shift, error) {
+    return scanShift(r.db.QueryRowContext(ctx, shiftSelect+` WHERE shift_id = $1`, id))
+}
+
+func (r *SqlShiftsRepository) GetByName(ctx context.Context, name string) (*models.Shift, error) {
+    return scanShift(r.db.QueryRowContext(ctx, shiftSelect+` WHERE shift_name = $1`, name))
+}
+
+func (r *SqlShiftsRepository) List(ctx context.Context, activeOnly bool) ([]models.Shift, error) {
+    rows, err := r.db.QueryContext(ctx, shiftSelect+` WHERE (NOT $1 OR is_active) ORDER BY start_time ASC, shift_id ASC`, activeOnly)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    res := []models.Shift{}
+    for rows.Next() {
+        sh, err := scanShift(rows)
+        if err != nil { return nil, err }
+        res = append(res, *sh)
+    }
+    return res, rows.Err()
+}
+
+func (r *SqlShiftsRepository) GetSettings(ctx context.Context) (*models.FactorySettings, error) {
+    var s models.FactorySettings
+    err := r.db.QueryRowContext(ctx, `SELECT timezone, updated_at FROM production.factory_settings WHERE singleton`).
+        Scan(&s.Timezone, &s.UpdatedAt)
+    if err != nil { return nil, err }
+    return &s, nil
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+
+    "cutrix-backend/internal/models"
+)
+
+type SqlStylesRepository struct{ db *sql.DB }
+
+var _ StylesRepository = (*SqlStylesRepository)(nil)
+
+func NewSqlStylesRepository(db *sql.DB) *SqlStylesRepository { return &SqlStylesRepository{db: db} }
+
+// styleSelect aggregates the size run, colors and components in list order, plus the number of orders of the style.
+const styleSelect = `
+    SELECT s.style_id, s.style_number, s.style_name, s.note, s.created_at, s.updated_at,
+        COALESCE((SELECT jsonb_agg(ss.size ORDER BY ss.sort_order) FROM production.style_sizes ss WHERE ss.style_id = s.style_id), '[]'),
+        COALESCE((SELECT jsonb_agg(sc.color ORDER BY sc.sort_order) FROM production.style_colors sc WHERE sc.style_id = s.style_id), '[]'),
+        COALESCE((SELECT jsonb_agg(jsonb_build_object('component_name', cp.component_name, 'pieces', cp.pieces) ORDER BY cp.sort_order)
+                  FROM production.style_components cp WHERE cp.style_id = s.style_id), '[]'),
+        (SELECT COUNT(*) FROM production.orders o WHERE o.style_number = s.style_number)
+    FROM production.styles s`
+
+func scanStyle(s scanner) (*models.Style, error) {
+    var st models.Style
+    var sizes, colors, components []byte
+    if err := s.Scan(&st.StyleID, &st.StyleNumber, &st.StyleName, &st.Note, &st.CreatedAt, &st.UpdatedAt,
+        &sizes, &colors, &components, &st.Orders); err != nil {
+        return nil, err
+    }
+    if err := json.Unmarshal(sizes, &st.Sizes); err != nil { return nil, err }
+    if err := json.Unmarshal(colors, &st.Colors); err != nil { return nil, err }
+    if err := json.Unmarshal(components, &st.Components); err != nil { return nil, err }
+    return &st, nil
+}
+
+func (r *SqlStylesRepository) Create(ctx context.Context, style *models.Style) (int, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil { return 0, err }
+    defer tx.Rollback()
+
+    if err := tx.QueryRowContext(ctx, `
+        INSERT INTO production.styles (style_number, style_name, note) VALUES ($1, $2, $3)
+        RETURNING style_id, created_at, updated_at`, style.StyleNumber, style.StyleName, style.Note).
+        Scan(&style.StyleID, &style.CreatedAt, &style.UpdatedAt); err != nil {
+        return 0, err
+    }
+    if err := insertStyleDetails(ctx, tx, style); err != nil { return 0, err }
+    return style.StyleID, tx.Commit()
+}
+
+func (r *SqlStylesRepository) Delete(ctx context.Context, id int) error {
+    res, err := r.db.ExecContext(ctx, `DELETE FROM production.styles WHERE style_id = $1`, id)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
+func (r *SqlStylesRepository) Update(ctx context.Context, style *models.Style) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil { return err }
+    defer tx.Rollback()
+
+    res, err := tx.ExecContext(ctx, `UPDATE production.styles SET style_name = $1, note = $2 WHERE style_id = $3`,
+        style.StyleName, style.Note, style.StyleID)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    for _, table := range []string{"style_sizes", "style_colors", "style_components"} {
+        if _, err := tx.ExecContext(ctx, `DELETE FROM production.`+table+` WHERE style_id = $1`, style.StyleID); err != nil {
+            return err
+        }
+    }
+    if err := insertStyleDetails(ctx, tx, style); err != nil { return err }
+    return tx.Commit()
+}
+
+// insertStyleDetails writes the size run, colors and components with sort_order following list order.
+func insertStyleDetails(ctx context.Context, tx *sql.Tx, style *models.Style) error {
+    for i, size := range style.Sizes {
+        if _, err := tx.ExecContext(ctx, `INSERT INTO production.style_sizes (style_id, size, sort_order) VALUES ($1, $2, $3)`,
+            style.StyleID, size, i+1); err != nil {
+            return err
+        }
+    }
+    for i, color := range style.Colors {
+        if _, err := tx.ExecContext(ctx, `INSERT INTO production.style_colors (style_id, color, sort_order) VALUES ($1, $2, $3)`,
+            style.StyleID, color, i+1); err != nil {
+            return err
+        }
+    }
+    for i, c := range style.Components {
+        if _, err := tx.ExecContext(ctx,
+            `INSERT INTO production.style_components (style_id, component_name, pieces, sort_order) VALUES ($1, $2, $3, $4)`,
+            style.StyleID, c.ComponentName, c.Pieces, i+1); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (r *SqlStylesRepository) GetByID(ctx context.Context, id int) (*models.Style, error) {
+    return scanStyle(r.db.QueryRowContext(ctx, styleSelect+` WHERE s.style_id = $1`, id))
+}
+
+func (r *SqlStylesRepository) GetByNumber(ctx context.Context, number string) (*models.Style, error) {
+    return scanStyle(r.db.QueryRowContext(ctx, styleSelect+` WHERE s.style_number = $1`, number))
+}
+
+func (r *SqlStylesRepository) List(ctx context.Context, query string) ([]models.Style, error) {
+    rows, err := r.db.QueryContext(ctx, styleSelect+`
+        WHERE ($1 = '' OR s.style_number ILIKE '%' || $1 || '%' OR s.style_name ILIKE '%' || $1 || '%')
+        ORDER BY s.style_number ASC`, query)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    res := []models.Style{}
+    for rows.Next() {
+        st, err := scanStyle(rows)
+        if err != nil { return nil, err }
+        res = append(res, *st)
+    }
+    return res, rows.Err()
+}
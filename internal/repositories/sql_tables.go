@@ -0,0 +1,117 @@
+package repositories
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "cutrix-backend/internal/models"
+)
+
+type SqlTablesRepository struct{ db *sql.DB }
+
+var _ TablesRepository = (*SqlTablesRepository)(nil)
+
+func NewSqlTablesRepository(db *sql.DB) *SqlTablesRepository { return &SqlTablesRepository{db: db} }
+
+// tableSelect derives the current assignment: the open task on the table, in-progress first.
+const tableSelect = `
+    SELECT ct.table_id, ct.table_name, ct.usable_length::float8, ct.status, ct.note, ct.created_at,
+        (SELECT t.task_id FROM production.tasks t
+         WHERE t.table_id = ct.table_id AND t.status <> 'completed'
+         ORDER BY (t.status = 'in_progress') DESC, t.task_id ASC LIMIT 1),
+        (SELECT COUNT(*) FROM production.tasks t WHERE t.table_id = ct.table_id AND t.status <> 'completed')
+    FROM production.cutting_tables ct`
+
+func scanTable(s scanner) (*models.CuttingTable, error) {
+    var t models.CuttingTable
+    if err := s.Scan(&t.TableID, &t.TableName, &t.UsableLength, &t.Status, &t.Note, &t.CreatedAt,
+        &t.CurrentTaskID, &t.OpenTasks); err != nil {
+        return nil, err
+    }
+    return &t, nil
+}
+
+func (r *SqlTablesRepository) Create(ctx context.Context, table *models.CuttingTable) (int, error) {
+    const q = `
+        INSERT INTO production.cutting_tables (table_name, usable_length, status, note)
+        VALUES ($1, $2, $3, $4)
+        RETURNING table_id, created_at`
+    err := r.db.QueryRowContext(ctx, q, table.TableName, table.UsableLength, table.Status, table.Note).
+        Scan(&table.TableID, &table.CreatedAt)
+    return table.TableID, err
+}
+
+func (r *SqlTablesRepository) Delete(ctx context.Context, id int) error {
+    // Pre-check: assigned tasks and logs keep a reference to the table
+    var used bool
+    const q = `
+        SELECT EXISTS (SELECT 1 FROM production.tasks WHERE table_id = $1)
+            OR EXISTS (SELECT 1 FROM production.logs WHERE table_id = $1)`
+    if err := r.db.QueryRowContext(ctx, q, id).Scan(&used); err != nil { return err }
+    if used {
+        return fmt.Errorf("裁床已分配任务或已有拉布日志，不允许删除 (table_id=%d)", id)
+    }
+    res, err := r.db.ExecContext(ctx, `DELETE FROM production.cutting_tables WHERE table_id = $1`, id)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
+func (r *SqlTablesRepository) Update(ctx context.Context, table *models.CuttingTable) error {
+    const q = `
+        UPDATE production.cutting_tables
+        SET table_name = $1, usable_length = $2, note = $3
+        WHERE table_id = $4`
+    res, err := r.db.ExecContext(ctx, q, table.TableName, table.UsableLength, table.Note, table.TableID)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
+func (r *SqlTablesRepository) UpdateStatus(ctx context.Context, id int, status string) error {
+    res, err := r.db.ExecContext(ctx, `UPDATE production.cutting_tables SET status = $1 WHERE table_id = $2`, status, id)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
+func (r *SqlTablesRepository) GetByID(ctx context.Context, id int) (*models.CuttingTable, error) {
+    return scanTable(r.db.QueryRowContext(ctx, tableSelect+` WHERE ct.table_id = $1`, id))
+}
+
+func (r *SqlTablesRepository) GetByName(ctx context.Context, name string) (*models.CuttingTable, error) {
+    return scanTable(r.db.QueryRowContext(ctx, tableSelect+` WHERE ct.table_name = $1`, name))
+}
+
+func (r *SqlTablesRepository) List(ctx context.Context, status string) ([]models.CuttingTable, error) {
+    rows, err := r.db.QueryContext(ctx, tableSelect+` WHERE ($1 = '' OR ct.status = $1) ORDER BY ct.table_name ASC`, status)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    res := []models.CuttingTable{}
+    for rows.Next() {
+        t, err := scanTable(rows)
+        if err != nil { return nil, err }
+        res = append(res, *t)
+    }
+    return res, rows.Err()
+}
+
+func (r *SqlTablesRepository) ListTasks(ctx context.Context, tableID int) ([]models.ProductionTask, error) {
+    var exists bool
+    if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM production.cutting_tables WHERE table_id = $1)`, tableID).Scan(&exists); err != nil {
+        return nil, err
+    }
+    if !exists { return nil, sql.ErrNoRows }
+    q := `SELECT ` + taskColumns + ` FROM production.tasks WHERE table_id = $1 ORDER BY task_id ASC`
+    rows, err := r.db.QueryContext(ctx, q, tableID)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    res := []models.ProductionTask{}
+    for rows.Next() {
+        t, err := scanTask(rows)
+        if err != nil { return nil, err }
+        res = append(res, *t)
+    }
+    return res, rows.Err()
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "cutrix-backend/internal/models"
+)
+
+type SqlTaskAssignmentsRepository struct{ db *sql.DB }
+
+var _ TaskAssignmentsRepository = (*SqlTaskAssignmentsRepository)(nil)
+
+func NewSqlTaskAssignmentsRepository(db *sql.DB) *SqlTaskAssignmentsRepository {
+    return &SqlTaskAssignmentsRepository{db: db}
+}
+
+const assignmentSelect = `
+    SELECT a.assignment_id, a.task_id, a.user_id, u.name, a.user_group,
+           a.assigned_by, a.assigned_at, a.unassigned_by, a.unassigned_at
+    FROM production.task_assignments a
+    LEFT JOIN public.users u ON u.user_id = a.user_id`
+
+func scanAssignment(s scanner) (*models.TaskAssignment, error) {
+    var a models.TaskAssignment
+    if err := s.Scan(&a.AssignmentID, &a.TaskID, &a.UserID, &a.UserName, &a.UserGroup,
+        &a.AssignedBy, &a.AssignedAt, &a.UnassignedBy, &a.UnassignedAt); err != nil {
+        return nil, err
+    }
+    return &a, nil
+}
+
+func (r *SqlTaskAssignmentsRepository) Assign(ctx context.Context, taskID int, userIDs []int, group *string, assignedBy *int) ([]models.TaskAssignment, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil { return nil, err }
+    defer tx.Rollback()
+
+    var exists int
+    if err := tx.QueryRowContext(ctx, `SELECT 1 FROM production.tasks WHERE task_id = $1`, taskID).Scan(&exists); err != nil {
+        return nil, err
+    }
+    for _, uid := range userIDs {
+        // Pre-check: only active users can be assigned
+        var active bool
+        err := tx.QueryRowContext(ctx, `SELECT is_active FROM public.users WHERE user_id = $1`, uid).Scan(&active)
+        if err == sql.ErrNoRows || (err == nil && !active) {
+            return nil, fmt.Errorf("用户不存在或已停用 (user_id=%d)", uid)
+        }
+        if err != nil { return nil, err }
+        const q = `
+            INSERT INTO production.task_assignments (task_id, user_id, assigned_by)
+            VALUES ($1, $2, $3)
+            ON CONFLICT (task_id, user_id) WHERE unassigned_at IS NULL AND user_id IS NOT NULL DO NOTHING`
+        if _, err := tx.ExecContext(ctx, q, taskID, uid, assignedBy); err != nil { return nil, err }
+    }
+    if group != nil {
+        const q = `
+            INSERT INTO production.task_assignments (task_id, user_group, assigned_by)
+            VALUES ($1, $2, $3)
+            ON CONFLICT (task_id, user_group) WHERE unassigned_at IS NULL AND user_group IS NOT NULL DO NOTHING`
+        if _, err := tx.ExecContext(ctx, q, taskID, *group, assignedBy); err != nil { return nil, err }
+    }
+    if err := tx.Commit(); err != nil { return nil, err }
+    return r.ListByTask(ctx, taskID, false)
+}
+
+func (r *SqlTaskAssignmentsRepository) Unassign(ctx context.Context, taskID, assignmentID int, unassignedBy *int) error {
+    const q = `
+        UPDATE production.task_assignments
+        SET unassigned_at = CURRENT_TIMESTAMP, unassigned_by = $3
+        WHERE assignment_id = $1 AND task_id = $2 AND unassigned_at IS NULL`
+    res, err := r.db.ExecContext(ctx, q, assignmentID, taskID, unassignedBy)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
+func (r *SqlTaskAssignmentsRepository) ListByTask(ctx context.Context, taskID int, includeHistory bool) ([]models.TaskAssignment, error) {
+    q := assignmentSelect + ` WHERE a.task_id = $1 AND ($2 OR a.unassigned_at IS NULL) ORDER BY a.assigned_at ASC, a.assignment_id ASC`
+    rows, err := r.db.QueryContext(ctx, q, taskID, includeHistory)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    res := []models.TaskAssignment{}
+    for rows.Next() {
+        a, err := scanAssignment(rows)
+        if err != nil { return nil, err }
+        res = append(res, *a)
+    }
+    return res, rows.Err()
+}
+
+func (r *SqlTaskAssignmentsRepository) IsAssignee(ctx context.Context, taskID, userID int) (bool, error) {
+    const q = `
+        SELECT EXISTS (
+            SELECT 1
+            FROM production.task_assignments a
+            JOIN public.users u ON u.user_id = $2
+            WHERE a.task_id = $1 AND a.unassigned_at IS NULL
+              AND (a.user_id = u.user_id OR (a.user_group IS NOT NULL AND a.user_group = u.user_group))
+        )`
+    var ok bool
+    err := r.db.QueryRowContext(ctx, q, taskID, userID).Scan(&ok)
+    return ok, err
+}
@@ -4,6 +4,7 @@ import (
     "context"
     "database/sql"
     "fmt"
+    "sort"
 
     "cutrix-backend/internal/models"
 )
@@ -14,6 +15,16 @@ var _ TasksRepository = (*SqlTasksRepository)(nil)
 
 func NewSqlTasksRepository(db *sql.DB) *SqlTasksRepository { return &SqlTasksRepository{db: db} }
 
+const taskColumns = `task_id, layout_id, color, planned_layers, completed_layers, status, table_id`
+
+func scanTask(s scanner) (*models.ProductionTask, error) {
+    var t models.ProductionTask
+    if err := s.Scan(&t.TaskID, &t.LayoutID, &t.Color, &t.PlannedLayers, &t.CompletedLayers, &t.Status, &t.TableID); err != nil {
+        return nil, err
+    }
+    return &t, nil
+}
+
 // planStatusByLayout returns the parent plan status for a given layout.
 func (r *SqlTasksRepository) planStatusByLayout(ctx context.Context, layoutID int) (string, error) {
     const q = `
@@ -43,11 +54,11 @@ func (r *SqlTasksRepository) Create(ctx context.Context, task *models.Production
     }
 
     const q = `
-        INSERT INTO production.tasks (layout_id, color, planned_layers)
-        VALUES ($1, $2, $3)
+        INSERT INTO production.tasks (layout_id, color, planned_layers, table_id)
+        VALUES ($1, $2, $3, $4)
         RETURNING task_id, completed_layers, status`
     var id int
-    err = r.db.QueryRowContext(ctx, q, task.LayoutID, task.Color, task.PlannedLayers).
+    err = r.db.QueryRowContext(ctx, q, task.LayoutID, task.Color, task.PlannedLayers, task.TableID).
         Scan(&id, &task.CompletedLayers, &task.Status)
     if err == nil { task.TaskID = id }
     return id, err
@@ -82,44 +93,230 @@ func (r *SqlTasksRepository) UpdateStatus(ctx context.Context, id int, status st
     return err
 }
 
+func (r *SqlTasksRepository) AssignTable(ctx context.Context, id int, tableID *int) error {
+    res, err := r.db.ExecContext(ctx, `UPDATE production.tasks SET table_id = $1 WHERE task_id = $2`, tableID, id)
+    if err != nil { return err }
+    if n, _ := res.RowsAffected(); n == 0 { return sql.ErrNoRows }
+    return nil
+}
+
 func (r *SqlTasksRepository) GetByID(ctx context.Context, id int) (*models.ProductionTask, error) {
-    const q = `
-        SELECT task_id, layout_id, color, planned_layers, completed_layers, status
-        FROM production.tasks WHERE task_id = $1`
-    row := r.db.QueryRowContext(ctx, q, id)
-    var t models.ProductionTask
-    if err := row.Scan(&t.TaskID, &t.LayoutID, &t.Color, &t.PlannedLayers, &t.CompletedLayers, &t.Status); err != nil { return nil, err }
-    return &t, nil
+    q := `SELECT ` + taskColumns + ` FROM production.tasks WHERE task_id = $1`
+    return scanTask(r.db.QueryRowContext(ctx, q, id))
 }
 
 func (r *SqlTasksRepository) List(ctx context.Context) ([]models.ProductionTask, error) {
-    const q = `
-        SELECT task_id, layout_id, color, planned_layers, completed_layers, status
-        FROM production.tasks ORDER BY task_id DESC`
-    rows, err := r.db.QueryContext(ctx, q)
+    q := `SELECT ` + taskColumns + ` FROM production.tasks ORDER BY task_id DESC`
+    return r.queryTasks(ctx, q)
+}
+
+func (r *SqlTasksRepository) ListByLayout(ctx context.Context, layoutID int) ([]models.ProductionTask, error) {
+    q := `SELECT ` + taskColumns + ` FROM production.tasks WHERE layout_id = $1 ORDER BY task_id ASC`
+    return r.queryTasks(ctx, q, layoutID)
+}
+
+func (r *SqlTasksRepository) ListAssigned(ctx context.Context, userID int) ([]models.ProductionTask, error) {
+    q := `SELECT ` + taskColumns + ` FROM production.tasks t
+        WHERE EXISTS (
+            SELECT 1
+            FROM production.task_assignments a
+            JOIN public.users u ON u.user_id = $1
+            WHERE a.task_id = t.task_id AND a.unassigned_at IS NULL
+              AND (a.user_id = u.user_id OR (a.user_group IS NOT NULL AND a.user_group = u.user_group))
+        )
+        ORDER BY task_id DESC`
+    return r.queryTasks(ctx, q, userID)
+}
+
+func (r *SqlTasksRepository) queryTasks(ctx context.Context, q string, args ...any) ([]models.ProductionTask, error) {
+    rows, err := r.db.QueryContext(ctx, q, args...)
     if err != nil { return nil, err }
     defer rows.Close()
     var res []models.ProductionTask
     for rows.Next() {
-        var t models.ProductionTask
-        if err := rows.Scan(&t.TaskID, &t.LayoutID, &t.Color, &t.PlannedLayers, &t.CompletedLayers, &t.Status); err != nil { return nil, err }
-        res = append(res, t)
+        t, err := scanTask(rows)
+        if err != nil { return nil, err }
+        res = append(res, *t)
     }
     return res, rows.Err()
 }
 
-func (r *SqlTasksRepository) ListByLayout(ctx context.Context, layoutID int) ([]models.ProductionTask, error) {
+func (r *SqlTasksRepository) ListLots(ctx context.Context, taskID int) ([]models.TaskLotLayers, error) {
     const q = `
-        SELECT task_id, layout_id, color, planned_layers, completed_layers, status
-        FROM production.tasks WHERE layout_id = $1 ORDER BY task_id ASC`
-    rows, err := r.db.QueryContext(ctx, q, layoutID)
+        SELECT task_id, dye_lot, completed_layers
+        FROM production.task_lot_layers WHERE task_id = $1 ORDER BY dye_lot ASC`
+    return r.queryLots(ctx, q, taskID)
+}
+
+func (r *SqlTasksRepository) ListLotsByPlan(ctx context.Context, planID int) ([]models.TaskLotLayers, error) {
+    const q = `
+        SELECT tl.task_id, tl.dye_lot, tl.completed_layers
+        FROM production.task_lot_layers tl
+        JOIN production.tasks t ON t.task_id = tl.task_id
+        JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+        WHERE l.plan_id = $1
+        ORDER BY tl.task_id ASC, tl.dye_lot ASC`
+    return r.queryLots(ctx, q, planID)
+}
+
+func (r *SqlTasksRepository) queryLots(ctx context.Context, q string, arg any) ([]models.TaskLotLayers, error) {
+    rows, err := r.db.QueryContext(ctx, q, arg)
     if err != nil { return nil, err }
     defer rows.Close()
-    var res []models.ProductionTask
+    res := []models.TaskLotLayers{}
     for rows.Next() {
-        var t models.ProductionTask
-        if err := rows.Scan(&t.TaskID, &t.LayoutID, &t.Color, &t.PlannedLayers, &t.CompletedLayers, &t.Status); err != nil { return nil, err }
-        res = append(res, t)
+        var tl models.TaskLotLayers
+        if err := rows.Scan(&tl.TaskID, &tl.DyeLot, &tl.CompletedLayers); err != nil { return nil, err }
+        res = append(res, tl)
     }
     return res, rows.Err()
-}
\ No newline at end of file
+}
+// lockTaskForAdjust locks the task row and returns it with its plan status.
+func lockTaskForAdjust(ctx context.Context, tx *sql.Tx, id int) (*models.ProductionTask, string, error) {
+    var t models.ProductionTask
+    var planStatus string
+    err := tx.QueryRowContext(ctx, `
+        SELECT t.task_id, t.layout_id, t.color, t.planned_layers, t.completed_layers, t.status, t.table_id, p.status
+        FROM production.tasks t
+        JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+        JOIN production.plans p ON p.plan_id = l.plan_id
+        WHERE t.task_id = $1
+        FOR UPDATE OF t`, id,
+    ).Scan(&t.TaskID, &t.LayoutID, &t.Color, &t.PlannedLayers, &t.CompletedLayers, &t.Status, &t.TableID, &planStatus)
+    if err != nil { return nil, "", err }
+    if planStatus != "pending" && planStatus != "in_progress" {
+        return nil, "", fmt.Errorf("%w: 仅待发布或进行中的计划可拆分/合并任务 (task_id=%d, plan_status=%s)", ErrConflict, id, planStatus)
+    }
+    return &t, planStatus, nil
+}
+
+func (r *SqlTasksRepository) Split(ctx context.Context, id int, layers int, tableID *int) (*models.ProductionTask, *models.ProductionTask, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil { return nil, nil, err }
+    defer tx.Rollback()
+
+    t, planStatus, err := lockTaskForAdjust(ctx, tx, id)
+    if err != nil { return nil, nil, err }
+    if remaining := t.PlannedLayers - t.CompletedLayers; layers > remaining {
+        return nil, nil, fmt.Errorf("%w: 拆分层数 %d 超过任务剩余层数 %d (task_id=%d)", ErrValidation, layers, remaining, id)
+    }
+    if layers >= t.PlannedLayers {
+        return nil, nil, fmt.Errorf("%w: 拆分后原任务至少保留 1 层 (task_id=%d)", ErrValidation, id)
+    }
+    if _, err := tx.ExecContext(ctx, `SET LOCAL cutrix.task_adjustment_flag = true`); err != nil { return nil, nil, err }
+
+    status := "in_progress"
+    if planStatus == "pending" { status = "pending" }
+    // Insert the new task first so the plan never looks fully completed in between
+    created, err := scanTask(tx.QueryRowContext(ctx, `
+        INSERT INTO production.tasks (layout_id, color, planned_layers, status, table_id)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING `+taskColumns, t.LayoutID, t.Color, layers, status, tableID))
+    if err != nil { return nil, nil, err }
+    updated, err := scanTask(tx.QueryRowContext(ctx, `
+        UPDATE production.tasks
+        SET planned_layers = planned_layers - $1,
+            status = CASE WHEN status = 'in_progress' AND completed_layers >= planned_layers - $1 THEN 'completed' ELSE status END
+        WHERE task_id = $2
+        RETURNING `+taskColumns, layers, id))
+    if err != nil { return nil, nil, err }
+    if err := tx.Commit(); err != nil { return nil, nil, err }
+    return updated, created, nil
+}
+
+func (r *SqlTasksRepository) Merge(ctx context.Context, targetID int, sourceIDs []int, allowLotMix bool) (*models.ProductionTask, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil { return nil, err }
+    defer tx.Rollback()
+
+    // Lock in id order so concurrent merges over the same tasks cannot deadlock
+    ids := append([]int{targetID}, sourceIDs...)
+    sort.Ints(ids)
+    locked := make(map[int]*models.ProductionTask, len(ids))
+    for _, id := range ids {
+        t, _, err := lockTaskForAdjust(ctx, tx, id)
+        if err != nil { return nil, err }
+        locked[id] = t
+    }
+    target := locked[targetID]
+    planned, completed := target.PlannedLayers, target.CompletedLayers
+    for _, id := range sourceIDs {
+        s := locked[id]
+        if s.LayoutID != target.LayoutID || s.Color != target.Color {
+            return nil, fmt.Errorf("%w: 仅可合并同一布局同一颜色的任务 (task_id=%d 与 %d)", ErrValidation, id, targetID)
+        }
+        planned += s.PlannedLayers
+        completed += s.CompletedLayers
+    }
+    if !allowLotMix {
+        if err := checkMergeLots(ctx, tx, targetID, sourceIDs); err != nil { return nil, err }
+    }
+    if _, err := tx.ExecContext(ctx, `SET LOCAL cutrix.task_adjustment_flag = true`); err != nil { return nil, err }
+
+    for _, id := range sourceIDs {
+        if _, err := tx.ExecContext(ctx, `UPDATE production.logs SET task_id = $1 WHERE task_id = $2`, targetID, id); err != nil {
+            return nil, err
+        }
+        if _, err := tx.ExecContext(ctx, `
+            INSERT INTO production.task_lot_layers (task_id, dye_lot, completed_layers)
+            SELECT $1, dye_lot, completed_layers FROM production.task_lot_layers WHERE task_id = $2
+            ON CONFLICT (task_id, dye_lot) DO UPDATE
+            SET completed_layers = production.task_lot_layers.completed_layers + EXCLUDED.completed_layers`, targetID, id); err != nil {
+            return nil, err
+        }
+    }
+    // Update the target before deleting the sources so the plan never looks fully completed in between;
+    // sync_task_bundles regenerates/clears bundles when the status or completed_layers changes
+    status := target.Status
+    if status != "pending" {
+        status = "in_progress"
+        if completed >= planned { status = "completed" }
+    }
+    merged, err := scanTask(tx.QueryRowContext(ctx, `
+        UPDATE production.tasks SET planned_layers = $1, completed_layers = $2, status = $3
+        WHERE task_id = $4
+        RETURNING `+taskColumns, planned, completed, status, targetID))
+    if err != nil { return nil, err }
+    if target.Status == "completed" && status == "completed" && completed == target.CompletedLayers {
+        // Nothing the trigger watches changed (sources had no plies); rebuild bundles with the moved logs
+        if _, err := tx.ExecContext(ctx, `SELECT production.generate_task_bundles($1)`, targetID); err != nil { return nil, err }
+    }
+    for _, id := range sourceIDs {
+        if _, err := tx.ExecContext(ctx, `DELETE FROM production.tasks WHERE task_id = $1`, id); err != nil { return nil, err }
+    }
+    if err := tx.Commit(); err != nil { return nil, err }
+    return merged, nil
+}
+
+// checkMergeLots returns *LotMixError when the merged task would carry more than one dye lot.
+func checkMergeLots(ctx context.Context, tx *sql.Tx, targetID int, sourceIDs []int) error {
+    lotsOf := func(id int) ([]string, error) {
+        rows, err := tx.QueryContext(ctx, `
+            SELECT dye_lot FROM production.task_lot_layers
+            WHERE task_id = $1 AND completed_layers > 0 ORDER BY dye_lot`, id)
+        if err != nil { return nil, err }
+        defer rows.Close()
+        var lots []string
+        for rows.Next() {
+            var lot string
+            if err := rows.Scan(&lot); err != nil { return nil, err }
+            lots = append(lots, lot)
+        }
+        return lots, rows.Err()
+    }
+    existing, err := lotsOf(targetID)
+    if err != nil { return err }
+    for _, id := range sourceIDs {
+        lots, err := lotsOf(id)
+        if err != nil { return err }
+        for _, lot := range lots {
+            if len(existing) == 0 { existing = append(existing, lot); continue }
+            known := false
+            for _, e := range existing { known = known || e == lot }
+            if !known {
+                return &LotMixError{TaskID: targetID, DyeLot: lot, ExistingLots: existing}
+            }
+        }
+    }
+    return nil
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+    "context"
+    "cutrix-backend/internal/models"
+)
+
+// StylesRepository defines data access for the style catalog.
+// 设计约束：
+// - style_number 唯一且创建后不可修改（触发器 guard_styles_update），订单按 style_number 关联款式，不设外键：
+//   未登记的款号照常建单。
+// - 尺码段、允许颜色与部件随款式整体写入：Update 以请求内容整体替换，顺序即列表顺序。
+// - production.style_size_rank(style_number, size) 给出尺码在尺码段中的位置，供布局与报表排序。
+type StylesRepository interface {
+    // Basic
+    Create(ctx context.Context, style *models.Style) (int, error)
+    // Delete removes the style and its size run, colors and components; orders keep their style_number.
+    Delete(ctx context.Context, id int) error
+
+    // Mutations
+    // Update replaces name, note, size run, colors and components (style_number is kept).
+    Update(ctx context.Context, style *models.Style) error
+
+    // Queries
+    GetByID(ctx context.Context, id int) (*models.Style, error)
+    GetByNumber(ctx context.Context, number string) (*models.Style, error)
+    // List returns styles ordered by style_number; a non-empty query matches number or name (ILIKE).
+    List(ctx context.Context, query string) ([]models.Style, error)
+}
@@ -0,0 +1,30 @@
+package repositories
+
+import (
+    "context"
+    "cutrix-backend/internal/models"
+)
+
+// TablesRepository defines data access for cutting tables.
+// 设计约束：
+// - 任务分配裁床时触发器校验：裁床须为 active，且可用长度不小于布局唛架长度；日志缺省记录任务所在裁床并做同样校验。
+// - 可用长度不可缩短到已分配未完成任务的唛架长度以下（触发器拒绝）。
+// - 已分配任务或已有日志的裁床不可删除（外键 RESTRICT），仓储层预检并返回业务错误。
+type TablesRepository interface {
+    // Basic
+    Create(ctx context.Context, table *models.CuttingTable) (int, error)
+    Delete(ctx context.Context, id int) error
+
+    // Mutations
+    // Update changes name, usable length and note.
+    Update(ctx context.Context, table *models.CuttingTable) error
+    UpdateStatus(ctx context.Context, id int, status string) error
+
+    // Queries
+    GetByID(ctx context.Context, id int) (*models.CuttingTable, error)
+    GetByName(ctx context.Context, name string) (*models.CuttingTable, error)
+    // List returns tables ordered by name; empty status means all.
+    List(ctx context.Context, status string) ([]models.CuttingTable, error)
+    // ListTasks returns the tasks currently assigned to the table (all statuses).
+    ListTasks(ctx context.Context, tableID int) ([]models.ProductionTask, error)
+}
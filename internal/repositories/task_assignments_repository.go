@@ -0,0 +1,22 @@
+package repositories
+
+import (
+    "context"
+    "cutrix-backend/internal/models"
+)
+
+// TaskAssignmentsRepository defines data access for task-to-worker assignments.
+// 设计约束：
+// - 分配对象为单个用户或用户组（二选一）；同一任务对同一用户/组最多一条有效分配。
+// - 撤销仅写入 unassigned_at/unassigned_by，记录不删除以保留历史；已完成任务不可新增分配（触发器）。
+type TaskAssignmentsRepository interface {
+    // Assign adds active assignments for the given users and/or group in one transaction; pairs that are
+    // already active are skipped. Returns the task's active assignments; sql.ErrNoRows if the task is missing.
+    Assign(ctx context.Context, taskID int, userIDs []int, group *string, assignedBy *int) ([]models.TaskAssignment, error)
+    // Unassign closes an active assignment of the task; sql.ErrNoRows if none matches.
+    Unassign(ctx context.Context, taskID, assignmentID int, unassignedBy *int) error
+    // ListByTask returns active assignments, or the full history when includeHistory is set.
+    ListByTask(ctx context.Context, taskID int, includeHistory bool) ([]models.TaskAssignment, error)
+    // IsAssignee reports whether the user holds an active assignment on the task, directly or via user_group.
+    IsAssignee(ctx context.Context, taskID, userID int) (bool, error)
+}
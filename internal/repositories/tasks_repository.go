@@ -10,6 +10,7 @@ import (
 // - 任务在所属计划发布后，INSERT/DELETE 被触发器拒绝；UPDATE 仅允许 status 与 completed_layers。
 // - 为保证审计与回滚一致性，completed_layers 不在任务仓储层直接更新，统一通过 LogsRepository.Create 写日志实现累计。
 // - 删除在发布后不可用；发布前受外键约束。
+// - 拆分/合并在 cutrix.task_adjustment_flag 上下文中执行，仅限 pending / in_progress 计划；两者不改变计划的颜色层数合计。
 type TasksRepository interface {
     // Basic
     Create(ctx context.Context, task *models.ProductionTask) (int, error)
@@ -17,10 +18,26 @@ type TasksRepository interface {
 
     // Mutations
     UpdateStatus(ctx context.Context, id int, status string) error
+    // AssignTable sets (nil clears) the task's cutting table; triggers reject inactive tables,
+    // tables shorter than the layout marker, and completed tasks.
+    AssignTable(ctx context.Context, id int, tableID *int) error
     // 注意：不提供 UpdateCompletedLayers；请使用 LogsRepository.Create 来记录完工并由触发器自动汇总。
+    // Split moves layers of the task's remaining planned layers into a new task of the same layout and color
+    // (optionally on tableID); logs stay on the original task. Returns the updated original and the new task.
+    Split(ctx context.Context, id int, layers int, tableID *int) (*models.ProductionTask, *models.ProductionTask, error)
+    // Merge folds the source tasks (same layout and color) into the target: planned/completed layers are summed,
+    // logs and per-lot layers move to the target and the sources are deleted. Tasks carrying different dye lots
+    // return *LotMixError unless allowLotMix.
+    Merge(ctx context.Context, targetID int, sourceIDs []int, allowLotMix bool) (*models.ProductionTask, error)
 
     // Queries
     GetByID(ctx context.Context, id int) (*models.ProductionTask, error)
     List(ctx context.Context) ([]models.ProductionTask, error)
     ListByLayout(ctx context.Context, layoutID int) ([]models.ProductionTask, error)
+    // ListAssigned returns tasks with an active assignment to the user, directly or via the user's group.
+    ListAssigned(ctx context.Context, userID int) ([]models.ProductionTask, error)
+    // ListLots returns completed layers per dye lot (maintained by log triggers); empty when no lot was logged.
+    ListLots(ctx context.Context, taskID int) ([]models.TaskLotLayers, error)
+    // ListLotsByPlan returns per-lot layers of every task under the plan.
+    ListLotsByPlan(ctx context.Context, planID int) ([]models.TaskLotLayers, error)
 }
\ No newline at end of file
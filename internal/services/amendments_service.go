@@ -0,0 +1,23 @@
+package services
+
+import "cutrix-backend/internal/models"
+
+// AmendmentsService 管理已发布计划的变更申请：制版员提出、管理层批准或驳回。
+// 约束与约定：
+// - 仅进行中（in_progress）的计划可提出与批准变更；变更类型为 add_layout / add_task / update_task。
+// - update_task 仅修改计划层数，且不得小于已完成层数；新增的布局与任务直接为 in_progress，可立即提交日志。
+// - 批准时在调整上下文中整体应用，记录每项的 before/after；结果超出订单裁剪容差返回 *ToleranceViolationError 且不应用任何变更。
+// - 已批准或驳回的申请不可再处理（ErrConflict）。
+// 注意：接口签名不传 context；实现中使用 context.Background() 调用仓储。
+ type AmendmentsService interface {
+    // 提出变更：proposedBy 为当前用户；返回写入 ID 与状态后的申请。
+    Propose(planID int, a *models.PlanAmendment, proposedBy *int) (*models.PlanAmendment, error)
+    // 查询单个申请（含变更项）。
+    Get(id int) (*models.PlanAmendment, error)
+    // 列出计划的申请，status 为空表示全部。
+    ListByPlan(planID int, status string) ([]models.PlanAmendment, error)
+    // 批准并应用变更。
+    Approve(id int, decidedBy *int, note *string) (*models.PlanAmendment, error)
+    // 驳回变更，不修改计划。
+    Reject(id int, decidedBy *int, note *string) (*models.PlanAmendment, error)
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "strings"
+    "cutrix-backend/internal/logger"
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/repositories"
+)
+
+// amendmentStatuses 变更申请可用的状态过滤值。
+var amendmentStatuses = map[string]bool{"pending": true, "approved": true, "rejected": true}
+
+// amendmentsService 实现 AmendmentsService：服务层校验变更项格式，归属与状态由仓储在事务内校验。
+ type amendmentsService struct {
+    repo repositories.AmendmentsRepository
+}
+
+// NewAmendmentsService 以给定仓储创建 AmendmentsService；nil 仓储将 panic。
+ func NewAmendmentsService(repo repositories.AmendmentsRepository) AmendmentsService {
+    if repo == nil {
+        panic("nil AmendmentsRepository")
+    }
+    return &amendmentsService{repo: repo}
+}
+
+// Propose 校验变更项后保存为 pending 申请。
+ func (s *amendmentsService) Propose(planID int, a *models.PlanAmendment, proposedBy *int) (*models.PlanAmendment, error) {
+    if planID <= 0 {
+        return nil, fmt.Errorf("%w: invalid plan_id", ErrValidation)
+    }
+    if a == nil || len(a.Changes) == 0 {
+        return nil, fmt.Errorf("%w: changes required", ErrValidation)
+    }
+    for i := range a.Changes {
+        if err := normalizeAmendmentChange(&a.Changes[i]); err != nil {
+            return nil, fmt.Errorf("%w: changes[%d]: %s", ErrValidation, i, err.Error())
+        }
+    }
+    a.PlanID, a.ProposedBy = planID, proposedBy
+    if err := s.repo.Create(context.Background(), a); err != nil {
+        return nil, err
+    }
+    // 事件日志：计划变更申请提出
+    // 字段：amendment_id、plan_id、changes、proposed_by
+    logger.L.Info("plan_amendment_proposed",
+        slog.Int("amendment_id", a.AmendmentID),
+        slog.Int("plan_id", planID),
+        slog.Int("changes", len(a.Changes)),
+        slog.Any("proposed_by", proposedBy),
+    )
+    return a, nil
+}
+
+// normalizeAmendmentChange 校验单个变更项并清除与其类型无关的字段。
+func normalizeAmendmentChange(c *models.AmendmentChange) error {
+    c.Kind = strings.TrimSpace(c.Kind)
+    c.Before, c.After = nil, nil
+    switch c.Kind {
+    case "add_layout":
+        if c.Layout == nil || strings.TrimSpace(c.Layout.LayoutName) == "" {
+            return fmt.Errorf("layout.layout_name required")
+        }
+        c.Layout.LayoutName = strings.TrimSpace(c.Layout.LayoutName)
+        for size, ratio := range c.Ratios {
+            if strings.TrimSpace(size) == "" || ratio < 0 {
+                return fmt.Errorf("ratios must have non-empty sizes and non-negative values")
+            }
+        }
+        for i := range c.Tasks {
+            t := &c.Tasks[i]
+            t.Color = strings.TrimSpace(t.Color)
+            if t.Color == "" || t.PlannedLayers <= 0 {
+                return fmt.Errorf("tasks require color and positive planned_layers")
+            }
+        }
+        c.LayoutID, c.TaskID, c.Color, c.PlannedLayers = nil, nil, nil, nil
+    case "add_task":
+        if c.LayoutID == nil || *c.LayoutID <= 0 {
+            return fmt.Errorf("layout_id required")
+        }
+        if c.Color == nil || strings.TrimSpace(*c.Color) == "" {
+            return fmt.Errorf("color required")
+        }
+        color := strings.TrimSpace(*c.Color)
+        c.Color = &color
+        if c.PlannedLayers == nil || *c.PlannedLayers <= 0 {
+            return fmt.Errorf("planned_layers must be positive")
+        }
+        c.TaskID, c.Layout, c.Ratios, c.Tasks = nil, nil, nil, nil
+    case "update_task":
+        if c.TaskID == nil || *c.TaskID <= 0 {
+            return fmt.Errorf("task_id required")
+        }
+        if c.PlannedLayers == nil || *c.PlannedLayers <= 0 {
+            return fmt.Errorf("planned_layers must be positive")
+        }
+        c.LayoutID, c.Layout, c.Ratios, c.Tasks, c.Color = nil, nil, nil, nil, nil
+    default:
+        return fmt.Errorf("kind must be one of add_layout, add_task, update_task")
+    }
+    return nil
+}
+
+// Get 返回申请及其变更项。
+ func (s *amendmentsService) Get(id int) (*models.PlanAmendment, error) {
+    if id <= 0 {
+        return nil, fmt.Errorf("%w: invalid amendment_id", ErrValidation)
+    }
+    return s.repo.GetByID(context.Background(), id)
+}
+
+// ListByPlan 列出计划的申请（新的在前）。
+ func (s *amendmentsService) ListByPlan(planID int, status string) ([]models.PlanAmendment, error) {
+    if planID <= 0 {
+        return nil, fmt.Errorf("%w: invalid plan_id", ErrValidation)
+    }
+    status = strings.TrimSpace(status)
+    if status != "" && !amendmentStatuses[status] {
+        return nil, fmt.Errorf("%w: status must be pending, approved or rejected", ErrValidation)
+    }
+    return s.repo.ListByPlan(context.Background(), planID, status)
+}
+
+// Approve 应用 pending 申请；已处理返回 ErrConflict，超出容差返回 *ToleranceViolationError。
+ func (s *amendmentsService) Approve(id int, decidedBy *int, note *string) (*models.PlanAmendment, error) {
+    if err := s.ensurePending(id); err != nil {
+        return nil, err
+    }
+    ctx := context.Background()
+    if err := s.repo.Apply(ctx, id, decidedBy, note); err != nil {
+        return nil, err
+    }
+    a, err := s.repo.GetByID(ctx, id)
+    if err != nil {
+        return nil, err
+    }
+    // 事件日志：计划变更批准并应用
+    // 字段：amendment_id、plan_id、changes、decided_by
+    logger.L.Info("plan_amendment_approved",
+        slog.Int("amendment_id", id),
+        slog.Int("plan_id", a.PlanID),
+        slog.Int("changes", len(a.Changes)),
+        slog.Any("decided_by", decidedBy),
+    )
+    return a, nil
+}
+
+// Reject 驳回 pending 申请；已处理返回 ErrConflict。
+ func (s *amendmentsService) Reject(id int, decidedBy *int, note *string) (*models.PlanAmendment, error) {
+    if err := s.ensurePending(id); err != nil {
+        return nil, err
+    }
+    ctx := context.Background()
+    if err := s.repo.Reject(ctx, id, decidedBy, note); err != nil {
+        return nil, err
+    }
+    a, err := s.repo.GetByID(ctx, id)
+    if err != nil {
+        return nil, err
+    }
+    // 事件日志：计划变更驳回
+    // 字段：amendment_id、plan_id、decided_by
+    logger.L.Info("plan_amendment_rejected",
+        slog.Int("amendment_id", id),
+        slog.Int("plan_id", a.PlanID),
+        slog.Any("decided_by", decidedBy),
+    )
+    return a, nil
+}
+
+// ensurePending 预检申请存在且仍为 pending。
+func (s *amendmentsService) ensurePending(id int) error {
+    if id <= 0 {
+        return fmt.Errorf("%w: invalid amendment_id", ErrValidation)
+    }
+    a, err := s.repo.GetByID(context.Background(), id)
+    if err != nil {
+        return err
+    }
+    if a.Status != "pending" {
+        return fmt.Errorf("%w: amendment already %s", ErrConflict, a.Status)
+    }
+    return nil
+}
@@ -0,0 +1,22 @@
+package services
+
+import "cutrix-backend/internal/models"
+
+// BundlesService 提供扎包（扎票）查询与重建。
+// 约束与约定：
+// - 生成：任务完成时由触发器按有效日志的层序与布局尺码比例自动生成；每扎为同一尺码、同一唛架份次的连续层，且不跨缸号。
+// - 扎包层数：取布局 bundle_size（默认 10）；Regenerate 可临时指定其它层数整体重建（仅 completed 任务）。
+// - 同步：任务保持完成而完成层数变化（如超裁任务作废日志）时，触发器按剩余日志重建扎包。
+// - 回退：任务因日志作废回到 in_progress 时，触发器清除其扎包，重新完成后再生成。
+// 注意：接口签名不传 context；实现中使用 context.Background() 调用仓储。
+ type BundlesService interface {
+    // 变更：按指定层数重建任务扎包（size 为 nil 时取布局设置），返回新的扎包列表。
+    Regenerate(taskID int, size *int) ([]models.Bundle, error)
+
+    // 查询：按 ID 获取扎包。
+    GetByID(id int) (*models.Bundle, error)
+    // 查询：按任务列出扎包。
+    ListByTask(taskID int) ([]models.Bundle, error)
+    // 查询：按计划列出扎包（缝制车间按计划领片）。
+    ListByPlan(planID int) ([]models.Bundle, error)
+}
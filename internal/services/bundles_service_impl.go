@@ -0,0 +1,73 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log/slog"
+    "cutrix-backend/internal/logger"
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/repositories"
+)
+
+// bundlesService 实现 BundlesService；扎包拆分逻辑在数据库函数中，服务层只做校验与编排。
+ type bundlesService struct {
+    repo repositories.BundlesRepository
+}
+
+// NewBundlesService 以给定仓储实现创建 BundlesService；nil 仓储将 panic。
+ func NewBundlesService(repo repositories.BundlesRepository) BundlesService {
+    if repo == nil {
+        panic("nil BundlesRepository")
+    }
+    return &bundlesService{repo: repo}
+}
+
+// Regenerate 重建任务扎包。
+// taskID：任务 ID；size：每扎层数，nil 取布局设置，须 > 0。
+// 返回：重建后的扎包列表；任务未完成时由仓储层返回业务错误。
+ func (s *bundlesService) Regenerate(taskID int, size *int) ([]models.Bundle, error) {
+    if taskID <= 0 {
+        return nil, errors.New("invalid task_id")
+    }
+    if size != nil && *size <= 0 {
+        return nil, fmt.Errorf("%w: bundle_size must be > 0", ErrValidation)
+    }
+    ctx := context.Background()
+    n, err := s.repo.Regenerate(ctx, taskID, size)
+    if err != nil {
+        return nil, err
+    }
+    // 事件日志：扎包重建
+    // 字段：task_id、bundle_size（nil 表示布局设置）、bundles
+    logger.L.Info("bundles_regenerated",
+        slog.Int("task_id", taskID),
+        slog.Any("bundle_size", size),
+        slog.Int("bundles", n),
+    )
+    return s.repo.ListByTask(ctx, taskID)
+}
+
+// GetByID 查询单个扎包。
+ func (s *bundlesService) GetByID(id int) (*models.Bundle, error) {
+    if id <= 0 {
+        return nil, errors.New("invalid bundle_id")
+    }
+    return s.repo.GetByID(context.Background(), id)
+}
+
+// ListByTask 按任务列出扎包；任务不存在返回 NotFound。
+ func (s *bundlesService) ListByTask(taskID int) ([]models.Bundle, error) {
+    if taskID <= 0 {
+        return nil, errors.New("invalid task_id")
+    }
+    return s.repo.ListByTask(context.Background(), taskID)
+}
+
+// ListByPlan 按计划列出扎包；计划不存在返回 NotFound。
+ func (s *bundlesService) ListByPlan(planID int) ([]models.Bundle, error) {
+    if planID <= 0 {
+        return nil, errors.New("invalid plan_id")
+    }
+    return s.repo.ListByPlan(context.Background(), planID)
+}
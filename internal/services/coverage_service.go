@@ -0,0 +1,18 @@
+package services
+
+import "cutrix-backend/internal/models"
+
+// CoverageService 提供计划覆盖度报表：将任务层数按布局尺码比例折算为件数，并与订单项逐格对比。
+// 约束与约定：
+// - 计划件数 = ProductionTask.PlannedLayers × LayoutSizeRatio.Ratio；已裁件数 = CompletedLayers × Ratio。
+// - 矩阵维度为颜色 × 尺码：颜色取自订单项录入顺序；尺码按款式尺码段顺序，款式未登记或未列出的尺码按录入顺序排在其后。
+//   订单中不存在的颜色/尺码组合按订单数量 0 处理（全部视为超裁）。
+// - 差额 = 件数 - 订单数量：正数为超裁，负数为欠裁。
+// - 部件：components 为各部件（布局 component）单独的矩阵；总矩阵按整件计，每格取各部件件数的最小值，
+//   避免大身与里布件数相加。款式登记了部件时未排的部件同样列出（件数为 0），整件口径随之为 0。
+// - 只读：不修改任何数据，复用 LayoutsRepository.GetRatiosBatch 与 TasksRepository.ListByLayout 取数。
+// 注意：接口签名不传 context；实现中使用 context.Background() 调用仓储，保持与 handlers 解耦。
+ type CoverageService interface {
+    // 查询：计算单个计划的颜色 × 尺码覆盖矩阵。
+    PlanCoverage(planID int) (*models.PlanCoverage, error)
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "sort"
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/repositories"
+)
+
+// coverageService 实现 CoverageService，组合计划、订单、布局与任务仓储计算覆盖矩阵。
+// 设计要点：
+// - 取数：计划 → 订单项；计划 → 布局 → 尺码比例（批量）与任务（按布局）；订单款号 → 款式尺码段与部件（排序用）。
+// - 计算：在服务层折算件数，不新增 SQL 聚合，保持与现有仓储接口一致。
+// - 上下文：统一使用 context.Background() 调用仓储，避免外部 context 泄漏。
+ type coverageService struct {
+    plans   repositories.PlansRepository
+    orders  repositories.OrdersRepository
+    layouts repositories.LayoutsRepository
+    tasks   repositories.TasksRepository
+    styles  repositories.StylesRepository
+}
+
+// NewCoverageService 以给定的仓储实现创建 CoverageService。
+// 返回：可用的 CoverageService；任一仓储为 nil 将 panic（与 plans/layouts 保持一致）。
+ func NewCoverageService(plans repositories.PlansRepository, orders repositories.OrdersRepository, layouts repositories.LayoutsRepository, tasks repositories.TasksRepository, styles repositories.StylesRepository) CoverageService {
+    if plans == nil || orders == nil || layouts == nil || tasks == nil || styles == nil {
+        panic("nil repository for CoverageService")
+    }
+    return &coverageService{plans: plans, orders: orders, layouts: layouts, tasks: tasks, styles: styles}
+}
+
+// PlanCoverage 计算计划的覆盖矩阵。
+// planID：计划 ID。
+// 返回：覆盖矩阵与错误；计划不存在时返回仓储层 NotFound 错误。
+ func (s *coverageService) PlanCoverage(planID int) (*models.PlanCoverage, error) {
+    if planID <= 0 {
+        return nil, errors.New("invalid plan_id")
+    }
+    ctx := context.Background()
+    plan, err := s.plans.GetByID(ctx, planID)
+    if err != nil {
+        return nil, err
+    }
+    order, items, err := s.orders.GetWithItems(ctx, plan.OrderID)
+    if err != nil {
+        return nil, err
+    }
+    var sizeRun, componentOrder []string
+    style, err := s.styles.GetByNumber(ctx, order.StyleNumber)
+    if err == nil {
+        sizeRun = style.Sizes
+        for _, c := range style.Components {
+            componentOrder = append(componentOrder, c.ComponentName)
+        }
+    } else if !errors.Is(err, sql.ErrNoRows) {
+        return nil, err
+    }
+    layouts, err := s.layouts.ListByPlan(ctx, planID)
+    if err != nil {
+        return nil, err
+    }
+    layoutIDs := make([]int, 0, len(layouts))
+    for _, l := range layouts {
+        layoutIDs = append(layoutIDs, l.LayoutID)
+    }
+    ratios, err := s.layouts.GetRatiosBatch(ctx, layoutIDs)
+    if err != nil {
+        return nil, err
+    }
+    var tasks []models.ProductionTask
+    for _, id := range layoutIDs {
+        ts, err := s.tasks.ListByLayout(ctx, id)
+        if err != nil {
+            return nil, err
+        }
+        tasks = append(tasks, ts...)
+    }
+
+    lots, err := s.tasks.ListLotsByPlan(ctx, planID)
+    if err != nil {
+        return nil, err
+    }
+
+    out := buildComponentCoverage(items, layouts, ratios, tasks, lots, sizeRun, componentOrder)
+    out.PlanID = plan.PlanID
+    out.OrderID = plan.OrderID
+    return out, nil
+}
+
+// buildCoverage 将订单项、布局尺码比例与任务折算为颜色 × 尺码矩阵。
+// 颜色与尺码顺序优先取订单项录入顺序，其后追加仅出现在布局/任务中的颜色与尺码；sizeRun 非空时尺码再按尺码段顺序稳定排序。
+func buildCoverage(items []models.OrderItem, ratios map[int][]models.LayoutSizeRatio, tasks []models.ProductionTask, sizeRun []string) *models.PlanCoverage {
+    type key struct{ color, size string }
+    ordered := make(map[key]int)
+    planned := make(map[key]int)
+    cut := make(map[key]int)
+
+    var colors, sizes []string
+    seenColor := make(map[string]bool)
+    seenSize := make(map[string]bool)
+    addColor := func(c string) {
+        if !seenColor[c] { seenColor[c] = true; colors = append(colors, c) }
+    }
+    addSize := func(s string) {
+        if !seenSize[s] { seenSize[s] = true; sizes = append(sizes, s) }
+    }
+
+    for _, it := range items {
+        addColor(it.Color)
+        addSize(it.Size)
+        ordered[key{it.Color, it.Size}] += it.Quantity
+    }
+    for _, t := range tasks {
+        addColor(t.Color)
+        for _, r := range ratios[t.LayoutID] {
+            addSize(r.Size)
+            k := key{t.Color, r.Size}
+            planned[k] += t.PlannedLayers * r.Ratio
+            cut[k] += t.CompletedLayers * r.Ratio
+        }
+    }
+
+    sortSizes(sizes, sizeRun)
+    out := &models.PlanCoverage{Colors: colors, Sizes: sizes, Cells: []models.CoverageCell{}}
+    if out.Colors == nil { out.Colors = []string{} }
+    if out.Sizes == nil { out.Sizes = []string{} }
+    for _, c := range colors {
+        for _, sz := range sizes {
+            k := key{c, sz}
+            cell := models.CoverageCell{
+                Color:         c,
+                Size:          sz,
+                OrderedQty:    ordered[k],
+                PlannedPieces: planned[k],
+                CutPieces:     cut[k],
+            }
+            // 颜色与尺码均未下单且未计划的空格不输出
+            if cell.OrderedQty == 0 && cell.PlannedPieces == 0 && cell.CutPieces == 0 {
+                continue
+            }
+            cell.PlannedDelta = cell.PlannedPieces - cell.OrderedQty
+            cell.CutDelta = cell.CutPieces - cell.OrderedQty
+            out.TotalOrdered += cell.OrderedQty
+            out.TotalPlannedPieces += cell.PlannedPieces
+            out.TotalCutPieces += cell.CutPieces
+            out.Cells = append(out.Cells, cell)
+        }
+    }
+    return out
+}
+
+// buildComponentCoverage 按部件分别计算覆盖矩阵，再合成整件口径的总矩阵：每格计划/已裁件数取各部件中的最小值，
+// 参与的部件为款式登记的部件加上计划中有任务的部件：款式部件没有布局或任务时整列按 0 计，整件口径随之为 0
+// （某部件在该格无任务同样按 0 计）。部件顺序按款式部件顺序，其后按布局顺序。
+// 只有一个部件时总矩阵与该部件一致；缸号拆分只在部件矩阵中给出，单部件时同时写入总矩阵。
+func buildComponentCoverage(items []models.OrderItem, layouts []models.CuttingLayout, ratios map[int][]models.LayoutSizeRatio,
+    tasks []models.ProductionTask, lots []models.TaskLotLayers, sizeRun, componentOrder []string) *models.PlanCoverage {
+    componentOf := make(map[int]string, len(layouts))
+    var components []string
+    seen := make(map[string]bool)
+    for _, l := range layouts {
+        componentOf[l.LayoutID] = l.Component
+    }
+    for _, c := range componentOrder {
+        if !seen[c] { seen[c] = true; components = append(components, c) }
+    }
+    tasksOf := make(map[string][]models.ProductionTask)
+    for _, t := range tasks {
+        c := componentOf[t.LayoutID]
+        if !seen[c] { seen[c] = true; components = append(components, c) }
+        tasksOf[c] = append(tasksOf[c], t)
+    }
+    // 部件与尺码同样按款式登记顺序稳定排序
+    sortSizes(components, componentOrder)
+
+    out := buildCoverage(items, ratios, tasks, sizeRun)
+    out.Components = []models.ComponentCoverage{}
+    type key struct{ color, size string }
+    minPlanned := make(map[key]int)
+    minCut := make(map[key]int)
+    for i, c := range components {
+        cov := buildCoverage(items, ratios, tasksOf[c], sizeRun)
+        applyLotCoverage(cov, ratios, tasksOf[c], lots)
+        out.Components = append(out.Components, models.ComponentCoverage{
+            Component:          c,
+            Cells:              cov.Cells,
+            TotalPlannedPieces: cov.TotalPlannedPieces,
+            TotalCutPieces:     cov.TotalCutPieces,
+        })
+        // 该部件在某格无输出即为 0 件
+        planned := make(map[key]int)
+        cut := make(map[key]int)
+        for _, cell := range cov.Cells {
+            planned[key{cell.Color, cell.Size}] = cell.PlannedPieces
+            cut[key{cell.Color, cell.Size}] = cell.CutPieces
+        }
+        for _, cell := range out.Cells {
+            k := key{cell.Color, cell.Size}
+            if i == 0 || planned[k] < minPlanned[k] { minPlanned[k] = planned[k] }
+            if i == 0 || cut[k] < minCut[k] { minCut[k] = cut[k] }
+        }
+    }
+    if len(components) == 1 {
+        applyLotCoverage(out, ratios, tasks, lots)
+        return out
+    }
+
+    cells := []models.CoverageCell{}
+    out.TotalOrdered, out.TotalPlannedPieces, out.TotalCutPieces = 0, 0, 0
+    for _, cell := range out.Cells {
+        k := key{cell.Color, cell.Size}
+        cell.PlannedPieces, cell.CutPieces = minPlanned[k], minCut[k]
+        if cell.OrderedQty == 0 && cell.PlannedPieces == 0 && cell.CutPieces == 0 {
+            continue
+        }
+        cell.PlannedDelta = cell.PlannedPieces - cell.OrderedQty
+        cell.CutDelta = cell.CutPieces - cell.OrderedQty
+        out.TotalOrdered += cell.OrderedQty
+        out.TotalPlannedPieces += cell.PlannedPieces
+        out.TotalCutPieces += cell.CutPieces
+        cells = append(cells, cell)
+    }
+    out.Cells = cells
+    return out
+}
+
+// sortSizes 按尺码段顺序稳定排序；尺码段未列出的尺码保持原顺序排在其后。
+func sortSizes(sizes []string, sizeRun []string) {
+    if len(sizeRun) == 0 {
+        return
+    }
+    rank := make(map[string]int, len(sizeRun))
+    for i, s := range sizeRun {
+        rank[s] = i
+    }
+    sort.SliceStable(sizes, func(i, j int) bool {
+        ri, okI := rank[sizes[i]]
+        rj, okJ := rank[sizes[j]]
+        if okI != okJ {
+            return okI
+        }
+        return okI && ri < rj
+    })
+}
+
+// applyLotCoverage 将各任务按缸号的完成层数折算为件数，写入对应格的 cut_by_lot，便于缝制按缸号配片。
+func applyLotCoverage(cov *models.PlanCoverage, ratios map[int][]models.LayoutSizeRatio, tasks []models.ProductionTask, lots []models.TaskLotLayers) {
+    if len(lots) == 0 {
+        return
+    }
+    taskByID := make(map[int]models.ProductionTask, len(tasks))
+    for _, t := range tasks {
+        taskByID[t.TaskID] = t
+    }
+    type key struct{ color, size string }
+    byLot := make(map[key]map[string]int)
+    for _, tl := range lots {
+        t, ok := taskByID[tl.TaskID]
+        if !ok {
+            continue
+        }
+        for _, r := range ratios[t.LayoutID] {
+            k := key{t.Color, r.Size}
+            if byLot[k] == nil {
+                byLot[k] = make(map[string]int)
+            }
+            byLot[k][tl.DyeLot] += tl.CompletedLayers * r.Ratio
+        }
+    }
+    for i := range cov.Cells {
+        cov.Cells[i].CutByLot = byLot[key{cov.Cells[i].Color, cov.Cells[i].Size}]
+    }
+}
\ No newline at end of file
@@ -0,0 +1,25 @@
+package services
+
+import "cutrix-backend/internal/models"
+
+// CustomersService 管理客户主数据：登记、修改、删除与查询。
+// 约束与约定：
+// - 登记：customer_code 与 customer_name 去除首尾空白后必填；代码唯一、名称忽略大小写唯一（重复返回 ErrConflict）。
+// - 默认容差：over/under_cut_tolerance 为百分比 [0, 100]，nil 表示不限制；新建订单未指定容差时带入。
+// - 订单：通过 customer_id 引用客户，订单列表、交期预测与报表可按客户过滤。
+// - 删除：被订单引用的客户不可删除。
+// 注意：接口签名不传 context；实现中使用 context.Background() 调用仓储。
+ type CustomersService interface {
+    // 基本：登记客户；成功后填充 CustomerID。
+    Create(customer *models.Customer) error
+    // 基本：删除客户（仅限无订单引用）。
+    Delete(id int) error
+
+    // 变更：修改代码、名称、联系方式、默认容差与备注。
+    Update(customer *models.Customer) error
+
+    // 查询：按 ID 获取客户。
+    GetByID(id int) (*models.Customer, error)
+    // 查询：列出客户，query 非空时按代码或名称模糊匹配。
+    List(query string) ([]models.Customer, error)
+}
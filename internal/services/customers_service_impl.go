@@ -0,0 +1,139 @@
+package services
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "log/slog"
+    "strings"
+    "cutrix-backend/internal/logger"
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/repositories"
+)
+
+// customersService 实现 CustomersService。
+// 设计要点：
+// - 输入校验在服务层完成并返回 ErrValidation；代码与名称唯一性预检后返回 ErrConflict。
+ type customersService struct {
+    repo repositories.CustomersRepository
+}
+
+// NewCustomersService 以给定仓储实现创建 CustomersService；nil 仓储将 panic。
+ func NewCustomersService(repo repositories.CustomersRepository) CustomersService {
+    if repo == nil {
+        panic("nil CustomersRepository")
+    }
+    return &customersService{repo: repo}
+}
+
+// Create 登记客户。
+// 返回：ErrValidation（参数错误）、ErrConflict（代码或名称重复）或仓储错误。
+ func (s *customersService) Create(customer *models.Customer) error {
+    if customer == nil {
+        return ErrValidation
+    }
+    if err := validateCustomer(customer); err != nil {
+        return err
+    }
+    ctx := context.Background()
+    if err := s.ensureUnique(ctx, customer, 0); err != nil {
+        return err
+    }
+    _, err := s.repo.Create(ctx, customer)
+    if err == nil {
+        // 事件日志：客户登记
+        // 字段：customer_id、customer_code
+        logger.L.Info("customer_created",
+            slog.Int("customer_id", customer.CustomerID),
+            slog.String("customer_code", customer.CustomerCode),
+        )
+    }
+    return err
+}
+
+// Delete 删除客户；已被订单引用时由仓储层返回业务错误。
+ func (s *customersService) Delete(id int) error {
+    if id <= 0 {
+        return ErrValidation
+    }
+    err := s.repo.Delete(context.Background(), id)
+    if err == nil {
+        logger.L.Info("customer_deleted", slog.Int("customer_id", id))
+    }
+    return err
+}
+
+// Update 修改客户资料；订单已保存的 customer_name 与容差不随之改变。
+ func (s *customersService) Update(customer *models.Customer) error {
+    if customer == nil || customer.CustomerID <= 0 {
+        return ErrValidation
+    }
+    if err := validateCustomer(customer); err != nil {
+        return err
+    }
+    ctx := context.Background()
+    if err := s.ensureUnique(ctx, customer, customer.CustomerID); err != nil {
+        return err
+    }
+    err := s.repo.Update(ctx, customer)
+    if err == nil {
+        // 事件日志：客户资料修改
+        // 字段：customer_id、customer_code
+        logger.L.Info("customer_updated",
+            slog.Int("customer_id", customer.CustomerID),
+            slog.String("customer_code", customer.CustomerCode),
+        )
+    }
+    return err
+}
+
+// GetByID 查询单个客户。
+ func (s *customersService) GetByID(id int) (*models.Customer, error) {
+    if id <= 0 {
+        return nil, ErrValidation
+    }
+    return s.repo.GetByID(context.Background(), id)
+}
+
+// List 列出客户。
+ func (s *customersService) List(query string) ([]models.Customer, error) {
+    return s.repo.List(context.Background(), strings.TrimSpace(query))
+}
+
+// ensureUnique 预检代码与名称唯一；selfID 为当前客户（修改时排除自身）。
+ func (s *customersService) ensureUnique(ctx context.Context, customer *models.Customer, selfID int) error {
+    existing, err := s.repo.GetByCode(ctx, customer.CustomerCode)
+    if err == nil && existing.CustomerID != selfID {
+        return fmt.Errorf("%w: customer_code already exists", ErrConflict)
+    }
+    if err != nil && !errors.Is(err, sql.ErrNoRows) {
+        return err
+    }
+    existing, err = s.repo.GetByName(ctx, customer.CustomerName)
+    if err == nil && existing.CustomerID != selfID {
+        return fmt.Errorf("%w: customer_name already exists", ErrConflict)
+    }
+    if err != nil && !errors.Is(err, sql.ErrNoRows) {
+        return err
+    }
+    return nil
+}
+
+// validateCustomer 规整并校验代码、名称与默认容差；空白的可选文本字段视为未填。
+func validateCustomer(customer *models.Customer) error {
+    customer.CustomerCode = strings.TrimSpace(customer.CustomerCode)
+    customer.CustomerName = strings.TrimSpace(customer.CustomerName)
+    if customer.CustomerCode == "" {
+        return fmt.Errorf("%w: customer_code required", ErrValidation)
+    }
+    if customer.CustomerName == "" {
+        return fmt.Errorf("%w: customer_name required", ErrValidation)
+    }
+    for _, p := range []**string{&customer.ContactName, &customer.Phone, &customer.Email, &customer.Address, &customer.Note} {
+        if *p != nil && strings.TrimSpace(**p) == "" {
+            *p = nil
+        }
+    }
+    return validateTolerance(customer.OverCutTolerance, customer.UnderCutTolerance)
+}
@@ -0,0 +1,18 @@
+package services
+
+import "cutrix-backend/internal/models"
+
+// CutPlanningService 根据订单项（颜色/尺码/数量）自动提出裁剪排料方案：布局、尺码比例与各颜色层数。
+// 约束与约定：
+// - 每个布局（唛架）的尺码比例之和不超过 MaxGarmentsPerMarker；每个任务的层数不超过 MaxPlies。
+// - 每个颜色/尺码的计划件数必须覆盖订单数量，且超裁不超过 OverCutTolerance（百分比，向下取整到件）；
+//   订单设定了超裁容差时，OverCutTolerance 未指定或超出订单容差则取订单值。
+// - 采用贪心启发式：每一轮选择覆盖剩余需求最多的比例与层数，尽量减少唛架数量；结果不保证全局最优。
+// - GenerateDraft 将方案以 pending 计划原子写入（计划+布局+比例+任务），供制版员在发布前调整。
+// 注意：接口签名不传 context；实现中使用 context.Background() 调用仓储，保持与 handlers 解耦。
+ type CutPlanningService interface {
+    // 查询：仅计算方案，不写库。
+    Propose(orderID int, opts models.CutPlanOptions) (*models.CutPlanProposal, error)
+    // 变更：计算方案并写入为 pending 计划；planName 为空时按订单号生成默认名称。
+    GenerateDraft(orderID int, planName string, opts models.CutPlanOptions) (*models.ProductionPlan, *models.CutPlanProposal, error)
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log/slog"
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/repositories"
+    "cutrix-backend/internal/logger"
+)
+
+const (
+    // 默认约束：请求未提供时使用
+    defaultMaxPlies             = 100
+    defaultMaxGarmentsPerMarker = 6
+    // 单个方案允许的最大唛架数，防止异常输入导致无界循环
+    maxProposalMarkers = 200
+)
+
+// cutPlanningService 实现 CutPlanningService。
+// 设计要点：
+// - 纯计算部分（proposeLayouts）不依赖仓储，便于复核与替换算法。
+// - 写入通过 PlansRepository.CreateWithLayouts 原子完成，任一布局/比例/任务失败整体回滚。
+// - 颜色/尺码约束仍由触发器（ensure_task_color_in_order / ensure_layout_size_in_order）兜底。
+ type cutPlanningService struct {
+    orders repositories.OrdersRepository
+    plans  repositories.PlansRepository
+}
+
+// NewCutPlanningService 以给定的订单与计划仓储创建 CutPlanningService。
+// 返回：可用的 CutPlanningService；任一仓储为 nil 将 panic。
+ func NewCutPlanningService(orders repositories.OrdersRepository, plans repositories.PlansRepository) CutPlanningService {
+    if orders == nil || plans == nil {
+        panic("nil repository for CutPlanningService")
+    }
+    return &cutPlanningService{orders: orders, plans: plans}
+}
+
+// Propose 计算订单的排料方案，不写库。
+// orderID：订单 ID；opts：约束，零值字段使用默认值。
+// 返回：方案与错误；约束非法时返回 ErrValidation。
+ func (s *cutPlanningService) Propose(orderID int, opts models.CutPlanOptions) (*models.CutPlanProposal, error) {
+    if orderID <= 0 {
+        return nil, errors.New("invalid order_id")
+    }
+    opts, err := normalizeCutPlanOptions(opts)
+    if err != nil {
+        return nil, err
+    }
+    order, items, err := s.orders.GetWithItems(context.Background(), orderID)
+    if err != nil {
+        return nil, err
+    }
+    opts = applyOrderTolerance(opts, order)
+    layouts, err := proposeLayouts(items, opts)
+    if err != nil {
+        return nil, err
+    }
+    return newCutPlanProposal(orderID, opts, items, layouts), nil
+}
+
+// GenerateDraft 计算方案并写入为 pending 计划。
+// orderID：订单 ID；planName：计划名称，可为空；opts：约束。
+// 返回：新计划、写入后的方案（含生成的布局/任务 ID）与错误。
+ func (s *cutPlanningService) GenerateDraft(orderID int, planName string, opts models.CutPlanOptions) (*models.ProductionPlan, *models.CutPlanProposal, error) {
+    if orderID <= 0 {
+        return nil, nil, errors.New("invalid order_id")
+    }
+    opts, err := normalizeCutPlanOptions(opts)
+    if err != nil {
+        return nil, nil, err
+    }
+    ctx := context.Background()
+    order, items, err := s.orders.GetWithItems(ctx, orderID)
+    if err != nil {
+        return nil, nil, err
+    }
+    opts = applyOrderTolerance(opts, order)
+    layouts, err := proposeLayouts(items, opts)
+    if err != nil {
+        return nil, nil, err
+    }
+    if planName == "" {
+        planName = order.OrderNumber + "-AUTO"
+    }
+    note := "自动排料草稿，发布前请制版员核对"
+    plan := &models.ProductionPlan{PlanName: planName, OrderID: orderID, Note: &note}
+    if _, err := s.plans.CreateWithLayouts(ctx, plan, layouts); err != nil {
+        return nil, nil, err
+    }
+    // 事件日志：自动排料草稿生成
+    // 字段：plan_id、order_id、marker_count
+    logger.L.Info("plan_draft_generated",
+        slog.Int("plan_id", plan.PlanID),
+        slog.Int("order_id", orderID),
+        slog.Int("marker_count", len(layouts)),
+    )
+    return plan, newCutPlanProposal(orderID, opts, items, layouts), nil
+}
+
+// normalizeCutPlanOptions 填充默认值并校验约束。
+func normalizeCutPlanOptions(opts models.CutPlanOptions) (models.CutPlanOptions, error) {
+    if opts.MaxPlies == 0 { opts.MaxPlies = defaultMaxPlies }
+    if opts.MaxGarmentsPerMarker == 0 { opts.MaxGarmentsPerMarker = defaultMaxGarmentsPerMarker }
+    if opts.MaxPlies < 0 {
+        return opts, fmt.Errorf("%w: max_plies must be > 0", ErrValidation)
+    }
+    if opts.MaxGarmentsPerMarker < 0 {
+        return opts, fmt.Errorf("%w: max_garments_per_marker must be > 0", ErrValidation)
+    }
+    if opts.OverCutTolerance < 0 || opts.OverCutTolerance > 100 {
+        return opts, fmt.Errorf("%w: over_cut_tolerance must be within [0, 100]", ErrValidation)
+    }
+    return opts, nil
+}
+
+// applyOrderTolerance 使超裁容差不超过订单设定：未指定（0）或超出订单容差时取订单值，保证草稿可以发布。
+func applyOrderTolerance(opts models.CutPlanOptions, order *models.ProductionOrder) models.CutPlanOptions {
+    if order == nil || order.OverCutTolerance == nil {
+        return opts
+    }
+    if opts.OverCutTolerance == 0 || opts.OverCutTolerance > *order.OverCutTolerance {
+        opts.OverCutTolerance = *order.OverCutTolerance
+    }
+    return opts
+}
+
+// newCutPlanProposal 组装方案输出，并用与覆盖度报表相同的口径计算各格件数。
+func newCutPlanProposal(orderID int, opts models.CutPlanOptions, items []models.OrderItem, layouts []models.DraftLayout) *models.CutPlanProposal {
+    ratios := make(map[int][]models.LayoutSizeRatio, len(layouts))
+    var tasks []models.ProductionTask
+    for i, l := range layouts {
+        key := i + 1 // 写库前布局尚无 ID，用序号作为临时键
+        for size, r := range l.Ratios {
+            ratios[key] = append(ratios[key], models.LayoutSizeRatio{LayoutID: key, Size: size, Ratio: r})
+        }
+        for _, t := range l.Tasks {
+            t.LayoutID = key
+            tasks = append(tasks, t)
+        }
+    }
+    cov := buildCoverage(items, ratios, tasks, nil)
+    return &models.CutPlanProposal{
+        OrderID:     orderID,
+        Options:     opts,
+        Layouts:     layouts,
+        MarkerCount: len(layouts),
+        Cells:       cov.Cells,
+    }
+}
+
+// cutMarker 为算法内部的唛架表示：按尺码下标的比例与按颜色下标的层数。
+type cutMarker struct {
+    ratio  []int
+    layers []int
+}
+
+// proposeLayouts 以贪心启发式生成唛架：
+// 1) 每轮枚举层高 h = 1..MaxPlies，比例取各颜色剩余需求 / h 的最大值，并按 MaxGarmentsPerMarker 等比缩放；
+// 2) 每个颜色的层数取不超过 MaxPlies、不突破超裁上限、且仍能产出所需件数的最大值；
+// 3) 选择本轮覆盖剩余需求最多的候选（同分取件数更少者），比例相同且层数不超限时并入已有唛架；
+// 4) 若无候选可行，则退化为单颜色唛架（比例受剩余需求与超裁余量约束），保证每轮至少覆盖一件；
+//    仍铺不出任何层时返回 ErrValidation。
+func proposeLayouts(items []models.OrderItem, opts models.CutPlanOptions) ([]models.DraftLayout, error) {
+    var colors, sizes []string
+    colorIdx := make(map[string]int)
+    sizeIdx := make(map[string]int)
+    for _, it := range items {
+        if _, ok := colorIdx[it.Color]; !ok { colorIdx[it.Color] = len(colors); colors = append(colors, it.Color) }
+        if _, ok := sizeIdx[it.Size]; !ok { sizeIdx[it.Size] = len(sizes); sizes = append(sizes, it.Size) }
+    }
+    if len(colors) == 0 {
+        return nil, fmt.Errorf("%w: order has no items", ErrValidation)
+    }
+
+    demand := newIntGrid(len(colors), len(sizes))
+    for _, it := range items {
+        demand[colorIdx[it.Color]][sizeIdx[it.Size]] += it.Quantity
+    }
+    upper := newIntGrid(len(colors), len(sizes))
+    for c := range demand {
+        for s := range demand[c] {
+            upper[c][s] = demand[c][s] + int(float64(demand[c][s])*opts.OverCutTolerance/100)
+        }
+    }
+    produced := newIntGrid(len(colors), len(sizes))
+
+    var markers []cutMarker
+    for {
+        need := newIntGrid(len(colors), len(sizes))
+        room := newIntGrid(len(colors), len(sizes))
+        total := 0
+        for c := range demand {
+            for s := range demand[c] {
+                if v := demand[c][s] - produced[c][s]; v > 0 { need[c][s] = v; total += v }
+                room[c][s] = upper[c][s] - produced[c][s]
+            }
+        }
+        if total == 0 {
+            break
+        }
+        if len(markers) >= maxProposalMarkers {
+            return nil, fmt.Errorf("%w: cannot fit order within %d markers, relax constraints", ErrValidation, maxProposalMarkers)
+        }
+
+        m := bestMarker(need, room, opts.MaxPlies, opts.MaxGarmentsPerMarker)
+        plies := 0
+        for c, l := range m.layers {
+            plies += l
+            for s, r := range m.ratio {
+                produced[c][s] += l * r
+            }
+        }
+        if plies == 0 {
+            // 零层唛架会被并入已有唛架，唛架数不再增长，继续循环将无法结束
+            return nil, fmt.Errorf("%w: cannot place any plies for the remaining demand, relax constraints", ErrValidation)
+        }
+        markers = mergeMarker(markers, m, opts.MaxPlies)
+    }
+
+    out := make([]models.DraftLayout, 0, len(markers))
+    for i, m := range markers {
+        dl := models.DraftLayout{
+            Layout: models.CuttingLayout{LayoutName: fmt.Sprintf("AUTO-%d", i+1)},
+            Ratios: make(map[string]int),
+        }
+        for s, r := range m.ratio {
+            if r > 0 { dl.Ratios[sizes[s]] = r }
+        }
+        for c, l := range m.layers {
+            if l > 0 { dl.Tasks = append(dl.Tasks, models.ProductionTask{Color: colors[c], PlannedLayers: l}) }
+        }
+        out = append(out, dl)
+    }
+    return out, nil
+}
+
+// bestMarker 枚举层高候选并返回覆盖剩余需求最多的唛架。
+func bestMarker(need, room [][]int, maxPlies, maxGarments int) cutMarker {
+    nSizes := len(need[0])
+    var best cutMarker
+    bestValue, bestGarments := 0, 0
+    for h := 1; h <= maxPlies; h++ {
+        ratio := make([]int, nSizes)
+        sum := 0
+        for s := 0; s < nSizes; s++ {
+            for c := range need {
+                if v := need[c][s] / h; v > ratio[s] { ratio[s] = v }
+            }
+            sum += ratio[s]
+        }
+        if sum == 0 {
+            break // 更高的层高只会得到更小的比例
+        }
+        if sum > maxGarments {
+            scaled := 0
+            for s := range ratio {
+                ratio[s] = ratio[s] * maxGarments / sum
+                scaled += ratio[s]
+            }
+            if scaled == 0 { continue }
+            sum = scaled
+        }
+        layers, value := fitLayers(ratio, need, room, maxPlies)
+        if value > bestValue || (value == bestValue && value > 0 && sum < bestGarments) {
+            best = cutMarker{ratio: ratio, layers: layers}
+            bestValue, bestGarments = value, sum
+        }
+    }
+    if bestValue > 0 {
+        return best
+    }
+
+    // 退化：取剩余需求最多的颜色，按其自身需求构造比例
+    pick, pickTotal := 0, -1
+    for c := range need {
+        t := 0
+        for _, v := range need[c] { t += v }
+        if t > pickTotal { pick, pickTotal = c, t }
+    }
+    ratio := make([]int, nSizes)
+    sum := 0
+    for s, v := range need[pick] {
+        // 比例不超过该格剩余需求与超裁余量，保证至少能铺一层
+        ratio[s] = min(v*maxGarments/pickTotal, v, room[pick][s])
+        sum += ratio[s]
+    }
+    if sum == 0 {
+        // 件数上限过小时逐个尺码取 1，优先需求最大的尺码
+        for sum < maxGarments {
+            maxS := -1
+            for s, v := range need[pick] {
+                if v > 0 && room[pick][s] > 0 && ratio[s] == 0 && (maxS < 0 || v > need[pick][maxS]) { maxS = s }
+            }
+            if maxS < 0 { break }
+            ratio[maxS] = 1
+            sum++
+        }
+    }
+    single := make([][]int, len(need))
+    for c := range need {
+        single[c] = make([]int, nSizes)
+        if c == pick { copy(single[c], need[c]) }
+    }
+    layers, _ := fitLayers(ratio, single, room, maxPlies)
+    return cutMarker{ratio: ratio, layers: layers}
+}
+
+// fitLayers 计算给定比例下每个颜色可铺的层数及其覆盖的剩余需求件数。
+func fitLayers(ratio []int, need, room [][]int, maxPlies int) ([]int, int) {
+    layers := make([]int, len(need))
+    value := 0
+    for c := range need {
+        l := maxPlies
+        useful := 0
+        for s, r := range ratio {
+            if r <= 0 { continue }
+            if v := room[c][s] / r; v < l { l = v }
+            if u := (need[c][s] + r - 1) / r; u > useful { useful = u }
+        }
+        if useful < l { l = useful }
+        if l <= 0 { continue }
+        layers[c] = l
+        for s, r := range ratio {
+            value += min(l*r, need[c][s])
+        }
+    }
+    return layers, value
+}
+
+// mergeMarker 将比例相同且合并后各颜色层数不超过 maxPlies 的唛架合并，减少唛架数量。
+func mergeMarker(markers []cutMarker, m cutMarker, maxPlies int) []cutMarker {
+    for i := range markers {
+        if !equalInts(markers[i].ratio, m.ratio) { continue }
+        fits := true
+        for c := range m.layers {
+            if markers[i].layers[c]+m.layers[c] > maxPlies { fits = false; break }
+        }
+        if !fits { continue }
+        for c := range m.layers {
+            markers[i].layers[c] += m.layers[c]
+        }
+        return markers
+    }
+    return append(markers, m)
+}
+
+func newIntGrid(rows, cols int) [][]int {
+    g := make([][]int, rows)
+    for i := range g { g[i] = make([]int, cols) }
+    return g
+}
+
+func equalInts(a, b []int) bool {
+    if len(a) != len(b) { return false }
+    for i := range a {
+        if a[i] != b[i] { return false }
+    }
+    return true
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+    "errors"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+// proposeWithin 在限定时间内运行 proposeLayouts，防止算法回退时无限循环拖住整个测试。
+func proposeWithin(t *testing.T, items []models.OrderItem, opts models.CutPlanOptions) ([]models.DraftLayout, error) {
+    t.Helper()
+    type result struct {
+        layouts []models.DraftLayout
+        err     error
+    }
+    done := make(chan result, 1)
+    go func() {
+        layouts, err := proposeLayouts(items, opts)
+        done <- result{layouts, err}
+    }()
+    select {
+    case r := <-done:
+        return r.layouts, r.err
+    case <-time.After(5 * time.Second):
+        t.Fatalf("proposeLayouts did not finish within 5s")
+        return nil, nil
+    }
+}
+
+// checkProposal 校验每个唛架满足约束，且各颜色/尺码的产出在 [下单数, 下单数 + 超裁容差] 内。
+func checkProposal(t *testing.T, items []models.OrderItem, opts models.CutPlanOptions, layouts []models.DraftLayout) {
+    t.Helper()
+    produced := map[[2]string]int{}
+    for i, l := range layouts {
+        garments := 0
+        for _, r := range l.Ratios { garments += r }
+        if garments == 0 || garments > opts.MaxGarmentsPerMarker {
+            t.Fatalf("layout %d: %d garments per marker, want 1..%d", i, garments, opts.MaxGarmentsPerMarker)
+        }
+        if len(l.Tasks) == 0 { t.Fatalf("layout %d has no tasks", i) }
+        for _, task := range l.Tasks {
+            if task.PlannedLayers <= 0 || task.PlannedLayers > opts.MaxPlies {
+                t.Fatalf("layout %d color %s: %d layers, want 1..%d", i, task.Color, task.PlannedLayers, opts.MaxPlies)
+            }
+            for size, r := range l.Ratios {
+                produced[[2]string{task.Color, size}] += task.PlannedLayers * r
+            }
+        }
+    }
+    ordered := map[[2]string]int{}
+    for _, it := range items {
+        ordered[[2]string{it.Color, it.Size}] += it.Quantity
+    }
+    for key, got := range produced {
+        if _, ok := ordered[key]; !ok && got > 0 { t.Fatalf("%s/%s: %d pieces planned but not ordered", key[0], key[1], got) }
+    }
+    for key, qty := range ordered {
+        maxQty := qty + int(float64(qty)*opts.OverCutTolerance/100)
+        if got := produced[key]; got < qty || got > maxQty {
+            t.Fatalf("%s/%s: planned %d, want %d..%d", key[0], key[1], got, qty, maxQty)
+        }
+    }
+}
+
+func TestProposeLayouts_CoversOrderExactly(t *testing.T) {
+    items := []models.OrderItem{
+        {Color: "Red", Size: "S", Quantity: 40},
+        {Color: "Red", Size: "M", Quantity: 80},
+        {Color: "Red", Size: "L", Quantity: 40},
+        {Color: "Blue", Size: "M", Quantity: 30},
+    }
+    opts := models.CutPlanOptions{MaxPlies: 100, MaxGarmentsPerMarker: 6}
+    layouts, err := proposeWithin(t, items, opts)
+    if err != nil { t.Fatalf("propose: %v", err) }
+    checkProposal(t, items, opts, layouts)
+}
+
+// 回归：回退比例未受剩余需求约束时铺不出任何层，且会被并入已有唛架，导致循环不收敛。
+func TestProposeLayouts_FallbackTerminates(t *testing.T) {
+    items := []models.OrderItem{
+        {Color: "R", Size: "S", Quantity: 174},
+        {Color: "R", Size: "M", Quantity: 362},
+        {Color: "R", Size: "L", Quantity: 293},
+        {Color: "R", Size: "XL", Quantity: 215},
+        {Color: "B", Size: "M", Quantity: 349},
+        {Color: "B", Size: "L", Quantity: 241},
+        {Color: "B", Size: "XL", Quantity: 191},
+    }
+    opts := models.CutPlanOptions{MaxPlies: 67, MaxGarmentsPerMarker: 8}
+    layouts, err := proposeWithin(t, items, opts)
+    if err != nil { t.Fatalf("propose: %v", err) }
+    checkProposal(t, items, opts, layouts)
+    if len(layouts) > maxProposalMarkers { t.Fatalf("%d markers exceed the limit %d", len(layouts), maxProposalMarkers) }
+}
+
+func TestProposeLayouts_OverCutTolerance(t *testing.T) {
+    items := []models.OrderItem{
+        {Color: "Navy", Size: "M", Quantity: 101},
+        {Color: "Navy", Size: "L", Quantity: 53},
+    }
+    opts := models.CutPlanOptions{MaxPlies: 20, MaxGarmentsPerMarker: 4, OverCutTolerance: 5}
+    layouts, err := proposeWithin(t, items, opts)
+    if err != nil { t.Fatalf("propose: %v", err) }
+    checkProposal(t, items, opts, layouts)
+}
+
+func TestProposeLayouts_NoItems(t *testing.T) {
+    if _, err := proposeLayouts(nil, models.CutPlanOptions{MaxPlies: 10, MaxGarmentsPerMarker: 4}); !errors.Is(err, ErrValidation) {
+        t.Fatalf("want ErrValidation, got %v", err)
+    }
+}
+
+func TestNormalizeCutPlanOptions(t *testing.T) {
+    opts, err := normalizeCutPlanOptions(models.CutPlanOptions{})
+    if err != nil || opts.MaxPlies != defaultMaxPlies || opts.MaxGarmentsPerMarker != defaultMaxGarmentsPerMarker {
+        t.Fatalf("defaults: %+v, %v", opts, err)
+    }
+    for _, bad := range []models.CutPlanOptions{{MaxPlies: -1}, {MaxGarmentsPerMarker: -1}, {OverCutTolerance: 101}} {
+        if _, err := normalizeCutPlanOptions(bad); !errors.Is(err, ErrValidation) { t.Fatalf("%+v: want ErrValidation, got %v", bad, err) }
+    }
+}
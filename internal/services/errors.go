@@ -1,12 +1,28 @@
 package services
 
-import "errors"
+import (
+    "errors"
+
+    "cutrix-backend/internal/repositories"
+)
 
 // Unified error variables used across services and handlers.
 var (
     ErrUnauthorized = errors.New("unauthorized")
     ErrForbidden    = errors.New("forbidden")
-    ErrConflict     = errors.New("conflict")
+    ErrConflict     = repositories.ErrConflict
     ErrNotFound     = errors.New("not found")
-    ErrValidation   = errors.New("validation error")
-)
\ No newline at end of file
+    ErrValidation   = repositories.ErrValidation
+)
+
+// ToleranceViolationError reports plan publish rejected by the order's over/under-cut tolerance.
+// It carries the offending color/size cells; handlers map it to 400 tolerance_violation.
+type ToleranceViolationError = repositories.ToleranceViolationError
+
+// LotMixError reports a log that would spread a second dye lot into a task without allow_lot_mix.
+// Handlers map it to 409 lot_mix.
+type LotMixError = repositories.LotMixError
+
+// PlanCloneMismatchError reports a plan clone whose colors/sizes (after remapping) are missing from the target order.
+// Handlers map it to 400 clone_mismatch.
+type PlanCloneMismatchError = repositories.PlanCloneMismatchError
\ No newline at end of file
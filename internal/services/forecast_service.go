@@ -0,0 +1,19 @@
+package services
+
+import "cutrix-backend/internal/models"
+
+// ForecastService 预测订单交期并识别有延误风险的订单。
+// 约束与约定：
+// - 剩余工作量：订单下未完成任务的剩余计划层数（计划层数 - 已完成层数）。
+// - 产能：近 14 天有效日志的总层数 ÷ 14，得到全厂每日层数（含停工日，反映实际日历产出）。
+// - 排队：有剩余层数的订单按交期（order_finish_date，空值靠后）排队共享产能，
+//   预测完成时间 = 当前时间 + 累计剩余层数 ÷ 每日层数。
+// - 分级：预测完成晚于交期为 late；余量不足 48 小时为 at_risk；否则 on_track。近期无产量时无法预测，
+//   已过交期为 late，否则为 at_risk。未设交期的订单参与排队但不输出。
+// - 客户过滤：customerID 非 nil 时仅输出该客户的订单，但排队仍包含全部订单（产能由所有订单共享）。
+// - 不落库：每次请求即时计算，无需在日志写入时维护预测结果。
+// 注意：接口签名不传 context；实现中使用 context.Background() 调用仓储。
+ type ForecastService interface {
+    // 交期风险：all 为 false 时仅返回 at_risk 与 late 的订单，按交期先后排序。
+    AtRisk(all bool, customerID *int) (*models.OrderRiskReport, error)
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "time"
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/repositories"
+)
+
+// 交期预测参数。
+const (
+    forecastLookbackDays = 14
+    forecastRiskSlack    = 48 * time.Hour
+    forecastMaxDays      = 100 * 365 // 预测天数上限，避免产能极低时 time.Duration 溢出
+)
+
+// 交期风险分级。
+const (
+    ForecastOnTrack = "on_track"
+    ForecastAtRisk  = "at_risk"
+    ForecastLate    = "late"
+)
+
+// forecastService 实现 ForecastService：仓储只负责取数，排队推算在服务层完成。
+ type forecastService struct {
+    repo repositories.ForecastRepository
+    now  func() time.Time
+}
+
+// NewForecastService 以给定仓储创建 ForecastService；nil 仓储将 panic。
+ func NewForecastService(repo repositories.ForecastRepository) ForecastService {
+    if repo == nil {
+        panic("nil repository for ForecastService")
+    }
+    return &forecastService{repo: repo, now: time.Now}
+}
+
+// AtRisk 按交期排队推算各订单的预测完成时间并分级。
+ func (s *forecastService) AtRisk(all bool, customerID *int) (*models.OrderRiskReport, error) {
+    if customerID != nil && *customerID <= 0 {
+        return nil, fmt.Errorf("%w: invalid customer_id", ErrValidation)
+    }
+    ctx := context.Background()
+    now := s.now()
+    backlog, err := s.repo.ListOrderBacklog(ctx)
+    if err != nil {
+        return nil, err
+    }
+    layers, err := s.repo.LayersSince(ctx, now.AddDate(0, 0, -forecastLookbackDays))
+    if err != nil {
+        return nil, err
+    }
+    perDay := float64(layers) / forecastLookbackDays
+
+    out := &models.OrderRiskReport{GeneratedAt: now, LookbackDays: forecastLookbackDays, LayersPerDay: round2(perDay), CustomerID: customerID, Orders: []models.OrderForecast{}}
+    queued := 0
+    for _, b := range backlog {
+        queued += b.RemainingLayers
+        if b.OrderFinishDate == nil || (customerID != nil && (b.CustomerID == nil || *b.CustomerID != *customerID)) {
+            continue
+        }
+        f := models.OrderForecast{
+            OrderID: b.OrderID, OrderNumber: b.OrderNumber, StyleNumber: b.StyleNumber, OrderFinishDate: *b.OrderFinishDate,
+            RemainingLayers: b.RemainingLayers, QueuedLayers: queued,
+        }
+        classifyForecast(&f, now, perDay)
+        if all || f.Status != ForecastOnTrack {
+            out.Orders = append(out.Orders, f)
+        }
+    }
+    return out, nil
+}
+
+// classifyForecast 计算预测完成时间与余量并分级；perDay 为 0 时不预测。
+func classifyForecast(f *models.OrderForecast, now time.Time, perDay float64) {
+    if perDay <= 0 {
+        f.Status = ForecastAtRisk
+        if now.After(f.OrderFinishDate) {
+            f.Status = ForecastLate
+        }
+        return
+    }
+    days := float64(f.QueuedLayers) / perDay
+    if days > forecastMaxDays {
+        days = forecastMaxDays
+    }
+    finish := now.Add(time.Duration(days * float64(24*time.Hour)))
+    slack := f.OrderFinishDate.Sub(finish)
+    hours := round2(slack.Hours())
+    f.ForecastFinishDate, f.SlackHours = &finish, &hours
+    switch {
+    case slack < 0:
+        f.Status = ForecastLate
+    case slack < forecastRiskSlack:
+        f.Status = ForecastAtRisk
+    default:
+        f.Status = ForecastOnTrack
+    }
+}
@@ -0,0 +1,22 @@
+package services
+
+import "cutrix-backend/internal/models"
+
+// LabelsService 渲染扎包与任务卡的可打印标签，并解析扫码结果。
+// 约束与约定：
+// - 格式：zpl（热敏打印机，4"×2"，203dpi）或 pdf（A4，每页 5 张，默认）。
+// - 条码：code128（默认）或 qr；qr 由打印机绘制，仅支持 zpl。
+// - 载荷：CTX1;<B|T>;<ID>;<订单号>;<款号>;<颜色>;<尺码>，文本字段百分号编码；任务卡尺码为布局尺码以 "/" 连接。
+// - 扫码：Lookup 以载荷中的类型与 ID 定位记录；所印字段与当前记录不一致时标记 stale。
+// 注意：接口签名不传 context；实现中使用 context.Background() 调用仓储。
+ type LabelsService interface {
+    // 渲染：单个扎包标签。
+    BundleLabel(id int, format, symbology string) (*models.LabelDocument, error)
+    // 渲染：任务下全部扎包标签（任务须已生成扎包）。
+    TaskBundleLabels(taskID int, format, symbology string) (*models.LabelDocument, error)
+    // 渲染：任务卡标签。
+    TaskLabel(taskID int, format, symbology string) (*models.LabelDocument, error)
+
+    // 查询：解析扫码内容并返回对应扎包或任务。
+    Lookup(code string) (*models.LabelLookup, error)
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "cutrix-backend/internal/labels"
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/repositories"
+)
+
+// labelsService 实现 LabelsService，组合扎包、任务、布局、计划与订单仓储取数，渲染交给 labels 包。
+ type labelsService struct {
+    bundles repositories.BundlesRepository
+    tasks   repositories.TasksRepository
+    layouts repositories.LayoutsRepository
+    plans   repositories.PlansRepository
+    orders  repositories.OrdersRepository
+}
+
+// NewLabelsService 以给定仓储实现创建 LabelsService；任一仓储为 nil 将 panic。
+ func NewLabelsService(bundles repositories.BundlesRepository, tasks repositories.TasksRepository, layouts repositories.LayoutsRepository, plans repositories.PlansRepository, orders repositories.OrdersRepository) LabelsService {
+    if bundles == nil || tasks == nil || layouts == nil || plans == nil || orders == nil {
+        panic("nil repository for LabelsService")
+    }
+    return &labelsService{bundles: bundles, tasks: tasks, layouts: layouts, plans: plans, orders: orders}
+}
+
+// taskCard 任务卡取数结果。
+ type taskCard struct {
+    task   *models.ProductionTask
+    layout *models.CuttingLayout
+    ratios []models.LayoutSizeRatio
+    order  *models.ProductionOrder
+}
+
+// BundleLabel 渲染单个扎包标签。
+ func (s *labelsService) BundleLabel(id int, format, symbology string) (*models.LabelDocument, error) {
+    if id <= 0 {
+        return nil, errors.New("invalid bundle_id")
+    }
+    b, err := s.bundles.GetByID(context.Background(), id)
+    if err != nil {
+        return nil, err
+    }
+    return renderLabels(fmt.Sprintf("bundle-%d", id), format, symbology, []labels.Label{bundleLabel(b)})
+}
+
+// TaskBundleLabels 渲染任务全部扎包标签；任务尚无扎包时返回校验错误。
+ func (s *labelsService) TaskBundleLabels(taskID int, format, symbology string) (*models.LabelDocument, error) {
+    if taskID <= 0 {
+        return nil, errors.New("invalid task_id")
+    }
+    list, err := s.bundles.ListByTask(context.Background(), taskID)
+    if err != nil {
+        return nil, err
+    }
+    if len(list) == 0 {
+        return nil, fmt.Errorf("%w: task has no bundles", ErrValidation)
+    }
+    out := make([]labels.Label, 0, len(list))
+    for i := range list {
+        out = append(out, bundleLabel(&list[i]))
+    }
+    return renderLabels(fmt.Sprintf("task-%d-bundles", taskID), format, symbology, out)
+}
+
+// TaskLabel 渲染任务卡标签。
+ func (s *labelsService) TaskLabel(taskID int, format, symbology string) (*models.LabelDocument, error) {
+    if taskID <= 0 {
+        return nil, errors.New("invalid task_id")
+    }
+    card, err := s.loadTaskCard(taskID)
+    if err != nil {
+        return nil, err
+    }
+    return renderLabels(fmt.Sprintf("task-%d", taskID), format, symbology, []labels.Label{taskLabel(card)})
+}
+
+// Lookup 解析扫码内容；非本系统标签返回校验错误，记录不存在返回 NotFound。
+ func (s *labelsService) Lookup(code string) (*models.LabelLookup, error) {
+    p, err := labels.ParsePayload(code)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+    }
+    switch p.Kind {
+    case labels.KindBundle:
+        b, err := s.bundles.GetByID(context.Background(), p.ID)
+        if err != nil {
+            return nil, err
+        }
+        return &models.LabelLookup{
+            Kind: "bundle", Bundle: b, OrderNumber: b.OrderNumber, StyleNumber: b.StyleNumber,
+            Stale: bundleLabel(b).Payload != p,
+        }, nil
+    default:
+        card, err := s.loadTaskCard(p.ID)
+        if err != nil {
+            return nil, err
+        }
+        return &models.LabelLookup{
+            Kind: "task", Task: card.task, OrderNumber: card.order.OrderNumber, StyleNumber: card.order.StyleNumber,
+            Stale: taskLabel(card).Payload != p,
+        }, nil
+    }
+}
+
+// loadTaskCard 按 任务 → 布局（含尺码比例）→ 计划 → 订单 取数。
+ func (s *labelsService) loadTaskCard(taskID int) (*taskCard, error) {
+    ctx := context.Background()
+    task, err := s.tasks.GetByID(ctx, taskID)
+    if err != nil {
+        return nil, err
+    }
+    layout, err := s.layouts.GetByID(ctx, task.LayoutID)
+    if err != nil {
+        return nil, err
+    }
+    ratios, err := s.layouts.GetRatios(ctx, task.LayoutID)
+    if err != nil {
+        return nil, err
+    }
+    plan, err := s.plans.GetByID(ctx, layout.PlanID)
+    if err != nil {
+        return nil, err
+    }
+    order, err := s.orders.GetByID(plan.OrderID)
+    if err != nil {
+        return nil, err
+    }
+    return &taskCard{task: task, layout: layout, ratios: ratios, order: order}, nil
+}
+
+// bundleLabel 组装扎包标签内容。
+func bundleLabel(b *models.Bundle) labels.Label {
+    lot := "-"
+    if b.DyeLot != nil { lot = *b.DyeLot }
+    return labels.Label{
+        Title: fmt.Sprintf("扎 #%d · %s · %d 件", b.BundleNo, b.Size, b.Pieces),
+        Lines: []string{
+            fmt.Sprintf("订单 %s  款号 %s", b.OrderNumber, b.StyleNumber),
+            fmt.Sprintf("颜色 %s  缸号 %s", b.Color, lot),
+            fmt.Sprintf("层号 %d-%d  份次 %d", b.PlyFrom, b.PlyTo, b.CopyNo),
+            fmt.Sprintf("布局 %s  任务 %d", b.LayoutName, b.TaskID),
+        },
+        Payload: labels.Payload{
+            Kind: labels.KindBundle, ID: b.BundleID, OrderNumber: b.OrderNumber, StyleNumber: b.StyleNumber,
+            Color: b.Color, Size: b.Size,
+        },
+    }
+}
+
+// taskLabel 组装任务卡标签内容；尺码按布局比例顺序列出。
+func taskLabel(c *taskCard) labels.Label {
+    sizes := make([]string, 0, len(c.ratios))
+    ratios := make([]string, 0, len(c.ratios))
+    for _, r := range c.ratios {
+        sizes = append(sizes, r.Size)
+        ratios = append(ratios, fmt.Sprintf("%s×%d", r.Size, r.Ratio))
+    }
+    return labels.Label{
+        Title: fmt.Sprintf("任务 #%d · %s", c.task.TaskID, c.task.Color),
+        Lines: []string{
+            fmt.Sprintf("订单 %s  款号 %s", c.order.OrderNumber, c.order.StyleNumber),
+            fmt.Sprintf("布局 %s  比例 %s", c.layout.LayoutName, strings.Join(ratios, " ")),
+            fmt.Sprintf("计划层数 %d", c.task.PlannedLayers),
+        },
+        Payload: labels.Payload{
+            Kind: labels.KindTask, ID: c.task.TaskID, OrderNumber: c.order.OrderNumber, StyleNumber: c.order.StyleNumber,
+            Color: c.task.Color, Size: strings.Join(sizes, "/"),
+        },
+    }
+}
+
+// renderLabels 按格式与条码类型渲染标签文件；pdf 为默认格式，code128 为默认条码。
+func renderLabels(name, format, symbology string, list []labels.Label) (*models.LabelDocument, error) {
+    sym, err := labels.ParseSymbology(symbology)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+    }
+    switch strings.ToLower(strings.TrimSpace(format)) {
+    case "zpl":
+        return &models.LabelDocument{Filename: name + ".zpl", ContentType: "application/zpl", Body: labels.RenderZPL(list, sym)}, nil
+    case "", "pdf":
+        if sym != labels.Code128 {
+            return nil, fmt.Errorf("%w: pdf labels support code128 only", ErrValidation)
+        }
+        body, err := labels.RenderPDF(list)
+        if err != nil {
+            return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+        }
+        return &models.LabelDocument{Filename: name + ".pdf", ContentType: "application/pdf", Body: body}, nil
+    }
+    return nil, fmt.Errorf("%w: unsupported format %q", ErrValidation, format)
+}
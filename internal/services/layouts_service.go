@@ -5,7 +5,8 @@ import "cutrix-backend/internal/models"
 // LayoutsService 管理生产布局的受控变更与查询：创建/删除、名称与备注更新、按计划查询。
 // 约束与约定：
 // - 创建/删除/更新：仅允许在所属计划处于 pending 状态时执行；发布后（in_progress/completed/frozen）不可修改结构。
-// - 字段更新：发布后仅允许更新 note；名称更新必须在 pending 阶段完成。
+// - 字段更新：发布后仅允许更新 note；名称与部件更新必须在 pending 阶段完成。
+// - 部件：每个布局裁一个部件（shell/lining/interlining…），覆盖度、容差与计划完成按部件分别计算。
 // - 查询：提供按 ID 与按计划列出的只读视图；用于上层处理器渲染或校验。
 // - 审计一致性：任务状态更新统一走日志，不在布局服务直接影响任务状态或计划完成度。
 // - 上下文：接口不透传 context；实现使用 context.Background() 调用仓储，与处理器层解耦。
@@ -17,8 +18,14 @@ import "cutrix-backend/internal/models"
 
     // 变更：更新布局名称（仅 pending 允许）。
     UpdateName(id int, name string) error
+    // 变更：更新布局所裁部件（仅 pending 允许；空恢复默认）。
+    UpdateComponent(id int, component string) error
     // 变更：更新布局备注（发布后允许）。
     UpdateNote(id int, note *string) error
+    // 变更：更新唛架参数（唛架长度/门幅/利用率/两端损耗，仅 pending 允许）。
+    UpdateMarker(id int, spec models.MarkerSpec) error
+    // 变更：更新每扎层数（仅 pending 允许；nil 恢复默认）。
+    UpdateBundleSize(id int, size *int) error
 
     // 查询：按 ID 获取布局详情。
     GetByID(id int) (*models.CuttingLayout, error)
@@ -26,6 +33,8 @@ import "cutrix-backend/internal/models"
     List() ([]models.CuttingLayout, error)
     // 查询：按计划列出布局列表。
     ListByPlan(planID int) ([]models.CuttingLayout, error)
+    // 查询：布局用布需求（每任务 层数 ×（唛架长度 + 两端损耗））。
+    Fabric(id int) (*models.FabricRequirement, error)
 
     // 尺码比例：设置布局的尺码比例（仅 pending 允许）。
     SetRatios(id int, ratios map[string]int) error
@@ -3,10 +3,18 @@ package services
 import (
     "context"
     "errors"
+    "fmt"
+    "math"
+    "strconv"
+    "strings"
+    "unicode/utf8"
     "cutrix-backend/internal/models"
     "cutrix-backend/internal/repositories"
 )
 
+// layoutComponentMaxLen 与 cutting_layouts.component 列宽一致。
+const layoutComponentMaxLen = 50
+
 // layoutsService 实现 LayoutsService，负责在服务层封装布局相关的受控写入与只读查询。
 // 设计要点：
 // - 状态约束：创建/删除/更新仅在计划 pending 时允许；发布后仅 note 可改。
@@ -40,6 +48,13 @@ import (
     if layout.LayoutName == "" {
         return errors.New("layout_name required")
     }
+    if layout.BundleSize != nil && *layout.BundleSize <= 0 {
+        return fmt.Errorf("%w: bundle_size must be > 0", ErrValidation)
+    }
+    layout.Component = strings.TrimSpace(layout.Component)
+    if utf8.RuneCountInString(layout.Component) > layoutComponentMaxLen {
+        return fmt.Errorf("%w: component must be at most %d characters", ErrValidation, layoutComponentMaxLen)
+    }
     _, err := s.repo.Create(context.Background(), layout)
     return err
 }
@@ -67,6 +82,19 @@ import (
     return s.repo.UpdateName(context.Background(), id, name)
 }
 
+// UpdateComponent 更新布局所裁部件，仅在计划 pending 时允许；空字符串恢复默认（款式首个部件或 shell）。
+// 款式列出部件时须属于其中，由触发器校验。
+ func (s *layoutsService) UpdateComponent(id int, component string) error {
+    if id <= 0 {
+        return errors.New("invalid layout_id")
+    }
+    component = strings.TrimSpace(component)
+    if utf8.RuneCountInString(component) > layoutComponentMaxLen {
+        return fmt.Errorf("%w: component must be at most %d characters", ErrValidation, layoutComponentMaxLen)
+    }
+    return s.repo.UpdateComponent(context.Background(), id, component)
+}
+
 // UpdateNote 更新布局备注，发布后也允许。
 // id：布局 ID；note：备注，可为 nil 表示清空。
 // 返回：错误信息；状态不允由仓储返回。
@@ -77,6 +105,107 @@ import (
     return s.repo.UpdateNote(context.Background(), id, note)
 }
 
+// UpdateMarker 更新布局的唛架参数（唛架长度、门幅、利用率、两端损耗），仅在计划 pending 时允许。
+// id：布局 ID；spec：整体替换，字段为 nil 表示清空。
+// 返回：错误信息；参数越界返回 ErrValidation，状态不允由仓储返回。
+ func (s *layoutsService) UpdateMarker(id int, spec models.MarkerSpec) error {
+    if id <= 0 {
+        return errors.New("invalid layout_id")
+    }
+    if err := validateMarkerSpec(spec); err != nil {
+        return err
+    }
+    return s.repo.UpdateMarker(context.Background(), id, spec)
+}
+
+// UpdateBundleSize 更新每扎层数，仅在计划 pending 时允许；nil 表示恢复默认 10 层。
+// 已生成的扎包不受影响，如需按新层数重排请对任务重新生成扎包。
+ func (s *layoutsService) UpdateBundleSize(id int, size *int) error {
+    if id <= 0 {
+        return errors.New("invalid layout_id")
+    }
+    if size != nil && *size <= 0 {
+        return fmt.Errorf("%w: bundle_size must be > 0", ErrValidation)
+    }
+    return s.repo.UpdateBundleSize(context.Background(), id, size)
+}
+
+// Fabric 计算布局下各任务的用布需求。
+// id：布局 ID。
+// 返回：用布需求汇总与错误；布局不存在返回仓储层 NotFound 错误。
+ func (s *layoutsService) Fabric(id int) (*models.FabricRequirement, error) {
+    if id <= 0 {
+        return nil, errors.New("invalid layout_id")
+    }
+    lines, err := s.repo.ListFabricByLayout(context.Background(), id)
+    if err != nil {
+        return nil, err
+    }
+    return buildFabricRequirement("layout", id, lines), nil
+}
+
+// validateMarkerSpec 校验唛架参数取值范围，与表约束保持一致。
+func validateMarkerSpec(spec models.MarkerSpec) error {
+    if spec.MarkerLength != nil && *spec.MarkerLength <= 0 {
+        return fmt.Errorf("%w: marker_length must be > 0", ErrValidation)
+    }
+    if spec.FabricWidth != nil && *spec.FabricWidth <= 0 {
+        return fmt.Errorf("%w: fabric_width must be > 0", ErrValidation)
+    }
+    if spec.MarkerEfficiency != nil && (*spec.MarkerEfficiency <= 0 || *spec.MarkerEfficiency > 100) {
+        return fmt.Errorf("%w: marker_efficiency must be within (0, 100]", ErrValidation)
+    }
+    if spec.EndLossAllowance != nil && *spec.EndLossAllowance < 0 {
+        return fmt.Errorf("%w: end_loss_allowance must be >= 0", ErrValidation)
+    }
+    return nil
+}
+
+// buildFabricRequirement 汇总用布明细：按部件+颜色+门幅合计（不同部件或同色不同门幅为不同面料），未设置唛架长度的任务单独列出且不计入合计。
+func buildFabricRequirement(scope string, id int, lines []models.FabricRequirementLine) *models.FabricRequirement {
+    out := &models.FabricRequirement{
+        Scope:              scope,
+        ID:                 id,
+        Lines:              lines,
+        ByColor:            []models.FabricColorTotal{},
+        MissingMarkerTasks: []int{},
+    }
+    if out.Lines == nil {
+        out.Lines = []models.FabricRequirementLine{}
+    }
+    index := make(map[string]int)
+    for _, l := range lines {
+        if l.PlannedLength == nil {
+            out.MissingMarkerTasks = append(out.MissingMarkerTasks, l.TaskID)
+            continue
+        }
+        key := l.Component + "\x00" + l.Color + "\x00"
+        if l.FabricWidth != nil {
+            key += strconv.FormatFloat(*l.FabricWidth, 'f', -1, 64)
+        }
+        i, ok := index[key]
+        if !ok {
+            i = len(out.ByColor)
+            index[key] = i
+            out.ByColor = append(out.ByColor, models.FabricColorTotal{Component: l.Component, Color: l.Color, FabricWidth: l.FabricWidth})
+        }
+        out.ByColor[i].PlannedLength += *l.PlannedLength
+        out.ByColor[i].ConsumedLength += *l.ConsumedLength
+        out.TotalPlannedLength += *l.PlannedLength
+        out.TotalConsumedLength += *l.ConsumedLength
+    }
+    // 浮点累加后按毫米取整，避免输出 12.000000001 之类的噪声
+    for i := range out.ByColor {
+        out.ByColor[i].PlannedLength = roundMillimeter(out.ByColor[i].PlannedLength)
+        out.ByColor[i].ConsumedLength = roundMillimeter(out.ByColor[i].ConsumedLength)
+    }
+    out.TotalPlannedLength = roundMillimeter(out.TotalPlannedLength)
+    out.TotalConsumedLength = roundMillimeter(out.TotalConsumedLength)
+    return out
+}
+
+func roundMillimeter(meters float64) float64 { return math.Round(meters*1000) / 1000 }
+
 // GetByID 查询单个布局详情。
 // id：布局 ID。
 // 返回：布局实体只读副本与错误；不存在时返回仓储层 NotFound 错误。
@@ -2,6 +2,7 @@ package services
 
 import (
     "log/slog"
+    "strings"
     "cutrix-backend/internal/models"
     "cutrix-backend/internal/repositories"
     "cutrix-backend/internal/logger"
@@ -17,23 +18,55 @@ func (s *LogsServiceImpl) Create(log *models.ProductionLog) error {
     if log == nil { return ErrValidation }
     if log.TaskID == 0 { return ErrValidation }
     if log.LayersCompleted <= 0 { return ErrValidation }
-    err := s.repo.Create(log)
+    if log.DyeLot != nil {
+        lot := strings.TrimSpace(*log.DyeLot)
+        if lot == "" { log.DyeLot = nil } else { log.DyeLot = &lot }
+    }
+    if log.TableID != nil && *log.TableID <= 0 { return ErrValidation }
+    seen := make(map[int]bool, len(log.RollIDs))
+    for _, id := range log.RollIDs {
+        if id <= 0 || seen[id] { return ErrValidation }
+        seen[id] = true
+    }
+    var err error
+    if len(log.RollIDs) > 0 {
+        // 指定布卷：按顺序领用，日志与领用记录同一事务写入
+        err = s.repo.CreateWithRolls(log)
+    } else {
+        err = s.repo.Create(log)
+    }
     if err == nil {
         // 事件日志：生产日志创建成功
-        // 字段：log_id（若已填充）、task_id、worker_id、layers_completed
+        // 字段：log_id（若已填充）、task_id、worker_id、layers_completed、roll_ids（若领用布卷）、table_id（裁床）
         logger.L.Info("log_created",
             slog.Int("log_id", log.LogID),
             slog.Int("task_id", log.TaskID),
             slog.Any("worker_id", log.WorkerID),
             slog.Int("layers_completed", log.LayersCompleted),
+            slog.Any("roll_ids", log.RollIDs),
+            slog.Any("dye_lot", log.DyeLot),
+            slog.Any("table_id", log.TableID),
         )
+        if len(log.Warnings) > 0 {
+            // 事件日志：明确允许的混缸提交，便于追溯色差问题
+            logger.L.Warn("log_lot_mixed",
+                slog.Int("log_id", log.LogID),
+                slog.Int("task_id", log.TaskID),
+                slog.Any("dye_lot", log.DyeLot),
+            )
+        }
     }
     return err
 }
 
 func (s *LogsServiceImpl) GetByID(logID int) (*models.ProductionLog, error) {
     if logID <= 0 { return nil, ErrValidation }
-    return s.repo.GetByID(logID)
+    log, err := s.repo.GetByID(logID)
+    if err != nil || log == nil { return log, err }
+    rolls, err := s.repo.ListRollsByLog(logID)
+    if err != nil { return nil, err }
+    log.Rolls = rolls
+    return log, nil
 }
 
 func (s *LogsServiceImpl) ListParticipants(taskID int) ([]string, error) {
@@ -8,18 +8,41 @@ import (
 
 // OrdersService handles production order lifecycle: create with items, query, update, delete.
 type OrdersService interface {
-    // CreateWithItems creates an order with its items atomically.
+    // CreateWithItems creates an order with its items atomically. When the style is registered, item sizes must be in
+    // its size run and colors in its color list (if any); otherwise ErrValidation. Revise applies the same check to added items.
     CreateWithItems(ctx context.Context, order *models.ProductionOrder, items []models.OrderItem) (*models.ProductionOrder, error)
 
-    // Updates allowed by policy: note and finish date.
+    // Updates allowed by policy: note, finish date and cut tolerance.
     UpdateNote(ctx context.Context, id int, note *string) error
     UpdateFinishDate(ctx context.Context, id int, finishDate *time.Time) error
+    // UpdateTolerance sets over/under-cut tolerance percentages enforced at plan publish; nil disables that side.
+    UpdateTolerance(ctx context.Context, id int, over, under *float64) error
+    // UpdateStatus applies a manual status transition: draft → confirmed, cut_complete → closed, or cancel
+    // an order with no plan in progress. in_production / cut_complete follow plan publish and completion.
+    UpdateStatus(ctx context.Context, id int, status string) (*models.ProductionOrder, error)
+    // UpdateCustomer points the order at another customer record (nil unlinks) and returns the updated order.
+    UpdateCustomer(ctx context.Context, id int, customerID *int) (*models.ProductionOrder, error)
+
+    // Revise applies add / remove / update item changes as a new order revision and reports published plans whose
+    // coverage no longer matches the new quantities. Closed or cancelled orders return ErrConflict.
+    Revise(ctx context.Context, orderID int, changes []models.OrderItemChange, reason *string, createdBy *int) (*models.OrderRevision, error)
+    // ListRevisions returns every revision of the order, newest first.
+    ListRevisions(ctx context.Context, orderID int) ([]models.OrderRevision, error)
+    // GetRevision returns one revision of the order.
+    GetRevision(ctx context.Context, orderID, revision int) (*models.OrderRevision, error)
 
     // Queries
     GetByID(ctx context.Context, id int) (*models.ProductionOrder, error)
-    GetAll(ctx context.Context) ([]models.ProductionOrder, error)
+    // GetAll returns orders, filtered by status, customer and style number when set.
+    GetAll(ctx context.Context, filter models.OrderFilter) ([]models.ProductionOrder, error)
     GetByOrderNumber(ctx context.Context, number string) (*models.ProductionOrder, error)
+    // GetWithItems returns the order and the items of its current revision.
     GetWithItems(ctx context.Context, id int) (*models.ProductionOrder, []models.OrderItem, error)
+    // Progress rolls up every plan, layout and task of the order: cut vs. ordered pieces per color/size,
+    // plan statuses, first publish / last finish dates and percent complete.
+    Progress(ctx context.Context, id int) (*models.OrderProgress, error)
+    // ProgressBatch returns Progress for up to MaxProgressBatch orders in request order; unknown IDs are skipped.
+    ProgressBatch(ctx context.Context, ids []int) ([]models.OrderProgress, error)
 
     // Delete removes an order by ID (cascades to items).
     Delete(ctx context.Context, id int) error
@@ -2,19 +2,31 @@ package services
 
 import (
     "context"
+    "database/sql"
     "errors"
+    "fmt"
+    "log/slog"
+    "math"
     "strings"
     "time"
 
+    "cutrix-backend/internal/logger"
     "cutrix-backend/internal/models"
     "cutrix-backend/internal/repositories"
 )
 
-// ordersService implements OrdersService using OrdersRepository.
-type ordersService struct { repo repositories.OrdersRepository }
+// ordersService implements OrdersService using OrdersRepository; the style catalog validates item colors and sizes,
+// the customer directory the referenced customer_id.
+type ordersService struct {
+    repo      repositories.OrdersRepository
+    styles    repositories.StylesRepository
+    customers repositories.CustomersRepository
+}
 
 // NewOrdersService constructs an OrdersService.
-func NewOrdersService(repo repositories.OrdersRepository) OrdersService { return &ordersService{repo: repo} }
+func NewOrdersService(repo repositories.OrdersRepository, styles repositories.StylesRepository, customers repositories.CustomersRepository) OrdersService {
+    return &ordersService{repo: repo, styles: styles, customers: customers}
+}
 
 // CreateWithItems creates an order with items atomically.
 func (s *ordersService) CreateWithItems(ctx context.Context, order *models.ProductionOrder, items []models.OrderItem) (*models.ProductionOrder, error) {
@@ -22,6 +34,14 @@ func (s *ordersService) CreateWithItems(ctx context.Context, order *models.Produ
     if strings.TrimSpace(order.OrderNumber) == "" { return nil, errors.New("order_number required") }
     if strings.TrimSpace(order.StyleNumber) == "" { return nil, errors.New("style_number required") }
     if len(items) == 0 { return nil, errors.New("order must include at least one item") }
+    if err := validateTolerance(order.OverCutTolerance, order.UnderCutTolerance); err != nil { return nil, err }
+    if err := s.checkCustomer(ctx, order.CustomerID); err != nil { return nil, err }
+    cells := make([][2]string, 0, len(items))
+    for i := range items {
+        items[i].Color, items[i].Size = strings.TrimSpace(items[i].Color), strings.TrimSpace(items[i].Size)
+        cells = append(cells, [2]string{items[i].Color, items[i].Size})
+    }
+    if err := s.checkStyle(ctx, order.StyleNumber, cells); err != nil { return nil, err }
     if err := s.repo.CreateWithItems(order, items); err != nil { return nil, err }
     return order, nil
 }
@@ -38,15 +58,216 @@ func (s *ordersService) UpdateFinishDate(ctx context.Context, id int, finishDate
     return s.repo.UpdateFinishDate(id, finishDate)
 }
 
+// UpdateTolerance updates the order cut tolerance.
+func (s *ordersService) UpdateTolerance(ctx context.Context, id int, over, under *float64) error {
+    if id <= 0 { return errors.New("invalid order_id") }
+    if err := validateTolerance(over, under); err != nil { return err }
+    return s.repo.UpdateTolerance(id, over, under)
+}
+
+// orderStatuses lists every order status; manualOrderTransitions maps each manual target to the statuses it may leave.
+var orderStatuses = map[string]bool{
+    "draft": true, "confirmed": true, "in_production": true, "cut_complete": true, "closed": true, "cancelled": true,
+}
+
+var manualOrderTransitions = map[string][]string{
+    "confirmed": {"draft"},
+    "closed":    {"cut_complete"},
+    "cancelled": {"draft", "confirmed", "cut_complete"},
+}
+
+// UpdateStatus validates a manual transition against the current status before handing it to the repository.
+func (s *ordersService) UpdateStatus(ctx context.Context, id int, status string) (*models.ProductionOrder, error) {
+    if id <= 0 { return nil, errors.New("invalid order_id") }
+    status = strings.TrimSpace(status)
+    if !orderStatuses[status] { return nil, fmt.Errorf("%w: unknown order status %q", ErrValidation, status) }
+    from, ok := manualOrderTransitions[status]
+    if !ok {
+        return nil, fmt.Errorf("%w: status %s follows plan publish and completion and cannot be set manually", ErrValidation, status)
+    }
+    order, err := s.repo.GetByID(id)
+    if err != nil { return nil, err }
+    if order.Status == status { return order, nil }
+    allowed := false
+    for _, f := range from {
+        if order.Status == f { allowed = true; break }
+    }
+    if !allowed {
+        return nil, fmt.Errorf("%w: cannot move order from %s to %s", ErrConflict, order.Status, status)
+    }
+    out, err := s.repo.UpdateStatus(ctx, id, status)
+    if err != nil { return nil, err }
+    // Event: order status changed manually; fields: order_id, from_status, status
+    logger.L.Info("order_status_changed",
+        slog.Int("order_id", id),
+        slog.String("from_status", order.Status),
+        slog.String("status", out.Status),
+    )
+    return out, nil
+}
+
+// UpdateCustomer reassigns the order's customer record; unknown customers are rejected before the update.
+func (s *ordersService) UpdateCustomer(ctx context.Context, id int, customerID *int) (*models.ProductionOrder, error) {
+    if id <= 0 { return nil, errors.New("invalid order_id") }
+    before, err := s.repo.GetByID(id)
+    if err != nil { return nil, err }
+    if err := s.checkCustomer(ctx, customerID); err != nil { return nil, err }
+    if err := s.repo.UpdateCustomer(ctx, id, customerID); err != nil { return nil, err }
+    out, err := s.repo.GetByID(id)
+    if err != nil { return nil, err }
+    // Event: order customer reassigned; fields: order_id, from_customer_id, customer_id
+    logger.L.Info("order_customer_changed",
+        slog.Int("order_id", id),
+        slog.Any("from_customer_id", before.CustomerID),
+        slog.Any("customer_id", out.CustomerID),
+    )
+    return out, nil
+}
+
+// Revise validates the changes against the current items before the repository applies them as a new revision.
+func (s *ordersService) Revise(ctx context.Context, orderID int, changes []models.OrderItemChange, reason *string, createdBy *int) (*models.OrderRevision, error) {
+    if orderID <= 0 { return nil, errors.New("invalid order_id") }
+    if len(changes) == 0 { return nil, fmt.Errorf("%w: changes required", ErrValidation) }
+    order, items, err := s.repo.GetWithItems(ctx, orderID)
+    if err != nil { return nil, err }
+    if order.Status == "closed" || order.Status == "cancelled" {
+        return nil, fmt.Errorf("%w: %s orders cannot be revised", ErrConflict, order.Status)
+    }
+    current := make(map[[2]string]bool, len(items))
+    for _, it := range items {
+        current[[2]string{it.Color, it.Size}] = true
+    }
+    seen := make(map[[2]string]bool, len(changes))
+    remaining := len(items)
+    for i := range changes {
+        c := &changes[i]
+        c.Color, c.Size = strings.TrimSpace(c.Color), strings.TrimSpace(c.Size)
+        c.PreviousQuantity = nil
+        if c.Color == "" || c.Size == "" { return nil, fmt.Errorf("%w: changes[%d]: color and size required", ErrValidation, i) }
+        key := [2]string{c.Color, c.Size}
+        if seen[key] { return nil, fmt.Errorf("%w: changes[%d]: %s/%s changed more than once", ErrValidation, i, c.Color, c.Size) }
+        seen[key] = true
+        switch c.Kind {
+        case "add", "update":
+            if c.Quantity == nil || *c.Quantity <= 0 { return nil, fmt.Errorf("%w: changes[%d]: quantity must be > 0", ErrValidation, i) }
+            if c.Kind == "add" && current[key] { return nil, fmt.Errorf("%w: changes[%d]: %s/%s already exists", ErrValidation, i, c.Color, c.Size) }
+            if c.Kind == "update" && !current[key] { return nil, fmt.Errorf("%w: changes[%d]: %s/%s not in order", ErrValidation, i, c.Color, c.Size) }
+            if c.Kind == "add" { remaining++ }
+        case "remove":
+            if !current[key] { return nil, fmt.Errorf("%w: changes[%d]: %s/%s not in order", ErrValidation, i, c.Color, c.Size) }
+            c.Quantity = nil
+            remaining--
+        default:
+            return nil, fmt.Errorf("%w: changes[%d]: unknown kind %q", ErrValidation, i, c.Kind)
+        }
+    }
+    if remaining <= 0 { return nil, fmt.Errorf("%w: order must keep at least one item", ErrValidation) }
+    var added [][2]string
+    for _, c := range changes {
+        if c.Kind == "add" { added = append(added, [2]string{c.Color, c.Size}) }
+    }
+    if err := s.checkStyle(ctx, order.StyleNumber, added); err != nil { return nil, err }
+
+    rev, err := s.repo.Revise(ctx, orderID, changes, reason, createdBy)
+    if err != nil { return nil, err }
+    flaggedIDs := make([]int, 0, len(rev.FlaggedPlans))
+    for _, f := range rev.FlaggedPlans {
+        flaggedIDs = append(flaggedIDs, f.PlanID)
+    }
+    // Event: order items revised; fields: order_id, revision, changes, flagged_plan_ids
+    logger.L.Info("order_revised",
+        slog.Int("order_id", orderID),
+        slog.Int("revision", rev.Revision),
+        slog.Int("changes", len(rev.Changes)),
+        slog.Any("flagged_plan_ids", flaggedIDs),
+    )
+    return rev, nil
+}
+
+// ListRevisions returns the revision history of an order.
+func (s *ordersService) ListRevisions(ctx context.Context, orderID int) ([]models.OrderRevision, error) {
+    if orderID <= 0 { return nil, errors.New("invalid order_id") }
+    return s.repo.ListRevisions(ctx, orderID)
+}
+
+// GetRevision returns a single revision of an order.
+func (s *ordersService) GetRevision(ctx context.Context, orderID, revision int) (*models.OrderRevision, error) {
+    if orderID <= 0 { return nil, errors.New("invalid order_id") }
+    if revision <= 0 { return nil, fmt.Errorf("%w: invalid revision", ErrValidation) }
+    return s.repo.GetRevision(ctx, orderID, revision)
+}
+
+// MaxProgressBatch caps the number of orders per ProgressBatch call.
+const MaxProgressBatch = 200
+
+// Progress returns the order progress rollup; sql.ErrNoRows when the order does not exist.
+func (s *ordersService) Progress(ctx context.Context, id int) (*models.OrderProgress, error) {
+    if id <= 0 { return nil, errors.New("invalid order_id") }
+    out, err := s.ProgressBatch(ctx, []int{id})
+    if err != nil { return nil, err }
+    if len(out) == 0 { return nil, sql.ErrNoRows }
+    return &out[0], nil
+}
+
+// ProgressBatch returns progress for several orders; duplicate IDs are collapsed.
+func (s *ordersService) ProgressBatch(ctx context.Context, ids []int) ([]models.OrderProgress, error) {
+    seen := make(map[int]bool, len(ids))
+    unique := make([]int, 0, len(ids))
+    for _, id := range ids {
+        if id <= 0 { return nil, fmt.Errorf("%w: invalid order id %d", ErrValidation, id) }
+        if !seen[id] { seen[id] = true; unique = append(unique, id) }
+    }
+    if len(unique) == 0 { return nil, fmt.Errorf("%w: ids required", ErrValidation) }
+    if len(unique) > MaxProgressBatch { return nil, fmt.Errorf("%w: at most %d orders per request", ErrValidation, MaxProgressBatch) }
+    out, err := s.repo.GetProgressBatch(ctx, unique)
+    if err != nil { return nil, err }
+    for i := range out {
+        out[i].PercentComplete = percentComplete(out[i].Cells)
+    }
+    return out, nil
+}
+
+// percentComplete caps each cell at its ordered quantity so over-cutting one size cannot hide a shortfall in another.
+func percentComplete(cells []models.CoverageCell) float64 {
+    var ordered, done int
+    for _, c := range cells {
+        ordered += c.OrderedQty
+        done += min(c.CutPieces, c.OrderedQty)
+    }
+    if ordered == 0 { return 0 }
+    return math.Round(float64(done)*10000/float64(ordered)) / 100
+}
+
+// validateTolerance checks that tolerance percentages, when set, are within [0, 100].
+func validateTolerance(over, under *float64) error {
+    if over != nil && (*over < 0 || *over > 100) {
+        return fmt.Errorf("%w: over_cut_tolerance must be within [0, 100]", ErrValidation)
+    }
+    if under != nil && (*under < 0 || *under > 100) {
+        return fmt.Errorf("%w: under_cut_tolerance must be within [0, 100]", ErrValidation)
+    }
+    return nil
+}
+
 // GetByID returns an order by ID.
 func (s *ordersService) GetByID(ctx context.Context, id int) (*models.ProductionOrder, error) {
     if id <= 0 { return nil, errors.New("invalid order_id") }
     return s.repo.GetByID(id)
 }
 
-// GetAll returns all orders.
-func (s *ordersService) GetAll(ctx context.Context) ([]models.ProductionOrder, error) {
-    return s.repo.GetAll(ctx)
+// GetAll returns all orders, or only those matching the status / customer / style filter.
+func (s *ordersService) GetAll(ctx context.Context, filter models.OrderFilter) ([]models.ProductionOrder, error) {
+    if filter.Status != nil {
+        status := strings.TrimSpace(*filter.Status)
+        if !orderStatuses[status] { return nil, fmt.Errorf("%w: unknown order status %q", ErrValidation, status) }
+        filter.Status = &status
+    }
+    if filter.CustomerID != nil && *filter.CustomerID <= 0 { return nil, fmt.Errorf("%w: invalid customer_id", ErrValidation) }
+    if filter.StyleNumber != nil {
+        number := strings.TrimSpace(*filter.StyleNumber)
+        filter.StyleNumber = &number
+    }
+    return s.repo.GetAll(ctx, filter)
 }
 
 // GetByOrderNumber returns order by unique order_number.
@@ -65,4 +286,37 @@ func (s *ordersService) GetWithItems(ctx context.Context, id int) (*models.Produ
 func (s *ordersService) Delete(ctx context.Context, id int) error {
     if id <= 0 { return errors.New("invalid order_id") }
     return s.repo.Delete(id)
-}
\ No newline at end of file
+}
+
+// checkCustomer rejects a customer_id that does not exist, so the foreign key never surfaces as an internal error.
+func (s *ordersService) checkCustomer(ctx context.Context, customerID *int) error {
+    if customerID == nil { return nil }
+    if *customerID <= 0 { return fmt.Errorf("%w: invalid customer_id", ErrValidation) }
+    if s.customers == nil { return nil }
+    _, err := s.customers.GetByID(ctx, *customerID)
+    if errors.Is(err, sql.ErrNoRows) { return fmt.Errorf("%w: customer %d does not exist", ErrValidation, *customerID) }
+    return err
+}
+
+// checkStyle validates color/size cells against the style catalog: sizes must be in the style's size run and colors in
+// its color list when it has one. Values are compared trimmed. Styles that are not registered are not checked.
+func (s *ordersService) checkStyle(ctx context.Context, styleNumber string, cells [][2]string) error {
+    if s.styles == nil || len(cells) == 0 { return nil }
+    style, err := s.styles.GetByNumber(ctx, strings.TrimSpace(styleNumber))
+    if errors.Is(err, sql.ErrNoRows) { return nil }
+    if err != nil { return err }
+    sizes := make(map[string]bool, len(style.Sizes))
+    for _, sz := range style.Sizes { sizes[sz] = true }
+    colors := make(map[string]bool, len(style.Colors))
+    for _, c := range style.Colors { colors[c] = true }
+    for _, cell := range cells {
+        color, size := strings.TrimSpace(cell[0]), strings.TrimSpace(cell[1])
+        if !sizes[size] {
+            return fmt.Errorf("%w: size %q is not in the size run of style %s", ErrValidation, size, style.StyleNumber)
+        }
+        if len(colors) > 0 && !colors[color] {
+            return fmt.Errorf("%w: color %q is not allowed for style %s", ErrValidation, color, style.StyleNumber)
+        }
+    }
+    return nil
+}
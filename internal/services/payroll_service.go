@@ -0,0 +1,33 @@
+package services
+
+import "cutrix-backend/internal/models"
+
+// PayrollService 管理计件单价、工资周期与计件工资单。
+// 约束与约定：
+// - 单价（每层）：作用范围为布局、款号或默认（二者皆空），同一范围唯一（重复返回 ErrConflict）；计件时布局优先，其次款号，再次默认。
+// - 周期：按日志的班次日期划分，period_start <= period_end，周期之间不可重叠（ErrValidation）。
+// - 工资单：未作废日志的层数 × 单价，按工人汇总并保留明细；未锁定周期即时计算，锁定后为快照。
+// - 锁定：须按时间顺序锁定（之前仍有未锁定周期返回 ErrValidation），且所有明细均有单价；已锁定返回 ErrConflict。
+// - 冲销：已锁定周期中的日志被作废后，以负数明细计入最早的未锁定周期。
+// 注意：接口签名不传 context；实现中使用 context.Background() 调用仓储。
+ type PayrollService interface {
+    // 单价：创建；成功后填充 RateID。
+    CreateRate(rate *models.PieceRate) error
+    // 单价：修改单价与备注（作用范围不可改）。
+    UpdateRate(rate *models.PieceRate) error
+    DeleteRate(id int) error
+    GetRate(id int) (*models.PieceRate, error)
+    ListRates() ([]models.PieceRate, error)
+
+    // 周期：创建；成功后填充 PeriodID 与状态。
+    CreatePeriod(period *models.PayPeriod) error
+    // 周期：删除（仅限未锁定）。
+    DeletePeriod(id int) error
+    GetPeriod(id int) (*models.PayPeriod, error)
+    ListPeriods() ([]models.PayPeriod, error)
+    // 周期：锁定并写入明细快照；lockedBy 为操作人，可空。
+    LockPeriod(id int, lockedBy *int) (*models.PayPeriod, error)
+
+    // 工资单：workerID 非空时仅含该工人。
+    Statement(periodID int, workerID *int) (*models.PayrollStatement, error)
+}
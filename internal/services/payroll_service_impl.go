@@ -0,0 +1,267 @@
+package services
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "log/slog"
+    "strings"
+    "time"
+    "cutrix-backend/internal/logger"
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/repositories"
+)
+
+// payrollService 实现 PayrollService。
+// 设计要点：
+// - 计件与冲销明细由仓储在 SQL 中生成；服务层负责校验、锁定顺序与按工人汇总。
+ type payrollService struct {
+    repo repositories.PayrollRepository
+}
+
+// NewPayrollService 以给定仓储实现创建 PayrollService；nil 仓储将 panic。
+ func NewPayrollService(repo repositories.PayrollRepository) PayrollService {
+    if repo == nil {
+        panic("nil PayrollRepository")
+    }
+    return &payrollService{repo: repo}
+}
+
+// CreateRate 创建计件单价。
+// 返回：ErrValidation（单价须 > 0，布局与款号至多指定其一）、ErrConflict（该范围已有单价）或仓储错误。
+ func (s *payrollService) CreateRate(rate *models.PieceRate) error {
+    if rate == nil {
+        return ErrValidation
+    }
+    if rate.StyleNumber != nil {
+        style := strings.TrimSpace(*rate.StyleNumber)
+        rate.StyleNumber = &style
+        if style == "" {
+            rate.StyleNumber = nil
+        }
+    }
+    if rate.LayoutID != nil && rate.StyleNumber != nil {
+        return fmt.Errorf("%w: layout_id and style_number are mutually exclusive", ErrValidation)
+    }
+    if rate.LayoutID != nil && *rate.LayoutID <= 0 {
+        return fmt.Errorf("%w: invalid layout_id", ErrValidation)
+    }
+    if rate.RatePerLayer <= 0 {
+        return fmt.Errorf("%w: rate_per_layer must be > 0", ErrValidation)
+    }
+    ctx := context.Background()
+    existing, err := s.repo.FindRate(ctx, rate.LayoutID, rate.StyleNumber)
+    if err == nil && existing != nil {
+        return ErrConflict
+    }
+    if err != nil && !errors.Is(err, sql.ErrNoRows) {
+        return err
+    }
+    _, err = s.repo.CreateRate(ctx, rate)
+    if err == nil {
+        // 事件日志：计件单价创建
+        // 字段：rate_id、layout_id、style_number、rate_per_layer
+        logger.L.Info("piece_rate_created",
+            slog.Int("rate_id", rate.RateID),
+            slog.Any("layout_id", rate.LayoutID),
+            slog.Any("style_number", rate.StyleNumber),
+            slog.Float64("rate_per_layer", rate.RatePerLayer),
+        )
+    }
+    return err
+}
+
+// UpdateRate 修改单价与备注；只影响未锁定周期。
+ func (s *payrollService) UpdateRate(rate *models.PieceRate) error {
+    if rate == nil || rate.RateID <= 0 {
+        return ErrValidation
+    }
+    if rate.RatePerLayer <= 0 {
+        return fmt.Errorf("%w: rate_per_layer must be > 0", ErrValidation)
+    }
+    err := s.repo.UpdateRate(context.Background(), rate)
+    if err == nil {
+        logger.L.Info("piece_rate_updated", slog.Int("rate_id", rate.RateID), slog.Float64("rate_per_layer", rate.RatePerLayer))
+    }
+    return err
+}
+
+// DeleteRate 删除单价；已锁定周期的明细保留当时的单价。
+ func (s *payrollService) DeleteRate(id int) error {
+    if id <= 0 {
+        return ErrValidation
+    }
+    err := s.repo.DeleteRate(context.Background(), id)
+    if err == nil {
+        logger.L.Info("piece_rate_deleted", slog.Int("rate_id", id))
+    }
+    return err
+}
+
+// GetRate 获取单价。
+ func (s *payrollService) GetRate(id int) (*models.PieceRate, error) {
+    if id <= 0 {
+        return nil, ErrValidation
+    }
+    return s.repo.GetRate(context.Background(), id)
+}
+
+// ListRates 列出单价：布局单价在前，其次款号单价，最后默认单价。
+ func (s *payrollService) ListRates() ([]models.PieceRate, error) {
+    return s.repo.ListRates(context.Background())
+}
+
+// CreatePeriod 创建工资周期。
+// 返回：ErrValidation（日期格式错误、结束早于开始或与已有周期重叠）或仓储错误。
+ func (s *payrollService) CreatePeriod(period *models.PayPeriod) error {
+    if period == nil {
+        return ErrValidation
+    }
+    period.PeriodStart, period.PeriodEnd = strings.TrimSpace(period.PeriodStart), strings.TrimSpace(period.PeriodEnd)
+    start, err := time.Parse("2006-01-02", period.PeriodStart)
+    if err != nil {
+        return fmt.Errorf("%w: period_start must be YYYY-MM-DD", ErrValidation)
+    }
+    end, err := time.Parse("2006-01-02", period.PeriodEnd)
+    if err != nil {
+        return fmt.Errorf("%w: period_end must be YYYY-MM-DD", ErrValidation)
+    }
+    if end.Before(start) {
+        return fmt.Errorf("%w: period_end must not be before period_start", ErrValidation)
+    }
+    ctx := context.Background()
+    periods, err := s.repo.ListPeriods(ctx)
+    if err != nil {
+        return err
+    }
+    for _, p := range periods {
+        // YYYY-MM-DD 可按字符串比较
+        if period.PeriodStart <= p.PeriodEnd && p.PeriodStart <= period.PeriodEnd {
+            return fmt.Errorf("%w: overlaps pay period %d", ErrValidation, p.PeriodID)
+        }
+    }
+    _, err = s.repo.CreatePeriod(ctx, period)
+    if err == nil {
+        logger.L.Info("pay_period_created",
+            slog.Int("period_id", period.PeriodID),
+            slog.String("period_start", period.PeriodStart),
+            slog.String("period_end", period.PeriodEnd),
+        )
+    }
+    return err
+}
+
+// DeletePeriod 删除未锁定的工资周期；已锁定时由仓储层返回业务错误。
+ func (s *payrollService) DeletePeriod(id int) error {
+    if id <= 0 {
+        return ErrValidation
+    }
+    err := s.repo.DeletePeriod(context.Background(), id)
+    if err == nil {
+        logger.L.Info("pay_period_deleted", slog.Int("period_id", id))
+    }
+    return err
+}
+
+// GetPeriod 获取工资周期。
+ func (s *payrollService) GetPeriod(id int) (*models.PayPeriod, error) {
+    if id <= 0 {
+        return nil, ErrValidation
+    }
+    return s.repo.GetPeriod(context.Background(), id)
+}
+
+// ListPeriods 列出工资周期（最近的在前）。
+ func (s *payrollService) ListPeriods() ([]models.PayPeriod, error) {
+    return s.repo.ListPeriods(context.Background())
+}
+
+// LockPeriod 锁定工资周期。
+// 返回：ErrConflict（已锁定）、ErrValidation（之前仍有未锁定周期，或存在无单价的明细）或仓储错误。
+ func (s *payrollService) LockPeriod(id int, lockedBy *int) (*models.PayPeriod, error) {
+    if id <= 0 {
+        return nil, ErrValidation
+    }
+    ctx := context.Background()
+    period, err := s.repo.GetPeriod(ctx, id)
+    if err != nil {
+        return nil, err
+    }
+    if period.Status == "locked" {
+        return nil, ErrConflict
+    }
+    periods, err := s.repo.ListPeriods(ctx)
+    if err != nil {
+        return nil, err
+    }
+    for _, p := range periods {
+        if p.Status == "open" && p.PeriodStart < period.PeriodStart {
+            return nil, fmt.Errorf("%w: lock pay period %d first", ErrValidation, p.PeriodID)
+        }
+    }
+    stmt, err := s.Statement(id, nil)
+    if err != nil {
+        return nil, err
+    }
+    if stmt.MissingRates > 0 {
+        return nil, fmt.Errorf("%w: %d lines have no piece rate", ErrValidation, stmt.MissingRates)
+    }
+    if err := s.repo.LockPeriod(ctx, id, lockedBy); err != nil {
+        return nil, err
+    }
+    // 事件日志：工资周期锁定
+    // 字段：period_id、layers、amount（锁定前计算的合计，仅供参考）
+    logger.L.Info("pay_period_locked",
+        slog.Int("period_id", id),
+        slog.Int("layers", stmt.Layers),
+        slog.Float64("amount", stmt.Amount),
+    )
+    return s.repo.GetPeriod(ctx, id)
+}
+
+// Statement 生成工资单：按工人汇总明细层数与金额，冲销金额单列。
+ func (s *payrollService) Statement(periodID int, workerID *int) (*models.PayrollStatement, error) {
+    if periodID <= 0 {
+        return nil, ErrValidation
+    }
+    ctx := context.Background()
+    period, err := s.repo.GetPeriod(ctx, periodID)
+    if err != nil {
+        return nil, err
+    }
+    lines, err := s.repo.ListLines(ctx, periodID)
+    if err != nil {
+        return nil, err
+    }
+    out := &models.PayrollStatement{Period: *period, Workers: []models.WorkerStatement{}}
+    index := map[string]int{}
+    for _, l := range lines {
+        if workerID != nil && (l.WorkerID == nil || *l.WorkerID != *workerID) {
+            continue
+        }
+        key := "name:" + deref(l.WorkerName)
+        if l.WorkerID != nil {
+            key = fmt.Sprint(*l.WorkerID)
+        }
+        i, ok := index[key]
+        if !ok {
+            i = len(out.Workers)
+            index[key] = i
+            out.Workers = append(out.Workers, models.WorkerStatement{WorkerID: l.WorkerID, WorkerName: deref(l.WorkerName), Lines: []models.PayrollLine{}})
+        }
+        w := &out.Workers[i]
+        w.Lines = append(w.Lines, l)
+        w.Layers += l.Layers
+        w.Amount = round2(w.Amount + l.Amount)
+        if l.Kind == "void_adjustment" {
+            w.Adjustments = round2(w.Adjustments + l.Amount)
+        }
+        out.Layers += l.Layers
+        out.Amount = round2(out.Amount + l.Amount)
+        if l.RatePerLayer == nil {
+            out.MissingRates++
+        }
+    }
+    return out, nil
+}
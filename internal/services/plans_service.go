@@ -5,8 +5,11 @@ import "cutrix-backend/internal/models"
 // PlansService 管理生产计划的生命周期与受控变更：创建/删除、发布、冻结、备注更新与查询。
 // 约束与约定：
 // - 发布（Publish）：仅允许从 pending 发布到 in_progress，需至少存在一个任务；发布时间由触发器自动写入。
-// - 完成（completed）：由系统根据任务完成情况自动推进，不提供直接接口；外部人工终态动作为冻结（Freeze）。
+// - 裁剪容差：订单设定了超裁/短缺容差时，本计划与同订单其它未冻结计划的计划件数按部件合计，各部件均须在容差内，否则返回 *ToleranceViolationError（列出短缺/超裁格）。
+// - 完成（completed）：由系统根据任务完成情况自动推进，计划中每个部件都有任务且全部完成才算完成，不提供直接接口；外部人工终态动作为冻结（Freeze）。
 // - 冻结（Freeze）：仅允许在 completed 状态下执行；冻结会锁定计划并保留完成时间（由触发器控制）。
+// - 重新打开（Reopen）：管理层将 completed/frozen 计划退回 in_progress 以便补裁；完成时间清空（原值记入历史），
+//   计划再次全部完成时由触发器重新写入。
 // - 字段更新：计划发布后仅允许更新 note；其它字段由触发器限制不可写。
 // - 查询：提供按 ID 与按订单列出的只读视图。
 // - 事务边界：复杂聚合写入（如计划+布局+任务原子创建）建议由服务层组合实现，仓储层保持单资源写入。
@@ -15,6 +18,9 @@ import "cutrix-backend/internal/models"
  type PlansService interface {
     // 基本：创建计划（必须关联订单）；成功返回填充的 PlanID 与默认状态。
     Create(plan *models.ProductionPlan) error
+    // 基本：复制计划（任意状态）的布局、尺码比例与任务到同一或其它订单下的新 pending 计划（单事务）；
+    // 颜色/尺码按映射改名后仍不在目标订单明细中时返回 *PlanCloneMismatchError。
+    Clone(sourceID int, opts models.PlanCloneOptions) (*models.ProductionPlan, []models.DraftLayout, error)
     // 基本：删除计划（任意状态），级联其布局/任务/比例。
     Delete(id int) error
 
@@ -24,6 +30,8 @@ import "cutrix-backend/internal/models"
     Publish(id int) error
     // 变更：冻结计划（completed -> frozen），由触发器校验完成态与完成时间。
     Freeze(id int) error
+    // 变更：重新打开计划（completed/frozen -> in_progress），须填写原因；清空完成时间并写入重新打开记录。
+    Reopen(id int, reason string, reopenedBy *int) (*models.ProductionPlan, *models.PlanReopening, error)
 
     // 查询：按 ID 获取计划详情。
     GetByID(id int) (*models.ProductionPlan, error)
@@ -31,4 +39,10 @@ import "cutrix-backend/internal/models"
     List() ([]models.ProductionPlan, error)
     // 查询：按订单列出所有计划。
     ListByOrder(orderID int) ([]models.ProductionPlan, error)
+    // 查询：计划的重新打开记录（新的在前）。
+    ReopenHistory(planID int) ([]models.PlanReopening, error)
+    // 查询：计划用布需求（汇总计划下所有布局的任务）。
+    Fabric(planID int) (*models.FabricRequirement, error)
+    // 查询：订单用布需求（汇总订单下所有计划的任务）。
+    FabricByOrder(orderID int) (*models.FabricRequirement, error)
 }
\ No newline at end of file
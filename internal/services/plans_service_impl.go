@@ -3,7 +3,9 @@ package services
 import (
     "context"
     "errors"
+    "fmt"
     "log/slog"
+    "strings"
     "cutrix-backend/internal/models"
     "cutrix-backend/internal/repositories"
     "cutrix-backend/internal/logger"
@@ -56,6 +58,42 @@ import (
     return err
 }
 
+// Clone 复制计划：布局（含唛架参数）、尺码比例与任务（计划层数）复制到新的 pending 计划，进度与裁床分配不复制。
+// sourceID：源计划 ID；opts：目标订单、名称、备注与颜色/尺码映射，零值取源计划的值。
+// 返回：新计划与其布局；源计划或目标订单不存在返回 sql.ErrNoRows，颜色/尺码不匹配返回 *PlanCloneMismatchError。
+ func (s *plansService) Clone(sourceID int, opts models.PlanCloneOptions) (*models.ProductionPlan, []models.DraftLayout, error) {
+    if sourceID <= 0 {
+        return nil, nil, errors.New("invalid plan_id")
+    }
+    if opts.OrderID < 0 {
+        return nil, nil, fmt.Errorf("%w: invalid order_id", ErrValidation)
+    }
+    for from, to := range opts.ColorMap {
+        if strings.TrimSpace(from) == "" || strings.TrimSpace(to) == "" {
+            return nil, nil, fmt.Errorf("%w: color_map entries must be non-empty", ErrValidation)
+        }
+    }
+    for from, to := range opts.SizeMap {
+        if strings.TrimSpace(from) == "" || strings.TrimSpace(to) == "" {
+            return nil, nil, fmt.Errorf("%w: size_map entries must be non-empty", ErrValidation)
+        }
+    }
+    plan := &models.ProductionPlan{OrderID: opts.OrderID, PlanName: strings.TrimSpace(opts.PlanName), Note: opts.Note}
+    layouts, err := s.repo.Clone(context.Background(), sourceID, plan, opts.ColorMap, opts.SizeMap)
+    if err != nil {
+        return nil, nil, err
+    }
+    // 事件日志：计划复制成功
+    // 字段：source_plan_id、plan_id、order_id、layouts
+    logger.L.Info("plan_cloned",
+        slog.Int("source_plan_id", sourceID),
+        slog.Int("plan_id", plan.PlanID),
+        slog.Int("order_id", plan.OrderID),
+        slog.Int("layouts", len(layouts)),
+    )
+    return plan, layouts, nil
+}
+
 // Delete 删除指定生产计划。删除为受控操作，可能级联布局与任务。
 // id：计划 ID，必须为正数。
 // 返回：错误信息；当计划已发布且受限时，由仓储层返回约束错误。
@@ -93,7 +131,7 @@ import (
 
 // Publish 发布计划，将状态从 pending 推进至 in_progress。发布时间由触发器自动记录。
 // id：计划 ID。
-// 返回：错误信息；如果计划没有任务或状态不为 pending，则返回仓储层错误。
+// 返回：错误信息；如果计划没有任务或状态不为 pending，则返回仓储层错误；超出订单裁剪容差返回 *ToleranceViolationError。
  func (s *plansService) Publish(id int) error {
     if id <= 0 {
         return errors.New("invalid plan_id")
@@ -123,6 +161,53 @@ import (
     return err
 }
 
+// Reopen 重新打开已完成或冻结的计划，使其回到 in_progress 以便补裁（通常随后经变更申请增加层数）。
+// 完成时间规则：planned_finish_date 清空，原值记入重新打开记录；计划再次全部完成时由触发器重新写入。
+// 返回：更新后的计划与重新打开记录；状态不允许返回 ErrConflict，冻结计划恢复后超出订单超裁上限返回 *ToleranceViolationError。
+ func (s *plansService) Reopen(id int, reason string, reopenedBy *int) (*models.ProductionPlan, *models.PlanReopening, error) {
+    if id <= 0 {
+        return nil, nil, errors.New("invalid plan_id")
+    }
+    reason = strings.TrimSpace(reason)
+    if reason == "" {
+        return nil, nil, fmt.Errorf("%w: reason required", ErrValidation)
+    }
+    ctx := context.Background()
+    plan, err := s.repo.GetByID(ctx, id)
+    if err != nil {
+        return nil, nil, err
+    }
+    if plan.Status != "completed" && plan.Status != "frozen" {
+        return nil, nil, fmt.Errorf("%w: only completed or frozen plans can be reopened (status %s)", ErrConflict, plan.Status)
+    }
+    h, err := s.repo.Reopen(ctx, id, reason, reopenedBy)
+    if err != nil {
+        return nil, nil, err
+    }
+    // 事件日志：计划重新打开
+    // 字段：plan_id、from_status、reopened_by
+    logger.L.Info("plan_reopened",
+        slog.Int("plan_id", id),
+        slog.String("from_status", h.FromStatus),
+        slog.Any("reopened_by", reopenedBy),
+    )
+    plan, err = s.repo.GetByID(ctx, id)
+    if err != nil {
+        return nil, nil, err
+    }
+    return plan, h, nil
+}
+
+// ReopenHistory 查询计划的重新打开记录。
+// planID：计划 ID。
+// 返回：记录列表（新的在前）；计划不存在返回 NotFound。
+ func (s *plansService) ReopenHistory(planID int) ([]models.PlanReopening, error) {
+    if planID <= 0 {
+        return nil, errors.New("invalid plan_id")
+    }
+    return s.repo.ListReopenHistory(context.Background(), planID)
+}
+
 // GetByID 查询单个计划详情。
 // id：计划 ID。
 // 返回：计划实体只读副本与错误；不存在时返回仓储层 NotFound 错误。
@@ -147,4 +232,32 @@ import (
         return nil, errors.New("invalid order_id")
     }
     return s.repo.ListByOrder(context.Background(), orderID)
+}
+
+// Fabric 计算计划的用布需求。
+// planID：计划 ID。
+// 返回：用布需求汇总与错误；计划不存在返回仓储层 NotFound 错误。
+ func (s *plansService) Fabric(planID int) (*models.FabricRequirement, error) {
+    if planID <= 0 {
+        return nil, errors.New("invalid plan_id")
+    }
+    lines, err := s.repo.ListFabricByPlan(context.Background(), planID)
+    if err != nil {
+        return nil, err
+    }
+    return buildFabricRequirement("plan", planID, lines), nil
+}
+
+// FabricByOrder 计算订单（所有计划）的用布需求。
+// orderID：订单 ID。
+// 返回：用布需求汇总与错误；订单不存在返回仓储层 NotFound 错误。
+ func (s *plansService) FabricByOrder(orderID int) (*models.FabricRequirement, error) {
+    if orderID <= 0 {
+        return nil, errors.New("invalid order_id")
+    }
+    lines, err := s.repo.ListFabricByOrder(context.Background(), orderID)
+    if err != nil {
+        return nil, err
+    }
+    return buildFabricRequirement("order", orderID, lines), nil
 }
\ No newline at end of file
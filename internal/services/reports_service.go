@@ -0,0 +1,18 @@
+package services
+
+import "cutrix-backend/internal/models"
+
+// ReportsService 提供基于生产日志的只读报表。
+// 约束与约定：
+// - 作废日志一律排除；件数 = 层数 × 布局尺码比例之和。
+// - 班次报表按日志写入时归属的班次日期统计：跨零点班次（夜班）整班计入开班日期，不会被拆到两个自然日。
+// - 效率分析在 SQL 中聚合；工时由同一工人相邻有效日志的间隔推算，超过 4 小时的间隔视为休息。
+// - customerID 非 nil 时仅统计该客户订单下的日志；效率的间隔仍按工人全部日志切分。
+// 注意：接口签名不传 context；实现中使用 context.Background() 调用仓储。
+ type ReportsService interface {
+    // 班次报表：date 为 YYYY-MM-DD（工厂时区的班次日期），空串取工厂时区的今天。
+    ShiftReport(date string, customerID *int) (*models.ShiftReport, error)
+    // 工人效率：from/to 为 YYYY-MM-DD 班次日期（含两端），空串默认截至工厂时区今天的最近 7 天，区间不超过 366 天；
+    // groupBy 为 worker、group、layout、color 的组合，空取 worker。
+    Productivity(from, to string, groupBy []string, customerID *int) (*models.ProductivityReport, error)
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "math"
+    "strings"
+    "time"
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/repositories"
+)
+
+// 效率分析参数。
+const (
+    productivityDefaultDays = 7
+    productivityMaxDays     = 366
+    productivityMaxGap      = scheduleRateMaxGap // 与排程工效口径一致
+)
+
+// productivityDimensions 效率分析可用的分组维度（按输出顺序）。
+var productivityDimensions = []string{"worker", "group", "layout", "color", "component"}
+
+// reportsService 实现 ReportsService：仓储按最细粒度聚合，服务层组装各维度小计。
+ type reportsService struct {
+    repo   repositories.ReportsRepository
+    shifts repositories.ShiftsRepository
+    now    func() time.Time
+}
+
+// NewReportsService 以给定仓储创建 ReportsService；nil 仓储将 panic。
+ func NewReportsService(repo repositories.ReportsRepository, shifts repositories.ShiftsRepository) ReportsService {
+    if repo == nil || shifts == nil {
+        panic("nil repository for ReportsService")
+    }
+    return &reportsService{repo: repo, shifts: shifts, now: time.Now}
+}
+
+// ShiftReport 汇总班次日期内各班次的层数与件数，并按工人、组、计划拆分。
+// 启用的班次即使无产量也会列出；不在任何班次内的日志归入 shift_id 为空的条目。
+ func (s *reportsService) ShiftReport(date string, customerID *int) (*models.ShiftReport, error) {
+    if customerID != nil && *customerID <= 0 {
+        return nil, fmt.Errorf("%w: invalid customer_id", ErrValidation)
+    }
+    ctx := context.Background()
+    settings, err := s.shifts.GetSettings(ctx)
+    if err != nil {
+        return nil, err
+    }
+    date = strings.TrimSpace(date)
+    if date == "" {
+        loc, err := time.LoadLocation(settings.Timezone)
+        if err != nil {
+            loc = time.UTC
+        }
+        date = s.now().In(loc).Format("2006-01-02")
+    } else if _, err := time.Parse("2006-01-02", date); err != nil {
+        return nil, fmt.Errorf("%w: date must be YYYY-MM-DD", ErrValidation)
+    }
+    active, err := s.shifts.List(ctx, true)
+    if err != nil {
+        return nil, err
+    }
+    rows, err := s.repo.ListShiftRows(ctx, date, customerID)
+    if err != nil {
+        return nil, err
+    }
+
+    out := &models.ShiftReport{Date: date, Timezone: settings.Timezone, CustomerID: customerID, Shifts: []models.ShiftSummary{}}
+    index := map[string]int{}
+    add := func(key string, sum models.ShiftSummary) *models.ShiftSummary {
+        if i, ok := index[key]; ok {
+            return &out.Shifts[i]
+        }
+        sum.Workers, sum.Groups, sum.Plans = []models.ShiftWorkerSummary{}, []models.ShiftGroupSummary{}, []models.ShiftPlanSummary{}
+        index[key] = len(out.Shifts)
+        out.Shifts = append(out.Shifts, sum)
+        return &out.Shifts[len(out.Shifts)-1]
+    }
+    for _, sh := range active {
+        id := sh.ShiftID
+        add(fmt.Sprint(id), models.ShiftSummary{ShiftID: &id, ShiftName: sh.ShiftName, StartTime: sh.StartTime, EndTime: sh.EndTime})
+    }
+    for _, r := range rows {
+        key, summary := "none", models.ShiftSummary{ShiftName: "unassigned"}
+        if r.ShiftID != nil {
+            key = fmt.Sprint(*r.ShiftID)
+            summary = models.ShiftSummary{ShiftID: r.ShiftID, ShiftName: deref(r.ShiftName), StartTime: deref(r.StartTime), EndTime: deref(r.EndTime)}
+        }
+        sum := add(key, summary)
+        sum.Layers += r.Layers
+        sum.Pieces += r.Pieces
+        out.Layers += r.Layers
+        out.Pieces += r.Pieces
+        addWorker(sum, r)
+        addGroup(sum, r)
+        addPlan(sum, r)
+    }
+    return out, nil
+}
+
+// Productivity 按班次日期区间与维度汇总工人效率：层/小时、件/小时与作废率。
+ func (s *reportsService) Productivity(from, to string, groupBy []string, customerID *int) (*models.ProductivityReport, error) {
+    dims, err := normalizeDimensions(groupBy)
+    if err != nil {
+        return nil, err
+    }
+    if customerID != nil && *customerID <= 0 {
+        return nil, fmt.Errorf("%w: invalid customer_id", ErrValidation)
+    }
+    ctx := context.Background()
+    from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+    if to == "" {
+        settings, err := s.shifts.GetSettings(ctx)
+        if err != nil {
+            return nil, err
+        }
+        loc, err := time.LoadLocation(settings.Timezone)
+        if err != nil {
+            loc = time.UTC
+        }
+        to = s.now().In(loc).Format("2006-01-02")
+    }
+    toDate, err := time.Parse("2006-01-02", to)
+    if err != nil {
+        return nil, fmt.Errorf("%w: to must be YYYY-MM-DD", ErrValidation)
+    }
+    if from == "" {
+        from = toDate.AddDate(0, 0, 1-productivityDefaultDays).Format("2006-01-02")
+    }
+    fromDate, err := time.Parse("2006-01-02", from)
+    if err != nil {
+        return nil, fmt.Errorf("%w: from must be YYYY-MM-DD", ErrValidation)
+    }
+    if toDate.Before(fromDate) {
+        return nil, fmt.Errorf("%w: to must not be before from", ErrValidation)
+    }
+    if toDate.Sub(fromDate) >= productivityMaxDays*24*time.Hour {
+        return nil, fmt.Errorf("%w: range must not exceed %d days", ErrValidation, productivityMaxDays)
+    }
+    rows, err := s.repo.ListProductivity(ctx, from, to, productivityMaxGap, dims, customerID)
+    if err != nil {
+        return nil, err
+    }
+    out := &models.ProductivityReport{From: from, To: to, GroupBy: dims, CustomerID: customerID, Rows: []models.ProductivityRow{}}
+    for _, r := range rows {
+        if r.Logs > 0 {
+            r.VoidRate = round2(float64(r.VoidedLogs) / float64(r.Logs))
+        }
+        if r.ActiveHours > 0 {
+            lph, pph := round2(float64(r.RatedLayers)/r.ActiveHours), round2(float64(r.RatedPieces)/r.ActiveHours)
+            r.LayersPerHour, r.PiecesPerHour = &lph, &pph
+        }
+        r.ActiveHours = round2(r.ActiveHours)
+        out.Rows = append(out.Rows, r)
+    }
+    return out, nil
+}
+
+// normalizeDimensions 校验并去重分组维度，按 productivityDimensions 的顺序返回；空取 worker。
+func normalizeDimensions(groupBy []string) ([]string, error) {
+    want := map[string]bool{}
+    for _, d := range groupBy {
+        d = strings.ToLower(strings.TrimSpace(d))
+        if d == "" {
+            continue
+        }
+        known := false
+        for _, k := range productivityDimensions {
+            known = known || k == d
+        }
+        if !known {
+            return nil, fmt.Errorf("%w: group_by must be one of %s", ErrValidation, strings.Join(productivityDimensions, ", "))
+        }
+        want[d] = true
+    }
+    if len(want) == 0 {
+        return []string{"worker"}, nil
+    }
+    var dims []string
+    for _, k := range productivityDimensions {
+        if want[k] {
+            dims = append(dims, k)
+        }
+    }
+    return dims, nil
+}
+
+func round2(v float64) float64 {
+    return math.Round(v*100) / 100
+}
+
+func addWorker(sum *models.ShiftSummary, r models.ShiftReportRow) {
+    name := deref(r.WorkerName)
+    for i := range sum.Workers {
+        w := &sum.Workers[i]
+        if sameIntPtr(w.WorkerID, r.WorkerID) && w.WorkerName == name {
+            w.Layers += r.Layers
+            w.Pieces += r.Pieces
+            return
+        }
+    }
+    sum.Workers = append(sum.Workers, models.ShiftWorkerSummary{
+        WorkerID: r.WorkerID, WorkerName: name, UserGroup: r.UserGroup, Layers: r.Layers, Pieces: r.Pieces,
+    })
+}
+
+func addGroup(sum *models.ShiftSummary, r models.ShiftReportRow) {
+    for i := range sum.Groups {
+        g := &sum.Groups[i]
+        if (g.UserGroup == nil && r.UserGroup == nil) || (g.UserGroup != nil && r.UserGroup != nil && *g.UserGroup == *r.UserGroup) {
+            g.Layers += r.Layers
+            g.Pieces += r.Pieces
+            return
+        }
+    }
+    sum.Groups = append(sum.Groups, models.ShiftGroupSummary{UserGroup: r.UserGroup, Layers: r.Layers, Pieces: r.Pieces})
+}
+
+func addPlan(sum *models.ShiftSummary, r models.ShiftReportRow) {
+    for i := range sum.Plans {
+        p := &sum.Plans[i]
+        if p.PlanID == r.PlanID {
+            p.Layers += r.Layers
+            p.Pieces += r.Pieces
+            return
+        }
+    }
+    sum.Plans = append(sum.Plans, models.ShiftPlanSummary{
+        PlanID: r.PlanID, PlanName: r.PlanName, OrderNumber: r.OrderNumber, Layers: r.Layers, Pieces: r.Pieces,
+    })
+}
+
+func sameIntPtr(a, b *int) bool {
+    return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func deref(s *string) string {
+    if s == nil {
+        return ""
+    }
+    return *s
+}
@@ -0,0 +1,29 @@
+package services
+
+import "cutrix-backend/internal/models"
+
+// RollsService 管理布卷库存：入库登记、查询、备注、结卷与删除，以及布卷领用明细。
+// 约束与约定：
+// - 入库：roll_code 唯一（重复返回 ErrConflict），color 必填，received_length 须 > 0；剩余长度初始等于入库长度。
+// - 领用：通过提交拉布日志时携带 roll_ids 完成，服务层不提供直接扣减接口；作废日志由触发器回补未结卷布卷。
+// - 结卷（Close）：登记实测余料长度（>= 0），结卷后不可再领用。
+// - 删除：仅允许删除从未领用过的布卷。
+// 注意：接口签名不传 context；实现中使用 context.Background() 调用仓储。
+ type RollsService interface {
+    // 基本：入库登记布卷；成功后填充 RollID 与默认状态。
+    Create(roll *models.FabricRoll) error
+    // 基本：删除布卷（仅限无领用记录）。
+    Delete(id int) error
+
+    // 变更：更新备注。
+    UpdateNote(id int, note *string) error
+    // 变更：结卷并登记余料长度。
+    Close(id int, remnant float64, note *string) error
+
+    // 查询：按 ID 获取布卷。
+    GetByID(id int) (*models.FabricRoll, error)
+    // 查询：按颜色/缸号/状态筛选布卷。
+    List(filter models.RollFilter) ([]models.FabricRoll, error)
+    // 查询：布卷领用明细（含已作废日志）。
+    ListUsage(id int) ([]models.LogRollUsage, error)
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "log/slog"
+    "strings"
+    "cutrix-backend/internal/logger"
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/repositories"
+)
+
+// rollsService 实现 RollsService。
+// 设计要点：
+// - 输入校验在服务层完成并返回 ErrValidation；唯一性冲突预检后返回 ErrConflict。
+// - 剩余长度、状态流转等一致性由触发器保证，服务层不重复计算。
+ type rollsService struct {
+    repo repositories.RollsRepository
+}
+
+// NewRollsService 以给定仓储实现创建 RollsService；nil 仓储将 panic。
+ func NewRollsService(repo repositories.RollsRepository) RollsService {
+    if repo == nil {
+        panic("nil RollsRepository")
+    }
+    return &rollsService{repo: repo}
+}
+
+// validRollStatuses 为筛选允许的状态值。
+var validRollStatuses = map[string]bool{"available": true, "in_use": true, "closed": true}
+
+// Create 入库登记布卷。
+// roll：待创建的布卷，roll_code/color 去除首尾空白后必填，received_length > 0，fabric_width 若提供须 > 0。
+// 返回：ErrValidation（参数错误）、ErrConflict（卷号重复）或仓储错误。
+ func (s *rollsService) Create(roll *models.FabricRoll) error {
+    if roll == nil {
+        return ErrValidation
+    }
+    roll.RollCode = strings.TrimSpace(roll.RollCode)
+    roll.Color = strings.TrimSpace(roll.Color)
+    if roll.RollCode == "" {
+        return fmt.Errorf("%w: roll_code required", ErrValidation)
+    }
+    if roll.Color == "" {
+        return fmt.Errorf("%w: color required", ErrValidation)
+    }
+    if roll.ReceivedLength <= 0 {
+        return fmt.Errorf("%w: received_length must be > 0", ErrValidation)
+    }
+    if roll.FabricWidth != nil && *roll.FabricWidth <= 0 {
+        return fmt.Errorf("%w: fabric_width must be > 0", ErrValidation)
+    }
+    ctx := context.Background()
+    if _, err := s.repo.GetByCode(ctx, roll.RollCode); err == nil {
+        return ErrConflict
+    } else if !errors.Is(err, sql.ErrNoRows) {
+        return err
+    }
+    _, err := s.repo.Create(ctx, roll)
+    if err == nil {
+        // 事件日志：布卷入库
+        // 字段：roll_id、roll_code、color、dye_lot、received_length
+        logger.L.Info("roll_created",
+            slog.Int("roll_id", roll.RollID),
+            slog.String("roll_code", roll.RollCode),
+            slog.String("color", roll.Color),
+            slog.Any("dye_lot", roll.DyeLot),
+            slog.Float64("received_length", roll.ReceivedLength),
+        )
+    }
+    return err
+}
+
+// Delete 删除布卷；已有领用记录时由仓储层返回业务错误。
+ func (s *rollsService) Delete(id int) error {
+    if id <= 0 {
+        return ErrValidation
+    }
+    err := s.repo.Delete(context.Background(), id)
+    if err == nil {
+        logger.L.Info("roll_deleted", slog.Int("roll_id", id))
+    }
+    return err
+}
+
+// UpdateNote 更新布卷备注（结卷后同样允许）。
+ func (s *rollsService) UpdateNote(id int, note *string) error {
+    if id <= 0 {
+        return ErrValidation
+    }
+    return s.repo.UpdateNote(context.Background(), id, note)
+}
+
+// Close 结卷：登记实测余料长度，结卷时间由触发器写入。
+// remnant：余料长度（米），须 >= 0；note 为 nil 时保留原备注。
+ func (s *rollsService) Close(id int, remnant float64, note *string) error {
+    if id <= 0 {
+        return ErrValidation
+    }
+    if remnant < 0 {
+        return fmt.Errorf("%w: remnant_length must be >= 0", ErrValidation)
+    }
+    err := s.repo.Close(context.Background(), id, remnant, note)
+    if err == nil {
+        // 事件日志：布卷结卷
+        // 字段：roll_id、remnant_length
+        logger.L.Info("roll_closed",
+            slog.Int("roll_id", id),
+            slog.Float64("remnant_length", remnant),
+        )
+    }
+    return err
+}
+
+// GetByID 查询单个布卷。
+ func (s *rollsService) GetByID(id int) (*models.FabricRoll, error) {
+    if id <= 0 {
+        return nil, ErrValidation
+    }
+    return s.repo.GetByID(context.Background(), id)
+}
+
+// List 按筛选条件列出布卷；status 仅允许 available/in_use/closed。
+ func (s *rollsService) List(filter models.RollFilter) ([]models.FabricRoll, error) {
+    if filter.Status != nil && !validRollStatuses[*filter.Status] {
+        return nil, fmt.Errorf("%w: invalid status", ErrValidation)
+    }
+    return s.repo.List(context.Background(), filter)
+}
+
+// ListUsage 查询布卷领用明细；布卷不存在时返回 sql.ErrNoRows。
+ func (s *rollsService) ListUsage(id int) ([]models.LogRollUsage, error) {
+    if id <= 0 {
+        return nil, ErrValidation
+    }
+    ctx := context.Background()
+    if _, err := s.repo.GetByID(ctx, id); err != nil {
+        return nil, err
+    }
+    return s.repo.ListUsage(ctx, id)
+}
@@ -0,0 +1,21 @@
+package services
+
+import (
+    "time"
+    "cutrix-backend/internal/models"
+)
+
+// ScheduleService 将已发布任务排到裁床与班次上，输出每个裁床的时间轴（甘特图数据）。
+// 约束与约定：
+// - 范围：进行中计划下状态为 in_progress 且仍有剩余层数的任务；已分配裁床的任务排在其裁床上，未分配的任务
+//   排到可容纳唛架（usable_length >= marker_length）且最早空闲的 active 裁床上（仅为建议，不写回分配）。
+// - 顺序：已开工任务优先，其次按计划完成日期、发布日期、任务 ID。
+// - 工时：剩余层数 ÷ 历史效率；效率取近 30 天日志的层数/小时（相邻日志间隔，超过 4 小时的间隔视为停工忽略），
+//   依次回退为裁床效率 → 全厂效率 → 默认值。
+// - 班次：按班次时间窗排程，跨班次的任务拆为多段；时间窗由配置 SCHEDULE_SHIFTS 提供。
+// - 实时：排程不落库，每次请求按当前任务与日志重算，日志提交或作废后立即反映。
+// 注意：接口签名不传 context；实现中使用 context.Background() 调用仓储。
+ type ScheduleService interface {
+    // 查询：计算 [from, to) 内的排程；nil 表示默认（from 为当前时间，to 为 from + 7 天）。
+    Build(from, to *time.Time) (*models.Schedule, error)
+}
@@ -0,0 +1,328 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "math"
+    "sort"
+    "strings"
+    "time"
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/repositories"
+)
+
+// 排程参数。
+const (
+    DefaultScheduleShifts = "day=08:00-16:00,night=16:00-24:00"
+    DefaultLayersPerHour  = 20.0
+    scheduleRateLookback  = 30 * 24 * time.Hour
+    scheduleRateMaxGap    = 4 * time.Hour
+    scheduleRateMinHours  = 1.0 // 样本少于 1 小时的效率不采用
+    scheduleDefaultWindow = 7 * 24 * time.Hour
+    scheduleMaxWindow     = 31 * 24 * time.Hour
+)
+
+// ShiftWindow 班次时间窗：每天 [Start, End) 距零点的偏移；End <= Start 表示跨零点。
+type ShiftWindow struct {
+    Name  string
+    Start time.Duration
+    End   time.Duration
+}
+
+// ParseShiftWindows 解析 "name=HH:MM-HH:MM,..." 格式的班次配置；空串使用 DefaultScheduleShifts。
+func ParseShiftWindows(spec string) ([]ShiftWindow, error) {
+    if strings.TrimSpace(spec) == "" {
+        spec = DefaultScheduleShifts
+    }
+    var out []ShiftWindow
+    for _, part := range strings.Split(spec, ",") {
+        name, span, ok := strings.Cut(strings.TrimSpace(part), "=")
+        if !ok || strings.TrimSpace(name) == "" {
+            return nil, fmt.Errorf("invalid shift %q", part)
+        }
+        from, to, ok := strings.Cut(span, "-")
+        if !ok {
+            return nil, fmt.Errorf("invalid shift %q", part)
+        }
+        start, err := parseClock(from)
+        if err != nil {
+            return nil, err
+        }
+        end, err := parseClock(to)
+        if err != nil {
+            return nil, err
+        }
+        if start == end {
+            return nil, fmt.Errorf("empty shift %q", part)
+        }
+        out = append(out, ShiftWindow{Name: strings.TrimSpace(name), Start: start, End: end})
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].Start < out[j].Start })
+    return out, nil
+}
+
+// parseClock 解析 HH:MM（允许 24:00）。
+func parseClock(s string) (time.Duration, error) {
+    var h, m int
+    if _, err := fmt.Sscanf(strings.TrimSpace(s), "%d:%d", &h, &m); err != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+        return 0, fmt.Errorf("invalid clock %q", s)
+    }
+    return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// scheduleService 实现 ScheduleService：仓储只负责取数，排程算法在服务层完成。
+ type scheduleService struct {
+    repo      repositories.ScheduleRepository
+    tables    repositories.TablesRepository
+    shiftDefs repositories.ShiftsRepository
+    shifts    []ShiftWindow
+    now       func() time.Time
+}
+
+// NewScheduleService 创建 ScheduleService。
+// shiftDefs：班次定义仓储；存在启用的班次时按工厂时区使用之，否则使用 shifts（配置的时间窗，服务器本地时区）。
+// nil 仓储（shiftDefs 除外）或空班次将 panic。
+ func NewScheduleService(repo repositories.ScheduleRepository, tables repositories.TablesRepository, shiftDefs repositories.ShiftsRepository, shifts []ShiftWindow) ScheduleService {
+    if repo == nil || tables == nil {
+        panic("nil repository for ScheduleService")
+    }
+    if len(shifts) == 0 {
+        panic("no shift windows for ScheduleService")
+    }
+    return &scheduleService{repo: repo, tables: tables, shiftDefs: shiftDefs, shifts: shifts, now: time.Now}
+}
+
+// shiftWindows 返回本次排程使用的班次时间窗及其时区。
+ func (s *scheduleService) shiftWindows(ctx context.Context) ([]ShiftWindow, *time.Location, error) {
+    if s.shiftDefs == nil {
+        return s.shifts, time.Local, nil
+    }
+    defs, err := s.shiftDefs.List(ctx, true)
+    if err != nil {
+        return nil, nil, err
+    }
+    if len(defs) == 0 {
+        return s.shifts, time.Local, nil
+    }
+    settings, err := s.shiftDefs.GetSettings(ctx)
+    if err != nil {
+        return nil, nil, err
+    }
+    loc, err := time.LoadLocation(settings.Timezone)
+    if err != nil {
+        return nil, nil, err
+    }
+    windows := make([]ShiftWindow, 0, len(defs))
+    for _, d := range defs {
+        w, err := ShiftWindowOf(d)
+        if err != nil {
+            return nil, nil, err
+        }
+        windows = append(windows, w)
+    }
+    return windows, loc, nil
+}
+
+// tableLane 单个裁床的排程游标。
+ type tableLane struct {
+    table    models.CuttingTable
+    timeline *models.TableTimeline
+    cursor   time.Time
+}
+
+// Build 计算排程。
+// from/to：输出窗口，nil 取默认；窗口不得超过 31 天。排程总是从当前时间开始，窗口之前的段不输出。
+ func (s *scheduleService) Build(from, to *time.Time) (*models.Schedule, error) {
+    now := s.now()
+    start := now
+    if from != nil {
+        start = *from
+    }
+    end := start.Add(scheduleDefaultWindow)
+    if to != nil {
+        end = *to
+    }
+    if !end.After(start) {
+        return nil, fmt.Errorf("%w: to must be after from", ErrValidation)
+    }
+    if end.Sub(start) > scheduleMaxWindow {
+        return nil, fmt.Errorf("%w: window must not exceed 31 days", ErrValidation)
+    }
+
+    ctx := context.Background()
+    windows, loc, err := s.shiftWindows(ctx)
+    if err != nil {
+        return nil, err
+    }
+    now = now.In(loc)
+    tasks, err := s.repo.ListOpenTasks(ctx)
+    if err != nil {
+        return nil, err
+    }
+    tables, err := s.tables.List(ctx, "active")
+    if err != nil {
+        return nil, err
+    }
+    rates, err := s.repo.ListLayerRates(ctx, now.Add(-scheduleRateLookback), scheduleRateMaxGap)
+    if err != nil {
+        return nil, err
+    }
+
+    out := &models.Schedule{From: start, To: end, GeneratedAt: now, Tables: []models.TableTimeline{}, Unscheduled: []models.UnscheduledTask{}}
+    lanes := make(map[int]*tableLane, len(tables))
+    order := make([]*tableLane, 0, len(tables))
+    for _, t := range tables {
+        rate, source := layerRate(rates, t.TableID)
+        out.Tables = append(out.Tables, models.TableTimeline{
+            TableID: t.TableID, TableName: t.TableName, LayersPerHour: rate, RateSource: source, Items: []models.ScheduleItem{},
+        })
+        lane := &tableLane{table: t, cursor: now}
+        lanes[t.TableID] = lane
+        order = append(order, lane)
+    }
+    for i := range order {
+        order[i].timeline = &out.Tables[i]
+    }
+
+    sortScheduleTasks(tasks)
+    for _, t := range tasks {
+        lane, reason := pickLane(t, lanes, order)
+        if lane == nil {
+            out.Unscheduled = append(out.Unscheduled, models.UnscheduledTask{TaskID: t.TaskID, Reason: reason})
+            continue
+        }
+        if !lane.cursor.Before(end) {
+            out.Unscheduled = append(out.Unscheduled, models.UnscheduledTask{TaskID: t.TaskID, Reason: "beyond_window"})
+            continue
+        }
+        place(lane, t, windows, start, end)
+    }
+    return out, nil
+}
+
+// place 从裁床游标开始按班次时间窗铺排任务，输出与 [from, to) 相交的段并推进游标。
+func place(lane *tableLane, t models.ScheduleTask, windows []ShiftWindow, from, to time.Time) {
+    remaining := t.PlannedLayers - t.CompletedLayers
+    need := time.Duration(float64(remaining) / lane.timeline.LayersPerHour * float64(time.Hour))
+    total := need
+    done := 0
+    cursor := lane.cursor
+    for need > 0 {
+        shift, segStart, segEnd := nextShift(cursor, windows)
+        if segEnd.Sub(segStart) > need {
+            segEnd = segStart.Add(need)
+        }
+        need -= segEnd.Sub(segStart)
+        // 层数按累计工时比例取整，最后一段补齐
+        layers := remaining - done
+        if need > 0 {
+            layers = int(math.Round(float64(remaining)*float64(total-need)/float64(total))) - done
+        }
+        done += layers
+        if segEnd.After(from) && segStart.Before(to) {
+            lane.timeline.Items = append(lane.timeline.Items, models.ScheduleItem{
+                TaskID: t.TaskID, PlanID: t.PlanID, LayoutID: t.LayoutID, LayoutName: t.LayoutName,
+                OrderNumber: t.OrderNumber, StyleNumber: t.StyleNumber, Color: t.Color,
+                Shift: shift, Start: segStart, End: segEnd, Layers: layers, RemainingLayers: remaining,
+                Assigned: t.TableID != nil,
+            })
+        }
+        cursor = segEnd
+    }
+    lane.cursor = cursor
+}
+
+// nextShift 返回 t 所在或之后最近的班次段 [start, end)；按 t 所在时区计算。
+func nextShift(t time.Time, windows []ShiftWindow) (string, time.Time, time.Time) {
+    day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+    // 从前一天开始，覆盖跨零点班次
+    for d := -1; ; d++ {
+        base := day.AddDate(0, 0, d)
+        for _, w := range windows {
+            start := base.Add(w.Start)
+            end := base.Add(w.End)
+            if w.End <= w.Start {
+                end = end.Add(24 * time.Hour)
+            }
+            if end.After(t) {
+                if start.Before(t) {
+                    start = t
+                }
+                return w.Name, start, end
+            }
+        }
+    }
+}
+
+// pickLane 选择任务的裁床：已分配的用其裁床，否则选可容纳唛架且最早空闲的裁床。
+func pickLane(t models.ScheduleTask, lanes map[int]*tableLane, order []*tableLane) (*tableLane, string) {
+    if t.TableID != nil {
+        if lane, ok := lanes[*t.TableID]; ok {
+            return lane, ""
+        }
+        return nil, "table_in_maintenance"
+    }
+    if t.MarkerLength == nil {
+        return nil, "marker_length_missing"
+    }
+    var best *tableLane
+    for _, lane := range order {
+        if lane.table.UsableLength < *t.MarkerLength {
+            continue
+        }
+        if best == nil || lane.cursor.Before(best.cursor) {
+            best = lane
+        }
+    }
+    if best == nil {
+        return nil, "no_fitting_table"
+    }
+    return best, ""
+}
+
+// sortScheduleTasks 已开工任务优先，其次计划完成日期、发布日期（空值靠后）、任务 ID。
+func sortScheduleTasks(tasks []models.ScheduleTask) {
+    before := func(a, b *time.Time) (bool, bool) {
+        switch {
+        case a == nil && b == nil:
+            return false, false
+        case a == nil:
+            return false, true
+        case b == nil:
+            return true, true
+        case !a.Equal(*b):
+            return a.Before(*b), true
+        }
+        return false, false
+    }
+    sort.SliceStable(tasks, func(i, j int) bool {
+        a, b := tasks[i], tasks[j]
+        if (a.CompletedLayers > 0) != (b.CompletedLayers > 0) {
+            return a.CompletedLayers > 0
+        }
+        if less, decided := before(a.PlannedFinishDate, b.PlannedFinishDate); decided {
+            return less
+        }
+        if less, decided := before(a.PublishedAt, b.PublishedAt); decided {
+            return less
+        }
+        return a.TaskID < b.TaskID
+    })
+}
+
+// layerRate 取裁床效率；样本不足时回退为全厂效率，再回退为默认值。
+func layerRate(rates []models.LayerRate, tableID int) (float64, string) {
+    var layers int
+    var hours float64
+    for _, r := range rates {
+        layers += r.Layers
+        hours += r.Hours
+        if r.TableID != nil && *r.TableID == tableID && r.Hours >= scheduleRateMinHours {
+            return float64(r.Layers) / r.Hours, "table"
+        }
+    }
+    if hours >= scheduleRateMinHours && layers > 0 {
+        return float64(layers) / hours, "global"
+    }
+    return DefaultLayersPerHour, "default"
+}
@@ -0,0 +1,28 @@
+package services
+
+import "cutrix-backend/internal/models"
+
+// ShiftsService 管理班次定义与工厂时区。
+// 约束与约定：
+// - 班次：shift_name 唯一（重复返回 ErrConflict）；start_time/end_time 为 HH:MM，不可相同；end_time <= start_time 表示跨零点。
+// - 有效班次之间时间不可重叠（ErrValidation），以保证每条日志归属唯一班次。
+// - 归属：日志写入时由触发器按工厂时区与当时的有效班次写入 shift_id / shift_date（跨零点班次取开班日期）；修改班次不回写历史日志。
+// - 删除：已有归属日志的班次不可删除，请停用。
+// 注意：接口签名不传 context；实现中使用 context.Background() 调用仓储。
+ type ShiftsService interface {
+    // 基本：创建班次；成功后填充 ShiftID。
+    Create(shift *models.Shift) error
+    // 基本：删除班次（仅限无归属日志）。
+    Delete(id int) error
+    // 变更：修改名称、时间、启用状态与备注。
+    Update(shift *models.Shift) error
+
+    // 查询：按 ID 获取班次。
+    GetByID(id int) (*models.Shift, error)
+    // 查询：列出班次；activeOnly 为 true 时仅含启用的班次。
+    List(activeOnly bool) ([]models.Shift, error)
+
+    // 设置：工厂时区（IANA 名称，如 Asia/Shanghai）。
+    GetSettings() (*models.FactorySettings, error)
+    SetTimezone(timezone string) (*models.FactorySettings, error)
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "log/slog"
+    "strings"
+    "time"
+    "cutrix-backend/internal/logger"
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/repositories"
+)
+
+// shiftsService 实现 ShiftsService。
+// 设计要点：
+// - 时间格式与重叠在服务层校验；日志归属由触发器完成，服务层不参与。
+ type shiftsService struct {
+    repo repositories.ShiftsRepository
+}
+
+// NewShiftsService 以给定仓储实现创建 ShiftsService；nil 仓储将 panic。
+ func NewShiftsService(repo repositories.ShiftsRepository) ShiftsService {
+    if repo == nil {
+        panic("nil ShiftsRepository")
+    }
+    return &shiftsService{repo: repo}
+}
+
+// Create 创建班次。
+// 返回：ErrValidation（参数错误或与有效班次重叠）、ErrConflict（名称重复）或仓储错误。
+ func (s *shiftsService) Create(shift *models.Shift) error {
+    if shift == nil {
+        return ErrValidation
+    }
+    if err := validateShift(shift); err != nil {
+        return err
+    }
+    ctx := context.Background()
+    if err := s.ensureConsistent(ctx, shift); err != nil {
+        return err
+    }
+    _, err := s.repo.Create(ctx, shift)
+    if err == nil {
+        // 事件日志：班次创建
+        // 字段：shift_id、shift_name、start_time、end_time
+        logger.L.Info("shift_created",
+            slog.Int("shift_id", shift.ShiftID),
+            slog.String("shift_name", shift.ShiftName),
+            slog.String("start_time", shift.StartTime),
+            slog.String("end_time", shift.EndTime),
+        )
+    }
+    return err
+}
+
+// Delete 删除班次；已有归属日志时由仓储层返回业务错误。
+ func (s *shiftsService) Delete(id int) error {
+    if id <= 0 {
+        return ErrValidation
+    }
+    err := s.repo.Delete(context.Background(), id)
+    if err == nil {
+        logger.L.Info("shift_deleted", slog.Int("shift_id", id))
+    }
+    return err
+}
+
+// Update 修改班次；仅影响之后写入的日志。
+ func (s *shiftsService) Update(shift *models.Shift) error {
+    if shift == nil || shift.ShiftID <= 0 {
+        return ErrValidation
+    }
+    if err := validateShift(shift); err != nil {
+        return err
+    }
+    ctx := context.Background()
+    if err := s.ensureConsistent(ctx, shift); err != nil {
+        return err
+    }
+    err := s.repo.Update(ctx, shift)
+    if err == nil {
+        // 事件日志：班次修改
+        // 字段：shift_id、start_time、end_time、is_active
+        logger.L.Info("shift_updated",
+            slog.Int("shift_id", shift.ShiftID),
+            slog.String("start_time", shift.StartTime),
+            slog.String("end_time", shift.EndTime),
+            slog.Bool("is_active", shift.IsActive),
+        )
+    }
+    return err
+}
+
+// GetByID 查询单个班次。
+ func (s *shiftsService) GetByID(id int) (*models.Shift, error) {
+    if id <= 0 {
+        return nil, ErrValidation
+    }
+    return s.repo.GetByID(context.Background(), id)
+}
+
+// List 列出班次（按开始时间）。
+ func (s *shiftsService) List(activeOnly bool) ([]models.Shift, error) {
+    return s.repo.List(context.Background(), activeOnly)
+}
+
+// GetSettings 查询工厂设置。
+ func (s *shiftsService) GetSettings() (*models.FactorySettings, error) {
+    return s.repo.GetSettings(context.Background())
+}
+
+// SetTimezone 设置工厂时区；无法识别的时区返回 ErrValidation。
+ func (s *shiftsService) SetTimezone(timezone string) (*models.FactorySettings, error) {
+    timezone = strings.TrimSpace(timezone)
+    if timezone == "" || strings.EqualFold(timezone, "local") {
+        return nil, fmt.Errorf("%w: timezone required", ErrValidation)
+    }
+    if _, err := time.LoadLocation(timezone); err != nil {
+        return nil, fmt.Errorf("%w: unknown timezone %q", ErrValidation, timezone)
+    }
+    out, err := s.repo.SetTimezone(context.Background(), timezone)
+    if err == nil {
+        logger.L.Info("factory_timezone_changed", slog.String("timezone", timezone))
+    }
+    return out, err
+}
+
+// ensureConsistent 预检名称唯一，并确保启用的班次与其它有效班次不重叠。
+ func (s *shiftsService) ensureConsistent(ctx context.Context, shift *models.Shift) error {
+    existing, err := s.repo.GetByName(ctx, shift.ShiftName)
+    if err == nil && existing.ShiftID != shift.ShiftID {
+        return ErrConflict
+    }
+    if err != nil && !errors.Is(err, sql.ErrNoRows) {
+        return err
+    }
+    if !shift.IsActive {
+        return nil
+    }
+    active, err := s.repo.List(ctx, true)
+    if err != nil {
+        return err
+    }
+    self, _ := ShiftWindowOf(*shift)
+    for _, other := range active {
+        if other.ShiftID == shift.ShiftID {
+            continue
+        }
+        w, err := ShiftWindowOf(other)
+        if err != nil {
+            return err
+        }
+        if shiftsOverlap(self, w) {
+            return fmt.Errorf("%w: shift overlaps %q", ErrValidation, other.ShiftName)
+        }
+    }
+    return nil
+}
+
+// validateShift 规整并校验名称与时间（HH:MM，00:00-23:59，开始与结束不同）。
+func validateShift(shift *models.Shift) error {
+    shift.ShiftName = strings.TrimSpace(shift.ShiftName)
+    if shift.ShiftName == "" {
+        return fmt.Errorf("%w: shift_name required", ErrValidation)
+    }
+    for _, v := range []*string{&shift.StartTime, &shift.EndTime} {
+        d, err := parseClock(*v)
+        if err != nil || d >= 24*time.Hour {
+            return fmt.Errorf("%w: times must be HH:MM", ErrValidation)
+        }
+        *v = fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+    }
+    if shift.StartTime == shift.EndTime {
+        return fmt.Errorf("%w: start_time and end_time must differ", ErrValidation)
+    }
+    return nil
+}
+
+// ShiftWindowOf 将班次定义转换为排程使用的时间窗。
+func ShiftWindowOf(shift models.Shift) (ShiftWindow, error) {
+    start, err := parseClock(shift.StartTime)
+    if err != nil {
+        return ShiftWindow{}, err
+    }
+    end, err := parseClock(shift.EndTime)
+    if err != nil {
+        return ShiftWindow{}, err
+    }
+    return ShiftWindow{Name: shift.ShiftName, Start: start, End: end}, nil
+}
+
+// shiftsOverlap 判断两个每日时间窗是否重叠（考虑跨零点）。
+func shiftsOverlap(a, b ShiftWindow) bool {
+    span := func(w ShiftWindow) (time.Duration, time.Duration) {
+        if w.End <= w.Start {
+            return w.Start, w.End + 24*time.Hour
+        }
+        return w.Start, w.End
+    }
+    as, ae := span(a)
+    bs, be := span(b)
+    for _, off := range []time.Duration{-24 * time.Hour, 0, 24 * time.Hour} {
+        if as < be+off && bs+off < ae {
+            return true
+        }
+    }
+    return false
+}
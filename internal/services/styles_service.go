@@ -0,0 +1,28 @@
+package services
+
+import "cutrix-backend/internal/models"
+
+// StylesService 管理款式主数据：尺码段（标准尺码顺序）、允许颜色、部件及默认裁片数。
+// 约束与约定：
+// - 款号去除首尾空白后必填且唯一（重复返回 ErrConflict），创建后不可修改；订单按款号关联款式。
+// - 尺码段至少一个尺码，列表顺序即标准尺码顺序；颜色可为空（不限制）；尺码、颜色、部件名称各自不可重复。
+// - 部件裁片数须大于 0，未填（0）按 1 处理。
+// - 订单：已登记款式的订单在创建与修订新增订单项时校验尺码与颜色；修改款式不回溯校验已有订单。
+// 注意：接口签名不传 context；实现中使用 context.Background() 调用仓储。
+ type StylesService interface {
+    // 基本：登记款式；成功后填充 StyleID。
+    Create(style *models.Style) error
+    // 基本：删除款式；订单保留款号，之后不再校验。
+    Delete(id int) error
+
+    // 变更：整体替换名称、备注、尺码段、颜色与部件（款号不变）。
+    Update(style *models.Style) error
+
+    // 查询：按 ID 或款号获取款式。
+    GetByID(id int) (*models.Style, error)
+    GetByNumber(number string) (*models.Style, error)
+    // 查询：列出款式，query 非空时按款号或名称模糊匹配。
+    List(query string) ([]models.Style, error)
+    // 查询：该款式的全部订单，按创建时间倒序。
+    ListOrders(id int) ([]models.ProductionOrder, error)
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "log/slog"
+    "strings"
+    "cutrix-backend/internal/logger"
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/repositories"
+)
+
+// stylesService 实现 StylesService。
+// 设计要点：
+// - 输入规整与校验在服务层完成并返回 ErrValidation；款号唯一性预检后返回 ErrConflict。
+// - 款式订单复用 OrdersRepository.GetAll 的款号过滤。
+ type stylesService struct {
+    repo   repositories.StylesRepository
+    orders repositories.OrdersRepository
+}
+
+// NewStylesService 以给定仓储实现创建 StylesService；任一仓储为 nil 将 panic。
+ func NewStylesService(repo repositories.StylesRepository, orders repositories.OrdersRepository) StylesService {
+    if repo == nil || orders == nil {
+        panic("nil repository for StylesService")
+    }
+    return &stylesService{repo: repo, orders: orders}
+}
+
+// Create 登记款式。
+// 返回：ErrValidation（参数错误）、ErrConflict（款号重复）或仓储错误。
+ func (s *stylesService) Create(style *models.Style) error {
+    if style == nil {
+        return ErrValidation
+    }
+    style.StyleNumber = strings.TrimSpace(style.StyleNumber)
+    if style.StyleNumber == "" {
+        return fmt.Errorf("%w: style_number required", ErrValidation)
+    }
+    if err := validateStyle(style); err != nil {
+        return err
+    }
+    ctx := context.Background()
+    if _, err := s.repo.GetByNumber(ctx, style.StyleNumber); err == nil {
+        return fmt.Errorf("%w: style_number already exists", ErrConflict)
+    } else if !errors.Is(err, sql.ErrNoRows) {
+        return err
+    }
+    _, err := s.repo.Create(ctx, style)
+    if err == nil {
+        // 事件日志：款式登记
+        // 字段：style_id、style_number、sizes
+        logger.L.Info("style_created",
+            slog.Int("style_id", style.StyleID),
+            slog.String("style_number", style.StyleNumber),
+            slog.Any("sizes", style.Sizes),
+        )
+    }
+    return err
+}
+
+// Delete 删除款式。
+ func (s *stylesService) Delete(id int) error {
+    if id <= 0 {
+        return ErrValidation
+    }
+    err := s.repo.Delete(context.Background(), id)
+    if err == nil {
+        logger.L.Info("style_deleted", slog.Int("style_id", id))
+    }
+    return err
+}
+
+// Update 整体替换款式资料；款号以库中记录为准，请求中的款号被忽略。
+ func (s *stylesService) Update(style *models.Style) error {
+    if style == nil || style.StyleID <= 0 {
+        return ErrValidation
+    }
+    if err := validateStyle(style); err != nil {
+        return err
+    }
+    ctx := context.Background()
+    current, err := s.repo.GetByID(ctx, style.StyleID)
+    if err != nil {
+        return err
+    }
+    style.StyleNumber = current.StyleNumber
+    err = s.repo.Update(ctx, style)
+    if err == nil {
+        // 事件日志：款式修改
+        // 字段：style_id、style_number、sizes
+        logger.L.Info("style_updated",
+            slog.Int("style_id", style.StyleID),
+            slog.String("style_number", style.StyleNumber),
+            slog.Any("sizes", style.Sizes),
+        )
+    }
+    return err
+}
+
+// GetByID 按 ID 查询款式。
+ func (s *stylesService) GetByID(id int) (*models.Style, error) {
+    if id <= 0 {
+        return nil, ErrValidation
+    }
+    return s.repo.GetByID(context.Background(), id)
+}
+
+// GetByNumber 按款号查询款式。
+ func (s *stylesService) GetByNumber(number string) (*models.Style, error) {
+    number = strings.TrimSpace(number)
+    if number == "" {
+        return nil, ErrValidation
+    }
+    return s.repo.GetByNumber(context.Background(), number)
+}
+
+// List 列出款式。
+ func (s *stylesService) List(query string) ([]models.Style, error) {
+    return s.repo.List(context.Background(), strings.TrimSpace(query))
+}
+
+// ListOrders 列出款式下的订单；款式不存在时返回 sql.ErrNoRows。
+ func (s *stylesService) ListOrders(id int) ([]models.ProductionOrder, error) {
+    if id <= 0 {
+        return nil, ErrValidation
+    }
+    ctx := context.Background()
+    style, err := s.repo.GetByID(ctx, id)
+    if err != nil {
+        return nil, err
+    }
+    list, err := s.orders.GetAll(ctx, models.OrderFilter{StyleNumber: &style.StyleNumber})
+    if err != nil {
+        return nil, err
+    }
+    if list == nil {
+        list = []models.ProductionOrder{}
+    }
+    return list, nil
+}
+
+// validateStyle 规整并校验名称、备注、尺码段、颜色与部件。
+func validateStyle(style *models.Style) error {
+    for _, p := range []**string{&style.StyleName, &style.Note} {
+        if *p != nil && strings.TrimSpace(**p) == "" {
+            *p = nil
+        }
+    }
+    var err error
+    if style.Sizes, err = normalizeStyleList("sizes", style.Sizes); err != nil {
+        return err
+    }
+    if len(style.Sizes) == 0 {
+        return fmt.Errorf("%w: sizes required", ErrValidation)
+    }
+    if style.Colors, err = normalizeStyleList("colors", style.Colors); err != nil {
+        return err
+    }
+    if style.Components == nil {
+        style.Components = []models.StyleComponent{}
+    }
+    seen := make(map[string]bool, len(style.Components))
+    for i := range style.Components {
+        c := &style.Components[i]
+        c.ComponentName = strings.TrimSpace(c.ComponentName)
+        if c.ComponentName == "" {
+            return fmt.Errorf("%w: components[%d]: component_name required", ErrValidation, i)
+        }
+        if seen[c.ComponentName] {
+            return fmt.Errorf("%w: components[%d]: duplicate component %q", ErrValidation, i, c.ComponentName)
+        }
+        seen[c.ComponentName] = true
+        if c.Pieces == 0 {
+            c.Pieces = 1
+        }
+        if c.Pieces < 0 {
+            return fmt.Errorf("%w: components[%d]: pieces must be > 0", ErrValidation, i)
+        }
+    }
+    return nil
+}
+
+// normalizeStyleList 去除首尾空白，拒绝空值与重复值，保持原顺序。
+func normalizeStyleList(field string, values []string) ([]string, error) {
+    out := make([]string, 0, len(values))
+    seen := make(map[string]bool, len(values))
+    for i, v := range values {
+        v = strings.TrimSpace(v)
+        if v == "" {
+            return nil, fmt.Errorf("%w: %s[%d] must not be blank", ErrValidation, field, i)
+        }
+        if seen[v] {
+            return nil, fmt.Errorf("%w: %s[%d]: duplicate %q", ErrValidation, field, i, v)
+        }
+        seen[v] = true
+        out = append(out, v)
+    }
+    return out, nil
+}
@@ -0,0 +1,30 @@
+package services
+
+import "cutrix-backend/internal/models"
+
+// TablesService 管理裁床：登记、修改、维护状态切换、删除与查询，以及裁床上的任务。
+// 约束与约定：
+// - 登记：table_name 唯一（重复返回 ErrConflict），usable_length（米）须 > 0，状态缺省 active。
+// - 状态：active | maintenance；维护中的裁床不可分配任务、不可拉布，已分配任务保留，需改派时通过任务接口调整。
+// - 长度：可用长度不可缩短到已分配未完成任务的唛架长度以下（触发器拒绝）。
+// - 分配：任务分配裁床通过 TasksService.AssignTable 完成；当前分配为裁床上进行中（其次待开始）的首个未完成任务。
+// - 删除：仅允许删除从未分配任务且无拉布日志的裁床。
+// 注意：接口签名不传 context；实现中使用 context.Background() 调用仓储。
+ type TablesService interface {
+    // 基本：登记裁床；成功后填充 TableID。
+    Create(table *models.CuttingTable) error
+    // 基本：删除裁床（仅限无任务、无日志引用）。
+    Delete(id int) error
+
+    // 变更：修改名称、可用长度与备注。
+    Update(table *models.CuttingTable) error
+    // 变更：切换 active / maintenance。
+    UpdateStatus(id int, status string) error
+
+    // 查询：按 ID 获取裁床（含当前分配）。
+    GetByID(id int) (*models.CuttingTable, error)
+    // 查询：列出裁床，status 为空表示全部。
+    List(status string) ([]models.CuttingTable, error)
+    // 查询：裁床上分配的任务（含已完成）。
+    ListTasks(id int) ([]models.ProductionTask, error)
+}
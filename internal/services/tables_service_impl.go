@@ -0,0 +1,165 @@
+package services
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "log/slog"
+    "strings"
+    "cutrix-backend/internal/logger"
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/repositories"
+)
+
+// tablesService 实现 TablesService。
+// 设计要点：
+// - 输入校验在服务层完成并返回 ErrValidation；名称唯一性预检后返回 ErrConflict。
+// - 唛架长度与裁床长度的匹配由触发器保证，服务层不重复查询布局。
+ type tablesService struct {
+    repo repositories.TablesRepository
+}
+
+// NewTablesService 以给定仓储实现创建 TablesService；nil 仓储将 panic。
+ func NewTablesService(repo repositories.TablesRepository) TablesService {
+    if repo == nil {
+        panic("nil TablesRepository")
+    }
+    return &tablesService{repo: repo}
+}
+
+// validTableStatuses 为裁床允许的状态值。
+var validTableStatuses = map[string]bool{"active": true, "maintenance": true}
+
+// Create 登记裁床。
+// table：table_name 去除首尾空白后必填，usable_length > 0，status 为空时取 active。
+// 返回：ErrValidation（参数错误）、ErrConflict（名称重复）或仓储错误。
+ func (s *tablesService) Create(table *models.CuttingTable) error {
+    if table == nil {
+        return ErrValidation
+    }
+    if table.Status == "" {
+        table.Status = "active"
+    }
+    if err := validateTable(table); err != nil {
+        return err
+    }
+    if !validTableStatuses[table.Status] {
+        return fmt.Errorf("%w: invalid status", ErrValidation)
+    }
+    ctx := context.Background()
+    if err := s.ensureNameFree(ctx, table.TableName, 0); err != nil {
+        return err
+    }
+    _, err := s.repo.Create(ctx, table)
+    if err == nil {
+        // 事件日志：裁床登记
+        // 字段：table_id、table_name、usable_length、status
+        logger.L.Info("table_created",
+            slog.Int("table_id", table.TableID),
+            slog.String("table_name", table.TableName),
+            slog.Float64("usable_length", table.UsableLength),
+            slog.String("status", table.Status),
+        )
+    }
+    return err
+}
+
+// Delete 删除裁床；已被任务或日志引用时由仓储层返回业务错误。
+ func (s *tablesService) Delete(id int) error {
+    if id <= 0 {
+        return ErrValidation
+    }
+    err := s.repo.Delete(context.Background(), id)
+    if err == nil {
+        logger.L.Info("table_deleted", slog.Int("table_id", id))
+    }
+    return err
+}
+
+// Update 修改名称、可用长度与备注；状态请使用 UpdateStatus。
+ func (s *tablesService) Update(table *models.CuttingTable) error {
+    if table == nil || table.TableID <= 0 {
+        return ErrValidation
+    }
+    if err := validateTable(table); err != nil {
+        return err
+    }
+    ctx := context.Background()
+    if err := s.ensureNameFree(ctx, table.TableName, table.TableID); err != nil {
+        return err
+    }
+    return s.repo.Update(ctx, table)
+}
+
+// UpdateStatus 切换裁床状态。
+ func (s *tablesService) UpdateStatus(id int, status string) error {
+    if id <= 0 {
+        return ErrValidation
+    }
+    status = strings.TrimSpace(status)
+    if !validTableStatuses[status] {
+        return fmt.Errorf("%w: invalid status", ErrValidation)
+    }
+    err := s.repo.UpdateStatus(context.Background(), id, status)
+    if err == nil {
+        // 事件日志：裁床状态变更（进入/结束维护）
+        logger.L.Info("table_status_changed",
+            slog.Int("table_id", id),
+            slog.String("status", status),
+        )
+    }
+    return err
+}
+
+// GetByID 查询单个裁床。
+ func (s *tablesService) GetByID(id int) (*models.CuttingTable, error) {
+    if id <= 0 {
+        return nil, ErrValidation
+    }
+    return s.repo.GetByID(context.Background(), id)
+}
+
+// List 列出裁床；status 仅允许空、active 或 maintenance。
+ func (s *tablesService) List(status string) ([]models.CuttingTable, error) {
+    status = strings.TrimSpace(status)
+    if status != "" && !validTableStatuses[status] {
+        return nil, fmt.Errorf("%w: invalid status", ErrValidation)
+    }
+    return s.repo.List(context.Background(), status)
+}
+
+// ListTasks 列出裁床上的任务；裁床不存在时返回 sql.ErrNoRows。
+ func (s *tablesService) ListTasks(id int) ([]models.ProductionTask, error) {
+    if id <= 0 {
+        return nil, ErrValidation
+    }
+    return s.repo.ListTasks(context.Background(), id)
+}
+
+// ensureNameFree 预检名称唯一；selfID 为当前裁床（修改时排除自身）。
+ func (s *tablesService) ensureNameFree(ctx context.Context, name string, selfID int) error {
+    existing, err := s.repo.GetByName(ctx, name)
+    if err == nil {
+        if existing.TableID != selfID {
+            return ErrConflict
+        }
+        return nil
+    }
+    if !errors.Is(err, sql.ErrNoRows) {
+        return err
+    }
+    return nil
+}
+
+// validateTable 规整并校验名称与可用长度。
+func validateTable(table *models.CuttingTable) error {
+    table.TableName = strings.TrimSpace(table.TableName)
+    if table.TableName == "" {
+        return fmt.Errorf("%w: table_name required", ErrValidation)
+    }
+    if table.UsableLength <= 0 {
+        return fmt.Errorf("%w: usable_length must be > 0", ErrValidation)
+    }
+    return nil
+}
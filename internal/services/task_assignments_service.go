@@ -0,0 +1,22 @@
+package services
+
+import "cutrix-backend/internal/models"
+
+// TaskAssignmentsService 管理任务的人员分配：将任务分配给一个或多个用户，或分配给用户组（User.Group）。
+// 约束与约定：
+// - 分配：user_ids 与 user_group 至少提供一个；重复分配同一用户/组将被忽略；已完成任务不可分配。
+// - 撤销：仅标记撤销时间与撤销人，记录保留为分配历史。
+// - 可见性：worker 角色的任务列表仅包含直接分配给本人或本人所在组的任务（TasksService.ListAssigned）。
+// - 日志：启用 REQUIRE_TASK_ASSIGNMENT 时，worker 仅可向已分配给自己（或所在组）的任务提交日志（EnsureAssignee）。
+// 注意：接口签名不传 context；实现中使用 context.Background() 调用仓储。
+ type TaskAssignmentsService interface {
+    // 变更：分配任务；assignedBy 为操作人。返回任务当前的有效分配。
+    Assign(taskID int, userIDs []int, group *string, assignedBy *int) ([]models.TaskAssignment, error)
+    // 变更：撤销任务的一条有效分配；unassignedBy 为操作人。
+    Unassign(taskID, assignmentID int, unassignedBy *int) error
+
+    // 查询：任务的有效分配；includeHistory 为 true 时包含已撤销的历史记录。
+    List(taskID int, includeHistory bool) ([]models.TaskAssignment, error)
+    // 校验：用户未被分配到任务（直接或经用户组）时返回 ErrForbidden。
+    EnsureAssignee(taskID, userID int) error
+}
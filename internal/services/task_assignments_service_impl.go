@@ -0,0 +1,113 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "strings"
+    "cutrix-backend/internal/logger"
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/repositories"
+)
+
+// taskAssignmentsService 实现 TaskAssignmentsService。
+// 设计要点：
+// - 输入校验在服务层完成并返回 ErrValidation；任务不存在由仓储返回 sql.ErrNoRows（404）。
+// - 用户有效性（存在且启用）由仓储在事务内预检；已完成任务由触发器拒绝。
+ type taskAssignmentsService struct {
+    repo repositories.TaskAssignmentsRepository
+}
+
+// NewTaskAssignmentsService 以给定仓储实现创建 TaskAssignmentsService；nil 仓储将 panic。
+ func NewTaskAssignmentsService(repo repositories.TaskAssignmentsRepository) TaskAssignmentsService {
+    if repo == nil {
+        panic("nil TaskAssignmentsRepository")
+    }
+    return &taskAssignmentsService{repo: repo}
+}
+
+// Assign 分配任务给用户和/或用户组。
+// userIDs：用户 ID 列表（去重，须 > 0）；group：用户组（去除首尾空白后非空）。
+// 返回：任务当前的有效分配；ErrValidation（参数错误）或仓储错误。
+ func (s *taskAssignmentsService) Assign(taskID int, userIDs []int, group *string, assignedBy *int) ([]models.TaskAssignment, error) {
+    if taskID <= 0 {
+        return nil, fmt.Errorf("%w: invalid task_id", ErrValidation)
+    }
+    seen := make(map[int]bool, len(userIDs))
+    ids := make([]int, 0, len(userIDs))
+    for _, id := range userIDs {
+        if id <= 0 {
+            return nil, fmt.Errorf("%w: invalid user_id", ErrValidation)
+        }
+        if !seen[id] {
+            seen[id] = true
+            ids = append(ids, id)
+        }
+    }
+    if group != nil {
+        g := strings.TrimSpace(*group)
+        if g == "" {
+            return nil, fmt.Errorf("%w: user_group must not be empty", ErrValidation)
+        }
+        group = &g
+    }
+    if len(ids) == 0 && group == nil {
+        return nil, fmt.Errorf("%w: user_ids or user_group required", ErrValidation)
+    }
+    out, err := s.repo.Assign(context.Background(), taskID, ids, group, assignedBy)
+    if err != nil {
+        return nil, err
+    }
+    // 事件日志：任务分配人员
+    // 字段：task_id、user_ids、user_group、assigned_by
+    logger.L.Info("task_assigned",
+        slog.Int("task_id", taskID),
+        slog.Any("user_ids", ids),
+        slog.Any("user_group", group),
+        slog.Any("assigned_by", assignedBy),
+    )
+    return out, nil
+}
+
+// Unassign 撤销任务的一条有效分配。
+// 返回：ErrValidation（参数错误）；分配不存在或已撤销返回 sql.ErrNoRows（404）。
+ func (s *taskAssignmentsService) Unassign(taskID, assignmentID int, unassignedBy *int) error {
+    if taskID <= 0 || assignmentID <= 0 {
+        return fmt.Errorf("%w: invalid id", ErrValidation)
+    }
+    if err := s.repo.Unassign(context.Background(), taskID, assignmentID, unassignedBy); err != nil {
+        return err
+    }
+    // 事件日志：撤销任务分配
+    // 字段：task_id、assignment_id、unassigned_by
+    logger.L.Info("task_unassigned",
+        slog.Int("task_id", taskID),
+        slog.Int("assignment_id", assignmentID),
+        slog.Any("unassigned_by", unassignedBy),
+    )
+    return nil
+}
+
+// List 查询任务的分配记录。
+ func (s *taskAssignmentsService) List(taskID int, includeHistory bool) ([]models.TaskAssignment, error) {
+    if taskID <= 0 {
+        return nil, fmt.Errorf("%w: invalid task_id", ErrValidation)
+    }
+    return s.repo.ListByTask(context.Background(), taskID, includeHistory)
+}
+
+// EnsureAssignee 校验用户是否被分配到任务（直接或经用户组）。
+// 返回：未分配时返回 ErrForbidden；查询失败返回仓储错误。
+ func (s *taskAssignmentsService) EnsureAssignee(taskID, userID int) error {
+    if taskID <= 0 || userID <= 0 {
+        return ErrValidation
+    }
+    ok, err := s.repo.IsAssignee(context.Background(), taskID, userID)
+    if err != nil {
+        return err
+    }
+    if !ok {
+        return fmt.Errorf("%w: task %d is not assigned to user %d", ErrForbidden, taskID, userID)
+    }
+    return nil
+}
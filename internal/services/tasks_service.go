@@ -6,7 +6,9 @@ import "cutrix-backend/internal/models"
 // 约束与约定：
 // - 创建/删除：仅允许在所属计划为 pending 时执行；发布后任务结构不可新增/删除。
 // - 状态更新：不直接暴露 UpdateStatus；任务进度通过 LogsService 记录，触发器汇总到任务/计划完成度，以保证审计与一致性。
-// - 查询：提供按 ID 与按布局列出的只读视图。
+// - 裁床：任务可分配到裁床（创建时或之后），已完成任务不可再分配；日志缺省记录任务所在裁床。
+// - 拆分/合并：发布前后均可（冻结、完成的计划除外），在任务调整上下文中执行，计划层数与完成层数合计不变。
+// - 查询：提供按 ID 与按布局列出的只读视图；worker 仅看到分配给本人或所在组的任务（ListAssigned）。
 // - 上下文：接口不透传 context；实现使用 context.Background() 调用仓储，与处理器层解耦。
  type TasksService interface {
     // 基本：创建任务（必须关联布局）；成功返回填充的 TaskID。
@@ -14,10 +16,22 @@ import "cutrix-backend/internal/models"
     // 基本：删除任务；受计划状态限制。
     Delete(id int) error
 
+    // 变更：分配裁床（nil 取消分配）；裁床须为 active 且可用长度不小于唛架长度。发布后仍可调整。
+    AssignTable(id int, tableID *int) (*models.ProductionTask, error)
+    // 变更：拆分任务，把 layers 层剩余计划层数移到同布局同颜色的新任务（可指定裁床）；日志留在原任务。
+    // 返回更新后的原任务与新任务。
+    Split(id int, layers int, tableID *int) (*models.ProductionTask, *models.ProductionTask, error)
+    // 变更：合并同布局同颜色的任务到 targetID；日志、缸号层数随之迁移，源任务删除。缸号不一致需 allowLotMix。
+    Merge(targetID int, sourceIDs []int, allowLotMix bool) (*models.ProductionTask, error)
+
     // 查询：按 ID 获取任务详情。
     GetByID(id int) (*models.ProductionTask, error)
     // 查询：列出所有任务。
     List() ([]models.ProductionTask, error)
+    // 查询：列出当前分配给用户（直接或经用户组）的任务。
+    ListAssigned(userID int) ([]models.ProductionTask, error)
     // 查询：按布局列出任务列表。
     ListByLayout(layoutID int) ([]models.ProductionTask, error)
+    // 查询：按缸号列出任务已完成层数（不同缸号不得混拉，混缸需提交日志时显式允许）。
+    Lots(id int) ([]models.TaskLotLayers, error)
 }
\ No newline at end of file
@@ -3,6 +3,7 @@ package services
 import (
     "context"
     "errors"
+    "fmt"
     "log/slog"
     "cutrix-backend/internal/models"
     "cutrix-backend/internal/repositories"
@@ -45,6 +46,9 @@ import (
     if task.PlannedLayers <= 0 {
         return errors.New("planned_layers must be > 0")
     }
+    if task.TableID != nil && *task.TableID <= 0 {
+        return fmt.Errorf("%w: invalid table_id", ErrValidation)
+    }
     _, err := s.repo.Create(context.Background(), task)
     if err == nil {
         // 事件日志：任务创建成功
@@ -75,6 +79,89 @@ import (
     return err
 }
 
+// AssignTable 将任务分配到裁床；tableID 为 nil 表示取消分配。
+// 校验（触发器）：裁床须为 active，可用长度不小于布局唛架长度；已完成任务不可分配。
+// 返回：更新后的任务；任务不存在返回 NotFound。
+ func (s *tasksService) AssignTable(id int, tableID *int) (*models.ProductionTask, error) {
+    if id <= 0 {
+        return nil, errors.New("invalid task_id")
+    }
+    if tableID != nil && *tableID <= 0 {
+        return nil, fmt.Errorf("%w: invalid table_id", ErrValidation)
+    }
+    ctx := context.Background()
+    if err := s.repo.AssignTable(ctx, id, tableID); err != nil {
+        return nil, err
+    }
+    // 事件日志：任务分配/取消分配裁床
+    // 字段：task_id、table_id（nil 表示取消分配）
+    logger.L.Info("task_table_assigned",
+        slog.Int("task_id", id),
+        slog.Any("table_id", tableID),
+    )
+    return s.repo.GetByID(ctx, id)
+}
+
+// Split 拆分任务：把 layers 层剩余计划层数移到同布局同颜色的新任务，常用于同一颜色分到两张裁床。
+// 约束：1 ≤ layers ≤ 剩余层数，原任务至少保留 1 层；原任务已完成层数达到新计划层数时变为 completed（生成扎包）。
+// 返回：更新后的原任务与新任务；任务不存在返回 NotFound。
+ func (s *tasksService) Split(id int, layers int, tableID *int) (*models.ProductionTask, *models.ProductionTask, error) {
+    if id <= 0 {
+        return nil, nil, errors.New("invalid task_id")
+    }
+    if layers <= 0 {
+        return nil, nil, fmt.Errorf("%w: layers must be > 0", ErrValidation)
+    }
+    if tableID != nil && *tableID <= 0 {
+        return nil, nil, fmt.Errorf("%w: invalid table_id", ErrValidation)
+    }
+    updated, created, err := s.repo.Split(context.Background(), id, layers, tableID)
+    if err != nil {
+        return nil, nil, err
+    }
+    // 事件日志：任务拆分
+    // 字段：task_id、new_task_id、layers、table_id
+    logger.L.Info("task_split",
+        slog.Int("task_id", id),
+        slog.Int("new_task_id", created.TaskID),
+        slog.Int("layers", layers),
+        slog.Any("table_id", tableID),
+    )
+    return updated, created, nil
+}
+
+// Merge 合并任务：sourceIDs 并入 targetID，计划层数与已完成层数相加，日志与缸号层数迁移到目标任务，源任务删除。
+// 约束：须同布局同颜色；缸号不一致返回 *LotMixError，除非 allowLotMix。源任务的分配与扎包随任务删除。
+// 返回：合并后的目标任务；任一任务不存在返回 NotFound。
+ func (s *tasksService) Merge(targetID int, sourceIDs []int, allowLotMix bool) (*models.ProductionTask, error) {
+    if targetID <= 0 {
+        return nil, errors.New("invalid task_id")
+    }
+    if len(sourceIDs) == 0 {
+        return nil, fmt.Errorf("%w: task_ids required", ErrValidation)
+    }
+    seen := map[int]bool{targetID: true}
+    for _, id := range sourceIDs {
+        if id <= 0 || seen[id] {
+            return nil, fmt.Errorf("%w: task_ids must be distinct positive ids other than the target", ErrValidation)
+        }
+        seen[id] = true
+    }
+    merged, err := s.repo.Merge(context.Background(), targetID, sourceIDs, allowLotMix)
+    if err != nil {
+        return nil, err
+    }
+    // 事件日志：任务合并
+    // 字段：task_id、merged_task_ids、planned_layers、completed_layers
+    logger.L.Info("task_merged",
+        slog.Int("task_id", targetID),
+        slog.Any("merged_task_ids", sourceIDs),
+        slog.Int("planned_layers", merged.PlannedLayers),
+        slog.Int("completed_layers", merged.CompletedLayers),
+    )
+    return merged, nil
+}
+
 // GetByID 查询单个任务详情。
 // id：任务 ID。
 // 返回：任务实体只读副本与错误；不存在时返回仓储层 NotFound 错误。
@@ -91,6 +178,16 @@ import (
     return s.repo.List(context.Background())
 }
 
+// ListAssigned 列出分配给用户（直接或经其用户组）的任务。
+// userID：用户 ID。
+// 返回：任务列表与错误；无分配时返回空列表。
+ func (s *tasksService) ListAssigned(userID int) ([]models.ProductionTask, error) {
+    if userID <= 0 {
+        return nil, errors.New("invalid user_id")
+    }
+    return s.repo.ListAssigned(context.Background(), userID)
+}
+
 // ListByLayout 按布局列出任务集合。
 // layoutID：布局 ID。
 // 返回：任务列表与错误；若布局不存在或无任务返回空列表或仓储层错误。
@@ -99,4 +196,18 @@ import (
         return nil, errors.New("invalid layout_id")
     }
     return s.repo.ListByLayout(context.Background(), layoutID)
+}
+
+// Lots 查询任务按缸号的完成层数。
+// id：任务 ID。
+// 返回：缸号层数列表；任务不存在时返回仓储层 NotFound 错误。
+ func (s *tasksService) Lots(id int) ([]models.TaskLotLayers, error) {
+    if id <= 0 {
+        return nil, errors.New("invalid task_id")
+    }
+    ctx := context.Background()
+    if _, err := s.repo.GetByID(ctx, id); err != nil {
+        return nil, err
+    }
+    return s.repo.ListLots(ctx, id)
 }
\ No newline at end of file
@@ -0,0 +1,90 @@
+-- Revert cut tolerance: restore the publish guard from 000001, then drop the function and columns
+
+BEGIN;
+
+CREATE OR REPLACE FUNCTION production.guard_plan_update()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_total INT;
+    v_completed INT;
+BEGIN
+    IF production.is_plan_adjustment_context() THEN
+        RETURN NEW;
+    END IF;
+
+    -- Disallow manual changes of publish/finish date when status unchanged
+    IF NEW.status = OLD.status THEN
+        IF NEW.planned_publish_date IS DISTINCT FROM OLD.planned_publish_date THEN
+            RAISE EXCEPTION '发布日期由发布动作自动记录，禁止手动修改';
+        END IF;
+        IF NEW.planned_finish_date IS DISTINCT FROM OLD.planned_finish_date THEN
+            RAISE EXCEPTION '完成时间由系统自动记录，禁止手动修改';
+        END IF;
+    END IF;
+
+    -- Disallow pending -> completed direct transition
+    IF OLD.status = 'pending' AND NEW.status = 'completed' THEN
+        RAISE EXCEPTION '禁止从 pending 直接变更为 completed';
+    END IF;
+    -- Disallow pending -> frozen direct transition
+    IF OLD.status = 'pending' AND NEW.status = 'frozen' THEN
+        RAISE EXCEPTION '仅允许在 completed 状态下冻结计划';
+    END IF;
+
+    -- Publish: pending -> in_progress, require tasks exist; publish date set by AFTER trigger
+    IF NEW.status = 'in_progress' AND (OLD.status IS DISTINCT FROM 'in_progress') THEN
+        IF OLD.status <> 'pending' THEN
+            RAISE EXCEPTION '仅允许从 pending 发布到 in_progress';
+        END IF;
+        SELECT COUNT(*) INTO v_total
+        FROM production.tasks t
+        JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+        WHERE l.plan_id = OLD.plan_id;
+        IF v_total <= 0 THEN
+            RAISE EXCEPTION '发布失败：该计划必须包含至少一个任务';
+        END IF;
+        -- publish date will be set by AFTER trigger, do not set NEW.planned_publish_date here
+    END IF;
+
+    -- After publish: enforce restrictions and controlled transitions
+    IF OLD.status IN ('in_progress','completed','frozen') THEN
+        IF NEW.status = OLD.status THEN
+            IF NEW.plan_name IS DISTINCT FROM OLD.plan_name OR NEW.order_id IS DISTINCT FROM OLD.order_id THEN
+                RAISE EXCEPTION '计划发布后仅允许修改备注';
+            END IF;
+        ELSE
+            IF NEW.status = 'completed' THEN
+                SELECT COUNT(*) INTO v_total
+                FROM production.tasks t
+                JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+                WHERE l.plan_id = OLD.plan_id;
+                SELECT COUNT(*) INTO v_completed
+                FROM production.tasks t
+                JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+                WHERE l.plan_id = OLD.plan_id AND t.status = 'completed';
+                IF v_total > 0 AND v_completed = v_total THEN
+                    NEW.planned_finish_date := CURRENT_TIMESTAMP;
+                ELSE
+                    RAISE EXCEPTION '状态变更为已完成失败：仍有未完成任务';
+                END IF;
+            ELSIF NEW.status = 'frozen' THEN
+                IF OLD.status <> 'completed' THEN
+                    RAISE EXCEPTION '仅允许在 completed 状态下冻结计划';
+                END IF;
+                NEW.planned_finish_date := OLD.planned_finish_date;
+            ELSE
+                RAISE EXCEPTION '计划发布后不允许更改为该状态';
+            END IF;
+        END IF;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP FUNCTION IF EXISTS production.plan_tolerance_violations(INT);
+
+ALTER TABLE production.orders DROP COLUMN IF EXISTS under_cut_tolerance;
+ALTER TABLE production.orders DROP COLUMN IF EXISTS over_cut_tolerance;
+
+COMMIT;
\ No newline at end of file
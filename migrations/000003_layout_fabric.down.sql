@@ -0,0 +1,10 @@
+-- Revert marker specification columns
+
+BEGIN;
+
+ALTER TABLE production.cutting_layouts DROP COLUMN IF EXISTS end_loss_allowance;
+ALTER TABLE production.cutting_layouts DROP COLUMN IF EXISTS marker_efficiency;
+ALTER TABLE production.cutting_layouts DROP COLUMN IF EXISTS fabric_width;
+ALTER TABLE production.cutting_layouts DROP COLUMN IF EXISTS marker_length;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,18 @@
+-- Marker specification on cutting layouts, used to compute fabric requirements
+
+BEGIN;
+
+-- =====================
+-- Columns
+-- =====================
+-- Lengths in meters, width in centimeters, efficiency in percent; NULL until the marker is made
+ALTER TABLE production.cutting_layouts ADD COLUMN IF NOT EXISTS marker_length NUMERIC(10,3)
+    CHECK (marker_length > 0);
+ALTER TABLE production.cutting_layouts ADD COLUMN IF NOT EXISTS fabric_width NUMERIC(10,2)
+    CHECK (fabric_width > 0);
+ALTER TABLE production.cutting_layouts ADD COLUMN IF NOT EXISTS marker_efficiency NUMERIC(5,2)
+    CHECK (marker_efficiency > 0 AND marker_efficiency <= 100);
+ALTER TABLE production.cutting_layouts ADD COLUMN IF NOT EXISTS end_loss_allowance NUMERIC(10,3)
+    CHECK (end_loss_allowance >= 0);
+
+COMMIT;
\ No newline at end of file
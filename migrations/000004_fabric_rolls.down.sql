@@ -0,0 +1,18 @@
+-- Revert fabric roll inventory
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_after_log_void_restore_rolls ON production.logs;
+DROP TRIGGER IF EXISTS trg_guard_log_rolls_change ON production.log_rolls;
+DROP TRIGGER IF EXISTS trg_before_log_roll_insert ON production.log_rolls;
+DROP TRIGGER IF EXISTS trg_guard_fabric_rolls_update ON production.fabric_rolls;
+
+DROP FUNCTION IF EXISTS production.restore_log_roll_usage();
+DROP FUNCTION IF EXISTS production.guard_log_rolls_change();
+DROP FUNCTION IF EXISTS production.apply_log_roll_usage();
+DROP FUNCTION IF EXISTS production.guard_fabric_rolls_update();
+
+DROP TABLE IF EXISTS production.log_rolls;
+DROP TABLE IF EXISTS production.fabric_rolls;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,149 @@
+-- Fabric roll inventory and per-log roll usage
+
+BEGIN;
+
+-- =====================
+-- Tables
+-- =====================
+-- Fabric rolls (布卷); lengths in meters, width in centimeters
+CREATE TABLE IF NOT EXISTS production.fabric_rolls (
+    roll_id SERIAL PRIMARY KEY,
+    roll_code VARCHAR(50) NOT NULL UNIQUE,
+    color VARCHAR(50) NOT NULL,
+    dye_lot VARCHAR(50),
+    fabric_width NUMERIC(10,2) CHECK (fabric_width > 0),
+    received_length NUMERIC(10,3) NOT NULL CHECK (received_length > 0),
+    remaining_length NUMERIC(10,3) NOT NULL CHECK (remaining_length >= 0),
+    remnant_length NUMERIC(10,3) CHECK (remnant_length >= 0),       -- measured leftover when closed
+    status VARCHAR(20) NOT NULL DEFAULT 'available' CHECK (status IN ('available','in_use','closed')),
+    note TEXT,
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP
+);
+
+-- Rolls spread by each log; length_used = layers × (marker_length + end_loss_allowance)
+CREATE TABLE IF NOT EXISTS production.log_rolls (
+    log_id INT NOT NULL REFERENCES production.logs(log_id) ON DELETE CASCADE,
+    roll_id INT NOT NULL REFERENCES production.fabric_rolls(roll_id) ON DELETE RESTRICT,
+    layers INT NOT NULL CHECK (layers > 0),
+    length_used NUMERIC(10,3) NOT NULL CHECK (length_used >= 0),
+    PRIMARY KEY (log_id, roll_id)
+);
+
+-- =====================
+-- Indexes
+-- =====================
+CREATE INDEX IF NOT EXISTS fabric_rolls_color_lot_idx ON production.fabric_rolls (color, dye_lot);
+CREATE INDEX IF NOT EXISTS log_rolls_roll_idx ON production.log_rolls (roll_id);
+
+-- =====================
+-- Functions & Triggers
+-- =====================
+-- Fabric rolls: closed rolls are immutable except note; remnant/closed_at only set by closing
+CREATE OR REPLACE FUNCTION production.guard_fabric_rolls_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.roll_code IS DISTINCT FROM OLD.roll_code
+        OR NEW.color IS DISTINCT FROM OLD.color
+        OR NEW.received_length IS DISTINCT FROM OLD.received_length THEN
+        RAISE EXCEPTION '布卷编号、颜色与到货长度不可修改 (roll=%)', OLD.roll_id;
+    END IF;
+    IF OLD.status = 'closed' THEN
+        IF NEW.status <> 'closed'
+            OR NEW.remaining_length IS DISTINCT FROM OLD.remaining_length
+            OR NEW.remnant_length IS DISTINCT FROM OLD.remnant_length THEN
+            RAISE EXCEPTION '布卷已结卷，不可再领用或修改 (roll=%)', OLD.roll_id;
+        END IF;
+    ELSIF NEW.status = 'closed' THEN
+        IF NEW.remnant_length IS NULL THEN
+            RAISE EXCEPTION '结卷必须登记余料长度 (roll=%)', OLD.roll_id;
+        END IF;
+        NEW.closed_at := CURRENT_TIMESTAMP;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_fabric_rolls_update ON production.fabric_rolls;
+CREATE TRIGGER trg_guard_fabric_rolls_update
+BEFORE UPDATE ON production.fabric_rolls
+FOR EACH ROW
+EXECUTE FUNCTION production.guard_fabric_rolls_update();
+
+-- Log rolls: roll must match task color, be open and have enough length; decrement remaining length
+CREATE OR REPLACE FUNCTION production.apply_log_roll_usage()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_task_color VARCHAR(50);
+    v_roll production.fabric_rolls%ROWTYPE;
+BEGIN
+    SELECT t.color INTO v_task_color
+    FROM production.logs l
+    JOIN production.tasks t ON t.task_id = l.task_id
+    WHERE l.log_id = NEW.log_id;
+
+    SELECT * INTO v_roll FROM production.fabric_rolls WHERE roll_id = NEW.roll_id FOR UPDATE;
+    IF v_roll.status = 'closed' THEN
+        RAISE EXCEPTION '布卷 % 已结卷，不可领用', v_roll.roll_code;
+    END IF;
+    IF v_roll.color IS DISTINCT FROM v_task_color THEN
+        RAISE EXCEPTION '布卷 % 颜色 % 与任务颜色 % 不一致', v_roll.roll_code, v_roll.color, v_task_color;
+    END IF;
+    IF v_roll.remaining_length < NEW.length_used THEN
+        RAISE EXCEPTION '布卷 % 剩余长度不足 (剩余: %, 需要: %)', v_roll.roll_code, v_roll.remaining_length, NEW.length_used;
+    END IF;
+
+    UPDATE production.fabric_rolls
+    SET remaining_length = remaining_length - NEW.length_used,
+        status = 'in_use'
+    WHERE roll_id = NEW.roll_id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_before_log_roll_insert ON production.log_rolls;
+CREATE TRIGGER trg_before_log_roll_insert
+BEFORE INSERT ON production.log_rolls
+FOR EACH ROW
+EXECUTE FUNCTION production.apply_log_roll_usage();
+
+-- Log rolls are an audit trail: no updates; deletes only via cascade under plan deletion
+CREATE OR REPLACE FUNCTION production.guard_log_rolls_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND production.is_plan_delete_context() THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION '布卷领用记录不可修改或删除，请作废对应日志';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_log_rolls_change ON production.log_rolls;
+CREATE TRIGGER trg_guard_log_rolls_change
+BEFORE UPDATE OR DELETE ON production.log_rolls
+FOR EACH ROW
+EXECUTE FUNCTION production.guard_log_rolls_change();
+
+-- Logs: voiding a log returns its length to rolls that are still open
+CREATE OR REPLACE FUNCTION production.restore_log_roll_usage()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.voided = TRUE AND (OLD.voided IS DISTINCT FROM TRUE) THEN
+        UPDATE production.fabric_rolls r
+        SET remaining_length = r.remaining_length + lr.length_used
+        FROM production.log_rolls lr
+        WHERE lr.log_id = NEW.log_id
+          AND lr.roll_id = r.roll_id
+          AND r.status <> 'closed';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_after_log_void_restore_rolls ON production.logs;
+CREATE TRIGGER trg_after_log_void_restore_rolls
+AFTER UPDATE OF voided ON production.logs
+FOR EACH ROW
+EXECUTE FUNCTION production.restore_log_roll_usage();
+
+COMMIT;
\ No newline at end of file
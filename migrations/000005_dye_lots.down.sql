@@ -0,0 +1,64 @@
+-- Revert dye-lot segregation
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_after_log_void_lot_delta ON production.logs;
+DROP TRIGGER IF EXISTS trg_guard_log_lot_mix ON production.logs;
+DROP FUNCTION IF EXISTS production.apply_log_void_lot_delta();
+DROP FUNCTION IF EXISTS production.guard_log_lot_mix();
+
+-- Restore original definitions
+CREATE OR REPLACE FUNCTION production.update_completed_layers()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.layers_completed <= 0 THEN
+        RAISE EXCEPTION '完成层数必须大于0';
+    END IF;
+
+    UPDATE production.tasks
+    SET 
+        completed_layers = completed_layers + NEW.layers_completed,
+        status = CASE
+            WHEN planned_layers IS NOT NULL AND completed_layers + NEW.layers_completed >= planned_layers THEN 'completed'
+            WHEN completed_layers + NEW.layers_completed > 0 THEN 'in_progress'
+            ELSE status
+        END
+    WHERE task_id = NEW.task_id;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION production.guard_logs_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Restrict immutable fields
+    IF (NEW.task_id IS DISTINCT FROM OLD.task_id)
+        OR (NEW.worker_id IS DISTINCT FROM OLD.worker_id)
+        OR (NEW.worker_name IS DISTINCT FROM OLD.worker_name)
+        OR (NEW.layers_completed IS DISTINCT FROM OLD.layers_completed)
+        OR (NEW.log_time IS DISTINCT FROM OLD.log_time)
+        OR (NEW.note IS DISTINCT FROM OLD.note) THEN
+        RAISE EXCEPTION '日志仅允许作废相关字段的变更';
+    END IF;
+
+    -- Disallow unvoid: once voided, cannot revert
+    IF NEW.voided = FALSE AND OLD.voided = TRUE THEN
+        RAISE EXCEPTION '日志作废后不可恢复';
+    END IF;
+
+    -- Only allow void info updates when voided is TRUE
+    IF (NEW.void_reason IS DISTINCT FROM OLD.void_reason OR NEW.voided_by IS DISTINCT FROM OLD.voided_by)
+       AND NEW.voided IS DISTINCT FROM TRUE THEN
+        RAISE EXCEPTION '仅在作废状态下允许更新作废信息';
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS production.task_lot_layers;
+ALTER TABLE production.logs DROP COLUMN IF EXISTS allow_lot_mix;
+ALTER TABLE production.logs DROP COLUMN IF EXISTS dye_lot;
+
+COMMIT;
\ No newline at end of file
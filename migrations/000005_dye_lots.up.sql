@@ -0,0 +1,139 @@
+-- Dye-lot (shade) segregation: logs carry the lot, completed layers are tracked per lot per task
+
+BEGIN;
+
+-- =====================
+-- Columns & Tables
+-- =====================
+ALTER TABLE production.logs ADD COLUMN IF NOT EXISTS dye_lot VARCHAR(50);
+-- Set when the submitter deliberately spreads a second lot into the task
+ALTER TABLE production.logs ADD COLUMN IF NOT EXISTS allow_lot_mix BOOLEAN NOT NULL DEFAULT false;
+
+-- Completed layers per task and dye lot (non-voided logs with a lot only)
+CREATE TABLE IF NOT EXISTS production.task_lot_layers (
+    task_id INT NOT NULL REFERENCES production.tasks(task_id) ON DELETE CASCADE,
+    dye_lot VARCHAR(50) NOT NULL,
+    completed_layers INT NOT NULL DEFAULT 0 CHECK (completed_layers >= 0),
+    PRIMARY KEY (task_id, dye_lot)
+);
+
+-- Backfill from existing logs (idempotent)
+INSERT INTO production.task_lot_layers (task_id, dye_lot, completed_layers)
+SELECT task_id, dye_lot, SUM(layers_completed)
+FROM production.logs
+WHERE dye_lot IS NOT NULL AND voided = FALSE
+GROUP BY task_id, dye_lot
+ON CONFLICT (task_id, dye_lot) DO NOTHING;
+
+-- =====================
+-- Functions & Triggers
+-- =====================
+-- Logs: reject a second dye lot inside a task unless allow_lot_mix is set
+CREATE OR REPLACE FUNCTION production.guard_log_lot_mix()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_lots TEXT;
+BEGIN
+    IF NEW.dye_lot IS NULL OR NEW.allow_lot_mix THEN
+        RETURN NEW;
+    END IF;
+    -- Serialize concurrent submissions on the same task
+    PERFORM 1 FROM production.tasks WHERE task_id = NEW.task_id FOR UPDATE;
+    SELECT string_agg(dye_lot, ', ' ORDER BY dye_lot) INTO v_lots
+    FROM production.task_lot_layers
+    WHERE task_id = NEW.task_id AND dye_lot <> NEW.dye_lot AND completed_layers > 0;
+    IF v_lots IS NOT NULL THEN
+        RAISE EXCEPTION '任务 % 已使用缸号 %，不可混入缸号 %', NEW.task_id, v_lots, NEW.dye_lot;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_log_lot_mix ON production.logs;
+CREATE TRIGGER trg_guard_log_lot_mix
+BEFORE INSERT ON production.logs
+FOR EACH ROW
+EXECUTE FUNCTION production.guard_log_lot_mix();
+
+-- Logs: accumulate task layers and per-lot layers
+CREATE OR REPLACE FUNCTION production.update_completed_layers()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.layers_completed <= 0 THEN
+        RAISE EXCEPTION '完成层数必须大于0';
+    END IF;
+
+    UPDATE production.tasks
+    SET 
+        completed_layers = completed_layers + NEW.layers_completed,
+        status = CASE
+            WHEN planned_layers IS NOT NULL AND completed_layers + NEW.layers_completed >= planned_layers THEN 'completed'
+            WHEN completed_layers + NEW.layers_completed > 0 THEN 'in_progress'
+            ELSE status
+        END
+    WHERE task_id = NEW.task_id;
+
+    IF NEW.dye_lot IS NOT NULL THEN
+        INSERT INTO production.task_lot_layers (task_id, dye_lot, completed_layers)
+        VALUES (NEW.task_id, NEW.dye_lot, NEW.layers_completed)
+        ON CONFLICT (task_id, dye_lot)
+        DO UPDATE SET completed_layers = production.task_lot_layers.completed_layers + EXCLUDED.completed_layers;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Logs: voiding removes the layers from the lot; empty lots are dropped
+CREATE OR REPLACE FUNCTION production.apply_log_void_lot_delta()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.voided = TRUE AND (OLD.voided IS DISTINCT FROM TRUE) AND OLD.dye_lot IS NOT NULL THEN
+        UPDATE production.task_lot_layers
+        SET completed_layers = GREATEST(completed_layers - OLD.layers_completed, 0)
+        WHERE task_id = OLD.task_id AND dye_lot = OLD.dye_lot;
+        DELETE FROM production.task_lot_layers
+        WHERE task_id = OLD.task_id AND dye_lot = OLD.dye_lot AND completed_layers = 0;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_after_log_void_lot_delta ON production.logs;
+CREATE TRIGGER trg_after_log_void_lot_delta
+AFTER UPDATE OF voided ON production.logs
+FOR EACH ROW
+EXECUTE FUNCTION production.apply_log_void_lot_delta();
+
+-- Logs: dye lot and the mix override are immutable like the other log fields
+CREATE OR REPLACE FUNCTION production.guard_logs_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Restrict immutable fields
+    IF (NEW.task_id IS DISTINCT FROM OLD.task_id)
+        OR (NEW.worker_id IS DISTINCT FROM OLD.worker_id)
+        OR (NEW.worker_name IS DISTINCT FROM OLD.worker_name)
+        OR (NEW.layers_completed IS DISTINCT FROM OLD.layers_completed)
+        OR (NEW.log_time IS DISTINCT FROM OLD.log_time)
+        OR (NEW.note IS DISTINCT FROM OLD.note)
+        OR (NEW.dye_lot IS DISTINCT FROM OLD.dye_lot)
+        OR (NEW.allow_lot_mix IS DISTINCT FROM OLD.allow_lot_mix) THEN
+        RAISE EXCEPTION '日志仅允许作废相关字段的变更';
+    END IF;
+
+    -- Disallow unvoid: once voided, cannot revert
+    IF NEW.voided = FALSE AND OLD.voided = TRUE THEN
+        RAISE EXCEPTION '日志作废后不可恢复';
+    END IF;
+
+    -- Only allow void info updates when voided is TRUE
+    IF (NEW.void_reason IS DISTINCT FROM OLD.void_reason OR NEW.voided_by IS DISTINCT FROM OLD.voided_by)
+       AND NEW.voided IS DISTINCT FROM TRUE THEN
+        RAISE EXCEPTION '仅在作废状态下允许更新作废信息';
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
\ No newline at end of file
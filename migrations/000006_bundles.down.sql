@@ -0,0 +1,12 @@
+-- Revert bundle tickets
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_after_task_status_sync_bundles ON production.tasks;
+DROP FUNCTION IF EXISTS production.sync_task_bundles();
+DROP FUNCTION IF EXISTS production.generate_task_bundles(INT, INT);
+
+DROP TABLE IF EXISTS production.bundles;
+ALTER TABLE production.cutting_layouts DROP COLUMN IF EXISTS bundle_size;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,131 @@
+-- Bundle tickets generated from completed tasks
+
+BEGIN;
+
+-- =====================
+-- Columns & Tables
+-- =====================
+-- Plies per bundle; NULL falls back to the default of 10
+ALTER TABLE production.cutting_layouts ADD COLUMN IF NOT EXISTS bundle_size INT
+    CHECK (bundle_size > 0);
+
+-- One bundle = consecutive plies of one size from one marker copy, never spanning dye lots
+CREATE TABLE IF NOT EXISTS production.bundles (
+    bundle_id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES production.tasks(task_id) ON DELETE CASCADE,
+    bundle_no INT NOT NULL CHECK (bundle_no > 0),
+    color VARCHAR(50) NOT NULL,
+    size VARCHAR(20) NOT NULL,
+    copy_no INT NOT NULL CHECK (copy_no > 0),          -- n-th copy of the size in the marker ratio
+    dye_lot VARCHAR(50),
+    ply_from INT NOT NULL CHECK (ply_from > 0),
+    ply_to INT NOT NULL,
+    pieces INT NOT NULL CHECK (pieces > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (task_id, bundle_no),
+    CHECK (ply_to >= ply_from)
+);
+
+CREATE INDEX IF NOT EXISTS bundles_task_idx ON production.bundles (task_id);
+
+-- =====================
+-- Functions & Triggers
+-- =====================
+-- (Re)generate bundles of a task from its non-voided logs and the layout's size ratios.
+-- Consecutive logs of the same dye lot form one ply segment; each segment is split per size,
+-- per ratio copy, into chunks of p_bundle_size plies (layout bundle_size, default 10).
+CREATE OR REPLACE FUNCTION production.generate_task_bundles(p_task_id INT, p_bundle_size INT DEFAULT NULL)
+RETURNS INT AS $$
+DECLARE
+    v_task production.tasks%ROWTYPE;
+    v_size INT;
+    v_no INT := 0;
+    v_seg RECORD;
+    v_ratio RECORD;
+    v_copy INT;
+    v_from INT;
+    v_to INT;
+BEGIN
+    SELECT * INTO v_task FROM production.tasks WHERE task_id = p_task_id;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION '任务不存在: %', p_task_id;
+    END IF;
+    SELECT COALESCE(p_bundle_size, l.bundle_size, 10) INTO v_size
+    FROM production.cutting_layouts l WHERE l.layout_id = v_task.layout_id;
+    IF v_size <= 0 THEN
+        RAISE EXCEPTION '扎包层数必须大于0';
+    END IF;
+
+    DELETE FROM production.bundles WHERE task_id = p_task_id;
+
+    FOR v_seg IN
+        WITH l AS (
+            SELECT dye_lot, layers_completed,
+                   SUM(layers_completed) OVER (ORDER BY log_time, log_id) AS ply_to
+            FROM production.logs
+            WHERE task_id = p_task_id AND voided = FALSE
+        ), g AS (
+            SELECT dye_lot, ply_to, ply_to - layers_completed + 1 AS ply_from,
+                   CASE WHEN dye_lot IS NOT DISTINCT FROM LAG(dye_lot) OVER (ORDER BY ply_to) THEN 0 ELSE 1 END AS brk
+            FROM l
+        ), s AS (
+            SELECT dye_lot, ply_from, ply_to, SUM(brk) OVER (ORDER BY ply_to) AS seg FROM g
+        )
+        SELECT seg, MIN(dye_lot) AS dye_lot, MIN(ply_from) AS ply_from, MAX(ply_to) AS ply_to
+        FROM s GROUP BY seg ORDER BY seg
+    LOOP
+        FOR v_ratio IN
+            SELECT size, ratio FROM production.layout_size_ratios
+            WHERE layout_id = v_task.layout_id ORDER BY ratio_id
+        LOOP
+            FOR v_copy IN 1..v_ratio.ratio LOOP
+                v_from := v_seg.ply_from;
+                WHILE v_from <= v_seg.ply_to LOOP
+                    v_to := LEAST(v_from + v_size - 1, v_seg.ply_to);
+                    v_no := v_no + 1;
+                    INSERT INTO production.bundles (task_id, bundle_no, color, size, copy_no, dye_lot, ply_from, ply_to, pieces)
+                    VALUES (p_task_id, v_no, v_task.color, v_ratio.size, v_copy, v_seg.dye_lot, v_from, v_to, v_to - v_from + 1);
+                    v_from := v_to + 1;
+                END LOOP;
+            END LOOP;
+        END LOOP;
+    END LOOP;
+
+    RETURN v_no;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Tasks: generate bundles when a task completes; drop them when it falls back (log voided)
+CREATE OR REPLACE FUNCTION production.sync_task_bundles()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.status = 'completed' AND OLD.status IS DISTINCT FROM 'completed' THEN
+        PERFORM production.generate_task_bundles(NEW.task_id);
+    ELSIF OLD.status = 'completed' AND NEW.status IS DISTINCT FROM 'completed' THEN
+        DELETE FROM production.bundles WHERE task_id = NEW.task_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_after_task_status_sync_bundles ON production.tasks;
+CREATE TRIGGER trg_after_task_status_sync_bundles
+AFTER UPDATE OF status ON production.tasks
+FOR EACH ROW
+EXECUTE FUNCTION production.sync_task_bundles();
+
+-- Backfill tasks completed before this migration (idempotent)
+DO $$
+DECLARE
+    r RECORD;
+BEGIN
+    FOR r IN
+        SELECT t.task_id FROM production.tasks t
+        WHERE t.status = 'completed'
+          AND NOT EXISTS (SELECT 1 FROM production.bundles b WHERE b.task_id = t.task_id)
+    LOOP
+        PERFORM production.generate_task_bundles(r.task_id);
+    END LOOP;
+END $$;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,50 @@
+-- Revert cutting tables
+
+BEGIN;
+
+-- Restore guard_logs_update without table_id (000005 version)
+CREATE OR REPLACE FUNCTION production.guard_logs_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Restrict immutable fields
+    IF (NEW.task_id IS DISTINCT FROM OLD.task_id)
+        OR (NEW.worker_id IS DISTINCT FROM OLD.worker_id)
+        OR (NEW.worker_name IS DISTINCT FROM OLD.worker_name)
+        OR (NEW.layers_completed IS DISTINCT FROM OLD.layers_completed)
+        OR (NEW.log_time IS DISTINCT FROM OLD.log_time)
+        OR (NEW.note IS DISTINCT FROM OLD.note)
+        OR (NEW.dye_lot IS DISTINCT FROM OLD.dye_lot)
+        OR (NEW.allow_lot_mix IS DISTINCT FROM OLD.allow_lot_mix) THEN
+        RAISE EXCEPTION '日志仅允许作废相关字段的变更';
+    END IF;
+
+    -- Disallow unvoid: once voided, cannot revert
+    IF NEW.voided = FALSE AND OLD.voided = TRUE THEN
+        RAISE EXCEPTION '日志作废后不可恢复';
+    END IF;
+
+    -- Only allow void info updates when voided is TRUE
+    IF (NEW.void_reason IS DISTINCT FROM OLD.void_reason OR NEW.voided_by IS DISTINCT FROM OLD.voided_by)
+       AND NEW.voided IS DISTINCT FROM TRUE THEN
+        RAISE EXCEPTION '仅在作废状态下允许更新作废信息';
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_cutting_table_length ON production.cutting_tables;
+DROP FUNCTION IF EXISTS production.guard_cutting_table_length();
+DROP TRIGGER IF EXISTS trg_guard_layout_marker_tables ON production.cutting_layouts;
+DROP FUNCTION IF EXISTS production.guard_layout_marker_tables();
+DROP TRIGGER IF EXISTS trg_before_log_insert_set_table ON production.logs;
+DROP FUNCTION IF EXISTS production.set_log_table();
+DROP TRIGGER IF EXISTS trg_guard_task_table_assignment ON production.tasks;
+DROP FUNCTION IF EXISTS production.guard_task_table_assignment();
+DROP FUNCTION IF EXISTS production.check_table_fits_layout(INT, INT);
+
+ALTER TABLE production.logs DROP COLUMN IF EXISTS table_id;
+ALTER TABLE production.tasks DROP COLUMN IF EXISTS table_id;
+DROP TABLE IF EXISTS production.cutting_tables;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,187 @@
+-- Cutting tables: where tasks are spread; tasks are assigned to a table and logs record the table used
+
+BEGIN;
+
+-- =====================
+-- Tables & Columns
+-- =====================
+-- Cutting tables (裁床); usable_length in meters
+CREATE TABLE IF NOT EXISTS production.cutting_tables (
+    table_id SERIAL PRIMARY KEY,
+    table_name VARCHAR(50) NOT NULL UNIQUE,
+    usable_length NUMERIC(10,3) NOT NULL CHECK (usable_length > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active','maintenance')),
+    note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Current table assignment of a task; tables with assigned tasks or logs cannot be deleted
+ALTER TABLE production.tasks ADD COLUMN IF NOT EXISTS table_id INT REFERENCES production.cutting_tables(table_id) ON DELETE RESTRICT;
+-- Table the log was spread on (defaults to the task's table)
+ALTER TABLE production.logs ADD COLUMN IF NOT EXISTS table_id INT REFERENCES production.cutting_tables(table_id) ON DELETE RESTRICT;
+
+-- =====================
+-- Indexes
+-- =====================
+CREATE INDEX IF NOT EXISTS tasks_table_idx ON production.tasks (table_id);
+CREATE INDEX IF NOT EXISTS logs_table_idx ON production.logs (table_id);
+
+-- =====================
+-- Functions & Triggers
+-- =====================
+-- Raises unless the table is active and at least as long as the layout's marker
+CREATE OR REPLACE FUNCTION production.check_table_fits_layout(p_table_id INT, p_layout_id INT)
+RETURNS VOID AS $$
+DECLARE
+    v_table production.cutting_tables%ROWTYPE;
+    v_marker NUMERIC;
+BEGIN
+    SELECT * INTO v_table FROM production.cutting_tables WHERE table_id = p_table_id;
+    IF v_table.table_id IS NULL THEN
+        RAISE EXCEPTION '裁床不存在 (table=%)', p_table_id;
+    END IF;
+    IF v_table.status <> 'active' THEN
+        RAISE EXCEPTION '裁床 % 维护中，不可使用', v_table.table_name;
+    END IF;
+    SELECT marker_length INTO v_marker FROM production.cutting_layouts WHERE layout_id = p_layout_id;
+    IF v_marker IS NULL THEN
+        RAISE EXCEPTION '布局未设置唛架长度，无法校验裁床长度 (layout=%)', p_layout_id;
+    END IF;
+    IF v_marker > v_table.usable_length THEN
+        RAISE EXCEPTION '唛架长度 % 米超出裁床 % 可用长度 % 米', v_marker, v_table.table_name, v_table.usable_length;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Tasks: check the table when it is assigned or changed
+CREATE OR REPLACE FUNCTION production.guard_task_table_assignment()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.table_id IS NULL THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP = 'UPDATE' AND NEW.table_id IS NOT DISTINCT FROM OLD.table_id THEN
+        RETURN NEW;
+    END IF;
+    IF NEW.status = 'completed' THEN
+        RAISE EXCEPTION '任务已完成，不可分配裁床 (task=%)', NEW.task_id;
+    END IF;
+    PERFORM production.check_table_fits_layout(NEW.table_id, NEW.layout_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_task_table_assignment ON production.tasks;
+CREATE TRIGGER trg_guard_task_table_assignment
+BEFORE INSERT OR UPDATE OF table_id ON production.tasks
+FOR EACH ROW
+EXECUTE FUNCTION production.guard_task_table_assignment();
+
+-- Logs: default to the task's table; the table must be usable for the layout
+CREATE OR REPLACE FUNCTION production.set_log_table()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_task_table INT;
+    v_layout_id INT;
+BEGIN
+    SELECT table_id, layout_id INTO v_task_table, v_layout_id FROM production.tasks WHERE task_id = NEW.task_id;
+    NEW.table_id := COALESCE(NEW.table_id, v_task_table);
+    IF NEW.table_id IS NOT NULL THEN
+        PERFORM production.check_table_fits_layout(NEW.table_id, v_layout_id);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_before_log_insert_set_table ON production.logs;
+CREATE TRIGGER trg_before_log_insert_set_table
+BEFORE INSERT ON production.logs
+FOR EACH ROW
+EXECUTE FUNCTION production.set_log_table();
+
+-- Layouts: marker length cannot grow beyond a table that open tasks are assigned to
+CREATE OR REPLACE FUNCTION production.guard_layout_marker_tables()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_table_name VARCHAR(50);
+BEGIN
+    IF NEW.marker_length IS NULL OR NEW.marker_length IS NOT DISTINCT FROM OLD.marker_length THEN
+        RETURN NEW;
+    END IF;
+    SELECT ct.table_name INTO v_table_name
+    FROM production.tasks t
+    JOIN production.cutting_tables ct ON ct.table_id = t.table_id
+    WHERE t.layout_id = NEW.layout_id AND t.status <> 'completed' AND ct.usable_length < NEW.marker_length
+    LIMIT 1;
+    IF v_table_name IS NOT NULL THEN
+        RAISE EXCEPTION '唛架长度 % 米超出已分配裁床 % 的可用长度', NEW.marker_length, v_table_name;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_layout_marker_tables ON production.cutting_layouts;
+CREATE TRIGGER trg_guard_layout_marker_tables
+BEFORE UPDATE OF marker_length ON production.cutting_layouts
+FOR EACH ROW
+EXECUTE FUNCTION production.guard_layout_marker_tables();
+
+-- Cutting tables: usable length cannot shrink below the marker of an open task assigned to it
+CREATE OR REPLACE FUNCTION production.guard_cutting_table_length()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_marker NUMERIC;
+BEGIN
+    IF NEW.usable_length >= OLD.usable_length THEN
+        RETURN NEW;
+    END IF;
+    SELECT MAX(l.marker_length) INTO v_marker
+    FROM production.tasks t
+    JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+    WHERE t.table_id = NEW.table_id AND t.status <> 'completed';
+    IF v_marker > NEW.usable_length THEN
+        RAISE EXCEPTION '裁床 % 已分配唛架长度 % 米的任务，可用长度不可小于该值', NEW.table_name, v_marker;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_cutting_table_length ON production.cutting_tables;
+CREATE TRIGGER trg_guard_cutting_table_length
+BEFORE UPDATE OF usable_length ON production.cutting_tables
+FOR EACH ROW
+EXECUTE FUNCTION production.guard_cutting_table_length();
+
+-- Logs: the table is part of the audit trail and immutable like the other fields
+CREATE OR REPLACE FUNCTION production.guard_logs_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Restrict immutable fields
+    IF (NEW.task_id IS DISTINCT FROM OLD.task_id)
+        OR (NEW.worker_id IS DISTINCT FROM OLD.worker_id)
+        OR (NEW.worker_name IS DISTINCT FROM OLD.worker_name)
+        OR (NEW.layers_completed IS DISTINCT FROM OLD.layers_completed)
+        OR (NEW.log_time IS DISTINCT FROM OLD.log_time)
+        OR (NEW.note IS DISTINCT FROM OLD.note)
+        OR (NEW.dye_lot IS DISTINCT FROM OLD.dye_lot)
+        OR (NEW.allow_lot_mix IS DISTINCT FROM OLD.allow_lot_mix)
+        OR (NEW.table_id IS DISTINCT FROM OLD.table_id) THEN
+        RAISE EXCEPTION '日志仅允许作废相关字段的变更';
+    END IF;
+
+    -- Disallow unvoid: once voided, cannot revert
+    IF NEW.voided = FALSE AND OLD.voided = TRUE THEN
+        RAISE EXCEPTION '日志作废后不可恢复';
+    END IF;
+
+    -- Only allow void info updates when voided is TRUE
+    IF (NEW.void_reason IS DISTINCT FROM OLD.void_reason OR NEW.voided_by IS DISTINCT FROM OLD.voided_by)
+       AND NEW.voided IS DISTINCT FROM TRUE THEN
+        RAISE EXCEPTION '仅在作废状态下允许更新作废信息';
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
\ No newline at end of file
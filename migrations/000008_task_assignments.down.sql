@@ -0,0 +1,11 @@
+-- Revert task assignments
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_guard_task_assignments_insert ON production.task_assignments;
+DROP TRIGGER IF EXISTS trg_guard_task_assignments_update ON production.task_assignments;
+DROP FUNCTION IF EXISTS production.guard_task_assignments_insert();
+DROP FUNCTION IF EXISTS production.guard_task_assignments_update();
+DROP TABLE IF EXISTS production.task_assignments;
+
+COMMIT;
\ No newline at end of file
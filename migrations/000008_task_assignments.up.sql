@@ -0,0 +1,81 @@
+-- Task assignments: tasks assigned to individual workers or to a user group, with history
+
+BEGIN;
+
+-- =====================
+-- Tables & Columns
+-- =====================
+-- One row per assignment; unassigning sets unassigned_at instead of deleting, so history is kept
+CREATE TABLE IF NOT EXISTS production.task_assignments (
+    assignment_id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES production.tasks(task_id) ON DELETE CASCADE,
+    user_id INT REFERENCES public.users(user_id) ON DELETE CASCADE,
+    user_group VARCHAR(50),
+    assigned_by INT REFERENCES public.users(user_id) ON DELETE SET NULL,
+    assigned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    unassigned_by INT REFERENCES public.users(user_id) ON DELETE SET NULL,
+    unassigned_at TIMESTAMP,
+    CHECK ((user_id IS NULL) <> (user_group IS NULL)),
+    CHECK (unassigned_at IS NULL OR unassigned_at >= assigned_at)
+);
+
+-- =====================
+-- Indexes
+-- =====================
+CREATE INDEX IF NOT EXISTS task_assignments_task_idx ON production.task_assignments (task_id);
+-- At most one active assignment per task and user / group
+CREATE UNIQUE INDEX IF NOT EXISTS task_assignments_active_user_uniq
+    ON production.task_assignments (task_id, user_id) WHERE unassigned_at IS NULL AND user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS task_assignments_active_group_uniq
+    ON production.task_assignments (task_id, user_group) WHERE unassigned_at IS NULL AND user_group IS NOT NULL;
+CREATE INDEX IF NOT EXISTS task_assignments_active_user_idx
+    ON production.task_assignments (user_id) WHERE unassigned_at IS NULL;
+CREATE INDEX IF NOT EXISTS task_assignments_active_group_idx
+    ON production.task_assignments (user_group) WHERE unassigned_at IS NULL;
+
+-- =====================
+-- Functions & Triggers
+-- =====================
+-- History rows are append-only: only an active assignment may be closed, nothing else changes
+CREATE OR REPLACE FUNCTION production.guard_task_assignments_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.unassigned_at IS NOT NULL THEN
+        RAISE EXCEPTION '分配记录已撤销，不可修改 (assignment_id=%)', OLD.assignment_id;
+    END IF;
+    IF (NEW.task_id IS DISTINCT FROM OLD.task_id)
+        OR (NEW.user_id IS DISTINCT FROM OLD.user_id)
+        OR (NEW.user_group IS DISTINCT FROM OLD.user_group)
+        OR (NEW.assigned_by IS DISTINCT FROM OLD.assigned_by)
+        OR (NEW.assigned_at IS DISTINCT FROM OLD.assigned_at) THEN
+        RAISE EXCEPTION '分配记录仅允许撤销';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_task_assignments_update ON production.task_assignments;
+CREATE TRIGGER trg_guard_task_assignments_update
+BEFORE UPDATE ON production.task_assignments
+FOR EACH ROW EXECUTE FUNCTION production.guard_task_assignments_update();
+
+-- Completed tasks accept no new assignments
+CREATE OR REPLACE FUNCTION production.guard_task_assignments_insert()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_status VARCHAR(20);
+BEGIN
+    SELECT status INTO v_status FROM production.tasks WHERE task_id = NEW.task_id;
+    IF v_status = 'completed' THEN
+        RAISE EXCEPTION '已完成的任务不可分配人员 (task_id=%)', NEW.task_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_task_assignments_insert ON production.task_assignments;
+CREATE TRIGGER trg_guard_task_assignments_insert
+BEFORE INSERT ON production.task_assignments
+FOR EACH ROW EXECUTE FUNCTION production.guard_task_assignments_insert();
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,49 @@
+-- Revert shifts
+
+BEGIN;
+
+-- Restore guard_logs_update without shift fields (000007 version)
+CREATE OR REPLACE FUNCTION production.guard_logs_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Restrict immutable fields
+    IF (NEW.task_id IS DISTINCT FROM OLD.task_id)
+        OR (NEW.worker_id IS DISTINCT FROM OLD.worker_id)
+        OR (NEW.worker_name IS DISTINCT FROM OLD.worker_name)
+        OR (NEW.layers_completed IS DISTINCT FROM OLD.layers_completed)
+        OR (NEW.log_time IS DISTINCT FROM OLD.log_time)
+        OR (NEW.note IS DISTINCT FROM OLD.note)
+        OR (NEW.dye_lot IS DISTINCT FROM OLD.dye_lot)
+        OR (NEW.allow_lot_mix IS DISTINCT FROM OLD.allow_lot_mix)
+        OR (NEW.table_id IS DISTINCT FROM OLD.table_id) THEN
+        RAISE EXCEPTION '日志仅允许作废相关字段的变更';
+    END IF;
+
+    -- Disallow unvoid: once voided, cannot revert
+    IF NEW.voided = FALSE AND OLD.voided = TRUE THEN
+        RAISE EXCEPTION '日志作废后不可恢复';
+    END IF;
+
+    -- Only allow void info updates when voided is TRUE
+    IF (NEW.void_reason IS DISTINCT FROM OLD.void_reason OR NEW.voided_by IS DISTINCT FROM OLD.voided_by)
+       AND NEW.voided IS DISTINCT FROM TRUE THEN
+        RAISE EXCEPTION '仅在作废状态下允许更新作废信息';
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_set_log_shift ON production.logs;
+DROP FUNCTION IF EXISTS production.set_log_shift();
+DROP FUNCTION IF EXISTS production.resolve_shift(TIMESTAMP);
+DROP FUNCTION IF EXISTS production.factory_local_time(TIMESTAMP);
+DROP INDEX IF EXISTS production.logs_shift_date_idx;
+ALTER TABLE production.logs DROP COLUMN IF EXISTS shift_date;
+ALTER TABLE production.logs DROP COLUMN IF EXISTS shift_id;
+DROP TABLE IF EXISTS production.shifts;
+DROP TRIGGER IF EXISTS trg_guard_factory_timezone ON production.factory_settings;
+DROP FUNCTION IF EXISTS production.guard_factory_timezone();
+DROP TABLE IF EXISTS production.factory_settings;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,151 @@
+-- Shifts: named shift windows in the factory timezone; every log is attributed to a shift and a shift date
+
+BEGIN;
+
+-- =====================
+-- Tables & Columns
+-- =====================
+-- Factory-wide settings (single row); timezone is an IANA name used to read log times as factory local time
+CREATE TABLE IF NOT EXISTS production.factory_settings (
+    singleton BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (singleton),
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO production.factory_settings (singleton) VALUES (TRUE) ON CONFLICT (singleton) DO NOTHING;
+
+-- Shift windows in factory local time; end_time <= start_time means the shift crosses midnight
+CREATE TABLE IF NOT EXISTS production.shifts (
+    shift_id SERIAL PRIMARY KEY,
+    shift_name VARCHAR(50) NOT NULL UNIQUE,
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (start_time <> end_time)
+);
+
+-- Shift of the log and the production date the shift started on (a night shift keeps its start date after midnight)
+ALTER TABLE production.logs ADD COLUMN IF NOT EXISTS shift_id INT REFERENCES production.shifts(shift_id) ON DELETE RESTRICT;
+ALTER TABLE production.logs ADD COLUMN IF NOT EXISTS shift_date DATE;
+
+-- =====================
+-- Indexes
+-- =====================
+CREATE INDEX IF NOT EXISTS logs_shift_date_idx ON production.logs (shift_date, shift_id);
+
+-- =====================
+-- Functions & Triggers
+-- =====================
+-- Rejects unknown timezone names
+CREATE OR REPLACE FUNCTION production.guard_factory_timezone()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_timezone_names WHERE name = NEW.timezone) THEN
+        RAISE EXCEPTION '无效的时区 (timezone=%)', NEW.timezone;
+    END IF;
+    NEW.updated_at := CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_factory_timezone ON production.factory_settings;
+CREATE TRIGGER trg_guard_factory_timezone
+BEFORE INSERT OR UPDATE ON production.factory_settings
+FOR EACH ROW EXECUTE FUNCTION production.guard_factory_timezone();
+
+-- Converts a log time (session-timezone wall clock) to factory local time
+CREATE OR REPLACE FUNCTION production.factory_local_time(p_ts TIMESTAMP)
+RETURNS TIMESTAMP AS $$
+    SELECT (p_ts AT TIME ZONE current_setting('TimeZone')) AT TIME ZONE
+        COALESCE((SELECT timezone FROM production.factory_settings WHERE singleton), 'UTC');
+$$ LANGUAGE sql STABLE;
+
+-- Resolves the active shift covering a factory local time and the shift date (start day of the shift).
+-- Times outside every active shift yield shift_id NULL and the calendar date.
+CREATE OR REPLACE FUNCTION production.resolve_shift(p_local TIMESTAMP, OUT shift_id INT, OUT shift_date DATE)
+AS $$
+DECLARE
+    v_time TIME := p_local::time;
+    v_shift production.shifts%ROWTYPE;
+BEGIN
+    shift_date := p_local::date;
+    FOR v_shift IN SELECT * FROM production.shifts s WHERE s.is_active ORDER BY s.start_time, s.shift_id LOOP
+        IF v_shift.start_time < v_shift.end_time THEN
+            IF v_time >= v_shift.start_time AND v_time < v_shift.end_time THEN
+                shift_id := v_shift.shift_id;
+                RETURN;
+            END IF;
+        ELSIF v_time >= v_shift.start_time THEN
+            shift_id := v_shift.shift_id;
+            RETURN;
+        ELSIF v_time < v_shift.end_time THEN
+            -- After midnight of a crossing shift: belongs to the previous day's shift
+            shift_id := v_shift.shift_id;
+            shift_date := p_local::date - 1;
+            RETURN;
+        END IF;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- Attributes each new log to its shift; the attribution is kept even if shifts are redefined later
+CREATE OR REPLACE FUNCTION production.set_log_shift()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_shift RECORD;
+BEGIN
+    SELECT * INTO v_shift FROM production.resolve_shift(production.factory_local_time(NEW.log_time));
+    NEW.shift_id := v_shift.shift_id;
+    NEW.shift_date := v_shift.shift_date;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_set_log_shift ON production.logs;
+CREATE TRIGGER trg_set_log_shift
+BEFORE INSERT ON production.logs
+FOR EACH ROW EXECUTE FUNCTION production.set_log_shift();
+
+-- Backfill logs written before shifts existed (calendar date only until shifts are defined)
+UPDATE production.logs l
+SET shift_id = r.shift_id, shift_date = r.shift_date
+FROM production.logs x
+CROSS JOIN LATERAL production.resolve_shift(production.factory_local_time(x.log_time)) r
+WHERE l.log_id = x.log_id AND l.shift_date IS NULL;
+
+-- Shift attribution is immutable once set (backfill above runs first)
+CREATE OR REPLACE FUNCTION production.guard_logs_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Restrict immutable fields
+    IF (NEW.task_id IS DISTINCT FROM OLD.task_id)
+        OR (NEW.worker_id IS DISTINCT FROM OLD.worker_id)
+        OR (NEW.worker_name IS DISTINCT FROM OLD.worker_name)
+        OR (NEW.layers_completed IS DISTINCT FROM OLD.layers_completed)
+        OR (NEW.log_time IS DISTINCT FROM OLD.log_time)
+        OR (NEW.note IS DISTINCT FROM OLD.note)
+        OR (NEW.dye_lot IS DISTINCT FROM OLD.dye_lot)
+        OR (NEW.allow_lot_mix IS DISTINCT FROM OLD.allow_lot_mix)
+        OR (NEW.table_id IS DISTINCT FROM OLD.table_id)
+        OR (NEW.shift_id IS DISTINCT FROM OLD.shift_id)
+        OR (NEW.shift_date IS DISTINCT FROM OLD.shift_date) THEN
+        RAISE EXCEPTION '日志仅允许作废相关字段的变更';
+    END IF;
+
+    -- Disallow unvoid: once voided, cannot revert
+    IF NEW.voided = FALSE AND OLD.voided = TRUE THEN
+        RAISE EXCEPTION '日志作废后不可恢复';
+    END IF;
+
+    -- Only allow void info updates when voided is TRUE
+    IF (NEW.void_reason IS DISTINCT FROM OLD.void_reason OR NEW.voided_by IS DISTINCT FROM OLD.voided_by)
+       AND NEW.voided IS DISTINCT FROM TRUE THEN
+        RAISE EXCEPTION '仅在作废状态下允许更新作废信息';
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- Revert payroll
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_guard_payroll_lines_change ON production.payroll_lines;
+DROP FUNCTION IF EXISTS production.guard_payroll_lines_change();
+DROP TRIGGER IF EXISTS trg_guard_pay_period_change ON production.pay_periods;
+DROP FUNCTION IF EXISTS production.guard_pay_period_change();
+DROP TABLE IF EXISTS production.payroll_lines;
+DROP TABLE IF EXISTS production.pay_periods;
+DROP TABLE IF EXISTS production.piece_rates;
+
+COMMIT;
\ No newline at end of file
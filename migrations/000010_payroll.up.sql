@@ -0,0 +1,108 @@
+-- Payroll: piece rates per layer and locked pay periods with line-item statements
+
+BEGIN;
+
+-- =====================
+-- Tables
+-- =====================
+-- Piece rate per layer; scope is a layout, a style, or the default (neither set). Most specific scope wins.
+CREATE TABLE IF NOT EXISTS production.piece_rates (
+    rate_id SERIAL PRIMARY KEY,
+    layout_id INT REFERENCES production.cutting_layouts(layout_id) ON DELETE CASCADE,
+    style_number VARCHAR(50),
+    rate_per_layer NUMERIC(10,4) NOT NULL CHECK (rate_per_layer > 0),
+    note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (layout_id IS NULL OR style_number IS NULL)
+);
+
+-- Pay periods cover shift dates [period_start, period_end]; locking snapshots the statement into payroll_lines
+CREATE TABLE IF NOT EXISTS production.pay_periods (
+    period_id SERIAL PRIMARY KEY,
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'locked')),
+    locked_at TIMESTAMP,
+    locked_by INT REFERENCES public.users(user_id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (period_end >= period_start)
+);
+
+-- Locked statement lines. kind 'log' pays a log once; 'void_adjustment' reverses a paid log voided after its period was locked.
+-- Log, task and layout details are copied so statements survive later deletes.
+CREATE TABLE IF NOT EXISTS production.payroll_lines (
+    line_id SERIAL PRIMARY KEY,
+    period_id INT NOT NULL REFERENCES production.pay_periods(period_id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('log', 'void_adjustment')),
+    log_id INT REFERENCES production.logs(log_id) ON DELETE SET NULL,
+    worker_id INT REFERENCES public.users(user_id) ON DELETE SET NULL,
+    worker_name VARCHAR(100),
+    task_id INT,
+    layout_id INT,
+    layout_name VARCHAR(100),
+    style_number VARCHAR(50),
+    color VARCHAR(50),
+    shift_date DATE,
+    log_time TIMESTAMP,
+    layers INT NOT NULL,
+    rate_id INT,
+    rate_per_layer NUMERIC(10,4) NOT NULL,
+    rate_scope VARCHAR(20) NOT NULL,
+    amount NUMERIC(12,2) NOT NULL,
+    UNIQUE (log_id, kind)
+);
+
+-- =====================
+-- Indexes
+-- =====================
+CREATE UNIQUE INDEX IF NOT EXISTS piece_rates_layout_uniq ON production.piece_rates (layout_id) WHERE layout_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS piece_rates_style_uniq ON production.piece_rates (style_number) WHERE style_number IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS piece_rates_default_uniq ON production.piece_rates ((TRUE)) WHERE layout_id IS NULL AND style_number IS NULL;
+CREATE INDEX IF NOT EXISTS payroll_lines_period_idx ON production.payroll_lines (period_id);
+
+-- =====================
+-- Functions & Triggers
+-- =====================
+-- Locked periods are final: only open periods may change or be deleted, and a lock cannot be undone
+CREATE OR REPLACE FUNCTION production.guard_pay_period_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.status = 'locked' THEN
+        RAISE EXCEPTION '工资周期已锁定，不可修改或删除 (period_id=%)', OLD.period_id;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_pay_period_change ON production.pay_periods;
+CREATE TRIGGER trg_guard_pay_period_change
+BEFORE UPDATE OR DELETE ON production.pay_periods
+FOR EACH ROW EXECUTE FUNCTION production.guard_pay_period_change();
+
+-- Lines are append-only; only the references cleared by ON DELETE SET NULL may change
+CREATE OR REPLACE FUNCTION production.guard_payroll_lines_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        RAISE EXCEPTION '工资明细不可删除';
+    END IF;
+    IF (NEW.log_id IS NOT NULL AND NEW.log_id IS DISTINCT FROM OLD.log_id)
+        OR (NEW.worker_id IS NOT NULL AND NEW.worker_id IS DISTINCT FROM OLD.worker_id)
+        OR ROW(NEW.period_id, NEW.kind, NEW.worker_name, NEW.layers, NEW.rate_per_layer, NEW.amount)
+           IS DISTINCT FROM ROW(OLD.period_id, OLD.kind, OLD.worker_name, OLD.layers, OLD.rate_per_layer, OLD.amount) THEN
+        RAISE EXCEPTION '工资明细不可修改';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_payroll_lines_change ON production.payroll_lines;
+CREATE TRIGGER trg_guard_payroll_lines_change
+BEFORE UPDATE OR DELETE ON production.payroll_lines
+FOR EACH ROW EXECUTE FUNCTION production.guard_payroll_lines_change();
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,57 @@
+-- Revert plan amendments
+
+BEGIN;
+
+-- Restore layout/ratio guards without the adjustment context (000001 version)
+CREATE OR REPLACE FUNCTION production.guard_layouts_by_plan_status()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_status VARCHAR(20);
+    v_plan_id INT;
+BEGIN
+    IF production.is_plan_delete_context() THEN
+        RETURN COALESCE(NEW, OLD);
+    END IF;
+
+    v_plan_id := COALESCE(NEW.plan_id, OLD.plan_id);
+    SELECT status INTO v_status FROM production.plans WHERE plan_id = v_plan_id;
+
+    IF v_status IN ('in_progress','completed','frozen') THEN
+        RAISE EXCEPTION '计划发布后布局不可增删改 (plan=%)', v_plan_id;
+    END IF;
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION production.guard_ratios_by_plan_status()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_status VARCHAR(20);
+    v_layout_id INT;
+    v_plan_id INT;
+BEGIN
+    IF production.is_plan_delete_context() THEN
+        RETURN COALESCE(NEW, OLD);
+    END IF;
+
+    v_layout_id := COALESCE(NEW.layout_id, OLD.layout_id);
+    SELECT l.plan_id, p.status INTO v_plan_id, v_status
+    FROM production.cutting_layouts l
+    JOIN production.plans p ON p.plan_id = l.plan_id
+    WHERE l.layout_id = v_layout_id;
+
+    IF v_status IN ('in_progress','completed','frozen') THEN
+        RAISE EXCEPTION '计划发布后比例不可增删改 (plan=%)', v_plan_id;
+    END IF;
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_plan_amendment_change ON production.plan_amendments;
+DROP FUNCTION IF EXISTS production.guard_plan_amendment_change();
+DROP TABLE IF EXISTS production.plan_amendment_changes;
+DROP TABLE IF EXISTS production.plan_amendments;
+
+COMMIT;
\ No newline at end of file
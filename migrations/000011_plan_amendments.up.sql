@@ -0,0 +1,111 @@
+-- Plan amendments: proposed changes to published plans, applied on approval with before/after audit
+
+BEGIN;
+
+-- =====================
+-- Tables
+-- =====================
+-- A change request against an in_progress plan; pending until a manager approves (applies) or rejects it
+CREATE TABLE IF NOT EXISTS production.plan_amendments (
+    amendment_id SERIAL PRIMARY KEY,
+    plan_id INT NOT NULL REFERENCES production.plans(plan_id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    reason TEXT,
+    proposed_by INT REFERENCES public.users(user_id) ON DELETE SET NULL,
+    proposed_by_name VARCHAR(100),
+    proposed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    decided_by INT REFERENCES public.users(user_id) ON DELETE SET NULL,
+    decided_by_name VARCHAR(100),
+    decided_at TIMESTAMP,
+    decision_note TEXT
+);
+
+-- Individual edits in order. payload holds the proposed values; before/after are written when the change is applied.
+-- layout_id / task_id reference the target (update_task, add_task) or the row created on approval (add_layout, add_task).
+CREATE TABLE IF NOT EXISTS production.plan_amendment_changes (
+    change_id SERIAL PRIMARY KEY,
+    amendment_id INT NOT NULL REFERENCES production.plan_amendments(amendment_id) ON DELETE CASCADE,
+    seq INT NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('add_layout', 'add_task', 'update_task')),
+    layout_id INT REFERENCES production.cutting_layouts(layout_id) ON DELETE SET NULL,
+    task_id INT REFERENCES production.tasks(task_id) ON DELETE SET NULL,
+    payload JSONB NOT NULL,
+    before_values JSONB,
+    after_values JSONB,
+    UNIQUE (amendment_id, seq)
+);
+
+-- =====================
+-- Indexes
+-- =====================
+CREATE INDEX IF NOT EXISTS plan_amendments_plan_idx ON production.plan_amendments (plan_id);
+
+-- =====================
+-- Functions & Triggers
+-- =====================
+-- Decided amendments are final; only the user references cleared by ON DELETE SET NULL may change
+CREATE OR REPLACE FUNCTION production.guard_plan_amendment_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.status <> 'pending'
+        AND ROW(NEW.plan_id, NEW.status, NEW.reason, NEW.decided_at, NEW.decision_note, NEW.decided_by_name)
+            IS DISTINCT FROM ROW(OLD.plan_id, OLD.status, OLD.reason, OLD.decided_at, OLD.decision_note, OLD.decided_by_name) THEN
+        RAISE EXCEPTION '变更申请已处理，不可修改 (amendment_id=%)', OLD.amendment_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_plan_amendment_change ON production.plan_amendments;
+CREATE TRIGGER trg_guard_plan_amendment_change
+BEFORE UPDATE ON production.plan_amendments
+FOR EACH ROW EXECUTE FUNCTION production.guard_plan_amendment_change();
+
+-- Layouts and ratios of published plans may change inside the plan adjustment context (approved amendments)
+CREATE OR REPLACE FUNCTION production.guard_layouts_by_plan_status()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_status VARCHAR(20);
+    v_plan_id INT;
+BEGIN
+    IF production.is_plan_delete_context() OR production.is_plan_adjustment_context() THEN
+        RETURN COALESCE(NEW, OLD);
+    END IF;
+
+    v_plan_id := COALESCE(NEW.plan_id, OLD.plan_id);
+    SELECT status INTO v_status FROM production.plans WHERE plan_id = v_plan_id;
+
+    IF v_status IN ('in_progress','completed','frozen') THEN
+        RAISE EXCEPTION '计划发布后布局不可增删改 (plan=%)', v_plan_id;
+    END IF;
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION production.guard_ratios_by_plan_status()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_status VARCHAR(20);
+    v_layout_id INT;
+    v_plan_id INT;
+BEGIN
+    IF production.is_plan_delete_context() OR production.is_plan_adjustment_context() THEN
+        RETURN COALESCE(NEW, OLD);
+    END IF;
+
+    v_layout_id := COALESCE(NEW.layout_id, OLD.layout_id);
+    SELECT l.plan_id, p.status INTO v_plan_id, v_status
+    FROM production.cutting_layouts l
+    JOIN production.plans p ON p.plan_id = l.plan_id
+    WHERE l.layout_id = v_layout_id;
+
+    IF v_status IN ('in_progress','completed','frozen') THEN
+        RAISE EXCEPTION '计划发布后比例不可增删改 (plan=%)', v_plan_id;
+    END IF;
+
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- Revert plan reopen history
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_guard_plan_reopen_history_update ON production.plan_reopen_history;
+DROP FUNCTION IF EXISTS production.guard_plan_reopen_history_update();
+DROP TABLE IF EXISTS production.plan_reopen_history;
+
+COMMIT;
\ No newline at end of file
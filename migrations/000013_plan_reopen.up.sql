@@ -0,0 +1,46 @@
+-- Plan reopen: completed/frozen plans can be moved back to in_progress by a manager, with an audit history
+
+BEGIN;
+
+-- =====================
+-- Tables
+-- =====================
+-- One row per reopen; previous_finish_date keeps the finish time that was cleared on the plan
+CREATE TABLE IF NOT EXISTS production.plan_reopen_history (
+    reopen_id SERIAL PRIMARY KEY,
+    plan_id INT NOT NULL REFERENCES production.plans(plan_id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL CHECK (from_status IN ('completed', 'frozen')),
+    reason TEXT NOT NULL CHECK (btrim(reason) <> ''),
+    previous_finish_date TIMESTAMP,
+    reopened_by INT REFERENCES public.users(user_id) ON DELETE SET NULL,
+    reopened_by_name VARCHAR(100),
+    reopened_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- =====================
+-- Indexes
+-- =====================
+CREATE INDEX IF NOT EXISTS plan_reopen_history_plan_idx ON production.plan_reopen_history (plan_id);
+
+-- =====================
+-- Functions & Triggers
+-- =====================
+-- History is append-only; only the user reference cleared by ON DELETE SET NULL may change
+CREATE OR REPLACE FUNCTION production.guard_plan_reopen_history_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF (NEW.reopened_by IS NOT NULL AND NEW.reopened_by IS DISTINCT FROM OLD.reopened_by)
+        OR ROW(NEW.plan_id, NEW.from_status, NEW.reason, NEW.previous_finish_date, NEW.reopened_by_name, NEW.reopened_at)
+           IS DISTINCT FROM ROW(OLD.plan_id, OLD.from_status, OLD.reason, OLD.previous_finish_date, OLD.reopened_by_name, OLD.reopened_at) THEN
+        RAISE EXCEPTION '计划重新打开记录不可修改';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_plan_reopen_history_update ON production.plan_reopen_history;
+CREATE TRIGGER trg_guard_plan_reopen_history_update
+BEFORE UPDATE ON production.plan_reopen_history
+FOR EACH ROW EXECUTE FUNCTION production.guard_plan_reopen_history_update();
+
+COMMIT;
\ No newline at end of file
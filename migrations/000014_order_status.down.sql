@@ -0,0 +1,15 @@
+-- Revert order status lifecycle
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_sync_order_status ON production.plans;
+DROP FUNCTION IF EXISTS production.sync_order_status();
+DROP TRIGGER IF EXISTS trg_guard_plan_order_status ON production.plans;
+DROP FUNCTION IF EXISTS production.guard_plan_order_status();
+DROP TRIGGER IF EXISTS trg_guard_order_status ON production.orders;
+DROP FUNCTION IF EXISTS production.guard_order_status();
+DROP INDEX IF EXISTS production.orders_status_idx;
+ALTER TABLE production.orders DROP COLUMN IF EXISTS status_changed_at;
+ALTER TABLE production.orders DROP COLUMN IF EXISTS status;
+
+COMMIT;
\ No newline at end of file
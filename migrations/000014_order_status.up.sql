@@ -0,0 +1,145 @@
+-- Order status lifecycle: draft → confirmed → in_production → cut_complete → closed, plus cancelled.
+-- in_production / cut_complete follow plan publish and completion; the other transitions are manual.
+
+BEGIN;
+
+-- =====================
+-- Columns
+-- =====================
+-- Existing orders are backfilled once from their plans; orders without published plans count as confirmed
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = 'production' AND table_name = 'orders' AND column_name = 'status'
+    ) THEN
+        ALTER TABLE production.orders ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'draft'
+            CHECK (status IN ('draft', 'confirmed', 'in_production', 'cut_complete', 'closed', 'cancelled'));
+        UPDATE production.orders o
+        SET status = CASE
+            WHEN EXISTS (SELECT 1 FROM production.plans p WHERE p.order_id = o.order_id AND p.status = 'in_progress') THEN 'in_production'
+            WHEN EXISTS (SELECT 1 FROM production.plans p WHERE p.order_id = o.order_id AND p.status IN ('completed', 'frozen')) THEN 'cut_complete'
+            ELSE 'confirmed'
+        END;
+    END IF;
+END $$;
+
+ALTER TABLE production.orders ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP;
+
+-- =====================
+-- Indexes
+-- =====================
+CREATE INDEX IF NOT EXISTS orders_status_idx ON production.orders (status);
+
+-- =====================
+-- Functions & Triggers
+-- =====================
+-- Orders: controlled transitions; closed and cancelled are terminal
+CREATE OR REPLACE FUNCTION production.guard_order_status()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_active INT;
+    v_done INT;
+BEGIN
+    IF NEW.status = OLD.status THEN
+        RETURN NEW;
+    END IF;
+    IF OLD.status IN ('closed', 'cancelled') THEN
+        RAISE EXCEPTION '订单已%，不允许变更状态', CASE OLD.status WHEN 'closed' THEN '关闭' ELSE '取消' END;
+    END IF;
+
+    SELECT COUNT(*) FILTER (WHERE status = 'in_progress'), COUNT(*) FILTER (WHERE status IN ('completed', 'frozen'))
+    INTO v_active, v_done
+    FROM production.plans WHERE order_id = OLD.order_id;
+
+    IF NEW.status = 'confirmed' THEN
+        IF OLD.status <> 'draft' THEN
+            RAISE EXCEPTION '仅允许从 draft 确认订单';
+        END IF;
+    ELSIF NEW.status = 'in_production' THEN
+        IF v_active = 0 THEN
+            RAISE EXCEPTION '订单状态变更为 in_production 失败：没有进行中的计划';
+        END IF;
+    ELSIF NEW.status = 'cut_complete' THEN
+        IF OLD.status <> 'in_production' OR v_active > 0 OR v_done = 0 THEN
+            RAISE EXCEPTION '订单状态变更为 cut_complete 失败：仍有进行中的计划或尚无已完成计划';
+        END IF;
+    ELSIF NEW.status = 'closed' THEN
+        IF OLD.status <> 'cut_complete' THEN
+            RAISE EXCEPTION '仅允许在 cut_complete 状态下关闭订单';
+        END IF;
+    ELSIF NEW.status = 'cancelled' THEN
+        IF v_active > 0 THEN
+            RAISE EXCEPTION '取消订单失败：仍有进行中的计划';
+        END IF;
+    ELSE
+        RAISE EXCEPTION '不允许将订单变更为该状态 (status=%)', NEW.status;
+    END IF;
+
+    NEW.status_changed_at := CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_order_status ON production.orders;
+CREATE TRIGGER trg_guard_order_status
+BEFORE UPDATE OF status ON production.orders
+FOR EACH ROW EXECUTE FUNCTION production.guard_order_status();
+
+-- Plans: no new plan, publish or reopen under a closed or cancelled order
+CREATE OR REPLACE FUNCTION production.guard_plan_order_status()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_status VARCHAR(20);
+BEGIN
+    IF TG_OP = 'UPDATE' AND (NEW.status <> 'in_progress' OR OLD.status = 'in_progress') THEN
+        RETURN NEW;
+    END IF;
+    SELECT status INTO v_status FROM production.orders WHERE order_id = NEW.order_id;
+    IF v_status IN ('closed', 'cancelled') THEN
+        RAISE EXCEPTION '订单已关闭或取消 (status=%)，不允许新建、发布或重新打开计划', v_status;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_plan_order_status ON production.plans;
+CREATE TRIGGER trg_guard_plan_order_status
+BEFORE INSERT OR UPDATE OF status ON production.plans
+FOR EACH ROW EXECUTE FUNCTION production.guard_plan_order_status();
+
+-- Plans: publish / reopen moves the order to in_production; once no plan is in progress it becomes cut_complete
+CREATE OR REPLACE FUNCTION production.sync_order_status()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_status VARCHAR(20);
+    v_active INT;
+    v_done INT;
+BEGIN
+    IF NEW.status = OLD.status THEN
+        RETURN NEW;
+    END IF;
+    SELECT status INTO v_status FROM production.orders WHERE order_id = NEW.order_id;
+    IF v_status IS NULL OR v_status IN ('closed', 'cancelled') THEN
+        RETURN NEW;
+    END IF;
+
+    SELECT COUNT(*) FILTER (WHERE status = 'in_progress'), COUNT(*) FILTER (WHERE status IN ('completed', 'frozen'))
+    INTO v_active, v_done
+    FROM production.plans WHERE order_id = NEW.order_id;
+
+    IF v_active > 0 AND v_status <> 'in_production' THEN
+        UPDATE production.orders SET status = 'in_production' WHERE order_id = NEW.order_id;
+    ELSIF v_active = 0 AND v_done > 0 AND v_status = 'in_production' THEN
+        UPDATE production.orders SET status = 'cut_complete' WHERE order_id = NEW.order_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_sync_order_status ON production.plans;
+CREATE TRIGGER trg_sync_order_status
+AFTER UPDATE OF status ON production.plans
+FOR EACH ROW EXECUTE FUNCTION production.sync_order_status();
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,21 @@
+-- Revert order revisions; order items become immutable again
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_guard_order_revisions_update ON production.order_revisions;
+DROP FUNCTION IF EXISTS production.guard_order_revisions_update();
+DROP TRIGGER IF EXISTS trg_guard_order_revision ON production.orders;
+DROP FUNCTION IF EXISTS production.guard_order_revision();
+
+CREATE OR REPLACE FUNCTION production.prevent_order_items_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '订单项不可修改';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP FUNCTION IF EXISTS production.is_order_revision_context();
+DROP TABLE IF EXISTS production.order_revisions;
+ALTER TABLE production.orders DROP COLUMN IF EXISTS revision;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,97 @@
+-- Order revisions: order items change through numbered revisions that keep a snapshot of every version
+
+BEGIN;
+
+-- =====================
+-- Columns
+-- =====================
+ALTER TABLE production.orders ADD COLUMN IF NOT EXISTS revision INT NOT NULL DEFAULT 1;
+
+-- =====================
+-- Tables
+-- =====================
+-- One row per revision. items is the full item snapshot of that revision; changes / flagged_plans are relative to
+-- the previous revision (empty for revision 1).
+CREATE TABLE IF NOT EXISTS production.order_revisions (
+    revision_id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL REFERENCES production.orders(order_id) ON DELETE CASCADE,
+    revision INT NOT NULL CHECK (revision > 0),
+    reason TEXT,
+    items JSONB NOT NULL,
+    changes JSONB NOT NULL DEFAULT '[]',
+    flagged_plans JSONB NOT NULL DEFAULT '[]',
+    created_by INT REFERENCES public.users(user_id) ON DELETE SET NULL,
+    created_by_name VARCHAR(100),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (order_id, revision)
+);
+
+-- Existing orders start at revision 1 with their current items
+INSERT INTO production.order_revisions (order_id, revision, items)
+SELECT o.order_id, o.revision,
+       COALESCE((SELECT jsonb_agg(jsonb_build_object('color', oi.color, 'size', oi.size, 'quantity', oi.quantity) ORDER BY oi.item_id)
+                 FROM production.order_items oi WHERE oi.order_id = o.order_id), '[]')
+FROM production.orders o
+WHERE NOT EXISTS (SELECT 1 FROM production.order_revisions r WHERE r.order_id = o.order_id);
+
+-- =====================
+-- Functions & Triggers
+-- =====================
+CREATE OR REPLACE FUNCTION production.is_order_revision_context()
+RETURNS BOOLEAN AS $$
+DECLARE
+    v_setting TEXT;
+BEGIN
+    v_setting := current_setting('cutrix.order_revision_flag', true);
+    RETURN COALESCE(v_setting::BOOLEAN, FALSE);
+EXCEPTION WHEN others THEN
+    RETURN FALSE;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- Order items: quantities change only while a revision is being written
+CREATE OR REPLACE FUNCTION production.prevent_order_items_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF production.is_order_revision_context() THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION '订单项不可修改，请通过订单修订变更';
+END;
+$$ LANGUAGE plpgsql;
+
+-- Orders: revision number is advanced by the revision workflow only
+CREATE OR REPLACE FUNCTION production.guard_order_revision()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.revision IS DISTINCT FROM OLD.revision AND NOT production.is_order_revision_context() THEN
+        RAISE EXCEPTION '订单版本号由订单修订自动维护，禁止手动修改';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_order_revision ON production.orders;
+CREATE TRIGGER trg_guard_order_revision
+BEFORE UPDATE OF revision ON production.orders
+FOR EACH ROW EXECUTE FUNCTION production.guard_order_revision();
+
+-- Revisions are append-only; only the user reference cleared by ON DELETE SET NULL may change
+CREATE OR REPLACE FUNCTION production.guard_order_revisions_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF (NEW.created_by IS NOT NULL AND NEW.created_by IS DISTINCT FROM OLD.created_by)
+        OR ROW(NEW.order_id, NEW.revision, NEW.reason, NEW.items, NEW.changes, NEW.flagged_plans, NEW.created_by_name, NEW.created_at)
+           IS DISTINCT FROM ROW(OLD.order_id, OLD.revision, OLD.reason, OLD.items, OLD.changes, OLD.flagged_plans, OLD.created_by_name, OLD.created_at) THEN
+        RAISE EXCEPTION '订单修订记录不可修改';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_order_revisions_update ON production.order_revisions;
+CREATE TRIGGER trg_guard_order_revisions_update
+BEFORE UPDATE ON production.order_revisions
+FOR EACH ROW EXECUTE FUNCTION production.guard_order_revisions_update();
+
+COMMIT;
\ No newline at end of file
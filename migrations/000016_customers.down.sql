@@ -0,0 +1,11 @@
+-- Revert customer master data; orders keep their free-text customer_name
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_guard_customers_update ON production.customers;
+DROP FUNCTION IF EXISTS production.guard_customers_update();
+DROP INDEX IF EXISTS production.orders_customer_idx;
+ALTER TABLE production.orders DROP COLUMN IF EXISTS customer_id;
+DROP TABLE IF EXISTS production.customers;
+
+COMMIT;
\ No newline at end of file
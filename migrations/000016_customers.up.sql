@@ -0,0 +1,85 @@
+-- Customer master data: orders reference customers by ID; existing free-text customer names are mapped once
+
+BEGIN;
+
+-- =====================
+-- Tables & Columns
+-- =====================
+-- Customers; names are unique case-insensitively so "ACME" and "acme" resolve to one record.
+-- Default tolerance (percent, nullable) is copied to new orders that do not set their own.
+CREATE TABLE IF NOT EXISTS production.customers (
+    customer_id SERIAL PRIMARY KEY,
+    customer_code VARCHAR(50) NOT NULL UNIQUE,
+    customer_name VARCHAR(100) NOT NULL CHECK (btrim(customer_name) <> ''),
+    contact_name VARCHAR(100),
+    phone VARCHAR(50),
+    email VARCHAR(100),
+    address TEXT,
+    over_cut_tolerance NUMERIC(5,2) CHECK (over_cut_tolerance IS NULL OR over_cut_tolerance BETWEEN 0 AND 100),
+    under_cut_tolerance NUMERIC(5,2) CHECK (under_cut_tolerance IS NULL OR under_cut_tolerance BETWEEN 0 AND 100),
+    note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- =====================
+-- Indexes
+-- =====================
+CREATE UNIQUE INDEX IF NOT EXISTS customers_name_key ON production.customers (lower(customer_name));
+
+-- =====================
+-- Columns & Data
+-- =====================
+-- Orders keep customer_name as entered; customer_id is the reference used for filtering and reports.
+-- When the column is first added, existing names are mapped to one customer per case/whitespace-insensitive name,
+-- coded C00001, C00002, ... from the customer_id sequence. Names that only differ otherwise (e.g. "Acme Ltd") stay
+-- separate; reassign those orders via PATCH /orders/:id/customer.
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = 'production' AND table_name = 'orders' AND column_name = 'customer_id'
+    ) THEN
+        ALTER TABLE production.orders ADD COLUMN customer_id INT REFERENCES production.customers(customer_id) ON DELETE RESTRICT;
+
+        INSERT INTO production.customers (customer_id, customer_code, customer_name)
+        SELECT id, 'C' || lpad(id::text, 5, '0'), name
+        FROM (
+            SELECT nextval(pg_get_serial_sequence('production.customers', 'customer_id')) AS id, n.name
+            FROM (
+                SELECT MIN(btrim(o.customer_name)) AS name
+                FROM production.orders o
+                WHERE btrim(COALESCE(o.customer_name, '')) <> ''
+                GROUP BY lower(btrim(o.customer_name))
+            ) n
+            WHERE NOT EXISTS (SELECT 1 FROM production.customers c WHERE lower(c.customer_name) = lower(n.name))
+        ) s
+        ON CONFLICT DO NOTHING;
+
+        UPDATE production.orders o
+        SET customer_id = c.customer_id
+        FROM production.customers c
+        WHERE lower(c.customer_name) = lower(btrim(o.customer_name));
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS orders_customer_idx ON production.orders (customer_id);
+
+-- =====================
+-- Functions & Triggers
+-- =====================
+-- Customers: maintain updated_at
+CREATE OR REPLACE FUNCTION production.guard_customers_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at := CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_customers_update ON production.customers;
+CREATE TRIGGER trg_guard_customers_update
+BEFORE UPDATE ON production.customers
+FOR EACH ROW EXECUTE FUNCTION production.guard_customers_update();
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,70 @@
+-- Revert style master; tolerance violations go back to alphabetical size order
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_guard_styles_update ON production.styles;
+DROP FUNCTION IF EXISTS production.guard_styles_update();
+
+CREATE OR REPLACE FUNCTION production.plan_tolerance_violations(p_plan_id INT)
+RETURNS TABLE (
+    color VARCHAR,
+    size VARCHAR,
+    ordered_qty INT,
+    planned_pieces INT,
+    min_qty INT,
+    max_qty INT,
+    kind TEXT
+) AS $$
+    WITH o AS (
+        SELECT ord.order_id, ord.over_cut_tolerance, ord.under_cut_tolerance
+        FROM production.plans p
+        JOIN production.orders ord ON ord.order_id = p.order_id
+        WHERE p.plan_id = p_plan_id
+          AND (ord.over_cut_tolerance IS NOT NULL OR ord.under_cut_tolerance IS NOT NULL)
+    ),
+    ordered AS (
+        SELECT oi.color, oi.size, oi.quantity
+        FROM production.order_items oi
+        JOIN o ON o.order_id = oi.order_id
+    ),
+    planned AS (
+        SELECT t.color, r.size, SUM(t.planned_layers * r.ratio)::INT AS pieces
+        FROM production.plans p
+        JOIN o ON o.order_id = p.order_id
+        JOIN production.cutting_layouts l ON l.plan_id = p.plan_id
+        JOIN production.tasks t ON t.layout_id = l.layout_id
+        JOIN production.layout_size_ratios r ON r.layout_id = l.layout_id
+        WHERE p.status <> 'frozen'
+        GROUP BY t.color, r.size
+    ),
+    cells AS (
+        SELECT COALESCE(od.color, pl.color) AS color,
+               COALESCE(od.size, pl.size) AS size,
+               COALESCE(od.quantity, 0) AS ordered_qty,
+               COALESCE(pl.pieces, 0) AS planned_pieces
+        FROM ordered od
+        FULL OUTER JOIN planned pl ON pl.color = od.color AND pl.size = od.size
+    ),
+    bounds AS (
+        SELECT c.color, c.size, c.ordered_qty, c.planned_pieces,
+               CASE WHEN o.under_cut_tolerance IS NULL THEN NULL
+                    ELSE c.ordered_qty - FLOOR(c.ordered_qty * o.under_cut_tolerance / 100)::INT END AS min_qty,
+               CASE WHEN o.over_cut_tolerance IS NULL THEN NULL
+                    ELSE c.ordered_qty + FLOOR(c.ordered_qty * o.over_cut_tolerance / 100)::INT END AS max_qty
+        FROM cells c CROSS JOIN o
+    )
+    SELECT b.color, b.size, b.ordered_qty, b.planned_pieces, b.min_qty, b.max_qty,
+           CASE WHEN b.planned_pieces < b.min_qty THEN 'short' ELSE 'over' END AS kind
+    FROM bounds b
+    WHERE b.planned_pieces < b.min_qty OR b.planned_pieces > b.max_qty
+    ORDER BY b.color, b.size;
+$$ LANGUAGE sql STABLE;
+
+DROP FUNCTION IF EXISTS production.style_size_rank(TEXT, TEXT);
+DROP INDEX IF EXISTS production.orders_style_number_idx;
+DROP TABLE IF EXISTS production.style_components;
+DROP TABLE IF EXISTS production.style_colors;
+DROP TABLE IF EXISTS production.style_sizes;
+DROP TABLE IF EXISTS production.styles;
+
+COMMIT;
\ No newline at end of file
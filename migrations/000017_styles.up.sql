@@ -0,0 +1,136 @@
+-- Style master: size runs with canonical size order, allowed colors and garment components per style_number
+
+BEGIN;
+
+-- =====================
+-- Tables
+-- =====================
+-- Styles are keyed by style_number, the value orders already carry; the number cannot change once created.
+CREATE TABLE IF NOT EXISTS production.styles (
+    style_id SERIAL PRIMARY KEY,
+    style_number VARCHAR(50) NOT NULL UNIQUE CHECK (btrim(style_number) <> ''),
+    style_name VARCHAR(100),
+    note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Size run; sort_order is the canonical size order used by layouts and reports (e.g. XS, S, M, L, XL)
+CREATE TABLE IF NOT EXISTS production.style_sizes (
+    style_id INT NOT NULL REFERENCES production.styles(style_id) ON DELETE CASCADE,
+    size VARCHAR(30) NOT NULL,
+    sort_order INT NOT NULL,
+    PRIMARY KEY (style_id, size),
+    UNIQUE (style_id, sort_order)
+);
+
+-- Allowed colors; a style without colors accepts any color
+CREATE TABLE IF NOT EXISTS production.style_colors (
+    style_id INT NOT NULL REFERENCES production.styles(style_id) ON DELETE CASCADE,
+    color VARCHAR(50) NOT NULL,
+    sort_order INT NOT NULL,
+    PRIMARY KEY (style_id, color)
+);
+
+-- Garment components (shell, lining, ...) with the default number of pieces cut per garment
+CREATE TABLE IF NOT EXISTS production.style_components (
+    style_id INT NOT NULL REFERENCES production.styles(style_id) ON DELETE CASCADE,
+    component_name VARCHAR(50) NOT NULL,
+    pieces INT NOT NULL DEFAULT 1 CHECK (pieces > 0),
+    sort_order INT NOT NULL,
+    PRIMARY KEY (style_id, component_name)
+);
+
+-- =====================
+-- Indexes
+-- =====================
+CREATE INDEX IF NOT EXISTS orders_style_number_idx ON production.orders (style_number);
+
+-- =====================
+-- Functions & Triggers
+-- =====================
+-- Position of a size in the style's size run; NULL when the style or size is not registered (callers sort NULLS LAST,
+-- then by size).
+CREATE OR REPLACE FUNCTION production.style_size_rank(p_style_number TEXT, p_size TEXT)
+RETURNS INT AS $$
+    SELECT ss.sort_order
+    FROM production.styles s
+    JOIN production.style_sizes ss ON ss.style_id = s.style_id
+    WHERE s.style_number = p_style_number AND ss.size = p_size
+$$ LANGUAGE sql STABLE;
+
+-- Styles: style_number is immutable (orders refer to it); maintain updated_at
+CREATE OR REPLACE FUNCTION production.guard_styles_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.style_number IS DISTINCT FROM OLD.style_number THEN
+        RAISE EXCEPTION '款号创建后不可修改 (style_id=%)', OLD.style_id;
+    END IF;
+    NEW.updated_at := CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_guard_styles_update ON production.styles;
+CREATE TRIGGER trg_guard_styles_update
+BEFORE UPDATE ON production.styles
+FOR EACH ROW EXECUTE FUNCTION production.guard_styles_update();
+
+-- Tolerance violations: same rules as 000002, cells ordered by color then the style's size order
+CREATE OR REPLACE FUNCTION production.plan_tolerance_violations(p_plan_id INT)
+RETURNS TABLE (
+    color VARCHAR,
+    size VARCHAR,
+    ordered_qty INT,
+    planned_pieces INT,
+    min_qty INT,
+    max_qty INT,
+    kind TEXT
+) AS $$
+    WITH o AS (
+        SELECT ord.order_id, ord.style_number, ord.over_cut_tolerance, ord.under_cut_tolerance
+        FROM production.plans p
+        JOIN production.orders ord ON ord.order_id = p.order_id
+        WHERE p.plan_id = p_plan_id
+          AND (ord.over_cut_tolerance IS NOT NULL OR ord.under_cut_tolerance IS NOT NULL)
+    ),
+    ordered AS (
+        SELECT oi.color, oi.size, oi.quantity
+        FROM production.order_items oi
+        JOIN o ON o.order_id = oi.order_id
+    ),
+    planned AS (
+        SELECT t.color, r.size, SUM(t.planned_layers * r.ratio)::INT AS pieces
+        FROM production.plans p
+        JOIN o ON o.order_id = p.order_id
+        JOIN production.cutting_layouts l ON l.plan_id = p.plan_id
+        JOIN production.tasks t ON t.layout_id = l.layout_id
+        JOIN production.layout_size_ratios r ON r.layout_id = l.layout_id
+        WHERE p.status <> 'frozen'
+        GROUP BY t.color, r.size
+    ),
+    cells AS (
+        SELECT COALESCE(od.color, pl.color) AS color,
+               COALESCE(od.size, pl.size) AS size,
+               COALESCE(od.quantity, 0) AS ordered_qty,
+               COALESCE(pl.pieces, 0) AS planned_pieces
+        FROM ordered od
+        FULL OUTER JOIN planned pl ON pl.color = od.color AND pl.size = od.size
+    ),
+    bounds AS (
+        SELECT c.color, c.size, c.ordered_qty, c.planned_pieces,
+               CASE WHEN o.under_cut_tolerance IS NULL THEN NULL
+                    ELSE c.ordered_qty - FLOOR(c.ordered_qty * o.under_cut_tolerance / 100)::INT END AS min_qty,
+               CASE WHEN o.over_cut_tolerance IS NULL THEN NULL
+                    ELSE c.ordered_qty + FLOOR(c.ordered_qty * o.over_cut_tolerance / 100)::INT END AS max_qty,
+               production.style_size_rank(o.style_number, c.size) AS size_rank
+        FROM cells c CROSS JOIN o
+    )
+    SELECT b.color, b.size, b.ordered_qty, b.planned_pieces, b.min_qty, b.max_qty,
+           CASE WHEN b.planned_pieces < b.min_qty THEN 'short' ELSE 'over' END AS kind
+    FROM bounds b
+    WHERE b.planned_pieces < b.min_qty OR b.planned_pieces > b.max_qty
+    ORDER BY b.color, b.size_rank NULLS LAST, b.size;
+$$ LANGUAGE sql STABLE;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,200 @@
+-- Revert multi-component layouts: restore whole-plan completion and the color/size tolerance check
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_ensure_layout_component ON production.cutting_layouts;
+DROP FUNCTION IF EXISTS production.ensure_layout_component();
+
+-- Restore the 000002 publish/completion guard
+CREATE OR REPLACE FUNCTION production.guard_plan_update()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_total INT;
+    v_completed INT;
+    v_violations TEXT;
+BEGIN
+    IF production.is_plan_adjustment_context() THEN
+        RETURN NEW;
+    END IF;
+
+    -- Disallow manual changes of publish/finish date when status unchanged
+    IF NEW.status = OLD.status THEN
+        IF NEW.planned_publish_date IS DISTINCT FROM OLD.planned_publish_date THEN
+            RAISE EXCEPTION '发布日期由发布动作自动记录，禁止手动修改';
+        END IF;
+        IF NEW.planned_finish_date IS DISTINCT FROM OLD.planned_finish_date THEN
+            RAISE EXCEPTION '完成时间由系统自动记录，禁止手动修改';
+        END IF;
+    END IF;
+
+    -- Disallow pending -> completed direct transition
+    IF OLD.status = 'pending' AND NEW.status = 'completed' THEN
+        RAISE EXCEPTION '禁止从 pending 直接变更为 completed';
+    END IF;
+    -- Disallow pending -> frozen direct transition
+    IF OLD.status = 'pending' AND NEW.status = 'frozen' THEN
+        RAISE EXCEPTION '仅允许在 completed 状态下冻结计划';
+    END IF;
+
+    -- Publish: pending -> in_progress, require tasks exist; publish date set by AFTER trigger
+    IF NEW.status = 'in_progress' AND (OLD.status IS DISTINCT FROM 'in_progress') THEN
+        IF OLD.status <> 'pending' THEN
+            RAISE EXCEPTION '仅允许从 pending 发布到 in_progress';
+        END IF;
+        SELECT COUNT(*) INTO v_total
+        FROM production.tasks t
+        JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+        WHERE l.plan_id = OLD.plan_id;
+        IF v_total <= 0 THEN
+            RAISE EXCEPTION '发布失败：该计划必须包含至少一个任务';
+        END IF;
+        -- Cut tolerance: planned pieces (with other non-frozen plans of the order) must stay within order tolerance
+        SELECT string_agg(
+                   format('%s/%s %s(下单 %s, 计划 %s, 允许 %s-%s)',
+                          v.color, v.size,
+                          CASE v.kind WHEN 'short' THEN '短缺' ELSE '超裁' END,
+                          v.ordered_qty, v.planned_pieces,
+                          COALESCE(v.min_qty::TEXT, '0'), COALESCE(v.max_qty::TEXT, '不限')),
+                   '; ')
+        INTO v_violations
+        FROM production.plan_tolerance_violations(OLD.plan_id) v;
+        IF v_violations IS NOT NULL THEN
+            RAISE EXCEPTION '发布失败：超出裁剪容差：%', v_violations;
+        END IF;
+        -- publish date will be set by AFTER trigger, do not set NEW.planned_publish_date here
+    END IF;
+
+    -- After publish: enforce restrictions and controlled transitions
+    IF OLD.status IN ('in_progress','completed','frozen') THEN
+        IF NEW.status = OLD.status THEN
+            IF NEW.plan_name IS DISTINCT FROM OLD.plan_name OR NEW.order_id IS DISTINCT FROM OLD.order_id THEN
+                RAISE EXCEPTION '计划发布后仅允许修改备注';
+            END IF;
+        ELSE
+            IF NEW.status = 'completed' THEN
+                SELECT COUNT(*) INTO v_total
+                FROM production.tasks t
+                JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+                WHERE l.plan_id = OLD.plan_id;
+                SELECT COUNT(*) INTO v_completed
+                FROM production.tasks t
+                JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+                WHERE l.plan_id = OLD.plan_id AND t.status = 'completed';
+                IF v_total > 0 AND v_completed = v_total THEN
+                    NEW.planned_finish_date := CURRENT_TIMESTAMP;
+                ELSE
+                    RAISE EXCEPTION '状态变更为已完成失败：仍有未完成任务';
+                END IF;
+            ELSIF NEW.status = 'frozen' THEN
+                IF OLD.status <> 'completed' THEN
+                    RAISE EXCEPTION '仅允许在 completed 状态下冻结计划';
+                END IF;
+                NEW.planned_finish_date := OLD.planned_finish_date;
+            ELSE
+                RAISE EXCEPTION '计划发布后不允许更改为该状态';
+            END IF;
+        END IF;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Restore the 000001 plan progress
+CREATE OR REPLACE FUNCTION production.update_plan_progress(p_plan_id INT)
+RETURNS VOID AS $$
+DECLARE
+    v_total INT;
+    v_completed INT;
+BEGIN
+    -- Count tasks under the plan
+    SELECT COUNT(*) INTO v_total
+    FROM production.tasks t
+    JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+    WHERE l.plan_id = p_plan_id;
+
+    SELECT COUNT(*) INTO v_completed
+    FROM production.tasks t
+    JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+    WHERE l.plan_id = p_plan_id AND t.status = 'completed';
+
+    -- Only mark completed when all tasks are completed and there is at least one task
+    UPDATE production.plans
+    SET
+        status = CASE
+            WHEN v_total > 0 AND v_completed = v_total THEN 'completed'
+            WHEN v_total > 0 AND v_completed <> v_total AND status = 'completed' THEN 'in_progress'
+            ELSE status
+        END,
+        planned_finish_date = CASE
+            WHEN v_total > 0 AND v_completed = v_total THEN CURRENT_TIMESTAMP
+            ELSE planned_finish_date
+        END
+    WHERE plan_id = p_plan_id AND status <> 'frozen';
+END;
+$$ LANGUAGE plpgsql;
+
+-- Restore the 000017 tolerance violations
+CREATE OR REPLACE FUNCTION production.plan_tolerance_violations(p_plan_id INT)
+RETURNS TABLE (
+    color VARCHAR,
+    size VARCHAR,
+    ordered_qty INT,
+    planned_pieces INT,
+    min_qty INT,
+    max_qty INT,
+    kind TEXT
+) AS $$
+    WITH o AS (
+        SELECT ord.order_id, ord.style_number, ord.over_cut_tolerance, ord.under_cut_tolerance
+        FROM production.plans p
+        JOIN production.orders ord ON ord.order_id = p.order_id
+        WHERE p.plan_id = p_plan_id
+          AND (ord.over_cut_tolerance IS NOT NULL OR ord.under_cut_tolerance IS NOT NULL)
+    ),
+    ordered AS (
+        SELECT oi.color, oi.size, oi.quantity
+        FROM production.order_items oi
+        JOIN o ON o.order_id = oi.order_id
+    ),
+    planned AS (
+        SELECT t.color, r.size, SUM(t.planned_layers * r.ratio)::INT AS pieces
+        FROM production.plans p
+        JOIN o ON o.order_id = p.order_id
+        JOIN production.cutting_layouts l ON l.plan_id = p.plan_id
+        JOIN production.tasks t ON t.layout_id = l.layout_id
+        JOIN production.layout_size_ratios r ON r.layout_id = l.layout_id
+        WHERE p.status <> 'frozen'
+        GROUP BY t.color, r.size
+    ),
+    cells AS (
+        SELECT COALESCE(od.color, pl.color) AS color,
+               COALESCE(od.size, pl.size) AS size,
+               COALESCE(od.quantity, 0) AS ordered_qty,
+               COALESCE(pl.pieces, 0) AS planned_pieces
+        FROM ordered od
+        FULL OUTER JOIN planned pl ON pl.color = od.color AND pl.size = od.size
+    ),
+    bounds AS (
+        SELECT c.color, c.size, c.ordered_qty, c.planned_pieces,
+               CASE WHEN o.under_cut_tolerance IS NULL THEN NULL
+                    ELSE c.ordered_qty - FLOOR(c.ordered_qty * o.under_cut_tolerance / 100)::INT END AS min_qty,
+               CASE WHEN o.over_cut_tolerance IS NULL THEN NULL
+                    ELSE c.ordered_qty + FLOOR(c.ordered_qty * o.over_cut_tolerance / 100)::INT END AS max_qty,
+               production.style_size_rank(o.style_number, c.size) AS size_rank
+        FROM cells c CROSS JOIN o
+    )
+    SELECT b.color, b.size, b.ordered_qty, b.planned_pieces, b.min_qty, b.max_qty,
+           CASE WHEN b.planned_pieces < b.min_qty THEN 'short' ELSE 'over' END AS kind
+    FROM bounds b
+    WHERE b.planned_pieces < b.min_qty OR b.planned_pieces > b.max_qty
+    ORDER BY b.color, b.size_rank NULLS LAST, b.size;
+$$ LANGUAGE sql STABLE;
+
+DROP FUNCTION IF EXISTS production.component_tolerance_violations(INT);
+DROP FUNCTION IF EXISTS production.plan_components_complete(INT);
+DROP FUNCTION IF EXISTS production.style_component_rank(TEXT, TEXT);
+DROP INDEX IF EXISTS production.cutting_layouts_plan_component_idx;
+ALTER TABLE production.cutting_layouts DROP COLUMN IF EXISTS component;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,299 @@
+-- Multi-component layouts: each layout cuts one garment component (shell, lining, interlining, ...); tolerance,
+-- coverage and plan completion are evaluated per component
+
+BEGIN;
+
+-- =====================
+-- Columns & Data
+-- =====================
+-- Component cut by the layout's marker. When the column is first added, existing layouts are mapped from the
+-- layout_name convention used so far ("...lining..." / "...interlining..."), everything else becomes shell.
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = 'production' AND table_name = 'cutting_layouts' AND column_name = 'component'
+    ) THEN
+        ALTER TABLE production.cutting_layouts ADD COLUMN component VARCHAR(50);
+
+        -- Published plans' layouts are guarded; the backfill runs in the plan adjustment context
+        PERFORM set_config('cutrix.plan_adjustment_flag', 'true', true);
+        UPDATE production.cutting_layouts
+        SET component = CASE
+            WHEN layout_name ~* 'interlining|fusing' OR layout_name LIKE '%衬%' THEN 'interlining'
+            WHEN layout_name ~* 'lining' OR layout_name LIKE '%里布%' THEN 'lining'
+            ELSE 'shell'
+        END;
+        PERFORM set_config('cutrix.plan_adjustment_flag', 'false', true);
+
+        ALTER TABLE production.cutting_layouts ALTER COLUMN component SET NOT NULL;
+        ALTER TABLE production.cutting_layouts ADD CONSTRAINT cutting_layouts_component_check CHECK (btrim(component) <> '');
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS cutting_layouts_plan_component_idx ON production.cutting_layouts (plan_id, component);
+
+-- =====================
+-- Functions & Triggers
+-- =====================
+-- Position of a component in the style's component list; NULL when the style or component is not registered.
+CREATE OR REPLACE FUNCTION production.style_component_rank(p_style_number TEXT, p_component TEXT)
+RETURNS INT AS $$
+    SELECT sc.sort_order
+    FROM production.styles s
+    JOIN production.style_components sc ON sc.style_id = s.style_id
+    WHERE s.style_number = p_style_number AND sc.component_name = p_component
+$$ LANGUAGE sql STABLE;
+
+-- Layouts: a blank component defaults to the style's first component (shell when the style is not registered or
+-- lists none); when the style lists components, the layout's component must be one of them
+CREATE OR REPLACE FUNCTION production.ensure_layout_component()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_style_id INT;
+    v_style_number VARCHAR(50);
+    v_default VARCHAR(50);
+BEGIN
+    NEW.component := NULLIF(btrim(NEW.component), '');
+
+    SELECT s.style_id, s.style_number INTO v_style_id, v_style_number
+    FROM production.plans p
+    JOIN production.orders o ON o.order_id = p.order_id
+    JOIN production.styles s ON s.style_number = o.style_number
+    WHERE p.plan_id = NEW.plan_id;
+
+    SELECT sc.component_name INTO v_default
+    FROM production.style_components sc
+    WHERE sc.style_id = v_style_id
+    ORDER BY sc.sort_order
+    LIMIT 1;
+
+    IF NEW.component IS NULL THEN
+        NEW.component := COALESCE(v_default, 'shell');
+    ELSIF v_default IS NOT NULL AND NOT EXISTS (
+        SELECT 1 FROM production.style_components sc
+        WHERE sc.style_id = v_style_id AND sc.component_name = NEW.component
+    ) THEN
+        RAISE EXCEPTION '布局部件 % 不属于款式 % 的部件', NEW.component, v_style_number;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_ensure_layout_component ON production.cutting_layouts;
+CREATE TRIGGER trg_ensure_layout_component
+BEFORE INSERT OR UPDATE OF component ON production.cutting_layouts
+FOR EACH ROW EXECUTE FUNCTION production.ensure_layout_component();
+
+-- A plan is complete when every component that has layouts in the plan has at least one task and all of its
+-- tasks are completed; a lining layout without tasks keeps the plan open even when the shell is fully cut
+CREATE OR REPLACE FUNCTION production.plan_components_complete(p_plan_id INT)
+RETURNS BOOLEAN AS $$
+    SELECT COALESCE(bool_and(c.total > 0 AND c.completed = c.total), false)
+    FROM (
+        SELECT l.component,
+               COUNT(t.task_id) AS total,
+               COUNT(t.task_id) FILTER (WHERE t.status = 'completed') AS completed
+        FROM production.cutting_layouts l
+        LEFT JOIN production.tasks t ON t.layout_id = l.layout_id
+        WHERE l.plan_id = p_plan_id
+        GROUP BY l.component
+    ) c
+$$ LANGUAGE sql STABLE;
+
+-- Plan progress: completion is evaluated per component (replaces the 000001 definition)
+CREATE OR REPLACE FUNCTION production.update_plan_progress(p_plan_id INT)
+RETURNS VOID AS $$
+DECLARE
+    v_total INT;
+    v_complete BOOLEAN;
+BEGIN
+    SELECT COUNT(*) INTO v_total
+    FROM production.tasks t
+    JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+    WHERE l.plan_id = p_plan_id;
+
+    v_complete := production.plan_components_complete(p_plan_id);
+
+    UPDATE production.plans
+    SET
+        status = CASE
+            WHEN v_complete THEN 'completed'
+            WHEN v_total > 0 AND status = 'completed' THEN 'in_progress'
+            ELSE status
+        END,
+        planned_finish_date = CASE
+            WHEN v_complete THEN CURRENT_TIMESTAMP
+            ELSE planned_finish_date
+        END
+    WHERE plan_id = p_plan_id AND status <> 'frozen';
+END;
+$$ LANGUAGE plpgsql;
+
+-- Tolerance violations per component: each component's planned pieces (summed over the order's non-frozen plans)
+-- are compared with the ordered quantity on their own, so shell and lining pieces are never added together.
+-- Every component planned in those plans is checked against every ordered cell.
+CREATE OR REPLACE FUNCTION production.component_tolerance_violations(p_plan_id INT)
+RETURNS TABLE (
+    component VARCHAR,
+    color VARCHAR,
+    size VARCHAR,
+    ordered_qty INT,
+    planned_pieces INT,
+    min_qty INT,
+    max_qty INT,
+    kind TEXT
+) AS $$
+    WITH o AS (
+        SELECT ord.order_id, ord.style_number, ord.over_cut_tolerance, ord.under_cut_tolerance
+        FROM production.plans p
+        JOIN production.orders ord ON ord.order_id = p.order_id
+        WHERE p.plan_id = p_plan_id
+          AND (ord.over_cut_tolerance IS NOT NULL OR ord.under_cut_tolerance IS NOT NULL)
+    ),
+    planned AS (
+        SELECT l.component, t.color, r.size, SUM(t.planned_layers * r.ratio)::INT AS pieces
+        FROM production.plans p
+        JOIN o ON o.order_id = p.order_id
+        JOIN production.cutting_layouts l ON l.plan_id = p.plan_id
+        JOIN production.tasks t ON t.layout_id = l.layout_id
+        JOIN production.layout_size_ratios r ON r.layout_id = l.layout_id
+        WHERE p.status <> 'frozen'
+        GROUP BY l.component, t.color, r.size
+    ),
+    ordered AS (
+        SELECT c.component, oi.color, oi.size, oi.quantity
+        FROM production.order_items oi
+        JOIN o ON o.order_id = oi.order_id
+        CROSS JOIN (SELECT DISTINCT component FROM planned) c
+    ),
+    cells AS (
+        SELECT COALESCE(od.component, pl.component) AS component,
+               COALESCE(od.color, pl.color) AS color,
+               COALESCE(od.size, pl.size) AS size,
+               COALESCE(od.quantity, 0) AS ordered_qty,
+               COALESCE(pl.pieces, 0) AS planned_pieces
+        FROM ordered od
+        FULL OUTER JOIN planned pl ON pl.component = od.component AND pl.color = od.color AND pl.size = od.size
+    ),
+    bounds AS (
+        SELECT c.component, c.color, c.size, c.ordered_qty, c.planned_pieces,
+               CASE WHEN o.under_cut_tolerance IS NULL THEN NULL
+                    ELSE c.ordered_qty - FLOOR(c.ordered_qty * o.under_cut_tolerance / 100)::INT END AS min_qty,
+               CASE WHEN o.over_cut_tolerance IS NULL THEN NULL
+                    ELSE c.ordered_qty + FLOOR(c.ordered_qty * o.over_cut_tolerance / 100)::INT END AS max_qty,
+               production.style_component_rank(o.style_number, c.component) AS component_rank,
+               production.style_size_rank(o.style_number, c.size) AS size_rank
+        FROM cells c CROSS JOIN o
+    )
+    SELECT b.component, b.color, b.size, b.ordered_qty, b.planned_pieces, b.min_qty, b.max_qty,
+           CASE WHEN b.planned_pieces < b.min_qty THEN 'short' ELSE 'over' END AS kind
+    FROM bounds b
+    WHERE b.planned_pieces < b.min_qty OR b.planned_pieces > b.max_qty
+    ORDER BY b.component_rank NULLS LAST, b.component, b.color, b.size_rank NULLS LAST, b.size;
+$$ LANGUAGE sql STABLE;
+
+-- Kept for callers of the color/size signature; cells now come from the per-component check
+CREATE OR REPLACE FUNCTION production.plan_tolerance_violations(p_plan_id INT)
+RETURNS TABLE (
+    color VARCHAR,
+    size VARCHAR,
+    ordered_qty INT,
+    planned_pieces INT,
+    min_qty INT,
+    max_qty INT,
+    kind TEXT
+) AS $$
+    SELECT v.color, v.size, v.ordered_qty, v.planned_pieces, v.min_qty, v.max_qty, v.kind
+    FROM production.component_tolerance_violations(p_plan_id) v;
+$$ LANGUAGE sql STABLE;
+
+-- Plans: completion requires every component complete; tolerance messages name the component
+-- (replaces the 000002 definition)
+CREATE OR REPLACE FUNCTION production.guard_plan_update()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_total INT;
+    v_violations TEXT;
+BEGIN
+    IF production.is_plan_adjustment_context() THEN
+        RETURN NEW;
+    END IF;
+
+    -- Disallow manual changes of publish/finish date when status unchanged
+    IF NEW.status = OLD.status THEN
+        IF NEW.planned_publish_date IS DISTINCT FROM OLD.planned_publish_date THEN
+            RAISE EXCEPTION '发布日期由发布动作自动记录，禁止手动修改';
+        END IF;
+        IF NEW.planned_finish_date IS DISTINCT FROM OLD.planned_finish_date THEN
+            RAISE EXCEPTION '完成时间由系统自动记录，禁止手动修改';
+        END IF;
+    END IF;
+
+    -- Disallow pending -> completed direct transition
+    IF OLD.status = 'pending' AND NEW.status = 'completed' THEN
+        RAISE EXCEPTION '禁止从 pending 直接变更为 completed';
+    END IF;
+    -- Disallow pending -> frozen direct transition
+    IF OLD.status = 'pending' AND NEW.status = 'frozen' THEN
+        RAISE EXCEPTION '仅允许在 completed 状态下冻结计划';
+    END IF;
+
+    -- Publish: pending -> in_progress, require tasks exist; publish date set by AFTER trigger
+    IF NEW.status = 'in_progress' AND (OLD.status IS DISTINCT FROM 'in_progress') THEN
+        IF OLD.status <> 'pending' THEN
+            RAISE EXCEPTION '仅允许从 pending 发布到 in_progress';
+        END IF;
+        SELECT COUNT(*) INTO v_total
+        FROM production.tasks t
+        JOIN production.cutting_layouts l ON l.layout_id = t.layout_id
+        WHERE l.plan_id = OLD.plan_id;
+        IF v_total <= 0 THEN
+            RAISE EXCEPTION '发布失败：该计划必须包含至少一个任务';
+        END IF;
+        -- Cut tolerance per component: planned pieces (with other non-frozen plans of the order) must stay within order tolerance
+        SELECT string_agg(
+                   format('%s %s/%s %s(下单 %s, 计划 %s, 允许 %s-%s)',
+                          v.component, v.color, v.size,
+                          CASE v.kind WHEN 'short' THEN '短缺' ELSE '超裁' END,
+                          v.ordered_qty, v.planned_pieces,
+                          COALESCE(v.min_qty::TEXT, '0'), COALESCE(v.max_qty::TEXT, '不限')),
+                   '; ')
+        INTO v_violations
+        FROM production.component_tolerance_violations(OLD.plan_id) v;
+        IF v_violations IS NOT NULL THEN
+            RAISE EXCEPTION '发布失败：超出裁剪容差：%', v_violations;
+        END IF;
+        -- publish date will be set by AFTER trigger, do not set NEW.planned_publish_date here
+    END IF;
+
+    -- After publish: enforce restrictions and controlled transitions
+    IF OLD.status IN ('in_progress','completed','frozen') THEN
+        IF NEW.status = OLD.status THEN
+            IF NEW.plan_name IS DISTINCT FROM OLD.plan_name OR NEW.order_id IS DISTINCT FROM OLD.order_id THEN
+                RAISE EXCEPTION '计划发布后仅允许修改备注';
+            END IF;
+        ELSE
+            IF NEW.status = 'completed' THEN
+                IF production.plan_components_complete(OLD.plan_id) THEN
+                    NEW.planned_finish_date := CURRENT_TIMESTAMP;
+                ELSE
+                    RAISE EXCEPTION '状态变更为已完成失败：仍有部件的任务未完成';
+                END IF;
+            ELSIF NEW.status = 'frozen' THEN
+                IF OLD.status <> 'completed' THEN
+                    RAISE EXCEPTION '仅允许在 completed 状态下冻结计划';
+                END IF;
+                NEW.planned_finish_date := OLD.planned_finish_date;
+            ELSE
+                RAISE EXCEPTION '计划发布后不允许更改为该状态';
+            END IF;
+        END IF;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
\ No newline at end of file
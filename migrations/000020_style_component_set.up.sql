@@ -0,0 +1,111 @@
+-- Component set from the style: when the order's style lists components, plan completion and the tolerance check
+-- cover every listed component, so a component without layouts keeps the plan open and its cells short
+
+BEGIN;
+
+-- =====================
+-- Functions
+-- =====================
+-- A plan is complete when every component it must cut has at least one task and all of its tasks are completed.
+-- The components are those the order's style lists plus those with layouts in the plan; a style component the plan
+-- has no layout for keeps it open (replaces the 000018 definition)
+CREATE OR REPLACE FUNCTION production.plan_components_complete(p_plan_id INT)
+RETURNS BOOLEAN AS $$
+    WITH comps AS (
+        SELECT sc.component_name AS component
+        FROM production.plans p
+        JOIN production.orders o ON o.order_id = p.order_id
+        JOIN production.styles s ON s.style_number = o.style_number
+        JOIN production.style_components sc ON sc.style_id = s.style_id
+        WHERE p.plan_id = p_plan_id
+        UNION
+        SELECT l.component
+        FROM production.cutting_layouts l
+        WHERE l.plan_id = p_plan_id
+    )
+    SELECT COALESCE(bool_and(c.total > 0 AND c.completed = c.total), false)
+    FROM (
+        SELECT comps.component,
+               COUNT(t.task_id) AS total,
+               COUNT(t.task_id) FILTER (WHERE t.status = 'completed') AS completed
+        FROM comps
+        LEFT JOIN production.cutting_layouts l ON l.plan_id = p_plan_id AND l.component = comps.component
+        LEFT JOIN production.tasks t ON t.layout_id = l.layout_id
+        GROUP BY comps.component
+    ) c
+$$ LANGUAGE sql STABLE;
+
+-- Tolerance violations per component: each component's planned pieces (summed over the order's non-frozen plans)
+-- are compared with the ordered quantity on their own, so shell and lining pieces are never added together.
+-- Every component the order's style lists or those plans plan is checked against every ordered cell; a style
+-- component nobody planned has 0 pieces, so all its cells are short when an under-cut tolerance is set
+-- (replaces the 000018 definition)
+CREATE OR REPLACE FUNCTION production.component_tolerance_violations(p_plan_id INT)
+RETURNS TABLE (
+    component VARCHAR,
+    color VARCHAR,
+    size VARCHAR,
+    ordered_qty INT,
+    planned_pieces INT,
+    min_qty INT,
+    max_qty INT,
+    kind TEXT
+) AS $$
+    WITH o AS (
+        SELECT ord.order_id, ord.style_number, ord.over_cut_tolerance, ord.under_cut_tolerance
+        FROM production.plans p
+        JOIN production.orders ord ON ord.order_id = p.order_id
+        WHERE p.plan_id = p_plan_id
+          AND (ord.over_cut_tolerance IS NOT NULL OR ord.under_cut_tolerance IS NOT NULL)
+    ),
+    planned AS (
+        SELECT l.component, t.color, r.size, SUM(t.planned_layers * r.ratio)::INT AS pieces
+        FROM production.plans p
+        JOIN o ON o.order_id = p.order_id
+        JOIN production.cutting_layouts l ON l.plan_id = p.plan_id
+        JOIN production.tasks t ON t.layout_id = l.layout_id
+        JOIN production.layout_size_ratios r ON r.layout_id = l.layout_id
+        WHERE p.status <> 'frozen'
+        GROUP BY l.component, t.color, r.size
+    ),
+    comps AS (
+        SELECT DISTINCT component FROM planned
+        UNION
+        SELECT sc.component_name
+        FROM o
+        JOIN production.styles s ON s.style_number = o.style_number
+        JOIN production.style_components sc ON sc.style_id = s.style_id
+    ),
+    ordered AS (
+        SELECT c.component, oi.color, oi.size, oi.quantity
+        FROM production.order_items oi
+        JOIN o ON o.order_id = oi.order_id
+        CROSS JOIN comps c
+    ),
+    cells AS (
+        SELECT COALESCE(od.component, pl.component) AS component,
+               COALESCE(od.color, pl.color) AS color,
+               COALESCE(od.size, pl.size) AS size,
+               COALESCE(od.quantity, 0) AS ordered_qty,
+               COALESCE(pl.pieces, 0) AS planned_pieces
+        FROM ordered od
+        FULL OUTER JOIN planned pl ON pl.component = od.component AND pl.color = od.color AND pl.size = od.size
+    ),
+    bounds AS (
+        SELECT c.component, c.color, c.size, c.ordered_qty, c.planned_pieces,
+               CASE WHEN o.under_cut_tolerance IS NULL THEN NULL
+                    ELSE c.ordered_qty - FLOOR(c.ordered_qty * o.under_cut_tolerance / 100)::INT END AS min_qty,
+               CASE WHEN o.over_cut_tolerance IS NULL THEN NULL
+                    ELSE c.ordered_qty + FLOOR(c.ordered_qty * o.over_cut_tolerance / 100)::INT END AS max_qty,
+               production.style_component_rank(o.style_number, c.component) AS component_rank,
+               production.style_size_rank(o.style_number, c.size) AS size_rank
+        FROM cells c CROSS JOIN o
+    )
+    SELECT b.component, b.color, b.size, b.ordered_qty, b.planned_pieces, b.min_qty, b.max_qty,
+           CASE WHEN b.planned_pieces < b.min_qty THEN 'short' ELSE 'over' END AS kind
+    FROM bounds b
+    WHERE b.planned_pieces < b.min_qty OR b.planned_pieces > b.max_qty
+    ORDER BY b.component_rank NULLS LAST, b.component, b.color, b.size_rank NULLS LAST, b.size;
+$$ LANGUAGE sql STABLE;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,138 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "strings"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestBundlesGeneratedOnTaskCompletion(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    orderNumber := fmt.Sprintf("ORD-%d", now.UnixNano())
+    createOrder := fmt.Sprintf(`{
+        "order_number": "%s",
+        "style_number": "STYLE-BDL-001",
+        "order_start_date": "%s",
+        "items": [{"color":"Black","size":"M","quantity":24},{"color":"Black","size":"L","quantity":12}]
+    }`, orderNumber, now.Format(time.RFC3339))
+    w, _ := doJSONAuth(r, "POST", "/api/v1/orders", createOrder, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-BDL","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-BDL","plan_id":%d,"bundle_size":5}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/layouts/%d/bundle-size", layout.LayoutID), `{"bundle_size":0}`, "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("invalid bundle size want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":2,"L":1}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Black","planned_layers":12}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+    var task models.ProductionTask
+    decodeJSON(t, w, &task)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Black","planned_layers":6}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task 2 want 201 got %d: %s", w.Code, w.Body.String()) }
+    var task2 models.ProductionTask
+    decodeJSON(t, w, &task2)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+
+    // 未完成的任务没有扎包，也不能重建
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":7,"dye_lot":"LOT-1"}`, task.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log 1 want 201 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/bundles", task.TaskID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("list bundles want 200 got %d: %s", w.Code, w.Body.String()) }
+    var bundles []models.Bundle
+    decodeJSON(t, w, &bundles)
+    if len(bundles) != 0 { t.Fatalf("in-progress task must have no bundles: %+v", bundles) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/tasks/%d/bundles/regenerate", task.TaskID), "", "")
+    if w.Code != http.StatusInternalServerError { t.Fatalf("regenerate in-progress want 500 got %d: %s", w.Code, w.Body.String()) }
+
+    // 第二缸号 5 层完成任务：层段 1-7（LOT-1）与 8-12（LOT-2）
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":5,"dye_lot":"LOT-2","allow_lot_mix":true}`, task.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log 2 want 201 got %d: %s", w.Code, w.Body.String()) }
+    var last models.ProductionLog
+    decodeJSON(t, w, &last)
+
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/bundles", task.TaskID), "", "")
+    decodeJSON(t, w, &bundles)
+    // 每段每份次：1-5、6-7 与 8-12 → 3 扎 × (M×2 + L×1) = 9 扎
+    if len(bundles) != 9 { t.Fatalf("want 9 bundles got %d: %+v", len(bundles), bundles) }
+    pieces := map[string]int{}
+    for _, b := range bundles {
+        pieces[b.Size] += b.Pieces
+        if b.PlyTo-b.PlyFrom+1 != b.Pieces || b.Pieces > 5 { t.Fatalf("bad bundle: %+v", b) }
+        if b.DyeLot == nil || (*b.DyeLot == "LOT-1") != (b.PlyTo <= 7) { t.Fatalf("bundle spans lots: %+v", b) }
+        if b.OrderNumber != orderNumber { t.Fatalf("missing ticket fields: %+v", b) }
+    }
+    if pieces["M"] != 24 || pieces["L"] != 12 { t.Fatalf("unexpected pieces per size: %+v", pieces) }
+
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d/bundles?format=csv", plan.PlanID), "", "")
+    if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+        t.Fatalf("csv want 200 text/csv got %d %s", w.Code, w.Header().Get("Content-Type"))
+    }
+    if lines := strings.Count(strings.TrimSpace(w.Body.String()), "\n"); lines != 9 { t.Fatalf("csv want 9 data rows got %d", lines) }
+
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/bundles/print", task.TaskID), "", "")
+    if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), orderNumber) { t.Fatalf("print want tickets got %d", w.Code) }
+
+    // 改为 12 层一扎重建：每段 1 扎 × 3 份次 × 2 段
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/tasks/%d/bundles/regenerate", task.TaskID), `{"bundle_size":12}`, "")
+    if w.Code != http.StatusOK { t.Fatalf("regenerate want 200 got %d: %s", w.Code, w.Body.String()) }
+    decodeJSON(t, w, &bundles)
+    if len(bundles) != 6 { t.Fatalf("want 6 bundles after regenerate got %d", len(bundles)) }
+
+    // 作废日志使任务回退，扎包清除
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/logs/%d", last.LogID), `{"void_reason":"recount"}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("void want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/bundles", task.TaskID), "", "")
+    decodeJSON(t, w, &bundles)
+    if len(bundles) != 0 { t.Fatalf("bundles must be cleared after void: %+v", bundles) }
+
+    // 超裁完成的任务作废一条日志后仍为完成：扎包按剩余层数原地更新，起始层号不变的扎包保留 ID
+    var logs2 []models.ProductionLog
+    for _, n := range []int{3, 2, 4} {
+        w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":%d,"dye_lot":"LOT-3"}`, task2.TaskID, n), "")
+        if w.Code != http.StatusCreated { t.Fatalf("task 2 log want 201 got %d: %s", w.Code, w.Body.String()) }
+        var l models.ProductionLog
+        decodeJSON(t, w, &l)
+        logs2 = append(logs2, l)
+    }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/bundles", task2.TaskID), "", "")
+    decodeJSON(t, w, &bundles)
+    // 层段 1-9：1-5、6-9 → 2 扎 × 3 份次
+    if len(bundles) != 6 { t.Fatalf("task 2 want 6 bundles got %d: %+v", len(bundles), bundles) }
+    ids := map[int]bool{}
+    for _, b := range bundles { ids[b.BundleID] = true }
+
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/logs/%d", logs2[1].LogID), `{"void_reason":"miscount"}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("void task 2 log want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d", task2.TaskID), "", "")
+    decodeJSON(t, w, &task2)
+    if task2.Status != "completed" || task2.CompletedLayers != 7 { t.Fatalf("task 2 should stay completed with 7 layers: %+v", task2) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/bundles", task2.TaskID), "", "")
+    decodeJSON(t, w, &bundles)
+    // 层段 1-7：1-5、6-7，扎包 ID 不变，件数按剩余层数
+    if len(bundles) != 6 { t.Fatalf("task 2 want 6 bundles after void got %d: %+v", len(bundles), bundles) }
+    pieces = map[string]int{}
+    for _, b := range bundles {
+        if !ids[b.BundleID] { t.Fatalf("bundle rebuilt with a new ID: %+v", b) }
+        if b.PlyFrom == 6 && b.PlyTo != 7 { t.Fatalf("last bundle not shortened: %+v", b) }
+        pieces[b.Size] += b.Pieces
+    }
+    if pieces["M"] != 14 || pieces["L"] != 7 { t.Fatalf("unexpected pieces after void: %+v", pieces) }
+}
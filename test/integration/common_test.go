@@ -70,8 +70,8 @@ func openDBAndMigrate(t *testing.T) *sql.DB {
     ensureDatabaseExists(t, dsn)
     conn, err := db.Open(dsn)
     if err != nil { t.Fatalf("db open: %v", err) }
-    mp := migrationsPath(t, "000001_initial_schema.up.sql")
-    if err := db.RunMigrations(conn, mp); err != nil {
+    mp := migrationsPath(t, "")
+    if err := db.RunAllMigrations(conn, mp); err != nil {
         t.Fatalf("migrate: %v", err)
     }
     return conn
@@ -88,12 +88,33 @@ func buildRouter(conn *sql.DB) *gin.Engine {
     layoutsRepo := repositories.NewSqlLayoutsRepository(conn)
     tasksRepo := repositories.NewSqlTasksRepository(conn)
     logsRepo := repositories.NewSqlLogsRepository(conn)
+    bundlesRepo := repositories.NewSqlBundlesRepository(conn)
+    stylesRepo := repositories.NewSqlStylesRepository(conn)
+    customersRepo := repositories.NewSqlCustomersRepository(conn)
 
-    handlers.NewOrdersHandler(services.NewOrdersService(ordersRepo)).Register(api)
+    handlers.NewOrdersHandler(services.NewOrdersService(ordersRepo, stylesRepo, customersRepo)).Register(api)
     handlers.NewPlansHandler(services.NewPlansService(plansRepo)).Register(api)
     handlers.NewLayoutsHandler(services.NewLayoutsService(layoutsRepo)).Register(api)
     handlers.NewTasksHandler(services.NewTasksService(tasksRepo)).Register(api)
     handlers.NewLogsHandler(services.NewLogsService(logsRepo)).Register(api)
+    handlers.NewCoverageHandler(services.NewCoverageService(plansRepo, ordersRepo, layoutsRepo, tasksRepo, stylesRepo)).Register(api)
+    handlers.NewCutPlanningHandler(services.NewCutPlanningService(ordersRepo, plansRepo)).Register(api)
+    handlers.NewRollsHandler(services.NewRollsService(repositories.NewSqlRollsRepository(conn))).Register(api)
+    handlers.NewBundlesHandler(services.NewBundlesService(bundlesRepo)).Register(api)
+    handlers.NewLabelsHandler(services.NewLabelsService(bundlesRepo, tasksRepo, layoutsRepo, plansRepo, ordersRepo)).Register(api)
+    tablesRepo := repositories.NewSqlTablesRepository(conn)
+    handlers.NewTablesHandler(services.NewTablesService(tablesRepo)).Register(api)
+    handlers.NewTaskAssignmentsHandler(services.NewTaskAssignmentsService(repositories.NewSqlTaskAssignmentsRepository(conn))).Register(api)
+    shiftsRepo := repositories.NewSqlShiftsRepository(conn)
+    shifts, _ := services.ParseShiftWindows("")
+    handlers.NewScheduleHandler(services.NewScheduleService(repositories.NewSqlScheduleRepository(conn), tablesRepo, shiftsRepo, shifts)).Register(api)
+    handlers.NewShiftsHandler(services.NewShiftsService(shiftsRepo)).Register(api)
+    handlers.NewReportsHandler(services.NewReportsService(repositories.NewSqlReportsRepository(conn), shiftsRepo)).Register(api)
+    handlers.NewPayrollHandler(services.NewPayrollService(repositories.NewSqlPayrollRepository(conn))).Register(api)
+    handlers.NewForecastHandler(services.NewForecastService(repositories.NewSqlForecastRepository(conn))).Register(api)
+    handlers.NewAmendmentsHandler(services.NewAmendmentsService(repositories.NewSqlAmendmentsRepository(conn))).Register(api)
+    handlers.NewCustomersHandler(services.NewCustomersService(customersRepo)).Register(api)
+    handlers.NewStylesHandler(services.NewStylesService(stylesRepo, ordersRepo)).Register(api)
     return r
 }
 
@@ -0,0 +1,85 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestPlanCoverage_Matrix(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    orderNumber := fmt.Sprintf("ORD-%d", now.UnixNano())
+
+    // 订单：Red M10/L5，Blue M4
+    createOrder := fmt.Sprintf(`{
+        "order_number": "%s",
+        "style_number": "STYLE-COV-001",
+        "order_start_date": "%s",
+        "items": [
+            {"color":"Red","size":"M","quantity":10},
+            {"color":"Red","size":"L","quantity":5},
+            {"color":"Blue","size":"M","quantity":4}
+        ]
+    }`, orderNumber, now.Format(time.RFC3339))
+    w, _ := doJSONAuth(r, "POST", "/api/v1/orders", createOrder, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-COV","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-COV","plan_id":%d}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":2,"L":1}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+
+    // Red 5 层 → M10 L5；Blue 2 层 → M4 L2（Blue/L 未下单，超裁 2）
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Red","planned_layers":5}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task red want 201 got %d: %s", w.Code, w.Body.String()) }
+    var taskRed models.ProductionTask
+    decodeJSON(t, w, &taskRed)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Blue","planned_layers":2}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task blue want 201 got %d: %s", w.Code, w.Body.String()) }
+
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":3,"worker_name":"张三"}`, taskRed.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log want 201 got %d: %s", w.Code, w.Body.String()) }
+
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d/coverage", plan.PlanID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("coverage want 200 got %d: %s", w.Code, w.Body.String()) }
+    var cov models.PlanCoverage
+    decodeJSON(t, w, &cov)
+
+    cells := map[string]models.CoverageCell{}
+    for _, c := range cov.Cells { cells[c.Color+"/"+c.Size] = c }
+    if c := cells["Red/M"]; c.OrderedQty != 10 || c.PlannedPieces != 10 || c.CutPieces != 6 || c.PlannedDelta != 0 || c.CutDelta != -4 {
+        t.Fatalf("Red/M unexpected: %+v", c)
+    }
+    if c := cells["Red/L"]; c.PlannedPieces != 5 || c.CutPieces != 3 {
+        t.Fatalf("Red/L unexpected: %+v", c)
+    }
+    if c := cells["Blue/L"]; c.OrderedQty != 0 || c.PlannedPieces != 2 || c.PlannedDelta != 2 {
+        t.Fatalf("Blue/L unexpected: %+v", c)
+    }
+    if cov.TotalOrdered != 19 || cov.TotalPlannedPieces != 21 {
+        t.Fatalf("totals unexpected: ordered=%d planned=%d", cov.TotalOrdered, cov.TotalPlannedPieces)
+    }
+
+    // 不存在的计划 → 404
+    w, _ = doJSONAuth(r, "GET", "/api/v1/plans/999999999/coverage", "", "")
+    if w.Code != http.StatusNotFound { t.Fatalf("coverage missing plan want 404 got %d", w.Code) }
+}
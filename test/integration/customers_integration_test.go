@@ -0,0 +1,136 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestCustomersAndOrderReference(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    suffix := fmt.Sprint(now.UnixNano())
+    createCustomer := func(body string) models.Customer {
+        w, _ := doJSONAuth(r, "POST", "/api/v1/customers", body, "")
+        if w.Code != http.StatusCreated { t.Fatalf("create customer want 201 got %d: %s", w.Code, w.Body.String()) }
+        var c models.Customer
+        decodeJSON(t, w, &c)
+        return c
+    }
+    createOrder := func(extra string) models.ProductionOrder {
+        body := fmt.Sprintf(`{"order_number":"ORD-CUS-%d","style_number":"STYLE-CUS-001","order_start_date":"%s"%s,"items":[{"color":"Navy","size":"M","quantity":10}]}`, time.Now().UnixNano(), now.Format(time.RFC3339), extra)
+        w, _ := doJSONAuth(r, "POST", "/api/v1/orders", body, "")
+        if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+        var o models.ProductionOrder
+        decodeJSON(t, w, &o)
+        return o
+    }
+    listOrders := func(query string) []models.ProductionOrder {
+        w, _ := doJSONAuth(r, "GET", "/api/v1/orders"+query, "", "")
+        if w.Code != http.StatusOK { t.Fatalf("list orders want 200 got %d: %s", w.Code, w.Body.String()) }
+        var list []models.ProductionOrder
+        decodeJSON(t, w, &list)
+        return list
+    }
+
+    // 创建客户；编码与名称（忽略大小写）唯一，容差范围校验
+    acme := createCustomer(fmt.Sprintf(`{"customer_code":"ACME-%s","customer_name":"Acme %s","phone":"021-1234","over_cut_tolerance":3,"under_cut_tolerance":1.5}`, suffix, suffix))
+    if acme.CustomerID == 0 || acme.OverCutTolerance == nil || *acme.OverCutTolerance != 3 { t.Fatalf("unexpected customer: %+v", acme) }
+    w, _ := doJSONAuth(r, "POST", "/api/v1/customers", fmt.Sprintf(`{"customer_code":"ACME-%s","customer_name":"Other %s"}`, suffix, suffix), "")
+    if w.Code != http.StatusConflict { t.Fatalf("duplicate code want 409 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/customers", fmt.Sprintf(`{"customer_code":"ACME2-%s","customer_name":"ACME %s"}`, suffix, suffix), "")
+    if w.Code != http.StatusConflict { t.Fatalf("duplicate name (case-insensitive) want 409 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/customers", fmt.Sprintf(`{"customer_code":"BAD-%s","customer_name":"Bad %s","over_cut_tolerance":120}`, suffix, suffix), "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("tolerance out of range want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/customers", `{"customer_code":"","customer_name":""}`, "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("blank code/name want 400 got %d: %s", w.Code, w.Body.String()) }
+    beta := createCustomer(fmt.Sprintf(`{"customer_code":"BETA-%s","customer_name":"Beta %s"}`, suffix, suffix))
+
+    // 查询与更新
+    w, _ = doJSONAuth(r, "GET", "/api/v1/customers?q=ACME-"+suffix, "", "")
+    if w.Code != http.StatusOK { t.Fatalf("list customers want 200 got %d: %s", w.Code, w.Body.String()) }
+    var customers []models.Customer
+    decodeJSON(t, w, &customers)
+    if len(customers) != 1 || customers[0].CustomerID != acme.CustomerID { t.Fatalf("search by code should return acme only: %+v", customers) }
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/customers/%d", beta.CustomerID), fmt.Sprintf(`{"customer_code":"BETA-%s","customer_name":"Beta %s","contact_name":"Li Lei"}`, suffix, suffix), "")
+    if w.Code != http.StatusOK { t.Fatalf("update customer want 200 got %d: %s", w.Code, w.Body.String()) }
+    var updated models.Customer
+    decodeJSON(t, w, &updated)
+    if updated.ContactName == nil || *updated.ContactName != "Li Lei" { t.Fatalf("contact not updated: %+v", updated) }
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/customers/%d", beta.CustomerID), fmt.Sprintf(`{"customer_code":"ACME-%s","customer_name":"Beta %s"}`, suffix, suffix), "")
+    if w.Code != http.StatusConflict { t.Fatalf("update to taken code want 409 got %d: %s", w.Code, w.Body.String()) }
+
+    // 按 customer_id 建单：带入客户名称与默认容差；显式容差优先
+    o1 := createOrder(fmt.Sprintf(`,"customer_id":%d`, acme.CustomerID))
+    if o1.CustomerID == nil || *o1.CustomerID != acme.CustomerID { t.Fatalf("order should reference acme: %+v", o1) }
+    if o1.CustomerName == nil || *o1.CustomerName != acme.CustomerName { t.Fatalf("order should take the customer name: %+v", o1) }
+    if o1.OverCutTolerance == nil || *o1.OverCutTolerance != 3 || o1.UnderCutTolerance == nil || *o1.UnderCutTolerance != 1.5 {
+        t.Fatalf("order should inherit the customer tolerance: %+v", o1)
+    }
+    o2 := createOrder(fmt.Sprintf(`,"customer_id":%d,"over_cut_tolerance":5`, acme.CustomerID))
+    if o2.OverCutTolerance == nil || *o2.OverCutTolerance != 5 { t.Fatalf("explicit tolerance should win: %+v", o2) }
+
+    // 仅提供 customer_name：按名称（忽略大小写与首尾空白）匹配客户
+    o3 := createOrder(fmt.Sprintf(`,"customer_name":"  beta %s "`, suffix))
+    if o3.CustomerID == nil || *o3.CustomerID != beta.CustomerID { t.Fatalf("order should map to beta by name: %+v", o3) }
+    o4 := createOrder(`,"customer_name":"Unknown Buyer ` + suffix + `"`)
+    if o4.CustomerID != nil { t.Fatalf("unmatched name should leave customer_id empty: %+v", o4) }
+
+    // 订单列表按客户过滤
+    list := listOrders(fmt.Sprintf("?customer_id=%d", acme.CustomerID))
+    ids := map[int]bool{}
+    for _, o := range list {
+        if o.CustomerID == nil || *o.CustomerID != acme.CustomerID { t.Fatalf("filter returned another customer's order: %+v", o) }
+        ids[o.OrderID] = true
+    }
+    if len(list) != 2 || !ids[o1.OrderID] || !ids[o2.OrderID] { t.Fatalf("acme filter want orders %d,%d got %+v", o1.OrderID, o2.OrderID, list) }
+    if l := listOrders(fmt.Sprintf("?customer_id=%d&status=confirmed", acme.CustomerID)); len(l) != 0 { t.Fatalf("combined filter should be empty: %+v", l) }
+    w, _ = doJSONAuth(r, "GET", "/api/v1/orders?customer_id=abc", "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("bad customer_id want 400 got %d: %s", w.Code, w.Body.String()) }
+
+    // 改挂客户
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/orders/%d/customer", o4.OrderID), fmt.Sprintf(`{"customer_id":%d}`, beta.CustomerID), "")
+    if w.Code != http.StatusOK { t.Fatalf("reassign customer want 200 got %d: %s", w.Code, w.Body.String()) }
+    var reassigned models.ProductionOrder
+    decodeJSON(t, w, &reassigned)
+    if reassigned.CustomerID == nil || *reassigned.CustomerID != beta.CustomerID { t.Fatalf("order should now reference beta: %+v", reassigned) }
+    if l := listOrders(fmt.Sprintf("?customer_id=%d", beta.CustomerID)); len(l) != 2 { t.Fatalf("beta should have 2 orders, got %d", len(l)) }
+    w, _ = doJSONAuth(r, "PATCH", "/api/v1/orders/999999999/customer", fmt.Sprintf(`{"customer_id":%d}`, beta.CustomerID), "")
+    if w.Code != http.StatusNotFound { t.Fatalf("reassign missing order want 404 got %d: %s", w.Code, w.Body.String()) }
+    // 不存在的客户：建单与改挂均为 400，不依赖外键报错
+    w, _ = doJSONAuth(r, "POST", "/api/v1/orders", fmt.Sprintf(`{"order_number":"ORD-CUS-%d","style_number":"STYLE-CUS-001","order_start_date":"%s","customer_id":999999999,"items":[{"color":"Navy","size":"M","quantity":10}]}`, time.Now().UnixNano(), now.Format(time.RFC3339)), "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("create order with unknown customer want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/orders/%d/customer", o4.OrderID), `{"customer_id":999999999}`, "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("reassign to unknown customer want 400 got %d: %s", w.Code, w.Body.String()) }
+
+    // 订单数统计；被引用的客户不可删除
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/customers/%d", acme.CustomerID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("get customer want 200 got %d: %s", w.Code, w.Body.String()) }
+    var got models.Customer
+    decodeJSON(t, w, &got)
+    if got.Orders != 2 { t.Fatalf("acme should count 2 orders, got %d", got.Orders) }
+    w, _ = doJSONAuth(r, "DELETE", fmt.Sprintf("/api/v1/customers/%d", acme.CustomerID), "", "")
+    if w.Code != http.StatusConflict { t.Fatalf("delete referenced customer want 409 got %d: %s", w.Code, w.Body.String()) }
+    spare := createCustomer(fmt.Sprintf(`{"customer_code":"SPARE-%s","customer_name":"Spare %s"}`, suffix, suffix))
+    w, _ = doJSONAuth(r, "DELETE", fmt.Sprintf("/api/v1/customers/%d", spare.CustomerID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("delete unused customer want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/customers/%d", spare.CustomerID), "", "")
+    if w.Code != http.StatusNotFound { t.Fatalf("deleted customer want 404 got %d: %s", w.Code, w.Body.String()) }
+
+    // 报表按客户过滤
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/reports/shifts?customer_id=%d", acme.CustomerID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("shift report by customer want 200 got %d: %s", w.Code, w.Body.String()) }
+    var shifts models.ShiftReport
+    decodeJSON(t, w, &shifts)
+    if shifts.CustomerID == nil || *shifts.CustomerID != acme.CustomerID || shifts.Layers != 0 { t.Fatalf("acme has no logs yet: %+v", shifts) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/orders/at-risk?all=true&customer_id=%d", beta.CustomerID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("at-risk by customer want 200 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", "/api/v1/reports/productivity?customer_id=0", "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("invalid customer_id want 400 got %d: %s", w.Code, w.Body.String()) }
+}
@@ -0,0 +1,62 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestCutPlanning_GenerateDraft(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    orderNumber := fmt.Sprintf("ORD-%d", now.UnixNano())
+    createOrder := fmt.Sprintf(`{
+        "order_number": "%s",
+        "style_number": "STYLE-COP-001",
+        "order_start_date": "%s",
+        "items": [
+            {"color":"Red","size":"S","quantity":40},
+            {"color":"Red","size":"M","quantity":80},
+            {"color":"Red","size":"L","quantity":40},
+            {"color":"Blue","size":"M","quantity":30}
+        ]
+    }`, orderNumber, now.Format(time.RFC3339))
+    w, _ := doJSONAuth(r, "POST", "/api/v1/orders", createOrder, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+
+    // dry_run 仅返回方案，不写库
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans/draft", fmt.Sprintf(`{"order_id":%d,"max_plies":50,"max_garments_per_marker":4,"dry_run":true}`, order.OrderID), "")
+    if w.Code != http.StatusOK { t.Fatalf("dry run want 200 got %d: %s", w.Code, w.Body.String()) }
+
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans/draft", fmt.Sprintf(`{"order_id":%d,"max_plies":50,"max_garments_per_marker":4}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("generate draft want 201 got %d: %s", w.Code, w.Body.String()) }
+    var resp struct {
+        Plan     models.ProductionPlan   `json:"plan"`
+        Proposal models.CutPlanProposal  `json:"proposal"`
+    }
+    decodeJSON(t, w, &resp)
+    if resp.Plan.Status != "pending" || resp.Proposal.MarkerCount == 0 {
+        t.Fatalf("unexpected draft: plan=%+v markers=%d", resp.Plan, resp.Proposal.MarkerCount)
+    }
+
+    // 草稿写入后，覆盖矩阵应与方案一致：每格不短缺且在容差内（默认 0%）
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d/coverage", resp.Plan.PlanID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("coverage want 200 got %d: %s", w.Code, w.Body.String()) }
+    var cov models.PlanCoverage
+    decodeJSON(t, w, &cov)
+    for _, c := range cov.Cells {
+        if c.PlannedDelta != 0 { t.Fatalf("cell %s/%s not exact: %+v", c.Color, c.Size, c) }
+    }
+    if cov.TotalPlannedPieces != 190 { t.Fatalf("total planned want 190 got %d", cov.TotalPlannedPieces) }
+
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans/draft", `{"order_id":999999999}`, "")
+    if w.Code != http.StatusNotFound { t.Fatalf("missing order want 404 got %d", w.Code) }
+}
@@ -0,0 +1,87 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestFabricRequirements(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    orderNumber := fmt.Sprintf("ORD-%d", now.UnixNano())
+    createOrder := fmt.Sprintf(`{
+        "order_number": "%s",
+        "style_number": "STYLE-FAB-001",
+        "order_start_date": "%s",
+        "items": [
+            {"color":"Red","size":"M","quantity":20},
+            {"color":"Blue","size":"M","quantity":10}
+        ]
+    }`, orderNumber, now.Format(time.RFC3339))
+    w, _ := doJSONAuth(r, "POST", "/api/v1/orders", createOrder, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-FAB","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+
+    // 唛架 2.5 米 + 两端损耗 0.05 米，门幅 150 厘米
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-FAB","plan_id":%d,"marker_length":2.5,"fabric_width":150,"marker_efficiency":82.5,"end_loss_allowance":0.05}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":1}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Red","planned_layers":20}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+
+    // 第二个布局暂无唛架长度
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-FAB-2","plan_id":%d}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout2 want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout2 models.CuttingLayout
+    decodeJSON(t, w, &layout2)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout2.LayoutID), `{"ratios":{"M":1}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios2 want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Blue","planned_layers":10}`, layout2.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task2 want 201 got %d: %s", w.Code, w.Body.String()) }
+
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d/fabric", plan.PlanID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("plan fabric want 200 got %d: %s", w.Code, w.Body.String()) }
+    var fab models.FabricRequirement
+    decodeJSON(t, w, &fab)
+    if fab.TotalPlannedLength != 51 || len(fab.MissingMarkerTasks) != 1 || len(fab.ByColor) != 1 {
+        t.Fatalf("unexpected plan fabric: %+v", fab)
+    }
+
+    // 补充唛架参数后订单级合计包含两个布局：20×2.55 + 10×1.8
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/layouts/%d/marker", layout2.LayoutID), `{"marker_length":1.8,"fabric_width":145}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("update marker want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/layouts/%d/marker", layout2.LayoutID), `{"marker_efficiency":120}`, "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("invalid efficiency want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/orders/%d/fabric", order.OrderID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("order fabric want 200 got %d: %s", w.Code, w.Body.String()) }
+    decodeJSON(t, w, &fab)
+    if fab.TotalPlannedLength != 69 || len(fab.MissingMarkerTasks) != 0 || len(fab.ByColor) != 2 {
+        t.Fatalf("unexpected order fabric: %+v", fab)
+    }
+
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/layouts/%d/fabric", layout.LayoutID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("layout fabric want 200 got %d: %s", w.Code, w.Body.String()) }
+    decodeJSON(t, w, &fab)
+    if len(fab.Lines) != 1 || fab.Lines[0].PlannedLength == nil || *fab.Lines[0].PlannedLength != 51 {
+        t.Fatalf("unexpected layout fabric: %+v", fab)
+    }
+
+    w, _ = doJSONAuth(r, "GET", "/api/v1/orders/999999999/fabric", "", "")
+    if w.Code != http.StatusNotFound { t.Fatalf("missing order want 404 got %d", w.Code) }
+}
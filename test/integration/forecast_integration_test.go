@@ -0,0 +1,75 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestOrdersAtRiskForecast(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    suffix := time.Now().UnixNano()
+    // 建订单 + 计划 + 布局 + 单个任务；返回订单与任务
+    setup := func(tag, finish string, layers int) (models.ProductionOrder, models.ProductionPlan, models.ProductionTask) {
+        body := fmt.Sprintf(`{"order_number":"ORD-%d-%s","style_number":"STYLE-FC-001","order_finish_date":"%s","items":[{"color":"Grey","size":"M","quantity":%d}]}`, suffix, tag, finish, layers)
+        w, _ := doJSONAuth(r, "POST", "/api/v1/orders", body, "")
+        if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+        var order models.ProductionOrder
+        decodeJSON(t, w, &order)
+        w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-FC-%s","order_id":%d}`, tag, order.OrderID), "")
+        var plan models.ProductionPlan
+        decodeJSON(t, w, &plan)
+        w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-FC-%s","plan_id":%d}`, tag, plan.PlanID), "")
+        var layout models.CuttingLayout
+        decodeJSON(t, w, &layout)
+        w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":1}}`, "")
+        if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+        w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Grey","planned_layers":%d}`, layout.LayoutID, layers), "")
+        if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+        var task models.ProductionTask
+        decodeJSON(t, w, &task)
+        return order, plan, task
+    }
+    // 已过交期且有剩余层数 → late；交期极远 → on_track
+    late, _, _ := setup("LATE", "2000-01-01T00:00:00Z", 10)
+    ok, okPlan, okTask := setup("OK", "2999-01-01T00:00:00Z", 10)
+    w, _ := doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", okPlan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":4}`, okTask.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log want 201 got %d: %s", w.Code, w.Body.String()) }
+
+    find := func(rep models.OrderRiskReport, id int) *models.OrderForecast {
+        for i := range rep.Orders {
+            if rep.Orders[i].OrderID == id { return &rep.Orders[i] }
+        }
+        return nil
+    }
+    w, _ = doJSONAuth(r, "GET", "/api/v1/orders/at-risk", "", "")
+    if w.Code != http.StatusOK { t.Fatalf("at-risk want 200 got %d: %s", w.Code, w.Body.String()) }
+    var rep models.OrderRiskReport
+    decodeJSON(t, w, &rep)
+    if rep.LookbackDays != 14 || rep.LayersPerDay <= 0 { t.Fatalf("unexpected throughput: %+v", rep) }
+    f := find(rep, late.OrderID)
+    if f == nil || f.Status != "late" || f.RemainingLayers != 10 || f.ForecastFinishDate == nil || f.SlackHours == nil || *f.SlackHours >= 0 {
+        t.Fatalf("late order forecast: %+v", f)
+    }
+    if find(rep, ok.OrderID) != nil { t.Fatalf("on-track order must be omitted by default") }
+
+    // all=true 含 on_track；每次请求按最新日志重算
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":2}`, okTask.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log want 201 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", "/api/v1/orders/at-risk?all=true", "", "")
+    if w.Code != http.StatusOK { t.Fatalf("at-risk all want 200 got %d: %s", w.Code, w.Body.String()) }
+    rep = models.OrderRiskReport{}
+    decodeJSON(t, w, &rep)
+    f = find(rep, ok.OrderID)
+    if f == nil || f.Status != "on_track" || f.RemainingLayers != 4 || f.QueuedLayers < 4 || f.ForecastFinishDate == nil {
+        t.Fatalf("on-track order forecast: %+v", f)
+    }
+}
@@ -0,0 +1,110 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "net/url"
+    "strings"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/labels"
+    "cutrix-backend/internal/models"
+)
+
+func TestLabelsRenderAndLookup(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    orderNumber := fmt.Sprintf("ORD-%d", now.UnixNano())
+    createOrder := fmt.Sprintf(`{
+        "order_number": "%s",
+        "style_number": "STYLE-LBL-001",
+        "order_start_date": "%s",
+        "items": [{"color":"黑色","size":"M","quantity":4},{"color":"黑色","size":"L","quantity":2}]
+    }`, orderNumber, now.Format(time.RFC3339))
+    w, _ := doJSONAuth(r, "POST", "/api/v1/orders", createOrder, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-LBL","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-LBL","plan_id":%d}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":2,"L":1}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"黑色","planned_layers":2}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+    var task models.ProductionTask
+    decodeJSON(t, w, &task)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+
+    // 任务卡：默认 PDF + Code128；ZPL + QR 由打印机绘码
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/label", task.TaskID), "", "")
+    if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/pdf" || !strings.HasPrefix(w.Body.String(), "%PDF-") {
+        t.Fatalf("task pdf label want 200 application/pdf got %d %s", w.Code, w.Header().Get("Content-Type"))
+    }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/label?format=zpl&symbology=qr", task.TaskID), "", "")
+    if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "^BQN") { t.Fatalf("task zpl qr label want ^BQ got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/label?format=pdf&symbology=qr", task.TaskID), "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("pdf qr want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/label?format=png", task.TaskID), "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("unknown format want 400 got %d: %s", w.Code, w.Body.String()) }
+
+    // 任务尚无扎包
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/bundles/labels", task.TaskID), "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("labels without bundles want 400 got %d: %s", w.Code, w.Body.String()) }
+
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":2}`, task.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log want 201 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/bundles", task.TaskID), "", "")
+    var bundles []models.Bundle
+    decodeJSON(t, w, &bundles)
+    if len(bundles) != 3 { t.Fatalf("want 3 bundles got %d", len(bundles)) }
+
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/bundles/labels?format=zpl", task.TaskID), "", "")
+    if w.Code != http.StatusOK || strings.Count(w.Body.String(), "^XA") != 3 || !strings.Contains(w.Body.String(), "^BCN") {
+        t.Fatalf("bundle zpl labels want 3 Code128 labels got %d: %s", w.Code, w.Body.String())
+    }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/bundles/%d/label", bundles[0].BundleID), "", "")
+    if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/pdf" { t.Fatalf("bundle pdf label want 200 got %d", w.Code) }
+
+    // 扫码：扎包与任务卡载荷均解析回原记录
+    b := bundles[0]
+    code := labels.Payload{Kind: labels.KindBundle, ID: b.BundleID, OrderNumber: orderNumber, StyleNumber: "STYLE-LBL-001", Color: b.Color, Size: b.Size}.Encode()
+    w, _ = doJSONAuth(r, "GET", "/api/v1/labels/lookup?code="+url.QueryEscape(code), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("lookup bundle want 200 got %d: %s", w.Code, w.Body.String()) }
+    var found models.LabelLookup
+    decodeJSON(t, w, &found)
+    if found.Kind != "bundle" || found.Bundle == nil || found.Bundle.BundleID != b.BundleID || found.Stale {
+        t.Fatalf("unexpected bundle lookup: %+v", found)
+    }
+
+    code = labels.Payload{Kind: labels.KindTask, ID: task.TaskID, OrderNumber: orderNumber, StyleNumber: "STYLE-LBL-001", Color: "黑色", Size: "M/L"}.Encode()
+    w, _ = doJSONAuth(r, "GET", "/api/v1/labels/lookup?code="+url.QueryEscape(code), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("lookup task want 200 got %d: %s", w.Code, w.Body.String()) }
+    found = models.LabelLookup{}
+    decodeJSON(t, w, &found)
+    if found.Kind != "task" || found.Task == nil || found.Task.TaskID != task.TaskID || found.OrderNumber != orderNumber {
+        t.Fatalf("unexpected task lookup: %+v", found)
+    }
+    // 订单号与当前记录不一致的标签标记为过期
+    code = labels.Payload{Kind: labels.KindTask, ID: task.TaskID, OrderNumber: "OLD", StyleNumber: "STYLE-LBL-001", Color: "黑色", Size: "M/L"}.Encode()
+    w, _ = doJSONAuth(r, "GET", "/api/v1/labels/lookup?code="+url.QueryEscape(code), "", "")
+    found = models.LabelLookup{}
+    decodeJSON(t, w, &found)
+    if !found.Stale { t.Fatalf("mismatched label must be stale: %+v", found) }
+
+    w, _ = doJSONAuth(r, "GET", "/api/v1/labels/lookup?code=hello", "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("foreign code want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", "/api/v1/labels/lookup?code="+url.QueryEscape("CTX1;B;999999999;x;x;x;x"), "", "")
+    if w.Code != http.StatusNotFound { t.Fatalf("missing bundle want 404 got %d: %s", w.Code, w.Body.String()) }
+}
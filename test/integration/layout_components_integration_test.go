@@ -0,0 +1,188 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "strings"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestLayoutComponents(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    styleNumber := fmt.Sprintf("STY-CMP-%d", now.UnixNano())
+    w, _ := doJSONAuth(r, "POST", "/api/v1/styles", fmt.Sprintf(`{"style_number":"%s","sizes":["M","L"],"components":[{"component_name":"shell","pieces":2},{"component_name":"lining"}]}`, styleNumber), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create style want 201 got %d: %s", w.Code, w.Body.String()) }
+    body := fmt.Sprintf(`{"order_number":"ORD-%d-CMP","style_number":"%s","order_start_date":"%s","over_cut_tolerance":10,"under_cut_tolerance":0,"items":[{"color":"Navy","size":"M","quantity":10},{"color":"Navy","size":"L","quantity":10}]}`, now.UnixNano(), styleNumber, now.Format(time.RFC3339))
+    w, _ = doJSONAuth(r, "POST", "/api/v1/orders", body, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-CMP","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+
+    createLayout := func(name, component string) (int, models.CuttingLayout) {
+        w, _ := doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"%s","plan_id":%d,"component":"%s"}`, name, plan.PlanID, component), "")
+        var l models.CuttingLayout
+        if w.Code == http.StatusCreated { decodeJSON(t, w, &l) }
+        return w.Code, l
+    }
+    createTask := func(layoutID, layers int) models.ProductionTask {
+        w, _ := doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Navy","planned_layers":%d}`, layoutID, layers), "")
+        if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+        var task models.ProductionTask
+        decodeJSON(t, w, &task)
+        return task
+    }
+
+    // 部件：空取款式首个部件；须属于款式部件
+    code, shell := createLayout("L-CMP-A", "")
+    if code != http.StatusCreated || shell.Component != "shell" { t.Fatalf("default component want shell got %d %+v", code, shell) }
+    code, lining := createLayout("L-CMP-B", " lining ")
+    if code != http.StatusCreated || lining.Component != "lining" { t.Fatalf("lining layout want 201 got %d %+v", code, lining) }
+    if code, _ := createLayout("L-CMP-C", "pocket"); code == http.StatusCreated { t.Fatalf("component outside the style must be rejected") }
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/layouts/%d/component", lining.LayoutID), `{"component":"pocket"}`, "")
+    if w.Code == http.StatusNoContent { t.Fatalf("update to component outside the style must be rejected") }
+    for _, l := range []models.CuttingLayout{shell, lining} {
+        w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", l.LayoutID), `{"ratios":{"M":1,"L":1}}`, "")
+        if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    }
+
+    // 容差按部件：大身与里布各 10 件不相加；里布 12 层超出上限 11
+    shellTask := createTask(shell.LayoutID, 10)
+    liningTask := createTask(lining.LayoutID, 12)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"component":"lining"`) || strings.Contains(w.Body.String(), `"component":"shell"`) {
+        t.Fatalf("lining over-cut want 400 on lining only got %d: %s", w.Code, w.Body.String())
+    }
+    w, _ = doJSONAuth(r, "DELETE", fmt.Sprintf("/api/v1/tasks/%d", liningTask.TaskID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("delete task want 204 got %d: %s", w.Code, w.Body.String()) }
+    liningTask = createTask(lining.LayoutID, 10)
+
+    // 覆盖度：各部件单独给出，总矩阵按整件（取最小值）
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d/coverage", plan.PlanID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("coverage want 200 got %d: %s", w.Code, w.Body.String()) }
+    var cov models.PlanCoverage
+    decodeJSON(t, w, &cov)
+    if len(cov.Components) != 2 || cov.Components[0].Component != "shell" || cov.Components[1].Component != "lining" {
+        t.Fatalf("coverage components want shell, lining got %+v", cov.Components)
+    }
+    if cov.TotalPlannedPieces != 20 || cov.Components[0].TotalPlannedPieces != 20 || cov.Components[1].TotalPlannedPieces != 20 {
+        t.Fatalf("coverage should count garments, not shell + lining pieces: %+v", cov)
+    }
+
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/layouts/%d/component", lining.LayoutID), `{"component":"shell"}`, "")
+    if w.Code == http.StatusNoContent { t.Fatalf("component change after publish must be rejected") }
+
+    // 完成：大身裁完但里布未完成时计划仍在进行中
+    getPlan := func() models.ProductionPlan {
+        w, _ := doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d", plan.PlanID), "", "")
+        var p models.ProductionPlan
+        decodeJSON(t, w, &p)
+        return p
+    }
+    logLayers := func(taskID, layers int) {
+        w, _ := doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":%d}`, taskID, layers), "")
+        if w.Code != http.StatusCreated { t.Fatalf("log want 201 got %d: %s", w.Code, w.Body.String()) }
+    }
+    logLayers(shellTask.TaskID, 10)
+    if p := getPlan(); p.Status != "in_progress" { t.Fatalf("plan with lining outstanding should stay in_progress: %+v", p) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/orders/%d/progress", order.OrderID), "", "")
+    var progress models.OrderProgress
+    decodeJSON(t, w, &progress)
+    if progress.TotalPlannedPieces != 20 || progress.TotalCutPieces != 0 || progress.Plans[0].PlannedPieces != 20 || progress.Plans[0].CutPieces != 0 {
+        t.Fatalf("progress should count whole garments: %+v", progress)
+    }
+    logLayers(liningTask.TaskID, 10)
+    if p := getPlan(); p.Status != "completed" || p.PlannedFinishDate == nil { t.Fatalf("plan should be completed once every component is cut: %+v", p) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/orders/%d/progress", order.OrderID), "", "")
+    decodeJSON(t, w, &progress)
+    if progress.TotalCutPieces != 20 || progress.PercentComplete != 100 { t.Fatalf("order should be fully cut: %+v", progress) }
+
+    // 用布按部件分列
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d/fabric", plan.PlanID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("fabric want 200 got %d: %s", w.Code, w.Body.String()) }
+    var fabric models.FabricRequirement
+    decodeJSON(t, w, &fabric)
+    if len(fabric.Lines) != 2 || fabric.Lines[0].Component != "shell" || fabric.Lines[1].Component != "lining" {
+        t.Fatalf("fabric lines should carry the component: %+v", fabric.Lines)
+    }
+}
+
+// 款式登记的部件没有布局时同样计入：发布按容差判短缺，覆盖度与进度按整件为 0，计划不因面布裁完而完成
+func TestLayoutComponents_StyleComponentWithoutLayouts(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    styleNumber := fmt.Sprintf("STY-CMQ-%d", now.UnixNano())
+    w, _ := doJSONAuth(r, "POST", "/api/v1/styles", fmt.Sprintf(`{"style_number":"%s","sizes":["M","L"],"components":[{"component_name":"shell"},{"component_name":"lining"}]}`, styleNumber), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create style want 201 got %d: %s", w.Code, w.Body.String()) }
+
+    // 只有大身布局与任务的计划
+    shellOnlyPlan := func(suffix, tolerance string) (models.ProductionOrder, models.ProductionPlan, models.ProductionTask) {
+        body := fmt.Sprintf(`{"order_number":"ORD-%d-%s","style_number":"%s","order_start_date":"%s"%s,"items":[{"color":"Navy","size":"M","quantity":10},{"color":"Navy","size":"L","quantity":10}]}`, time.Now().UnixNano(), suffix, styleNumber, now.Format(time.RFC3339), tolerance)
+        w, _ := doJSONAuth(r, "POST", "/api/v1/orders", body, "")
+        if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+        var order models.ProductionOrder
+        decodeJSON(t, w, &order)
+        w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-%s","order_id":%d}`, suffix, order.OrderID), "")
+        if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+        var plan models.ProductionPlan
+        decodeJSON(t, w, &plan)
+        w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-%s","plan_id":%d,"component":"shell"}`, suffix, plan.PlanID), "")
+        if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+        var layout models.CuttingLayout
+        decodeJSON(t, w, &layout)
+        w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":1,"L":1}}`, "")
+        if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+        w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Navy","planned_layers":10}`, layout.LayoutID), "")
+        if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+        var task models.ProductionTask
+        decodeJSON(t, w, &task)
+        return order, plan, task
+    }
+
+    // 有短缺容差：未排里布的每个单元都短缺，发布被拒
+    _, plan, _ := shellOnlyPlan("CMQ-A", `,"under_cut_tolerance":0`)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusBadRequest || strings.Count(w.Body.String(), `"component":"lining"`) != 2 || strings.Contains(w.Body.String(), `"component":"shell"`) {
+        t.Fatalf("missing lining want 400 short on both lining cells got %d: %s", w.Code, w.Body.String())
+    }
+
+    // 无容差：可发布，但整件口径为 0，面布裁完计划仍在进行中
+    order, plan, task := shellOnlyPlan("CMQ-B", "")
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d/coverage", plan.PlanID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("coverage want 200 got %d: %s", w.Code, w.Body.String()) }
+    var cov models.PlanCoverage
+    decodeJSON(t, w, &cov)
+    if len(cov.Components) != 2 || cov.Components[1].Component != "lining" || cov.Components[1].TotalPlannedPieces != 0 || cov.Components[0].TotalPlannedPieces != 20 || cov.TotalPlannedPieces != 0 {
+        t.Fatalf("coverage should list the unplanned lining and count 0 garments: %+v", cov)
+    }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish without tolerance want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":10}`, task.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log want 201 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d", plan.PlanID), "", "")
+    var got models.ProductionPlan
+    decodeJSON(t, w, &got)
+    if got.Status != "in_progress" || got.PlannedFinishDate != nil { t.Fatalf("plan without lining should stay in_progress: %+v", got) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/orders/%d/progress", order.OrderID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("progress want 200 got %d: %s", w.Code, w.Body.String()) }
+    var progress models.OrderProgress
+    decodeJSON(t, w, &progress)
+    if progress.TotalPlannedPieces != 0 || progress.TotalCutPieces != 0 || progress.Plans[0].PlannedPieces != 0 || progress.Plans[0].CutPieces != 0 {
+        t.Fatalf("progress should count 0 whole garments without lining: %+v", progress)
+    }
+}
@@ -0,0 +1,93 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestDyeLotSegregation(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    orderNumber := fmt.Sprintf("ORD-%d", now.UnixNano())
+    createOrder := fmt.Sprintf(`{
+        "order_number": "%s",
+        "style_number": "STYLE-LOT-001",
+        "order_start_date": "%s",
+        "items": [{"color":"Navy","size":"M","quantity":20},{"color":"Navy","size":"L","quantity":10}]
+    }`, orderNumber, now.Format(time.RFC3339))
+    w, _ := doJSONAuth(r, "POST", "/api/v1/orders", createOrder, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-LOT","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-LOT","plan_id":%d}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":2,"L":1}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Navy","planned_layers":10}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+    var task models.ProductionTask
+    decodeJSON(t, w, &task)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":4,"worker_name":"lot-worker","dye_lot":"LOT-A"}`, task.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log lot A want 201 got %d: %s", w.Code, w.Body.String()) }
+
+    // 混入第二个缸号被拒绝，且任务层数不变
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":2,"dye_lot":"LOT-B"}`, task.TaskID), "")
+    if w.Code != http.StatusConflict { t.Fatalf("lot mix want 409 got %d: %s", w.Code, w.Body.String()) }
+    var mix struct {
+        Error        string   `json:"error"`
+        ExistingLots []string `json:"existing_lots"`
+    }
+    decodeJSON(t, w, &mix)
+    if mix.Error != "lot_mix" || len(mix.ExistingLots) != 1 || mix.ExistingLots[0] != "LOT-A" {
+        t.Fatalf("unexpected lot mix body: %+v", mix)
+    }
+
+    // 显式允许混缸：成功并返回警告
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":2,"dye_lot":"LOT-B","allow_lot_mix":true}`, task.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("allowed lot mix want 201 got %d: %s", w.Code, w.Body.String()) }
+    var mixed models.ProductionLog
+    decodeJSON(t, w, &mixed)
+    if len(mixed.Warnings) != 1 || !mixed.AllowLotMix { t.Fatalf("expected lot mix warning: %+v", mixed) }
+
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/lots", task.TaskID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("task lots want 200 got %d: %s", w.Code, w.Body.String()) }
+    var lots []models.TaskLotLayers
+    decodeJSON(t, w, &lots)
+    if len(lots) != 2 || lots[0].DyeLot != "LOT-A" || lots[0].CompletedLayers != 4 || lots[1].CompletedLayers != 2 {
+        t.Fatalf("unexpected task lots: %+v", lots)
+    }
+
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d/coverage", plan.PlanID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("coverage want 200 got %d: %s", w.Code, w.Body.String()) }
+    var cov models.PlanCoverage
+    decodeJSON(t, w, &cov)
+    for _, c := range cov.Cells {
+        if c.Size == "M" && (c.CutByLot["LOT-A"] != 8 || c.CutByLot["LOT-B"] != 4) {
+            t.Fatalf("unexpected cut_by_lot for M: %+v", c)
+        }
+    }
+
+    // 作废混入的日志后，任务回到单一缸号
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/logs/%d", mixed.LogID), `{"void_reason":"shade mix"}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("void log want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/lots", task.TaskID), "", "")
+    decodeJSON(t, w, &lots)
+    if len(lots) != 1 || lots[0].DyeLot != "LOT-A" { t.Fatalf("unexpected lots after void: %+v", lots) }
+}
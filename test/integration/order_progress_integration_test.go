@@ -0,0 +1,130 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestOrderProgressRollup(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    suffix := now.UnixNano()
+    createOrder := func(number, items string) models.ProductionOrder {
+        body := fmt.Sprintf(`{"order_number":"%s","style_number":"STYLE-PRG-001","order_start_date":"%s","items":%s}`, number, now.Format(time.RFC3339), items)
+        w, _ := doJSONAuth(r, "POST", "/api/v1/orders", body, "")
+        if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+        var o models.ProductionOrder
+        decodeJSON(t, w, &o)
+        return o
+    }
+    order := createOrder(fmt.Sprintf("ORD-%d-A", suffix), `[{"color":"Navy","size":"M","quantity":10},{"color":"Navy","size":"L","quantity":10}]`)
+    other := createOrder(fmt.Sprintf("ORD-%d-B", suffix), `[{"color":"Red","size":"S","quantity":5}]`)
+
+    getPlan := func(id int) models.ProductionPlan {
+        w, _ := doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d", id), "", "")
+        if w.Code != http.StatusOK { t.Fatalf("get plan want 200 got %d: %s", w.Code, w.Body.String()) }
+        var plan models.ProductionPlan
+        decodeJSON(t, w, &plan)
+        return plan
+    }
+    getProgress := func() models.OrderProgress {
+        w, _ := doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/orders/%d/progress", order.OrderID), "", "")
+        if w.Code != http.StatusOK { t.Fatalf("progress want 200 got %d: %s", w.Code, w.Body.String()) }
+        var p models.OrderProgress
+        decodeJSON(t, w, &p)
+        return p
+    }
+    sameTime := func(got, want *time.Time) bool { return got != nil && want != nil && got.Equal(*want) }
+    addPlan := func(name, ratios string, layers int) (models.ProductionPlan, models.ProductionTask) {
+        w, _ := doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"%s","order_id":%d}`, name, order.OrderID), "")
+        if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+        var plan models.ProductionPlan
+        decodeJSON(t, w, &plan)
+        w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-%s","plan_id":%d}`, name, plan.PlanID), "")
+        var layout models.CuttingLayout
+        decodeJSON(t, w, &layout)
+        w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), ratios, "")
+        if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+        w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Navy","planned_layers":%d}`, layout.LayoutID, layers), "")
+        var task models.ProductionTask
+        decodeJSON(t, w, &task)
+        return plan, task
+    }
+    // 计划 1：M2/L1 × 10 层；计划 2：M1 × 4 层（未发布）
+    plan1, task1 := addPlan("PRG-1", `{"ratios":{"M":2,"L":1}}`, 10)
+    plan2, _ := addPlan("PRG-2", `{"ratios":{"M":1}}`, 4)
+    // 未发布计划带有更早的发布日期（如导入数据），不应计入订单最早发布日期
+    tx, err := conn.Begin()
+    if err != nil { t.Fatalf("begin: %v", err) }
+    if _, err := tx.Exec(`SET LOCAL cutrix.plan_adjustment_flag = true`); err != nil { t.Fatalf("set flag: %v", err) }
+    if _, err := tx.Exec(`UPDATE production.plans SET planned_publish_date = '2026-01-05T00:00:00Z' WHERE plan_id = $1`, plan2.PlanID); err != nil { t.Fatalf("stamp pending plan: %v", err) }
+    if err := tx.Commit(); err != nil { t.Fatalf("commit: %v", err) }
+    w, _ := doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan1.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":6}`, task1.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log want 201 got %d: %s", w.Code, w.Body.String()) }
+
+    p := getProgress()
+    // 已裁 M 12（封顶 10）+ L 6 = 16 / 20
+    if p.TotalOrdered != 20 || p.TotalPlannedPieces != 34 || p.TotalCutPieces != 18 || p.PercentComplete != 80 {
+        t.Fatalf("unexpected totals: %+v", p)
+    }
+    if len(p.Cells) != 2 || p.Cells[0].Size != "M" || p.Cells[0].CutPieces != 12 || p.Cells[0].CutDelta != 2 || p.Cells[1].PlannedPieces != 10 {
+        t.Fatalf("unexpected cells: %+v", p.Cells)
+    }
+    if len(p.Plans) != 2 || p.PlanStatus["in_progress"] != 1 || p.PlanStatus["pending"] != 1 || p.Plans[0].CompletedLayers != 6 || p.Plans[0].CutPieces != 18 {
+        t.Fatalf("unexpected plans: %+v %+v", p.Plans, p.PlanStatus)
+    }
+    // 最早发布日期取已发布的计划 1；尚无完成的计划，无完成日期
+    plan1 = getPlan(plan1.PlanID)
+    if !sameTime(p.FirstPublishDate, plan1.PlannedPublishDate) || p.LastFinishDate != nil {
+        t.Fatalf("unexpected dates: %v / %v, want publish %v", p.FirstPublishDate, p.LastFinishDate, plan1.PlannedPublishDate)
+    }
+
+    // 批量：按请求顺序返回，不存在的订单省略，重复 ID 合并
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/orders/progress?ids=%d,%d,%d,999999999", other.OrderID, order.OrderID, other.OrderID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("batch want 200 got %d: %s", w.Code, w.Body.String()) }
+    var batch []models.OrderProgress
+    decodeJSON(t, w, &batch)
+    if len(batch) != 2 || batch[0].OrderID != other.OrderID || batch[1].OrderID != order.OrderID || batch[1].PercentComplete != 80 {
+        t.Fatalf("unexpected batch: %+v", batch)
+    }
+    if batch[0].PercentComplete != 0 || len(batch[0].Plans) != 0 || len(batch[0].Cells) != 1 { t.Fatalf("empty order progress: %+v", batch[0]) }
+
+    // 完成计划 3 后，最晚完成日期取其完成时间
+    plan3, task3 := addPlan("PRG-3", `{"ratios":{"L":1}}`, 2)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan3.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish plan 3 want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":2}`, task3.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log plan 3 want 201 got %d: %s", w.Code, w.Body.String()) }
+    var log3 models.ProductionLog
+    decodeJSON(t, w, &log3)
+    plan3 = getPlan(plan3.PlanID)
+    if plan3.Status != "completed" || plan3.PlannedFinishDate == nil { t.Fatalf("plan 3 should be completed: %+v", plan3) }
+    p = getProgress()
+    if !sameTime(p.FirstPublishDate, plan1.PlannedPublishDate) || !sameTime(p.LastFinishDate, plan3.PlannedFinishDate) {
+        t.Fatalf("unexpected dates after completion: %v / %v, want %v / %v", p.FirstPublishDate, p.LastFinishDate, plan1.PlannedPublishDate, plan3.PlannedFinishDate)
+    }
+    // 作废日志使计划 3 回到进行中，残留的完成时间不再计入
+    if _, err := conn.Exec(`UPDATE production.logs SET voided = TRUE, void_reason = 'test' WHERE log_id = $1`, log3.LogID); err != nil { t.Fatalf("void: %v", err) }
+    plan3 = getPlan(plan3.PlanID)
+    if plan3.Status != "in_progress" || plan3.PlannedFinishDate == nil { t.Fatalf("plan 3 should be in progress with its old finish date: %+v", plan3) }
+    p = getProgress()
+    if !sameTime(p.FirstPublishDate, plan1.PlannedPublishDate) || p.LastFinishDate != nil {
+        t.Fatalf("unexpected dates after void: %v / %v", p.FirstPublishDate, p.LastFinishDate)
+    }
+
+    w, _ = doJSONAuth(r, "GET", "/api/v1/orders/progress?ids=1,x", "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("bad id want 400 got %d", w.Code) }
+    w, _ = doJSONAuth(r, "GET", "/api/v1/orders/progress", "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("missing ids want 400 got %d", w.Code) }
+    w, _ = doJSONAuth(r, "GET", "/api/v1/orders/999999999/progress", "", "")
+    if w.Code != http.StatusNotFound { t.Fatalf("unknown order want 404 got %d", w.Code) }
+}
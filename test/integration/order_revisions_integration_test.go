@@ -0,0 +1,100 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestOrderRevisions(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    body := fmt.Sprintf(`{"order_number":"ORD-%d-REV","style_number":"STYLE-REV-001","order_start_date":"%s","items":[{"color":"Navy","size":"M","quantity":10},{"color":"Navy","size":"L","quantity":5}]}`, now.UnixNano(), now.Format(time.RFC3339))
+    w, _ := doJSONAuth(r, "POST", "/api/v1/orders", body, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+    if order.Revision != 1 { t.Fatalf("new order should be revision 1, got %d", order.Revision) }
+
+    // 计划：Navy/M 2 层 × 比例 5 = 10 件，发布后覆盖与订单一致
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-REV","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-REV","plan_id":%d}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":5}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Navy","planned_layers":2}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+
+    revPath := fmt.Sprintf("/api/v1/orders/%d/revisions", order.OrderID)
+    // 校验：重复的颜色/尺码、不存在的行、删除全部行
+    w, _ = doJSONAuth(r, "POST", revPath, `{"changes":[{"kind":"update","color":"Navy","size":"M","quantity":8},{"kind":"remove","color":"Navy","size":"M"}]}`, "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("duplicate cell want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", revPath, `{"changes":[{"kind":"update","color":"Red","size":"M","quantity":8}]}`, "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("unknown line want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", revPath, `{"changes":[{"kind":"remove","color":"Navy","size":"M"},{"kind":"remove","color":"Navy","size":"L"}]}`, "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("remove all want 400 got %d: %s", w.Code, w.Body.String()) }
+
+    // 修订：M 改为 8、删除 L、新增 S；已发布计划在 M 上超出新数量而被标记
+    w, _ = doJSONAuth(r, "POST", revPath, `{"reason":"customer cut M","changes":[{"kind":"update","color":"Navy","size":"M","quantity":8},{"kind":"remove","color":"Navy","size":"L"},{"kind":"add","color":"Navy","size":"S","quantity":4}]}`, "")
+    if w.Code != http.StatusCreated { t.Fatalf("revise want 201 got %d: %s", w.Code, w.Body.String()) }
+    var rev models.OrderRevision
+    decodeJSON(t, w, &rev)
+    if rev.Revision != 2 || len(rev.Changes) != 3 || len(rev.Items) != 2 { t.Fatalf("unexpected revision: %+v", rev) }
+    if rev.Changes[0].PreviousQuantity == nil || *rev.Changes[0].PreviousQuantity != 10 { t.Fatalf("update should record previous quantity: %+v", rev.Changes[0]) }
+    if rev.Changes[1].PreviousQuantity == nil || *rev.Changes[1].PreviousQuantity != 5 { t.Fatalf("remove should record previous quantity: %+v", rev.Changes[1]) }
+    if len(rev.FlaggedPlans) != 1 || rev.FlaggedPlans[0].PlanID != plan.PlanID || len(rev.FlaggedPlans[0].Cells) != 1 {
+        t.Fatalf("published plan should be flagged once: %+v", rev.FlaggedPlans)
+    }
+    if c := rev.FlaggedPlans[0].Cells[0]; c.Size != "M" || c.Kind != "over" || c.OrderedQty != 8 || c.PlannedPieces != 10 {
+        t.Fatalf("unexpected flagged cell: %+v", c)
+    }
+
+    // 当前版本：订单项与版本号
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/orders/%d/full", order.OrderID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("get full want 200 got %d: %s", w.Code, w.Body.String()) }
+    var full struct {
+        Order models.ProductionOrder `json:"order"`
+        Items []models.OrderItem     `json:"items"`
+    }
+    decodeJSON(t, w, &full)
+    if full.Order.Revision != 2 || len(full.Items) != 2 { t.Fatalf("unexpected current revision: %+v", full) }
+    for _, it := range full.Items {
+        if (it.Size == "M" && it.Quantity != 8) || (it.Size == "S" && it.Quantity != 4) || it.Size == "L" {
+            t.Fatalf("unexpected current item: %+v", it)
+        }
+    }
+
+    // 历史版本可读
+    w, _ = doJSONAuth(r, "GET", revPath, "", "")
+    if w.Code != http.StatusOK { t.Fatalf("list revisions want 200 got %d: %s", w.Code, w.Body.String()) }
+    var history []models.OrderRevision
+    decodeJSON(t, w, &history)
+    if len(history) != 2 || history[0].Revision != 2 || history[1].Revision != 1 { t.Fatalf("unexpected history: %+v", history) }
+    w, _ = doJSONAuth(r, "GET", revPath+"/1", "", "")
+    if w.Code != http.StatusOK { t.Fatalf("get revision want 200 got %d: %s", w.Code, w.Body.String()) }
+    var first models.OrderRevision
+    decodeJSON(t, w, &first)
+    if len(first.Items) != 2 || first.Items[0].Size != "M" || first.Items[0].Quantity != 10 || len(first.Changes) != 0 {
+        t.Fatalf("revision 1 should keep the original items: %+v", first)
+    }
+    w, _ = doJSONAuth(r, "GET", revPath+"/9", "", "")
+    if w.Code != http.StatusNotFound { t.Fatalf("missing revision want 404 got %d: %s", w.Code, w.Body.String()) }
+
+    // 订单项只能经修订变更
+    if _, err := conn.Exec(`UPDATE production.order_items SET quantity = 1 WHERE order_id = $1`, order.OrderID); err == nil {
+        t.Fatalf("direct order item update must fail")
+    }
+}
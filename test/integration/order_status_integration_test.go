@@ -0,0 +1,110 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestOrderStatusLifecycle(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    createOrder := func(suffix string) models.ProductionOrder {
+        body := fmt.Sprintf(`{"order_number":"ORD-%d-%s","style_number":"STYLE-OST-001","order_start_date":"%s","items":[{"color":"Navy","size":"M","quantity":10}]}`, now.UnixNano(), suffix, now.Format(time.RFC3339))
+        w, _ := doJSONAuth(r, "POST", "/api/v1/orders", body, "")
+        if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+        var o models.ProductionOrder
+        decodeJSON(t, w, &o)
+        return o
+    }
+    getOrder := func(id int) models.ProductionOrder {
+        w, _ := doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/orders/%d", id), "", "")
+        if w.Code != http.StatusOK { t.Fatalf("get order want 200 got %d: %s", w.Code, w.Body.String()) }
+        var o models.ProductionOrder
+        decodeJSON(t, w, &o)
+        return o
+    }
+    setStatus := func(id int, status string) int {
+        w, _ := doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/orders/%d/status", id), fmt.Sprintf(`{"status":"%s"}`, status), "")
+        return w.Code
+    }
+
+    order := createOrder("OST")
+    if order.Status != "draft" { t.Fatalf("new order should be draft, got %q", order.Status) }
+
+    // 手动流转：仅 draft → confirmed；自动状态不可手动设置；不允许跳转
+    if code := setStatus(order.OrderID, "in_production"); code != http.StatusBadRequest { t.Fatalf("manual in_production want 400 got %d", code) }
+    if code := setStatus(order.OrderID, "bogus"); code != http.StatusBadRequest { t.Fatalf("unknown status want 400 got %d", code) }
+    if code := setStatus(order.OrderID, "closed"); code != http.StatusConflict { t.Fatalf("close draft want 409 got %d", code) }
+    if code := setStatus(order.OrderID, "confirmed"); code != http.StatusOK { t.Fatalf("confirm want 200 got %d", code) }
+    if o := getOrder(order.OrderID); o.Status != "confirmed" || o.StatusChangedAt == nil { t.Fatalf("order should be confirmed: %+v", o) }
+
+    // 按状态过滤
+    w, _ := doJSONAuth(r, "GET", "/api/v1/orders?status=confirmed", "", "")
+    if w.Code != http.StatusOK { t.Fatalf("list by status want 200 got %d: %s", w.Code, w.Body.String()) }
+    var list []models.ProductionOrder
+    decodeJSON(t, w, &list)
+    found := false
+    for _, o := range list {
+        if o.Status != "confirmed" { t.Fatalf("filter returned order in status %q", o.Status) }
+        if o.OrderID == order.OrderID { found = true }
+    }
+    if !found { t.Fatalf("confirmed order missing from filtered list") }
+    w, _ = doJSONAuth(r, "GET", "/api/v1/orders?status=bogus", "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("list unknown status want 400 got %d: %s", w.Code, w.Body.String()) }
+
+    // 发布计划 → in_production；完成后 → cut_complete
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-OST","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-OST","plan_id":%d}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":1}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Navy","planned_layers":2}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+    var task models.ProductionTask
+    decodeJSON(t, w, &task)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+    if o := getOrder(order.OrderID); o.Status != "in_production" { t.Fatalf("order should be in_production after publish, got %q", o.Status) }
+    if code := setStatus(order.OrderID, "cancelled"); code != http.StatusConflict { t.Fatalf("cancel in_production want 409 got %d", code) }
+
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":2}`, task.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log want 201 got %d: %s", w.Code, w.Body.String()) }
+    if o := getOrder(order.OrderID); o.Status != "cut_complete" { t.Fatalf("order should be cut_complete after plan completion, got %q", o.Status) }
+
+    // 重新打开计划回到 in_production，再次完成后关闭
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/reopen", plan.PlanID), `{"reason":"recount"}`, "")
+    if w.Code != http.StatusOK { t.Fatalf("reopen want 200 got %d: %s", w.Code, w.Body.String()) }
+    if o := getOrder(order.OrderID); o.Status != "in_production" { t.Fatalf("order should be in_production after reopen, got %q", o.Status) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/amendments", plan.PlanID), fmt.Sprintf(`{"changes":[{"kind":"update_task","task_id":%d,"planned_layers":3}]}`, task.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("propose want 201 got %d: %s", w.Code, w.Body.String()) }
+    var amd models.PlanAmendment
+    decodeJSON(t, w, &amd)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/amendments/%d/approve", amd.AmendmentID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("approve want 200 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":1}`, task.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log want 201 got %d: %s", w.Code, w.Body.String()) }
+    if o := getOrder(order.OrderID); o.Status != "cut_complete" { t.Fatalf("order should be cut_complete again, got %q", o.Status) }
+    if code := setStatus(order.OrderID, "closed"); code != http.StatusOK { t.Fatalf("close want 200 got %d", code) }
+
+    // 终态：不可再变更，也不可新建计划
+    if code := setStatus(order.OrderID, "cancelled"); code != http.StatusConflict { t.Fatalf("cancel closed want 409 got %d", code) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-OST-2","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusInternalServerError { t.Fatalf("plan on closed order want 500 got %d: %s", w.Code, w.Body.String()) }
+
+    // 未开工的订单可直接取消
+    other := createOrder("OSC")
+    if code := setStatus(other.OrderID, "cancelled"); code != http.StatusOK { t.Fatalf("cancel draft want 200 got %d", code) }
+    if o := getOrder(other.OrderID); o.Status != "cancelled" { t.Fatalf("order should be cancelled, got %q", o.Status) }
+}
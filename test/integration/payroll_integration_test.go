@@ -0,0 +1,149 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "strings"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestPayrollStatementsAndLocking(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    suffix := now.UnixNano()
+    style := fmt.Sprintf("STYLE-PAY-%d", suffix)
+    createOrder := fmt.Sprintf(`{
+        "order_number": "ORD-%d",
+        "style_number": "%s",
+        "order_start_date": "%s",
+        "items": [{"color":"Navy","size":"M","quantity":100}]
+    }`, suffix, style, now.Format(time.RFC3339))
+    w, _ := doJSONAuth(r, "POST", "/api/v1/orders", createOrder, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-PAY","order_id":%d}`, order.OrderID), "")
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+    var tasks []models.ProductionTask
+    var layouts []models.CuttingLayout
+    for _, name := range []string{"L-PAY-A", "L-PAY-B"} {
+        w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"%s","plan_id":%d}`, name, plan.PlanID), "")
+        var layout models.CuttingLayout
+        decodeJSON(t, w, &layout)
+        w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":1}}`, "")
+        if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+        w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Navy","planned_layers":20}`, layout.LayoutID), "")
+        var task models.ProductionTask
+        decodeJSON(t, w, &task)
+        layouts, tasks = append(layouts, layout), append(tasks, task)
+    }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+
+    // 单价：布局 A 1.25/层，款号 0.5/层（布局 B 使用）
+    w, _ = doJSONAuth(r, "POST", "/api/v1/payroll/rates", fmt.Sprintf(`{"style_number":"%s","rate_per_layer":0.5}`, style), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create style rate want 201 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/payroll/rates", fmt.Sprintf(`{"layout_id":%d,"rate_per_layer":1.25}`, layouts[0].LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout rate want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layoutRate models.PieceRate
+    decodeJSON(t, w, &layoutRate)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/payroll/rates", fmt.Sprintf(`{"layout_id":%d,"rate_per_layer":3}`, layouts[0].LayoutID), "")
+    if w.Code != http.StatusConflict { t.Fatalf("duplicate scope want 409 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/payroll/rates", fmt.Sprintf(`{"layout_id":%d,"style_number":"%s","rate_per_layer":3}`, layouts[1].LayoutID, style), "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("two scopes want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/payroll/rates", fmt.Sprintf(`{"layout_id":%d,"rate_per_layer":0}`, layouts[1].LayoutID), "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("zero rate want 400 got %d: %s", w.Code, w.Body.String()) }
+
+    // 周期放在远期的唯一日期上，避免与其它运行冲突
+    base := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(now.Unix()%200000))
+    day := func(d int) string { return base.AddDate(0, 0, d).Format("2006-01-02") }
+    var periods []models.PayPeriod
+    for _, span := range [][2]int{{0, 6}, {7, 13}} {
+        w, _ = doJSONAuth(r, "POST", "/api/v1/payroll/periods", fmt.Sprintf(`{"period_start":"%s","period_end":"%s"}`, day(span[0]), day(span[1])), "")
+        if w.Code != http.StatusCreated { t.Fatalf("create period want 201 got %d: %s", w.Code, w.Body.String()) }
+        var p models.PayPeriod
+        decodeJSON(t, w, &p)
+        periods = append(periods, p)
+    }
+    t.Cleanup(func() {
+        for _, p := range periods { doJSONAuth(r, "DELETE", fmt.Sprintf("/api/v1/payroll/periods/%d", p.PeriodID), "", "") }
+    })
+    w, _ = doJSONAuth(r, "POST", "/api/v1/payroll/periods", fmt.Sprintf(`{"period_start":"%s","period_end":"%s"}`, day(3), day(8)), "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("overlapping period want 400 got %d: %s", w.Code, w.Body.String()) }
+
+    worker := fmt.Sprintf("W-PAY-%d", suffix)
+    insertAt := func(task models.ProductionTask, at string, layers int) int {
+        var id int
+        err := conn.QueryRow(`
+            INSERT INTO production.logs (task_id, worker_name, layers_completed, log_time)
+            VALUES ($1, $2, $3, ($4::timestamp AT TIME ZONE 'UTC') AT TIME ZONE current_setting('TimeZone'))
+            RETURNING log_id`, task.TaskID, worker, layers, at).Scan(&id)
+        if err != nil { t.Fatalf("insert log at %s: %v", at, err) }
+        return id
+    }
+    insertAt(tasks[0], day(1)+" 12:00", 4) // 4 × 1.25 = 5.00
+    insertAt(tasks[1], day(1)+" 13:00", 2) // 2 × 0.5 = 1.00（款号单价）
+    paidThenVoided := insertAt(tasks[0], day(2)+" 12:00", 3) // 3 × 1.25 = 3.75
+
+    statement := func(p models.PayPeriod) *models.WorkerStatement {
+        w, _ := doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/payroll/periods/%d/statement", p.PeriodID), "", "")
+        if w.Code != http.StatusOK { t.Fatalf("statement want 200 got %d: %s", w.Code, w.Body.String()) }
+        var st models.PayrollStatement
+        decodeJSON(t, w, &st)
+        for i := range st.Workers {
+            if st.Workers[i].WorkerName == worker { return &st.Workers[i] }
+        }
+        return nil
+    }
+    ws := statement(periods[0])
+    if ws == nil || len(ws.Lines) != 3 || ws.Layers != 9 || ws.Amount != 9.75 { t.Fatalf("unexpected open statement: %+v", ws) }
+    if ws.Lines[1].RateScope == nil || *ws.Lines[1].RateScope != "style" { t.Fatalf("layout B must use the style rate: %+v", ws.Lines[1]) }
+
+    // 须按顺序锁定
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/payroll/periods/%d/lock", periods[1].PeriodID), "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("lock out of order want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/payroll/periods/%d/lock", periods[0].PeriodID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("lock want 200 got %d: %s", w.Code, w.Body.String()) }
+    var locked models.PayPeriod
+    decodeJSON(t, w, &locked)
+    if locked.Status != "locked" || locked.LockedAt == nil { t.Fatalf("period not locked: %+v", locked) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/payroll/periods/%d/lock", periods[0].PeriodID), "", "")
+    if w.Code != http.StatusConflict { t.Fatalf("relock want 409 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "DELETE", fmt.Sprintf("/api/v1/payroll/periods/%d", periods[0].PeriodID), "", "")
+    if w.Code != http.StatusInternalServerError { t.Fatalf("delete locked want 500 got %d: %s", w.Code, w.Body.String()) }
+
+    // 锁定后改单价、作废日志均不影响已锁定的工资单
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/payroll/rates/%d", layoutRate.RateID), `{"rate_per_layer":2}`, "")
+    if w.Code != http.StatusOK { t.Fatalf("update rate want 200 got %d: %s", w.Code, w.Body.String()) }
+    if _, err := conn.Exec(`UPDATE production.logs SET voided = TRUE, void_reason = 'test' WHERE log_id = $1`, paidThenVoided); err != nil { t.Fatalf("void: %v", err) }
+    ws = statement(periods[0])
+    if ws == nil || ws.Amount != 9.75 || ws.Lines[0].LineID == nil { t.Fatalf("locked statement changed: %+v", ws) }
+
+    // 冲销计入下一周期：2 × 2.00 − 3.75 = 0.25
+    insertAt(tasks[0], day(8)+" 12:00", 2)
+    ws = statement(periods[1])
+    if ws == nil || len(ws.Lines) != 2 || ws.Layers != -1 || ws.Amount != 0.25 || ws.Adjustments != -3.75 {
+        t.Fatalf("unexpected next statement: %+v", ws)
+    }
+
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/payroll/periods/%d/statement?format=csv", periods[1].PeriodID), "", "")
+    if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") { t.Fatalf("csv want 200 text/csv got %d %s", w.Code, w.Header().Get("Content-Type")) }
+    body := w.Body.String()
+    if !strings.HasPrefix(body, "period_id,status,worker_id,worker_name,kind") || !strings.Contains(body, worker+",void_adjustment") {
+        t.Fatalf("unexpected csv: %s", body)
+    }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/payroll/periods/%d/statement?format=xlsx", periods[1].PeriodID), "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("unknown format want 400 got %d", w.Code) }
+
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/payroll/periods/%d/lock", periods[1].PeriodID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("lock next want 200 got %d: %s", w.Code, w.Body.String()) }
+    ws = statement(periods[1])
+    if ws == nil || ws.Amount != 0.25 || len(ws.Lines) != 2 { t.Fatalf("locked next statement mismatch: %+v", ws) }
+}
@@ -0,0 +1,125 @@
+package integration
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestPlanAmendments(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    suffix := now.UnixNano()
+    body := fmt.Sprintf(`{"order_number":"ORD-%d-AMD","style_number":"STYLE-AMD-001","order_start_date":"%s","items":[{"color":"Navy","size":"M","quantity":40},{"color":"Navy","size":"L","quantity":30}]}`, suffix, now.Format(time.RFC3339))
+    w, _ := doJSONAuth(r, "POST", "/api/v1/orders", body, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+
+    // 计划：布局 M1/L1，任务 Navy 10 层
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-AMD","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-AMD","plan_id":%d}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":1,"L":1}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Navy","planned_layers":10}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+    var task models.ProductionTask
+    decodeJSON(t, w, &task)
+
+    // 未发布计划不可提出变更
+    update := func(layers int) string {
+        return fmt.Sprintf(`{"reason":"customer top-up","changes":[{"kind":"update_task","task_id":%d,"planned_layers":%d}]}`, task.TaskID, layers)
+    }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/amendments", plan.PlanID), update(12), "")
+    if w.Code == http.StatusCreated { t.Fatalf("amendment on pending plan must fail: %s", w.Body.String()) }
+
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":4}`, task.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log want 201 got %d: %s", w.Code, w.Body.String()) }
+
+    // 校验：未知类型与低于已完成层数
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/amendments", plan.PlanID), `{"changes":[{"kind":"delete_task","task_id":1}]}`, "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("unknown kind want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/amendments", plan.PlanID), update(3), "")
+    if w.Code == http.StatusCreated { t.Fatalf("planned below completed must fail: %s", w.Body.String()) }
+
+    // 提出：改层数、在原布局加任务、新增布局（M2 + 任务）
+    body = fmt.Sprintf(`{"reason":"customer top-up","changes":[
+        {"kind":"update_task","task_id":%d,"planned_layers":12},
+        {"kind":"add_task","layout_id":%d,"color":"Navy","planned_layers":5},
+        {"kind":"add_layout","layout":{"layout_name":"L-AMD-2"},"ratios":{"M":2},"tasks":[{"color":"Navy","planned_layers":3}]}
+    ]}`, task.TaskID, layout.LayoutID)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/amendments", plan.PlanID), body, "")
+    if w.Code != http.StatusCreated { t.Fatalf("propose want 201 got %d: %s", w.Code, w.Body.String()) }
+    var amd models.PlanAmendment
+    decodeJSON(t, w, &amd)
+    if amd.Status != "pending" || len(amd.Changes) != 3 { t.Fatalf("unexpected amendment: %+v", amd) }
+
+    // 批准前计划未变化
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d", task.TaskID), "", "")
+    var got models.ProductionTask
+    decodeJSON(t, w, &got)
+    if got.PlannedLayers != 10 { t.Fatalf("pending amendment must not change task: %+v", got) }
+
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/amendments/%d/approve", amd.AmendmentID), `{"note":"ok"}`, "")
+    if w.Code != http.StatusOK { t.Fatalf("approve want 200 got %d: %s", w.Code, w.Body.String()) }
+    decodeJSON(t, w, &amd)
+    if amd.Status != "approved" || amd.DecidedAt == nil || amd.DecisionNote == nil || *amd.DecisionNote != "ok" {
+        t.Fatalf("unexpected approved amendment: %+v", amd)
+    }
+    var before, after models.ProductionTask
+    if err := json.Unmarshal(amd.Changes[0].Before, &before); err != nil { t.Fatalf("before: %v", err) }
+    if err := json.Unmarshal(amd.Changes[0].After, &after); err != nil { t.Fatalf("after: %v", err) }
+    if before.PlannedLayers != 10 || after.PlannedLayers != 12 || after.CompletedLayers != 4 || after.Status != "in_progress" {
+        t.Fatalf("unexpected before/after: %s / %s", amd.Changes[0].Before, amd.Changes[0].After)
+    }
+    if amd.Changes[1].TaskID == nil || amd.Changes[1].Before != nil || amd.Changes[1].After == nil { t.Fatalf("unexpected add_task change: %+v", amd.Changes[1]) }
+    if amd.Changes[2].LayoutID == nil { t.Fatalf("add_layout change must record new layout: %+v", amd.Changes[2]) }
+
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d", task.TaskID), "", "")
+    decodeJSON(t, w, &got)
+    if got.PlannedLayers != 12 || got.Status != "in_progress" { t.Fatalf("task not updated: %+v", got) }
+    // 新任务可立即提交日志
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/layouts/%d/tasks", *amd.Changes[2].LayoutID), "", "")
+    var newTasks []models.ProductionTask
+    decodeJSON(t, w, &newTasks)
+    if len(newTasks) != 1 || newTasks[0].PlannedLayers != 3 || newTasks[0].Status != "in_progress" { t.Fatalf("unexpected new layout tasks: %+v", newTasks) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":3}`, newTasks[0].TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log on added task want 201 got %d: %s", w.Code, w.Body.String()) }
+
+    // 已处理的申请不可再处理
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/amendments/%d/approve", amd.AmendmentID), "", "")
+    if w.Code != http.StatusConflict { t.Fatalf("re-approve want 409 got %d: %s", w.Code, w.Body.String()) }
+
+    // 驳回：计划不变
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/amendments", plan.PlanID), update(20), "")
+    if w.Code != http.StatusCreated { t.Fatalf("propose want 201 got %d: %s", w.Code, w.Body.String()) }
+    var rej models.PlanAmendment
+    decodeJSON(t, w, &rej)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/amendments/%d/reject", rej.AmendmentID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("reject want 200 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/amendments/%d/approve", rej.AmendmentID), "", "")
+    if w.Code != http.StatusConflict { t.Fatalf("approve rejected want 409 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d", task.TaskID), "", "")
+    decodeJSON(t, w, &got)
+    if got.PlannedLayers != 12 { t.Fatalf("rejected amendment must not change task: %+v", got) }
+
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d/amendments?status=approved", plan.PlanID), "", "")
+    var list []models.PlanAmendment
+    decodeJSON(t, w, &list)
+    if len(list) != 1 || list[0].AmendmentID != amd.AmendmentID || len(list[0].Changes) != 3 { t.Fatalf("unexpected approved list: %+v", list) }
+}
@@ -0,0 +1,114 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestPlanClone(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    suffix := now.UnixNano()
+    createOrder := func(tag, items string) models.ProductionOrder {
+        body := fmt.Sprintf(`{"order_number":"ORD-%d-%s","style_number":"STYLE-CLN-001","order_start_date":"%s","items":%s}`, suffix, tag, now.Format(time.RFC3339), items)
+        w, _ := doJSONAuth(r, "POST", "/api/v1/orders", body, "")
+        if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+        var o models.ProductionOrder
+        decodeJSON(t, w, &o)
+        return o
+    }
+    src := createOrder("SRC", `[{"color":"Navy","size":"M","quantity":20},{"color":"Navy","size":"L","quantity":10},{"color":"Red","size":"M","quantity":10}]`)
+    // 目标订单：无 Red 与 L，改用 Black 与 XL
+    dst := createOrder("DST", `[{"color":"Navy","size":"M","quantity":20},{"color":"Black","size":"M","quantity":10},{"color":"Black","size":"XL","quantity":10}]`)
+
+    // 源计划：一个布局 M2/L1，marker 6.5m，任务 Navy 10 层、Red 5 层；发布并登记进度
+    w, _ := doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-CLN","order_id":%d,"note":"repeat"}`, src.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-CLN","plan_id":%d,"marker_length":6.5}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":2,"L":1}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    var navy models.ProductionTask
+    for _, body := range []string{`"color":"Navy","planned_layers":10`, `"color":"Red","planned_layers":5`} {
+        w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,%s}`, layout.LayoutID, body), "")
+        if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+        if navy.TaskID == 0 { decodeJSON(t, w, &navy) }
+    }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":3}`, navy.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log want 201 got %d: %s", w.Code, w.Body.String()) }
+
+    type cloneResp struct {
+        Plan    models.ProductionPlan `json:"plan"`
+        Layouts []models.DraftLayout  `json:"layouts"`
+    }
+    // 同订单复制（无请求体）：默认名称与备注，进度不复制
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/clone", plan.PlanID), "", "")
+    if w.Code != http.StatusCreated { t.Fatalf("clone want 201 got %d: %s", w.Code, w.Body.String()) }
+    var same cloneResp
+    decodeJSON(t, w, &same)
+    if same.Plan.OrderID != src.OrderID || same.Plan.PlanName != "Plan-CLN (copy)" || same.Plan.Status != "pending" || same.Plan.Note == nil || *same.Plan.Note != "repeat" {
+        t.Fatalf("unexpected cloned plan: %+v", same.Plan)
+    }
+    if len(same.Layouts) != 1 || same.Layouts[0].Layout.LayoutID == layout.LayoutID || same.Layouts[0].Layout.MarkerLength == nil || *same.Layouts[0].Layout.MarkerLength != 6.5 {
+        t.Fatalf("unexpected cloned layouts: %+v", same.Layouts)
+    }
+    if tasks := same.Layouts[0].Tasks; len(tasks) != 2 || tasks[0].Color != "Navy" || tasks[0].PlannedLayers != 10 || tasks[0].CompletedLayers != 0 || tasks[0].Status != "pending" {
+        t.Fatalf("unexpected cloned tasks: %+v", same.Layouts[0].Tasks)
+    }
+
+    countPlans := func(orderID int) int {
+        w, _ := doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/orders/%d/plans", orderID), "", "")
+        var plans []models.ProductionPlan
+        decodeJSON(t, w, &plans)
+        return len(plans)
+    }
+    // 跨订单未映射：报告缺失的颜色与尺码，且不写入
+    before := countPlans(dst.OrderID)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/clone", plan.PlanID), fmt.Sprintf(`{"order_id":%d}`, dst.OrderID), "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("mismatch want 400 got %d: %s", w.Code, w.Body.String()) }
+    var mismatch struct {
+        Error         string   `json:"error"`
+        MissingColors []string `json:"missing_colors"`
+        MissingSizes  []string `json:"missing_sizes"`
+    }
+    decodeJSON(t, w, &mismatch)
+    if mismatch.Error != "clone_mismatch" || len(mismatch.MissingColors) != 1 || mismatch.MissingColors[0] != "Red" || len(mismatch.MissingSizes) != 1 || mismatch.MissingSizes[0] != "L" {
+        t.Fatalf("unexpected mismatch: %+v", mismatch)
+    }
+    if countPlans(dst.OrderID) != before { t.Fatalf("mismatched clone must not create a plan") }
+
+    // 映射后复制：Red→Black，L→XL；映射到同一尺码（L→M）时比例相加
+    body := fmt.Sprintf(`{"order_id":%d,"plan_name":"Plan-CLN-DST","color_map":{"Red":"Black"},"size_map":{"L":"XL"}}`, dst.OrderID)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/clone", plan.PlanID), body, "")
+    if w.Code != http.StatusCreated { t.Fatalf("mapped clone want 201 got %d: %s", w.Code, w.Body.String()) }
+    var mapped cloneResp
+    decodeJSON(t, w, &mapped)
+    if mapped.Plan.OrderID != dst.OrderID || mapped.Plan.PlanName != "Plan-CLN-DST" { t.Fatalf("unexpected mapped plan: %+v", mapped.Plan) }
+    l := mapped.Layouts[0]
+    if l.Ratios["M"] != 2 || l.Ratios["XL"] != 1 || len(l.Ratios) != 2 || l.Tasks[1].Color != "Black" { t.Fatalf("unexpected mapped layout: %+v", l) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/clone", plan.PlanID), fmt.Sprintf(`{"order_id":%d,"color_map":{"Red":"Black"},"size_map":{"L":"M"}}`, dst.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("merged clone want 201 got %d: %s", w.Code, w.Body.String()) }
+    var merged cloneResp
+    decodeJSON(t, w, &merged)
+    if merged.Layouts[0].Ratios["M"] != 3 || len(merged.Layouts[0].Ratios) != 1 { t.Fatalf("unexpected merged ratios: %+v", merged.Layouts[0].Ratios) }
+
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans/999999999/clone", "", "")
+    if w.Code != http.StatusNotFound { t.Fatalf("unknown plan want 404 got %d", w.Code) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/clone", plan.PlanID), `{"order_id":999999999}`, "")
+    if w.Code != http.StatusNotFound { t.Fatalf("unknown order want 404 got %d", w.Code) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/clone", plan.PlanID), `{"color_map":{"Red":""}}`, "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("empty mapping want 400 got %d", w.Code) }
+}
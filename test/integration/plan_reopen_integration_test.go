@@ -0,0 +1,99 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestPlanReopen(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    body := fmt.Sprintf(`{"order_number":"ORD-%d-RPN","style_number":"STYLE-RPN-001","order_start_date":"%s","items":[{"color":"Navy","size":"M","quantity":10}]}`, now.UnixNano(), now.Format(time.RFC3339))
+    w, _ := doJSONAuth(r, "POST", "/api/v1/orders", body, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-RPN","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-RPN","plan_id":%d}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":1}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Navy","planned_layers":2}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+    var task models.ProductionTask
+    decodeJSON(t, w, &task)
+
+    reopenPath := fmt.Sprintf("/api/v1/plans/%d/reopen", plan.PlanID)
+    // 未完成的计划不可重新打开
+    w, _ = doJSONAuth(r, "POST", reopenPath, `{"reason":"recut"}`, "")
+    if w.Code != http.StatusConflict { t.Fatalf("reopen pending want 409 got %d: %s", w.Code, w.Body.String()) }
+
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+    logLayers := func(layers int) {
+        w, _ := doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":%d}`, task.TaskID, layers), "")
+        if w.Code != http.StatusCreated { t.Fatalf("log want 201 got %d: %s", w.Code, w.Body.String()) }
+    }
+    getPlan := func() models.ProductionPlan {
+        w, _ := doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d", plan.PlanID), "", "")
+        var p models.ProductionPlan
+        decodeJSON(t, w, &p)
+        return p
+    }
+    logLayers(2)
+    if p := getPlan(); p.Status != "completed" || p.PlannedFinishDate == nil { t.Fatalf("plan should be completed: %+v", p) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/freeze", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("freeze want 204 got %d: %s", w.Code, w.Body.String()) }
+
+    // 原因必填
+    w, _ = doJSONAuth(r, "POST", reopenPath, `{"reason":"  "}`, "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("reopen without reason want 400 got %d: %s", w.Code, w.Body.String()) }
+
+    w, _ = doJSONAuth(r, "POST", reopenPath, `{"reason":"customer recut request"}`, "")
+    if w.Code != http.StatusOK { t.Fatalf("reopen want 200 got %d: %s", w.Code, w.Body.String()) }
+    var out struct {
+        Plan      models.ProductionPlan `json:"plan"`
+        Reopening models.PlanReopening  `json:"reopening"`
+    }
+    decodeJSON(t, w, &out)
+    if out.Plan.Status != "in_progress" || out.Plan.PlannedFinishDate != nil || out.Plan.PlannedPublishDate == nil {
+        t.Fatalf("unexpected reopened plan: %+v", out.Plan)
+    }
+    if out.Reopening.FromStatus != "frozen" || out.Reopening.Reason != "customer recut request" || out.Reopening.PreviousFinishDate == nil {
+        t.Fatalf("unexpected reopening: %+v", out.Reopening)
+    }
+    w, _ = doJSONAuth(r, "POST", reopenPath, `{"reason":"again"}`, "")
+    if w.Code != http.StatusConflict { t.Fatalf("reopen in_progress want 409 got %d: %s", w.Code, w.Body.String()) }
+
+    // 补裁：经变更申请增加层数后继续提交日志，再次完成时写入完成时间
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/amendments", plan.PlanID), fmt.Sprintf(`{"changes":[{"kind":"update_task","task_id":%d,"planned_layers":3}]}`, task.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("propose want 201 got %d: %s", w.Code, w.Body.String()) }
+    var amd models.PlanAmendment
+    decodeJSON(t, w, &amd)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/amendments/%d/approve", amd.AmendmentID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("approve want 200 got %d: %s", w.Code, w.Body.String()) }
+    logLayers(1)
+    if p := getPlan(); p.Status != "completed" || p.PlannedFinishDate == nil { t.Fatalf("plan should be completed again: %+v", p) }
+
+    w, _ = doJSONAuth(r, "POST", reopenPath, `{"reason":"second recut"}`, "")
+    if w.Code != http.StatusOK { t.Fatalf("reopen completed want 200 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d/reopen-history", plan.PlanID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("history want 200 got %d: %s", w.Code, w.Body.String()) }
+    var history []models.PlanReopening
+    decodeJSON(t, w, &history)
+    if len(history) != 2 || history[0].FromStatus != "completed" || history[0].Reason != "second recut" || history[1].FromStatus != "frozen" {
+        t.Fatalf("unexpected reopen history: %+v", history)
+    }
+}
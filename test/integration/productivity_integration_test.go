@@ -0,0 +1,98 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestWorkerProductivity(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    suffix := time.Now().UnixNano()
+    createOrder := fmt.Sprintf(`{
+        "order_number": "ORD-%d",
+        "style_number": "STYLE-PROD-001",
+        "order_start_date": "%s",
+        "items": [{"color":"Navy","size":"M","quantity":100}]
+    }`, suffix, time.Now().UTC().Format(time.RFC3339))
+    w, _ := doJSONAuth(r, "POST", "/api/v1/orders", createOrder, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-PROD","order_id":%d}`, order.OrderID), "")
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-PROD","plan_id":%d}`, plan.PlanID), "")
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":2}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Navy","planned_layers":50}`, layout.LayoutID), "")
+    var task models.ProductionTask
+    decodeJSON(t, w, &task)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+
+    // 日志时间取日中（UTC），避免跨零点班次改变班次日期
+    worker := fmt.Sprintf("W-PROD-%d", suffix)
+    insertAt := func(at string, layers int) int {
+        var id int
+        err := conn.QueryRow(`
+            INSERT INTO production.logs (task_id, worker_name, layers_completed, log_time)
+            VALUES ($1, $2, $3, ($4::timestamp AT TIME ZONE 'UTC') AT TIME ZONE current_setting('TimeZone'))
+            RETURNING log_id`, task.TaskID, worker, layers, at).Scan(&id)
+        if err != nil { t.Fatalf("insert log at %s: %v", at, err) }
+        return id
+    }
+    insertAt("2032-05-02 10:00", 2) // 首条无间隔：计入产量不计工时
+    insertAt("2032-05-02 10:30", 3)
+    insertAt("2032-05-02 11:00", 4)
+    voided := insertAt("2032-05-02 11:15", 1)
+    insertAt("2032-05-02 16:00", 5) // 间隔 5 小时视为休息
+    if _, err := conn.Exec(`UPDATE production.logs SET voided = TRUE, void_reason = 'test' WHERE log_id = $1`, voided); err != nil { t.Fatalf("void: %v", err) }
+
+    find := func(rep models.ProductivityReport) *models.ProductivityRow {
+        for i := range rep.Rows {
+            if rep.Rows[i].WorkerName != nil && *rep.Rows[i].WorkerName == worker { return &rep.Rows[i] }
+        }
+        return nil
+    }
+    var rep models.ProductivityReport
+    w, _ = doJSONAuth(r, "GET", "/api/v1/reports/productivity?from=2032-05-01&to=2032-05-03", "", "")
+    if w.Code != http.StatusOK { t.Fatalf("productivity want 200 got %d: %s", w.Code, w.Body.String()) }
+    decodeJSON(t, w, &rep)
+    row := find(rep)
+    if row == nil { t.Fatalf("worker %s missing: %s", worker, w.Body.String()) }
+    if row.Logs != 5 || row.VoidedLogs != 1 || row.VoidRate != 0.2 || row.Layers != 14 || row.Pieces != 28 || row.ActiveHours != 1 {
+        t.Fatalf("unexpected totals: %+v", row)
+    }
+    if row.LayersPerHour == nil || *row.LayersPerHour != 7 || row.PiecesPerHour == nil || *row.PiecesPerHour != 14 {
+        t.Fatalf("unexpected rates: %+v", row)
+    }
+
+    w, _ = doJSONAuth(r, "GET", "/api/v1/reports/productivity?from=2032-05-01&to=2032-05-03&group_by=color,worker,layout", "", "")
+    decodeJSON(t, w, &rep)
+    if len(rep.GroupBy) != 3 || rep.GroupBy[0] != "worker" { t.Fatalf("group_by not normalized: %v", rep.GroupBy) }
+    row = find(rep)
+    if row == nil || row.LayoutID == nil || *row.LayoutID != layout.LayoutID || row.Color == nil || *row.Color != "Navy" || row.Layers != 14 {
+        t.Fatalf("unexpected breakdown row: %+v", row)
+    }
+
+    // 区间外无数据
+    w, _ = doJSONAuth(r, "GET", "/api/v1/reports/productivity?from=2032-06-01&to=2032-06-30", "", "")
+    decodeJSON(t, w, &rep)
+    if find(rep) != nil { t.Fatalf("worker must not appear outside range") }
+
+    w, _ = doJSONAuth(r, "GET", "/api/v1/reports/productivity?group_by=shoe_size", "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("unknown dimension want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", "/api/v1/reports/productivity?from=2032-05-03&to=2032-05-01", "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("reversed range want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", "/api/v1/reports/productivity?from=2030-01-01&to=2032-05-01", "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("range too long want 400 got %d: %s", w.Code, w.Body.String()) }
+}
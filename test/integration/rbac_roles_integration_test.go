@@ -35,7 +35,7 @@ func buildProtectedRouter(conn *sql.DB) *gin.Engine {
 
     protected := api.Group("")
     protected.Use(middleware.RequireAuth(authSvc))
-    handlers.NewOrdersHandler(services.NewOrdersService(ordersRepo)).RegisterProtected(protected)
+    handlers.NewOrdersHandler(services.NewOrdersService(ordersRepo, repositories.NewSqlStylesRepository(conn), repositories.NewSqlCustomersRepository(conn))).RegisterProtected(protected)
     handlers.NewPlansHandler(services.NewPlansService(plansRepo)).RegisterProtected(protected)
     handlers.NewLayoutsHandler(services.NewLayoutsService(layoutsRepo)).RegisterProtected(protected)
     handlers.NewTasksHandler(services.NewTasksService(tasksRepo)).RegisterProtected(protected)
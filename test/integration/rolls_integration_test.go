@@ -0,0 +1,116 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestFabricRollsConsumedByLogs(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    suffix := now.UnixNano()
+    orderNumber := fmt.Sprintf("ORD-%d", suffix)
+    createOrder := fmt.Sprintf(`{
+        "order_number": "%s",
+        "style_number": "STYLE-ROLL-001",
+        "order_start_date": "%s",
+        "items": [{"color":"Red","size":"M","quantity":10}]
+    }`, orderNumber, now.Format(time.RFC3339))
+    w, _ := doJSONAuth(r, "POST", "/api/v1/orders", createOrder, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-ROLL","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+
+    // 每层 2.0 + 0.1 = 2.1 米
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-ROLL","plan_id":%d,"marker_length":2.0,"end_loss_allowance":0.1}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":1}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Red","planned_layers":10}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+    var task models.ProductionTask
+    decodeJSON(t, w, &task)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+
+    createRoll := func(code, color string, length float64) models.FabricRoll {
+        body := fmt.Sprintf(`{"roll_code":"%s","color":"%s","dye_lot":"LOT-A","fabric_width":150,"received_length":%v}`, code, color, length)
+        w, _ := doJSONAuth(r, "POST", "/api/v1/rolls", body, "")
+        if w.Code != http.StatusCreated { t.Fatalf("create roll %s want 201 got %d: %s", code, w.Code, w.Body.String()) }
+        var roll models.FabricRoll
+        decodeJSON(t, w, &roll)
+        return roll
+    }
+    roll1 := createRoll(fmt.Sprintf("R1-%d", suffix), "Red", 10)
+    roll2 := createRoll(fmt.Sprintf("R2-%d", suffix), "Red", 50)
+    blue := createRoll(fmt.Sprintf("RB-%d", suffix), "Blue", 50)
+    if roll1.Status != "available" || roll1.RemainingLength != 10 { t.Fatalf("unexpected new roll: %+v", roll1) }
+
+    w, _ = doJSONAuth(r, "POST", "/api/v1/rolls", fmt.Sprintf(`{"roll_code":"%s","color":"Red","received_length":5}`, roll1.RollCode), "")
+    if w.Code != http.StatusConflict { t.Fatalf("duplicate roll want 409 got %d: %s", w.Code, w.Body.String()) }
+
+    // 6 层：第一卷 10 米容纳 4 层（8.4 米），其余 2 层由第二卷领用
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":6,"worker_name":"roller","roll_ids":[%d,%d]}`, task.TaskID, roll1.RollID, roll2.RollID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create log want 201 got %d: %s", w.Code, w.Body.String()) }
+    var log models.ProductionLog
+    decodeJSON(t, w, &log)
+    if len(log.Rolls) != 2 || log.Rolls[0].Layers != 4 || log.Rolls[1].Layers != 2 {
+        t.Fatalf("unexpected roll allocation: %+v", log.Rolls)
+    }
+
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/rolls/%d", roll1.RollID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("get roll want 200 got %d: %s", w.Code, w.Body.String()) }
+    var got models.FabricRoll
+    decodeJSON(t, w, &got)
+    if got.Status != "in_use" || got.RemainingLength != 1.6 { t.Fatalf("unexpected roll1 after log: %+v", got) }
+
+    // 颜色不符与长度不足均整体回滚
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":1,"roll_ids":[%d]}`, task.TaskID, blue.RollID), "")
+    if w.Code != http.StatusInternalServerError { t.Fatalf("wrong color roll want 500 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":1,"roll_ids":[%d]}`, task.TaskID, roll1.RollID), "")
+    if w.Code != http.StatusConflict { t.Fatalf("short roll want 409 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":1,"roll_ids":[999999999]}`, task.TaskID), "")
+    if w.Code != http.StatusNotFound { t.Fatalf("unknown roll want 404 got %d: %s", w.Code, w.Body.String()) }
+
+    // 作废日志退回长度
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/logs/%d", log.LogID), `{"void_reason":"wrong roll"}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("void log want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/rolls/%d", roll2.RollID), "", "")
+    decodeJSON(t, w, &got)
+    if got.RemainingLength != 50 { t.Fatalf("roll2 length not restored: %+v", got) }
+
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/rolls/%d/usage", roll1.RollID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("usage want 200 got %d: %s", w.Code, w.Body.String()) }
+    var usage []models.LogRollUsage
+    decodeJSON(t, w, &usage)
+    if len(usage) != 1 || !usage[0].Voided { t.Fatalf("unexpected usage: %+v", usage) }
+
+    // 结卷后不可领用；已领用的布卷不可删除
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/rolls/%d/close", roll1.RollID), `{"remnant_length":9.8}`, "")
+    if w.Code != http.StatusOK { t.Fatalf("close roll want 200 got %d: %s", w.Code, w.Body.String()) }
+    decodeJSON(t, w, &got)
+    if got.Status != "closed" || got.ClosedAt == nil { t.Fatalf("unexpected closed roll: %+v", got) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":1,"roll_ids":[%d]}`, task.TaskID, roll1.RollID), "")
+    if w.Code == http.StatusCreated { t.Fatalf("closed roll must not be spread: %s", w.Body.String()) }
+    w, _ = doJSONAuth(r, "DELETE", fmt.Sprintf("/api/v1/rolls/%d", roll1.RollID), "", "")
+    if w.Code != http.StatusInternalServerError { t.Fatalf("delete used roll want 500 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "DELETE", fmt.Sprintf("/api/v1/rolls/%d", blue.RollID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("delete unused roll want 204 got %d: %s", w.Code, w.Body.String()) }
+
+    w, _ = doJSONAuth(r, "GET", "/api/v1/rolls?status=bogus", "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("invalid status filter want 400 got %d: %s", w.Code, w.Body.String()) }
+}
@@ -0,0 +1,127 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+// findScheduleItems returns the timeline items of a task and the unscheduled reason, if any.
+func findScheduleItems(s models.Schedule, taskID int) ([]models.ScheduleItem, int, string) {
+    var items []models.ScheduleItem
+    tableID := 0
+    for _, tl := range s.Tables {
+        for _, it := range tl.Items {
+            if it.TaskID == taskID { items = append(items, it); tableID = tl.TableID }
+        }
+    }
+    for _, u := range s.Unscheduled {
+        if u.TaskID == taskID { return items, tableID, u.Reason }
+    }
+    return items, tableID, ""
+}
+
+func TestScheduleTimelineFollowsLogs(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    suffix := now.UnixNano()
+    w, _ := doJSONAuth(r, "POST", "/api/v1/tables", fmt.Sprintf(`{"table_name":"T-SCH-%d","usable_length":8}`, suffix), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create table want 201 got %d: %s", w.Code, w.Body.String()) }
+    var table models.CuttingTable
+    decodeJSON(t, w, &table)
+
+    createOrder := fmt.Sprintf(`{
+        "order_number": "ORD-%d",
+        "style_number": "STYLE-SCH-001",
+        "order_start_date": "%s",
+        "items": [{"color":"Navy","size":"M","quantity":20},{"color":"Red","size":"M","quantity":20}]
+    }`, suffix, now.Format(time.RFC3339))
+    w, _ = doJSONAuth(r, "POST", "/api/v1/orders", createOrder, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-SCH","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-SCH","plan_id":%d,"marker_length":4.2}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":2}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Navy","planned_layers":10,"table_id":%d}`, layout.LayoutID, table.TableID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create assigned task want 201 got %d: %s", w.Code, w.Body.String()) }
+    var assigned models.ProductionTask
+    decodeJSON(t, w, &assigned)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Red","planned_layers":10}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create unassigned task want 201 got %d: %s", w.Code, w.Body.String()) }
+    var free models.ProductionTask
+    decodeJSON(t, w, &free)
+
+    // 未发布计划不参与排程
+    var sched models.Schedule
+    w, _ = doJSONAuth(r, "GET", "/api/v1/schedule", "", "")
+    if w.Code != http.StatusOK { t.Fatalf("schedule want 200 got %d: %s", w.Code, w.Body.String()) }
+    decodeJSON(t, w, &sched)
+    if items, _, reason := findScheduleItems(sched, assigned.TaskID); len(items) > 0 || reason != "" {
+        t.Fatalf("pending plan must not be scheduled: %+v %s", items, reason)
+    }
+
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+
+    to := now.AddDate(0, 0, 30).Format("2006-01-02")
+    w, _ = doJSONAuth(r, "GET", "/api/v1/schedule?to="+to, "", "")
+    if w.Code != http.StatusOK { t.Fatalf("schedule want 200 got %d: %s", w.Code, w.Body.String()) }
+    decodeJSON(t, w, &sched)
+    items, tableID, reason := findScheduleItems(sched, assigned.TaskID)
+    if reason != "" || len(items) == 0 || tableID != table.TableID { t.Fatalf("assigned task not on its table: %+v table=%d reason=%s", items, tableID, reason) }
+    total := 0
+    for _, it := range items {
+        if !it.Assigned || it.RemainingLayers != 10 || !it.End.After(it.Start) { t.Fatalf("unexpected item: %+v", it) }
+        total += it.Layers
+    }
+    if total != 10 { t.Fatalf("scheduled layers want 10 got %d", total) }
+    // 未分配任务要么作为建议排入某张裁床，要么给出原因
+    items, _, reason = findScheduleItems(sched, free.TaskID)
+    if len(items) == 0 && reason == "" { t.Fatalf("unassigned task missing from schedule") }
+    for _, it := range items { if it.Assigned { t.Fatalf("unassigned task marked assigned: %+v", it) } }
+
+    // 提交日志后剩余层数立即减少，作废后恢复
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":4}`, assigned.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log want 201 got %d: %s", w.Code, w.Body.String()) }
+    var log models.ProductionLog
+    decodeJSON(t, w, &log)
+    w, _ = doJSONAuth(r, "GET", "/api/v1/schedule?to="+to, "", "")
+    decodeJSON(t, w, &sched)
+    items, _, _ = findScheduleItems(sched, assigned.TaskID)
+    if len(items) == 0 || items[0].RemainingLayers != 6 { t.Fatalf("remaining after log want 6 got %+v", items) }
+
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/logs/%d", log.LogID), `{"void_reason":"recount"}`, "")
+    if w.Code != http.StatusOK && w.Code != http.StatusNoContent { t.Fatalf("void want 2xx got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", "/api/v1/schedule?to="+to, "", "")
+    decodeJSON(t, w, &sched)
+    items, _, _ = findScheduleItems(sched, assigned.TaskID)
+    if len(items) == 0 || items[0].RemainingLayers != 10 { t.Fatalf("remaining after void want 10 got %+v", items) }
+
+    // 维护中的裁床上的已分配任务不排程
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/tables/%d/status", table.TableID), `{"status":"maintenance"}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("maintenance want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", "/api/v1/schedule", "", "")
+    decodeJSON(t, w, &sched)
+    if _, _, reason = findScheduleItems(sched, assigned.TaskID); reason != "table_in_maintenance" {
+        t.Fatalf("reason want table_in_maintenance got %q", reason)
+    }
+
+    w, _ = doJSONAuth(r, "GET", "/api/v1/schedule?from=yesterday", "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("bad from want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", "/api/v1/schedule?from=2030-01-10&to=2030-01-01", "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("inverted window want 400 got %d: %s", w.Code, w.Body.String()) }
+}
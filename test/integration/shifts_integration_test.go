@@ -0,0 +1,122 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func findShiftSummary(rep models.ShiftReport, shiftID int) *models.ShiftSummary {
+    for i := range rep.Shifts {
+        if rep.Shifts[i].ShiftID != nil && *rep.Shifts[i].ShiftID == shiftID { return &rep.Shifts[i] }
+    }
+    return nil
+}
+
+func TestShiftsAttributionAndReport(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    // 独占班次配置：停用已有班次，时区固定为 UTC
+    if _, err := conn.Exec(`UPDATE production.shifts SET is_active = FALSE WHERE is_active`); err != nil { t.Fatalf("reset shifts: %v", err) }
+    w, _ := doJSONAuth(r, "PATCH", "/api/v1/factory-settings", `{"timezone":"Mars/Base"}`, "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("unknown timezone want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "PATCH", "/api/v1/factory-settings", `{"timezone":"UTC"}`, "")
+    if w.Code != http.StatusOK { t.Fatalf("set timezone want 200 got %d: %s", w.Code, w.Body.String()) }
+
+    suffix := time.Now().UnixNano()
+    w, _ = doJSONAuth(r, "POST", "/api/v1/shifts", fmt.Sprintf(`{"shift_name":"DAY-%d","start_time":"08:00","end_time":"20:00"}`, suffix), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create day shift want 201 got %d: %s", w.Code, w.Body.String()) }
+    var day models.Shift
+    decodeJSON(t, w, &day)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/shifts", fmt.Sprintf(`{"shift_name":"NIGHT-%d","start_time":"20:00","end_time":"08:00"}`, suffix), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create night shift want 201 got %d: %s", w.Code, w.Body.String()) }
+    var night models.Shift
+    decodeJSON(t, w, &night)
+    if !night.CrossesMidnight || day.CrossesMidnight { t.Fatalf("crosses_midnight wrong: day=%+v night=%+v", day, night) }
+    t.Cleanup(func() {
+        for _, s := range []models.Shift{day, night} {
+            doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/shifts/%d", s.ShiftID), fmt.Sprintf(`{"shift_name":"%s","start_time":"%s","end_time":"%s","is_active":false}`, s.ShiftName, s.StartTime, s.EndTime), "")
+        }
+    })
+    w, _ = doJSONAuth(r, "POST", "/api/v1/shifts", fmt.Sprintf(`{"shift_name":"LATE-%d","start_time":"19:00","end_time":"23:00"}`, suffix), "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("overlapping shift want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/shifts", fmt.Sprintf(`{"shift_name":"DAY-%d","start_time":"01:00","end_time":"02:00","is_active":false}`, suffix), "")
+    if w.Code != http.StatusConflict { t.Fatalf("duplicate name want 409 got %d: %s", w.Code, w.Body.String()) }
+
+    createOrder := fmt.Sprintf(`{
+        "order_number": "ORD-%d",
+        "style_number": "STYLE-SHIFT-001",
+        "order_start_date": "%s",
+        "items": [{"color":"Navy","size":"M","quantity":40}]
+    }`, suffix, time.Now().UTC().Format(time.RFC3339))
+    w, _ = doJSONAuth(r, "POST", "/api/v1/orders", createOrder, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-SHIFT","order_id":%d}`, order.OrderID), "")
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-SHIFT","plan_id":%d}`, plan.PlanID), "")
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":2}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Navy","planned_layers":20}`, layout.LayoutID), "")
+    var task models.ProductionTask
+    decodeJSON(t, w, &task)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+
+    // 按 UTC 时刻写入日志（log_time 为会话时区的本地时间）
+    worker := fmt.Sprintf("W-%d", suffix)
+    insertAt := func(at string, layers int) int {
+        var id int
+        err := conn.QueryRow(`
+            INSERT INTO production.logs (task_id, worker_name, layers_completed, log_time)
+            VALUES ($1, $2, $3, ($4::timestamp AT TIME ZONE 'UTC') AT TIME ZONE current_setting('TimeZone'))
+            RETURNING log_id`, task.TaskID, worker, layers, at).Scan(&id)
+        if err != nil { t.Fatalf("insert log at %s: %v", at, err) }
+        return id
+    }
+    insertAt("2031-03-10 22:00", 3)
+    insertAt("2031-03-11 02:00", 2) // 夜班跨零点，仍归属 03-10
+    insertAt("2031-03-11 09:00", 4)
+    voided := insertAt("2031-03-10 23:00", 5)
+    if _, err := conn.Exec(`UPDATE production.logs SET voided = TRUE, void_reason = 'test' WHERE log_id = $1`, voided); err != nil { t.Fatalf("void: %v", err) }
+
+    var rep models.ShiftReport
+    w, _ = doJSONAuth(r, "GET", "/api/v1/reports/shifts?date=2031-03-10", "", "")
+    if w.Code != http.StatusOK { t.Fatalf("report want 200 got %d: %s", w.Code, w.Body.String()) }
+    decodeJSON(t, w, &rep)
+    if rep.Timezone != "UTC" { t.Fatalf("timezone want UTC got %s", rep.Timezone) }
+    n := findShiftSummary(rep, night.ShiftID)
+    if n == nil || n.Layers != 5 || n.Pieces != 10 { t.Fatalf("night shift want 5 layers/10 pieces got %+v", n) }
+    if len(n.Workers) != 1 || n.Workers[0].WorkerName != worker || len(n.Plans) != 1 || n.Plans[0].PlanID != plan.PlanID || len(n.Groups) != 1 {
+        t.Fatalf("unexpected night breakdown: %+v", n)
+    }
+    if d := findShiftSummary(rep, day.ShiftID); d == nil || d.Layers != 0 { t.Fatalf("day shift on 03-10 want 0 layers got %+v", d) }
+
+    w, _ = doJSONAuth(r, "GET", "/api/v1/reports/shifts?date=2031-03-11", "", "")
+    decodeJSON(t, w, &rep)
+    if d := findShiftSummary(rep, day.ShiftID); d == nil || d.Layers != 4 || d.Pieces != 8 { t.Fatalf("day shift on 03-11 want 4 layers got %+v", d) }
+    if n := findShiftSummary(rep, night.ShiftID); n == nil || n.Layers != 0 { t.Fatalf("night shift on 03-11 want 0 layers got %+v", n) }
+
+    w, _ = doJSONAuth(r, "GET", "/api/v1/reports/shifts?date=11/03/2031", "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("bad date want 400 got %d: %s", w.Code, w.Body.String()) }
+
+    // 通过接口提交的日志同样归属班次日期
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":1}`, task.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log want 201 got %d: %s", w.Code, w.Body.String()) }
+    var log models.ProductionLog
+    decodeJSON(t, w, &log)
+    if log.ShiftID == nil || log.ShiftDate == nil { t.Fatalf("log not attributed: %+v", log) }
+
+    // 有归属日志的班次不可删除
+    w, _ = doJSONAuth(r, "DELETE", fmt.Sprintf("/api/v1/shifts/%d", night.ShiftID), "", "")
+    if w.Code != http.StatusInternalServerError { t.Fatalf("delete used shift want 500 got %d: %s", w.Code, w.Body.String()) }
+}
@@ -0,0 +1,133 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestStyleCatalogAndSizeOrder(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    styleNumber := fmt.Sprintf("STY-%d", now.UnixNano())
+    createOrder := func(items string) (int, models.ProductionOrder) {
+        body := fmt.Sprintf(`{"order_number":"ORD-STY-%d","style_number":"%s","order_start_date":"%s","items":[%s]}`, time.Now().UnixNano(), styleNumber, now.Format(time.RFC3339), items)
+        w, _ := doJSONAuth(r, "POST", "/api/v1/orders", body, "")
+        var o models.ProductionOrder
+        if w.Code == http.StatusCreated { decodeJSON(t, w, &o) }
+        return w.Code, o
+    }
+
+    // 未登记款式：不校验
+    if code, _ := createOrder(`{"color":"Green","size":"XXL","quantity":5}`); code != http.StatusCreated { t.Fatalf("unregistered style order want 201 got %d", code) }
+
+    // 登记款式：尺码段顺序即标准顺序；部件裁片数默认 1
+    w, _ := doJSONAuth(r, "POST", "/api/v1/styles", fmt.Sprintf(`{"style_number":" %s ","style_name":"Parka","sizes":["XS","S","M","L","XL","XXL"],"colors":["Navy","Red","Green"],"components":[{"component_name":"shell","pieces":4},{"component_name":"lining"}]}`, styleNumber), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create style want 201 got %d: %s", w.Code, w.Body.String()) }
+    var style models.Style
+    decodeJSON(t, w, &style)
+    if style.StyleNumber != styleNumber || len(style.Sizes) != 6 || style.Components[1].Pieces != 1 { t.Fatalf("unexpected style: %+v", style) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/styles", fmt.Sprintf(`{"style_number":"%s","sizes":["M"]}`, styleNumber), "")
+    if w.Code != http.StatusConflict { t.Fatalf("duplicate style want 409 got %d: %s", w.Code, w.Body.String()) }
+    for _, body := range []string{
+        `{"style_number":"STY-BAD-1","sizes":[]}`,
+        `{"style_number":"STY-BAD-2","sizes":["M","M"]}`,
+        `{"style_number":"STY-BAD-3","sizes":["M"],"components":[{"component_name":"shell","pieces":-1}]}`,
+        `{"style_number":"","sizes":["M"]}`,
+    } {
+        w, _ = doJSONAuth(r, "POST", "/api/v1/styles", body, "")
+        if w.Code != http.StatusBadRequest { t.Fatalf("invalid style %s want 400 got %d: %s", body, w.Code, w.Body.String()) }
+    }
+    w, _ = doJSONAuth(r, "GET", "/api/v1/styles/by-number/"+styleNumber, "", "")
+    if w.Code != http.StatusOK { t.Fatalf("get style by number want 200 got %d: %s", w.Code, w.Body.String()) }
+    var got models.Style
+    decodeJSON(t, w, &got)
+    if got.StyleID != style.StyleID || got.Orders != 1 { t.Fatalf("style by number should count the existing order: %+v", got) }
+
+    // 已登记款式：订单项尺码须在尺码段内、颜色须在允许颜色内
+    if code, _ := createOrder(`{"color":"Navy","size":"3XL","quantity":5}`); code != http.StatusBadRequest { t.Fatalf("size outside run want 400 got %d", code) }
+    if code, _ := createOrder(`{"color":"Black","size":"M","quantity":5}`); code != http.StatusBadRequest { t.Fatalf("color not allowed want 400 got %d", code) }
+    // 首尾空白不影响校验，订单项按去除空白后的值保存
+    code, padded := createOrder(`{"color":"Navy ","size":" M","quantity":5}`)
+    if code != http.StatusCreated { t.Fatalf("padded color/size want 201 got %d", code) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/orders/%d/revisions/1", padded.OrderID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("get revision want 200 got %d: %s", w.Code, w.Body.String()) }
+    var paddedRev models.OrderRevision
+    decodeJSON(t, w, &paddedRev)
+    if len(paddedRev.Items) != 1 || paddedRev.Items[0].Color != "Navy" || paddedRev.Items[0].Size != "M" { t.Fatalf("items should be stored trimmed: %+v", paddedRev.Items) }
+    code, order := createOrder(`{"color":"Navy","size":"L","quantity":10},{"color":"Navy","size":"XS","quantity":10},{"color":"Navy","size":"M","quantity":10}`)
+    if code != http.StatusCreated { t.Fatalf("valid order want 201 got %d", code) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/orders/%d/revisions", order.OrderID), `{"changes":[{"kind":"add","color":"Navy","size":"3XL","quantity":5}]}`, "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("revision adding size outside run want 400 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/orders/%d/revisions", order.OrderID), `{"changes":[{"kind":"add","color":"Red","size":"S","quantity":5}]}`, "")
+    if w.Code != http.StatusCreated { t.Fatalf("revision adding valid item want 201 got %d: %s", w.Code, w.Body.String()) }
+
+    // 布局尺码比例与覆盖度按尺码段顺序，而非字母顺序
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-STY","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-STY","plan_id":%d}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"L":1,"M":1,"XS":1}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("get ratios want 200 got %d: %s", w.Code, w.Body.String()) }
+    var ratios []models.LayoutSizeRatio
+    decodeJSON(t, w, &ratios)
+    if len(ratios) != 3 || ratios[0].Size != "XS" || ratios[1].Size != "M" || ratios[2].Size != "L" { t.Fatalf("ratios should follow the size run: %+v", ratios) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Navy","planned_layers":2}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d/coverage", plan.PlanID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("coverage want 200 got %d: %s", w.Code, w.Body.String()) }
+    var cov models.PlanCoverage
+    decodeJSON(t, w, &cov)
+    if fmt.Sprint(cov.Sizes) != "[XS S M L]" { t.Fatalf("coverage sizes should follow the size run, got %v", cov.Sizes) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/orders/%d/progress", order.OrderID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("progress want 200 got %d: %s", w.Code, w.Body.String()) }
+    var progress models.OrderProgress
+    decodeJSON(t, w, &progress)
+    var navy []string
+    for _, c := range progress.Cells {
+        if c.Color == "Navy" { navy = append(navy, c.Size) }
+    }
+    if fmt.Sprint(navy) != "[XS M L]" { t.Fatalf("progress cells should follow the size run, got %v", navy) }
+
+    // 款式订单列表
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/styles/%d/orders", style.StyleID), "", "")
+    if w.Code != http.StatusOK { t.Fatalf("style orders want 200 got %d: %s", w.Code, w.Body.String()) }
+    var orders []models.ProductionOrder
+    decodeJSON(t, w, &orders)
+    if len(orders) != 3 || orders[0].OrderID != order.OrderID { t.Fatalf("style orders want 3 newest first, got %+v", orders) }
+    w, _ = doJSONAuth(r, "GET", "/api/v1/orders?style_number="+styleNumber, "", "")
+    if w.Code != http.StatusOK { t.Fatalf("orders by style want 200 got %d: %s", w.Code, w.Body.String()) }
+    decodeJSON(t, w, &orders)
+    if len(orders) != 3 { t.Fatalf("orders by style want 3, got %d", len(orders)) }
+    w, _ = doJSONAuth(r, "GET", "/api/v1/styles/999999999/orders", "", "")
+    if w.Code != http.StatusNotFound { t.Fatalf("orders of missing style want 404 got %d: %s", w.Code, w.Body.String()) }
+
+    // 修改：整体替换，款号不变
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/styles/%d", style.StyleID), `{"style_number":"OTHER","sizes":["L","M","XS"],"colors":[]}`, "")
+    if w.Code != http.StatusOK { t.Fatalf("update style want 200 got %d: %s", w.Code, w.Body.String()) }
+    decodeJSON(t, w, &got)
+    if got.StyleNumber != styleNumber || fmt.Sprint(got.Sizes) != "[L M XS]" || len(got.Colors) != 0 || len(got.Components) != 0 || got.StyleName != nil {
+        t.Fatalf("style not replaced as expected: %+v", got)
+    }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), "", "")
+    decodeJSON(t, w, &ratios)
+    if ratios[0].Size != "L" || ratios[2].Size != "XS" { t.Fatalf("ratios should follow the new size run: %+v", ratios) }
+    if code, _ := createOrder(`{"color":"Black","size":"M","quantity":5}`); code != http.StatusCreated { t.Fatalf("any color allowed after clearing colors, got %d", code) }
+
+    // 删除款式后不再校验
+    w, _ = doJSONAuth(r, "DELETE", fmt.Sprintf("/api/v1/styles/%d", style.StyleID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("delete style want 204 got %d: %s", w.Code, w.Body.String()) }
+    if code, _ := createOrder(`{"color":"Navy","size":"3XL","quantity":5}`); code != http.StatusCreated { t.Fatalf("order after style delete want 201 got %d", code) }
+}
@@ -0,0 +1,112 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestCuttingTablesAssignmentAndLogs(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    suffix := now.UnixNano()
+    w, _ := doJSONAuth(r, "POST", "/api/v1/tables", fmt.Sprintf(`{"table_name":"T-SHORT-%d","usable_length":2.5}`, suffix), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create short table want 201 got %d: %s", w.Code, w.Body.String()) }
+    var short models.CuttingTable
+    decodeJSON(t, w, &short)
+    if short.Status != "active" { t.Fatalf("default status want active got %s", short.Status) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tables", fmt.Sprintf(`{"table_name":"T-LONG-%d","usable_length":8}`, suffix), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create long table want 201 got %d: %s", w.Code, w.Body.String()) }
+    var long models.CuttingTable
+    decodeJSON(t, w, &long)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tables", fmt.Sprintf(`{"table_name":"T-LONG-%d","usable_length":8}`, suffix), "")
+    if w.Code != http.StatusConflict { t.Fatalf("duplicate name want 409 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tables", `{"table_name":"T-BAD","usable_length":0}`, "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("zero length want 400 got %d: %s", w.Code, w.Body.String()) }
+
+    orderNumber := fmt.Sprintf("ORD-%d", suffix)
+    createOrder := fmt.Sprintf(`{
+        "order_number": "%s",
+        "style_number": "STYLE-TBL-001",
+        "order_start_date": "%s",
+        "items": [{"color":"Navy","size":"M","quantity":20}]
+    }`, orderNumber, now.Format(time.RFC3339))
+    w, _ = doJSONAuth(r, "POST", "/api/v1/orders", createOrder, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-TBL","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-TBL","plan_id":%d,"marker_length":4.2}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":2}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Navy","planned_layers":10}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+    var task models.ProductionTask
+    decodeJSON(t, w, &task)
+
+    // 唛架 4.2 米超出 2.5 米裁床
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/tasks/%d/table", task.TaskID), fmt.Sprintf(`{"table_id":%d}`, short.TableID), "")
+    if w.Code != http.StatusInternalServerError { t.Fatalf("assign short table want 500 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/tasks/%d/table", task.TaskID), fmt.Sprintf(`{"table_id":%d}`, long.TableID), "")
+    if w.Code != http.StatusOK { t.Fatalf("assign long table want 200 got %d: %s", w.Code, w.Body.String()) }
+    decodeJSON(t, w, &task)
+    if task.TableID == nil || *task.TableID != long.TableID { t.Fatalf("task table not set: %+v", task) }
+
+    // 已分配后不可把唛架加长到超出裁床，也不可缩短裁床
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/layouts/%d/marker", layout.LayoutID), `{"marker_length":9}`, "")
+    if w.Code == http.StatusNoContent || w.Code == http.StatusOK { t.Fatalf("marker beyond table must be rejected got %d", w.Code) }
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/tables/%d", long.TableID), fmt.Sprintf(`{"table_name":"T-LONG-%d","usable_length":4}`, suffix), "")
+    if w.Code != http.StatusInternalServerError { t.Fatalf("shrink table want 500 got %d: %s", w.Code, w.Body.String()) }
+
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tables/%d", long.TableID), "", "")
+    decodeJSON(t, w, &long)
+    if long.CurrentTaskID == nil || *long.CurrentTaskID != task.TaskID || long.OpenTasks != 1 {
+        t.Fatalf("unexpected current assignment: %+v", long)
+    }
+
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+
+    // 日志缺省记录任务所在裁床
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":4}`, task.TaskID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("log want 201 got %d: %s", w.Code, w.Body.String()) }
+    var log models.ProductionLog
+    decodeJSON(t, w, &log)
+    if log.TableID == nil || *log.TableID != long.TableID { t.Fatalf("log table want %d got %+v", long.TableID, log.TableID) }
+    // 指定的裁床同样需容纳唛架
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":1,"table_id":%d}`, task.TaskID, short.TableID), "")
+    if w.Code != http.StatusInternalServerError { t.Fatalf("log on short table want 500 got %d: %s", w.Code, w.Body.String()) }
+
+    // 维护中的裁床不可拉布；发布后仍可改派
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/tables/%d/status", long.TableID), `{"status":"maintenance"}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("maintenance want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":1}`, task.TaskID), "")
+    if w.Code != http.StatusInternalServerError { t.Fatalf("log on maintenance table want 500 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/tasks/%d/table", task.TaskID), `{"table_id":null}`, "")
+    if w.Code != http.StatusOK { t.Fatalf("unassign want 200 got %d: %s", w.Code, w.Body.String()) }
+
+    w, _ = doJSONAuth(r, "GET", "/api/v1/tables?status=maintenance", "", "")
+    var list []models.CuttingTable
+    decodeJSON(t, w, &list)
+    found := false
+    for _, tb := range list { if tb.TableID == long.TableID { found = true } }
+    if !found { t.Fatalf("maintenance filter missing table %d", long.TableID) }
+
+    // 有日志引用的裁床不可删除；未使用的可以
+    w, _ = doJSONAuth(r, "DELETE", fmt.Sprintf("/api/v1/tables/%d", long.TableID), "", "")
+    if w.Code != http.StatusInternalServerError { t.Fatalf("delete used table want 500 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "DELETE", fmt.Sprintf("/api/v1/tables/%d", short.TableID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("delete unused table want 204 got %d: %s", w.Code, w.Body.String()) }
+}
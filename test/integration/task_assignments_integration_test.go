@@ -0,0 +1,122 @@
+package integration
+
+import (
+    "database/sql"
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+
+    "cutrix-backend/internal/handlers"
+    "cutrix-backend/internal/middleware"
+    "cutrix-backend/internal/models"
+    "cutrix-backend/internal/repositories"
+    "cutrix-backend/internal/services"
+)
+
+// buildAssignmentRouter mounts tasks, logs (with the assignment check enabled) and assignments under RequireAuth.
+func buildAssignmentRouter(conn *sql.DB) *gin.Engine {
+    r := gin.New()
+    r.Use(middleware.RequestID())
+    api := r.Group("/api/v1")
+
+    usersRepo := repositories.NewSqlUsersRepository(conn)
+    authSvc := services.NewAuthService(usersRepo, "test-secret", time.Minute, 24*time.Hour)
+    handlers.RegisterRoutes(api, authSvc, services.NewUsersService(usersRepo))
+
+    assignmentsSvc := services.NewTaskAssignmentsService(repositories.NewSqlTaskAssignmentsRepository(conn))
+    protected := api.Group("")
+    protected.Use(middleware.RequireAuth(authSvc))
+    handlers.NewOrdersHandler(services.NewOrdersService(repositories.NewSqlOrdersRepository(conn), repositories.NewSqlStylesRepository(conn), repositories.NewSqlCustomersRepository(conn))).RegisterProtected(protected)
+    handlers.NewPlansHandler(services.NewPlansService(repositories.NewSqlPlansRepository(conn))).RegisterProtected(protected)
+    handlers.NewLayoutsHandler(services.NewLayoutsService(repositories.NewSqlLayoutsRepository(conn))).RegisterProtected(protected)
+    handlers.NewTasksHandler(services.NewTasksService(repositories.NewSqlTasksRepository(conn))).RegisterProtected(protected)
+    handlers.NewLogsHandler(services.NewLogsService(repositories.NewSqlLogsRepository(conn))).RequireAssignment(assignmentsSvc).RegisterProtected(protected)
+    handlers.NewTaskAssignmentsHandler(assignmentsSvc).RegisterProtected(protected)
+    return r
+}
+
+func listTaskIDs(t *testing.T, r *gin.Engine, token string) map[int]bool {
+    t.Helper()
+    w, _ := doJSONAuth(r, http.MethodGet, "/api/v1/tasks", "", token)
+    if w.Code != http.StatusOK { t.Fatalf("list tasks code=%d body=%s", w.Code, w.Body.String()) }
+    var tasks []models.ProductionTask
+    decodeJSON(t, w, &tasks)
+    ids := map[int]bool{}
+    for _, task := range tasks { ids[task.TaskID] = true }
+    return ids
+}
+
+func TestTaskAssignments_WorkerScopeAndLogCheck(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    t.Cleanup(func(){ conn.Close() })
+    r := buildAssignmentRouter(conn)
+
+    suffix := time.Now().UnixNano()
+    mgrName := fmt.Sprintf("manager_%d", suffix)
+    _ = createUser(t, conn, mgrName, "manager", "Mgr123!")
+    mgrToken, _ := login(t, r, mgrName, "Mgr123!")
+    _, _, taskA := seedPlanLayoutTask(t, r, mgrToken, seedOrder(t, r, mgrToken))
+    _, _, taskB := seedPlanLayoutTask(t, r, mgrToken, seedOrder(t, r, mgrToken))
+
+    group := fmt.Sprintf("G-%d", suffix)
+    soloID := createUser(t, conn, fmt.Sprintf("solo_%d", suffix), "worker", "Wkr123!")
+    memberID := createUser(t, conn, fmt.Sprintf("member_%d", suffix), "worker", "Wkr123!")
+    if _, err := conn.Exec(`UPDATE public.users SET user_group = $1 WHERE user_id = $2`, group, memberID); err != nil { t.Fatalf("set group: %v", err) }
+    soloToken, _ := login(t, r, fmt.Sprintf("solo_%d", suffix), "Wkr123!")
+    memberToken, _ := login(t, r, fmt.Sprintf("member_%d", suffix), "Wkr123!")
+
+    // 工人不可分配任务
+    w, _ := doJSONAuth(r, http.MethodPost, fmt.Sprintf("/api/v1/tasks/%d/assignments", taskA), fmt.Sprintf(`{"user_ids":[%d]}`, soloID), soloToken)
+    if w.Code != http.StatusForbidden { t.Fatalf("worker assign should be 403, got %d", w.Code) }
+    w, _ = doJSONAuth(r, http.MethodPost, fmt.Sprintf("/api/v1/tasks/%d/assignments", taskA), `{}`, mgrToken)
+    if w.Code != http.StatusBadRequest { t.Fatalf("empty assignment want 400 got %d: %s", w.Code, w.Body.String()) }
+
+    w, _ = doJSONAuth(r, http.MethodPost, fmt.Sprintf("/api/v1/tasks/%d/assignments", taskA), fmt.Sprintf(`{"user_ids":[%d,%d]}`, soloID, soloID), mgrToken)
+    if w.Code != http.StatusCreated { t.Fatalf("assign user want 201 got %d: %s", w.Code, w.Body.String()) }
+    var active []models.TaskAssignment
+    decodeJSON(t, w, &active)
+    if len(active) != 1 || active[0].UserID == nil || *active[0].UserID != soloID || active[0].AssignedBy == nil {
+        t.Fatalf("unexpected assignments: %+v", active)
+    }
+    w, _ = doJSONAuth(r, http.MethodPost, fmt.Sprintf("/api/v1/tasks/%d/assignments", taskB), fmt.Sprintf(`{"user_group":"%s"}`, group), mgrToken)
+    if w.Code != http.StatusCreated { t.Fatalf("assign group want 201 got %d: %s", w.Code, w.Body.String()) }
+
+    // 工人只看到分配给自己或所在组的任务
+    ids := listTaskIDs(t, r, soloToken)
+    if !ids[taskA] || ids[taskB] { t.Fatalf("solo worker tasks: %v", ids) }
+    ids = listTaskIDs(t, r, memberToken)
+    if ids[taskA] || !ids[taskB] { t.Fatalf("group member tasks: %v", ids) }
+    ids = listTaskIDs(t, r, mgrToken)
+    if !ids[taskA] || !ids[taskB] { t.Fatalf("manager must see all tasks: %v", ids) }
+
+    // 仅已分配的工人可提交日志；管理者不受限制
+    logBody := func(taskID int) string { return fmt.Sprintf(`{"task_id":%d,"layers_completed":1}`, taskID) }
+    w, _ = doJSONAuth(r, http.MethodPost, "/api/v1/logs", logBody(taskB), soloToken)
+    if w.Code != http.StatusForbidden { t.Fatalf("unassigned worker log should be 403, got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, http.MethodPost, "/api/v1/logs", logBody(taskA), soloToken)
+    if w.Code != http.StatusCreated { t.Fatalf("assigned worker log want 201 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, http.MethodPost, "/api/v1/logs", logBody(taskB), memberToken)
+    if w.Code != http.StatusCreated { t.Fatalf("group worker log want 201 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, http.MethodPost, "/api/v1/logs", logBody(taskB), mgrToken)
+    if w.Code != http.StatusCreated { t.Fatalf("manager log want 201 got %d: %s", w.Code, w.Body.String()) }
+
+    // 撤销后保留历史，工人失去可见性与提交权限
+    w, _ = doJSONAuth(r, http.MethodDelete, fmt.Sprintf("/api/v1/tasks/%d/assignments/%d", taskA, active[0].AssignmentID), "", mgrToken)
+    if w.Code != http.StatusNoContent { t.Fatalf("unassign want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, http.MethodDelete, fmt.Sprintf("/api/v1/tasks/%d/assignments/%d", taskA, active[0].AssignmentID), "", mgrToken)
+    if w.Code != http.StatusNotFound { t.Fatalf("repeat unassign want 404 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, http.MethodGet, fmt.Sprintf("/api/v1/tasks/%d/assignments", taskA), "", soloToken)
+    if w.Code != http.StatusOK { t.Fatalf("list assignments want 200 got %d: %s", w.Code, w.Body.String()) }
+    decodeJSON(t, w, &active)
+    if len(active) != 0 { t.Fatalf("active assignments after unassign: %+v", active) }
+    w, _ = doJSONAuth(r, http.MethodGet, fmt.Sprintf("/api/v1/tasks/%d/assignments?history=true", taskA), "", mgrToken)
+    var history []models.TaskAssignment
+    decodeJSON(t, w, &history)
+    if len(history) != 1 || history[0].UnassignedAt == nil || history[0].UnassignedBy == nil { t.Fatalf("unexpected history: %+v", history) }
+    if ids = listTaskIDs(t, r, soloToken); ids[taskA] { t.Fatalf("unassigned task still visible: %v", ids) }
+    w, _ = doJSONAuth(r, http.MethodPost, "/api/v1/logs", logBody(taskA), soloToken)
+    if w.Code != http.StatusForbidden { t.Fatalf("log after unassign should be 403, got %d", w.Code) }
+}
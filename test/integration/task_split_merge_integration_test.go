@@ -0,0 +1,130 @@
+package integration
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestTaskSplitMerge(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    body := fmt.Sprintf(`{"order_number":"ORD-%d-SPL","style_number":"STYLE-SPL-001","order_start_date":"%s","items":[{"color":"Navy","size":"M","quantity":20},{"color":"Navy","size":"L","quantity":20}]}`, now.UnixNano(), now.Format(time.RFC3339))
+    w, _ := doJSONAuth(r, "POST", "/api/v1/orders", body, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-SPL","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-SPL","plan_id":%d}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":1,"L":1}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+    createTask := func(layers int) models.ProductionTask {
+        w, _ := doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Navy","planned_layers":%d}`, layout.LayoutID, layers), "")
+        if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+        var task models.ProductionTask
+        decodeJSON(t, w, &task)
+        return task
+    }
+    taskA, taskB := createTask(10), createTask(4)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish want 204 got %d: %s", w.Code, w.Body.String()) }
+    logLayers := func(taskID, layers int, extra string) {
+        w, _ := doJSONAuth(r, "POST", "/api/v1/logs", fmt.Sprintf(`{"task_id":%d,"layers_completed":%d,"worker_name":"spl-worker"%s}`, taskID, layers, extra), "")
+        if w.Code != http.StatusCreated { t.Fatalf("log want 201 got %d: %s", w.Code, w.Body.String()) }
+    }
+    getTask := func(id int) (models.ProductionTask, int) {
+        w, _ := doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d", id), "", "")
+        var task models.ProductionTask
+        if w.Code == http.StatusOK { decodeJSON(t, w, &task) }
+        return task, w.Code
+    }
+    logLayers(taskA.TaskID, 4, `,"dye_lot":"LOT-A"`)
+
+    // 拆分：超过剩余层数被拒绝
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/tasks/%d/split", taskA.TaskID), `{"layers":7}`, "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("split beyond remaining want 400 got %d: %s", w.Code, w.Body.String()) }
+    // 拆出全部剩余 6 层：原任务 4/4 完成，新任务 0/6 进行中，日志留在原任务
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/tasks/%d/split", taskA.TaskID), `{"layers":6}`, "")
+    if w.Code != http.StatusCreated { t.Fatalf("split want 201 got %d: %s", w.Code, w.Body.String()) }
+    var split struct {
+        Task    models.ProductionTask `json:"task"`
+        NewTask models.ProductionTask `json:"new_task"`
+    }
+    decodeJSON(t, w, &split)
+    if split.Task.PlannedLayers != 4 || split.Task.CompletedLayers != 4 || split.Task.Status != "completed" {
+        t.Fatalf("unexpected original after split: %+v", split.Task)
+    }
+    taskC := split.NewTask
+    if taskC.LayoutID != layout.LayoutID || taskC.Color != "Navy" || taskC.PlannedLayers != 6 || taskC.CompletedLayers != 0 || taskC.Status != "in_progress" {
+        t.Fatalf("unexpected new task: %+v", taskC)
+    }
+
+    logLayers(taskC.TaskID, 2, `,"dye_lot":"LOT-B"`)
+    logLayers(taskB.TaskID, 4, `,"dye_lot":"LOT-A"`)
+
+    // 合并不同缸号：默认拒绝
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/tasks/%d/merge", taskB.TaskID), fmt.Sprintf(`{"task_ids":[%d]}`, taskC.TaskID), "")
+    if w.Code != http.StatusConflict { t.Fatalf("merge lot mix want 409 got %d: %s", w.Code, w.Body.String()) }
+
+    // 合并其它布局的任务：400
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-SPL-2","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan 2 want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan2 models.ProductionPlan
+    decodeJSON(t, w, &plan2)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-SPL-2","plan_id":%d}`, plan2.PlanID), "")
+    var layout2 models.CuttingLayout
+    decodeJSON(t, w, &layout2)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Navy","planned_layers":2}`, layout2.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create other-layout task want 201 got %d: %s", w.Code, w.Body.String()) }
+    var other models.ProductionTask
+    decodeJSON(t, w, &other)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/tasks/%d/merge", taskB.TaskID), fmt.Sprintf(`{"task_ids":[%d]}`, other.TaskID), "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("merge other layout want 400 got %d: %s", w.Code, w.Body.String()) }
+
+    // 合并两个已完成的同缸号任务：层数相加，日志与缸号层数迁移，扎包重建
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/tasks/%d/merge", taskB.TaskID), fmt.Sprintf(`{"task_ids":[%d]}`, taskA.TaskID), "")
+    if w.Code != http.StatusOK { t.Fatalf("merge want 200 got %d: %s", w.Code, w.Body.String()) }
+    var merged models.ProductionTask
+    decodeJSON(t, w, &merged)
+    if merged.PlannedLayers != 8 || merged.CompletedLayers != 8 || merged.Status != "completed" { t.Fatalf("unexpected merged task: %+v", merged) }
+    if _, code := getTask(taskA.TaskID); code != http.StatusNotFound { t.Fatalf("merged source want 404 got %d", code) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/lots", taskB.TaskID), "", "")
+    var lots []models.TaskLotLayers
+    decodeJSON(t, w, &lots)
+    if len(lots) != 1 || lots[0].DyeLot != "LOT-A" || lots[0].CompletedLayers != 8 { t.Fatalf("unexpected merged lots: %+v", lots) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/tasks/%d/bundles", taskB.TaskID), "", "")
+    var bundles []models.Bundle
+    decodeJSON(t, w, &bundles)
+    if len(bundles) != 2 || bundles[0].PlyFrom != 1 || bundles[0].PlyTo != 8 { t.Fatalf("unexpected rebuilt bundles: %+v", bundles) }
+
+    // 显式允许混缸：合并进行中的任务后目标回到 in_progress，计划仍为进行中
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/tasks/%d/merge", taskB.TaskID), fmt.Sprintf(`{"task_ids":[%d],"allow_lot_mix":true}`, taskC.TaskID), "")
+    if w.Code != http.StatusOK { t.Fatalf("merge with lot mix want 200 got %d: %s", w.Code, w.Body.String()) }
+    decodeJSON(t, w, &merged)
+    if merged.PlannedLayers != 14 || merged.CompletedLayers != 10 || merged.Status != "in_progress" { t.Fatalf("unexpected merged task: %+v", merged) }
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d", plan.PlanID), "", "")
+    decodeJSON(t, w, &plan)
+    if plan.Status != "in_progress" { t.Fatalf("plan status want in_progress got %s", plan.Status) }
+
+    // 其余 4 层完成后计划完成
+    logLayers(taskB.TaskID, 4, `,"dye_lot":"LOT-A","allow_lot_mix":true`)
+    w, _ = doJSONAuth(r, "GET", fmt.Sprintf("/api/v1/plans/%d", plan.PlanID), "", "")
+    decodeJSON(t, w, &plan)
+    if plan.Status != "completed" { t.Fatalf("plan status want completed got %s", plan.Status) }
+
+    // 已完成计划不可拆分：409
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/tasks/%d/split", taskB.TaskID), `{"layers":1}`, "")
+    if w.Code != http.StatusConflict { t.Fatalf("split on completed plan want 409 got %d: %s", w.Code, w.Body.String()) }
+}
@@ -0,0 +1,108 @@
+package integration
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "strings"
+    "testing"
+    "time"
+
+    "cutrix-backend/internal/models"
+)
+
+func TestPlanPublish_CutTolerance(t *testing.T) {
+    conn := openDBAndMigrate(t)
+    defer conn.Close()
+    r := buildRouter(conn)
+
+    now := time.Now().UTC()
+    orderNumber := fmt.Sprintf("ORD-%d", now.UnixNano())
+
+    // 订单：Red M20/L10，容差 +10% / -0%
+    createOrder := fmt.Sprintf(`{
+        "order_number": "%s",
+        "style_number": "STYLE-TOL-001",
+        "order_start_date": "%s",
+        "over_cut_tolerance": 10,
+        "under_cut_tolerance": 0,
+        "items": [
+            {"color":"Red","size":"M","quantity":20},
+            {"color":"Red","size":"L","quantity":10}
+        ]
+    }`, orderNumber, now.Format(time.RFC3339))
+    w, _ := doJSONAuth(r, "POST", "/api/v1/orders", createOrder, "")
+    if w.Code != http.StatusCreated { t.Fatalf("create order want 201 got %d: %s", w.Code, w.Body.String()) }
+    var order models.ProductionOrder
+    decodeJSON(t, w, &order)
+
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-TOL","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan models.ProductionPlan
+    decodeJSON(t, w, &plan)
+
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-TOL","plan_id":%d}`, plan.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout models.CuttingLayout
+    decodeJSON(t, w, &layout)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout.LayoutID), `{"ratios":{"M":2,"L":1}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios want 204 got %d: %s", w.Code, w.Body.String()) }
+
+    // 9 层 → M18（短缺）L9（短缺）
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Red","planned_layers":9}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+    var task models.ProductionTask
+    decodeJSON(t, w, &task)
+
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusBadRequest { t.Fatalf("publish short want 400 got %d: %s", w.Code, w.Body.String()) }
+    var resp struct {
+        Error string                      `json:"error"`
+        Cells []models.ToleranceViolation `json:"cells"`
+    }
+    if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil { t.Fatalf("decode: %v", err) }
+    if resp.Error != "tolerance_violation" || len(resp.Cells) != 2 {
+        t.Fatalf("unexpected violation response: %s", w.Body.String())
+    }
+    for _, c := range resp.Cells {
+        if c.Kind != "short" { t.Fatalf("want short cell, got %+v", c) }
+    }
+
+    // 触发器路径：绕过仓储直接更新状态同样被拒绝
+    _, err := conn.Exec(`UPDATE production.plans SET status = 'in_progress' WHERE plan_id = $1`, plan.PlanID)
+    if err == nil || !strings.Contains(err.Error(), "超出裁剪容差") {
+        t.Fatalf("trigger want tolerance error, got %v", err)
+    }
+
+    // 换成 11 层 → M22（上限 22）L11（上限 11），在容差内
+    w, _ = doJSONAuth(r, "DELETE", fmt.Sprintf("/api/v1/tasks/%d", task.TaskID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("delete task want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Red","planned_layers":11}`, layout.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task want 201 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish within tolerance want 204 got %d: %s", w.Code, w.Body.String()) }
+
+    // 同订单第二个计划：与已发布计划合计后超裁
+    w, _ = doJSONAuth(r, "POST", "/api/v1/plans", fmt.Sprintf(`{"plan_name":"Plan-TOL-2","order_id":%d}`, order.OrderID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create plan2 want 201 got %d: %s", w.Code, w.Body.String()) }
+    var plan2 models.ProductionPlan
+    decodeJSON(t, w, &plan2)
+    w, _ = doJSONAuth(r, "POST", "/api/v1/layouts", fmt.Sprintf(`{"layout_name":"L-TOL-2","plan_id":%d}`, plan2.PlanID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create layout2 want 201 got %d: %s", w.Code, w.Body.String()) }
+    var layout2 models.CuttingLayout
+    decodeJSON(t, w, &layout2)
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/layouts/%d/ratios", layout2.LayoutID), `{"ratios":{"M":1}}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("set ratios2 want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", "/api/v1/tasks", fmt.Sprintf(`{"layout_id":%d,"color":"Red","planned_layers":1}`, layout2.LayoutID), "")
+    if w.Code != http.StatusCreated { t.Fatalf("create task2 want 201 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan2.PlanID), "", "")
+    if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"kind":"over"`) {
+        t.Fatalf("publish over want 400 over got %d: %s", w.Code, w.Body.String())
+    }
+
+    // 关闭容差后可发布
+    w, _ = doJSONAuth(r, "PATCH", fmt.Sprintf("/api/v1/orders/%d/tolerance", order.OrderID), `{"over_cut_tolerance":null,"under_cut_tolerance":null}`, "")
+    if w.Code != http.StatusNoContent { t.Fatalf("clear tolerance want 204 got %d: %s", w.Code, w.Body.String()) }
+    w, _ = doJSONAuth(r, "POST", fmt.Sprintf("/api/v1/plans/%d/publish", plan2.PlanID), "", "")
+    if w.Code != http.StatusNoContent { t.Fatalf("publish without tolerance want 204 got %d: %s", w.Code, w.Body.String()) }
+}